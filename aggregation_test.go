@@ -0,0 +1,168 @@
+package ledger_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xraph/ledger"
+	"github.com/xraph/ledger/feature"
+	"github.com/xraph/ledger/id"
+	"github.com/xraph/ledger/invoice"
+	"github.com/xraph/ledger/meter"
+	"github.com/xraph/ledger/plan"
+	"github.com/xraph/ledger/store/memory"
+	"github.com/xraph/ledger/types"
+)
+
+// largeEvents is a usage aggregator that counts the events of at least
+// min units.
+type largeEvents struct{ min int64 }
+
+func (a *largeEvents) Name() string           { return "large-events" }
+func (a *largeEvents) AggregatorName() string { return "large_events" }
+
+func (a *largeEvents) Aggregate(_ context.Context, events []interface{}) (int64, error) {
+	var n int64
+	for _, e := range events {
+		if e.(*meter.UsageEvent).Quantity >= a.min {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func TestAggregationsAcrossPeriods(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	l := startLedger(t, s, ledger.WithPlugin(&largeEvents{min: 5}))
+
+	// usage is one event of a feature, at an offset into its period.
+	type usage struct {
+		at   time.Duration
+		qty  int64
+		user string
+	}
+	tests := []struct {
+		key          string
+		agg          meter.Aggregation
+		previous     []usage
+		current      []usage
+		wantPrevious int64
+		wantCurrent  int64
+	}{
+		{
+			key:          "peak",
+			agg:          meter.Aggregation{Type: meter.AggregateMax},
+			previous:     []usage{{time.Hour, 50, ""}},
+			current:      []usage{{time.Hour, 3, ""}, {2 * time.Hour, 9, ""}, {3 * time.Hour, 4, ""}},
+			wantPrevious: 50,
+			wantCurrent:  9,
+		},
+		{
+			key:          "users",
+			agg:          meter.Aggregation{Type: meter.AggregateUnique, Property: "user"},
+			previous:     []usage{{time.Hour, 1, "u1"}, {2 * time.Hour, 1, "u9"}},
+			current:      []usage{{time.Hour, 1, "u1"}, {2 * time.Hour, 1, "u2"}, {3 * time.Hour, 1, "u1"}, {4 * time.Hour, 1, ""}},
+			wantPrevious: 2,
+			wantCurrent:  2,
+		},
+		{
+			key:          "gauge",
+			agg:          meter.Aggregation{Type: meter.AggregateLast},
+			previous:     []usage{{time.Hour, 100, ""}, {2 * time.Hour, 80, ""}},
+			current:      []usage{{2 * time.Hour, 7, ""}, {time.Hour, 5, ""}},
+			wantPrevious: 80,
+			wantCurrent:  7,
+		},
+		{
+			key:          "batches",
+			agg:          meter.Aggregation{Type: "large_events"},
+			previous:     []usage{{time.Hour, 10, ""}},
+			current:      []usage{{time.Hour, 2, ""}, {2 * time.Hour, 6, ""}, {3 * time.Hour, 8, ""}},
+			wantPrevious: 1,
+			wantCurrent:  2,
+		},
+	}
+
+	now := time.Now().UTC()
+	current := now.AddDate(0, 0, -10)
+	previous := current.AddDate(0, -1, 0)
+
+	var (
+		features []plan.Feature
+		tiers    []plan.PriceTier
+		events   []*meter.UsageEvent
+	)
+	for _, tt := range tests {
+		f := &feature.Feature{
+			Key: tt.key, Name: tt.key, Type: feature.FeatureMetered, Period: feature.PeriodMonthly,
+			Status: feature.StatusActive, AppID: "app", Aggregation: tt.agg.Type, AggregationProperty: tt.agg.Property,
+		}
+		if err := l.CreateFeature(ctx, f); err != nil {
+			t.Fatal(err)
+		}
+		features = append(features, plan.Feature{Key: tt.key, Name: tt.key, Type: plan.FeatureMetered, Limit: -1, Period: plan.PeriodMonthly})
+		tiers = append(tiers, plan.PriceTier{FeatureKey: tt.key, Type: plan.TierGraduated, UpTo: -1, UnitAmount: types.USD(1)})
+
+		for start, period := range map[time.Time][]usage{previous: tt.previous, current: tt.current} {
+			for _, u := range period {
+				e := &meter.UsageEvent{
+					ID: id.NewUsageEventID(), TenantID: "t1", AppID: "app", FeatureKey: tt.key,
+					Quantity: u.qty, Timestamp: start.Add(u.at),
+				}
+				if u.user != "" {
+					e.Metadata = map[string]string{"user": u.user}
+				}
+				events = append(events, e)
+			}
+		}
+	}
+	if _, err := s.IngestBatch(ctx, events); err != nil {
+		t.Fatal(err)
+	}
+	_, sub := subscribe(t, l, "t1", features, tiers...)
+
+	// billed generates an invoice for the period starting at start and
+	// returns the quantity billed per feature.
+	billed := func(start time.Time) map[string]int64 {
+		t.Helper()
+		sub.CurrentPeriodStart = start
+		sub.CurrentPeriodEnd = start.AddDate(0, 1, 0)
+		if err := s.UpdateSubscription(ctx, sub); err != nil {
+			t.Fatal(err)
+		}
+		inv, err := l.GenerateInvoice(ctx, sub.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		quantities := make(map[string]int64)
+		for _, li := range inv.LineItems {
+			if li.Type == invoice.LineItemUsage {
+				quantities[li.FeatureKey] += li.Quantity
+			}
+		}
+		return quantities
+	}
+	billedPrevious := billed(previous)
+	billedCurrent := billed(current)
+
+	tctx := tenantContext("t1", "app")
+	for _, tt := range tests {
+		t.Run(string(tt.agg.Kind()), func(t *testing.T) {
+			if got := billedPrevious[tt.key]; got != tt.wantPrevious {
+				t.Errorf("previous period billed %d, want %d", got, tt.wantPrevious)
+			}
+			if got := billedCurrent[tt.key]; got != tt.wantCurrent {
+				t.Errorf("current period billed %d, want %d", got, tt.wantCurrent)
+			}
+			res, err := l.Entitled(tctx, tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Used != tt.wantCurrent {
+				t.Errorf("Entitled used = %d, want %d", res.Used, tt.wantCurrent)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	log "github.com/xraph/go-utils/log"
+
+	"github.com/xraph/ledger/entitlement"
+	"github.com/xraph/ledger/meter"
+	"github.com/xraph/ledger/plan"
+)
+
+const (
+	// softLimitAlert is the threshold under which soft-limit
+	// notifications are recorded; real thresholds are positive.
+	softLimitAlert = 0
+
+	// usageAlertSweepInterval is how often alert records of past usage
+	// windows are purged.
+	usageAlertSweepInterval = time.Hour
+)
+
+// queueUsageAlerts hands the tenants and features of a flushed batch to
+// the usage alert worker.
+func (l *Ledger) queueUsageAlerts(batch []*meter.UsageEvent) {
+	l.alertMu.Lock()
+	for _, e := range batch {
+		t := tenantApp{tenantID: e.TenantID, appID: e.AppID}
+		if !slices.Contains(l.alertPending[t], e.FeatureKey) {
+			l.alertPending[t] = append(l.alertPending[t], e.FeatureKey)
+		}
+	}
+	l.alertMu.Unlock()
+
+	select {
+	case l.alertWake <- struct{}{}:
+	default:
+	}
+}
+
+// usageAlertWorker checks the usage alerts of the features flushed since
+// it last ran, and purges old alert records every usageAlertSweepInterval.
+// Features flushed while the Ledger stops are checked after their next
+// flush.
+func (l *Ledger) usageAlertWorker(ctx context.Context) {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(usageAlertSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stopChan:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := l.store.PurgeUsageAlerts(ctx, time.Now().UTC()); err != nil {
+				l.logger.Error("failed to purge usage alerts", log.Error(err))
+			}
+			continue
+		case <-l.alertWake:
+		}
+
+		l.alertMu.Lock()
+		pending := l.alertPending
+		l.alertPending = make(map[tenantApp][]string)
+		l.alertMu.Unlock()
+
+		for t, keys := range pending {
+			if err := l.checkUsageAlerts(ctx, t.tenantID, t.appID, keys); err != nil {
+				l.logger.Warn("failed to check usage alerts",
+					log.String("tenant_id", t.tenantID),
+					log.String("app_id", t.appID),
+					log.Error(err),
+				)
+			}
+		}
+	}
+}
+
+// checkUsageAlerts sends the alerts a tenant's metered features have
+// reached in their current usage window: OnUsageThreshold for each
+// threshold reached, and OnSoftLimitReached once usage of a soft-limit
+// feature reaches its limit. Each alert is recorded in the store before it
+// is sent, so it goes out at most once per usage window even with several
+// Ledger instances. Rolling periods, seats and unlimited features have no
+// alerts.
+func (l *Ledger) checkUsageAlerts(ctx context.Context, tenantID, appID string, keys []string) error {
+	sub, err := l.store.GetActiveSubscription(ctx, tenantID, appID)
+	if err != nil {
+		if errors.Is(err, ErrNoActiveSubscription) || IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	p, err := l.store.GetPlan(ctx, sub.PlanID)
+	if err != nil {
+		return err
+	}
+	overrides, err := l.store.ListOverrides(ctx, tenantID, appID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, key := range keys {
+		feat := l.planFeature(ctx, appID, p, key, overrides, now)
+		if feat == nil || feat.Type != plan.FeatureMetered || feat.Period.Rolling() || feat.Limit <= 0 {
+			continue
+		}
+		thresholds := feat.Thresholds
+		if len(thresholds) == 0 {
+			thresholds = l.usageThresholds
+		}
+		if len(thresholds) == 0 && !feat.SoftLimit {
+			continue
+		}
+
+		start, end := usageWindow(sub, p, feat.Period, now)
+		used, err := l.aggregateUsage(ctx, tenantID, appID, feat, l.aggregationFor(ctx, appID, feat), start, end)
+		if err != nil {
+			return err
+		}
+		window := entitlement.QuotaKey{TenantID: tenantID, AppID: appID, FeatureKey: key, WindowStart: start}
+		var expires time.Time
+		if !end.IsZero() {
+			expires = end.Add(quotaGrace)
+		}
+
+		for _, t := range slices.Sorted(slices.Values(thresholds)) {
+			if t <= 0 || used*100 < int64(t)*feat.Limit {
+				continue
+			}
+			sent, err := l.store.RecordUsageAlert(ctx, window, t, expires)
+			if err != nil {
+				return err
+			}
+			if sent {
+				l.plugins.EmitUsageThreshold(ctx, tenantID, key, t, used, feat.Limit)
+			}
+		}
+		if feat.SoftLimit && used >= feat.Limit {
+			sent, err := l.store.RecordUsageAlert(ctx, window, softLimitAlert, expires)
+			if err != nil {
+				return err
+			}
+			if sent {
+				l.plugins.EmitSoftLimitReached(ctx, tenantID, key, used, feat.Limit)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package ledger_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xraph/ledger"
+	"github.com/xraph/ledger/plan"
+	"github.com/xraph/ledger/store/memory"
+)
+
+// alertRecorder counts the usage threshold alerts sent per threshold.
+type alertRecorder struct {
+	mu    sync.Mutex
+	sent  map[int]int
+	usage map[int]int64
+}
+
+func (r *alertRecorder) Name() string { return "alert-recorder" }
+
+func (r *alertRecorder) OnUsageThreshold(_ context.Context, _, _ string, threshold int, used, _ int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent[threshold]++
+	r.usage[threshold] = used
+	return nil
+}
+
+func (r *alertRecorder) count(threshold int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.sent[threshold]
+}
+
+func TestUsageAlertsOncePerPeriod(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	alerts := &alertRecorder{sent: make(map[int]int), usage: make(map[int]int64)}
+	l := startLedger(t, s,
+		ledger.WithMeterConfig(1, 5*time.Millisecond),
+		ledger.WithPlugin(alerts),
+	)
+	_, sub := subscribe(t, l, "t1", []plan.Feature{{
+		Key: "api_calls", Name: "API calls", Type: plan.FeatureMetered,
+		Limit: 10, Period: plan.PeriodMonthly, Thresholds: []int{50, 80, 100},
+	}})
+
+	tctx := tenantContext("t1", "app")
+	meter := func(qty int64) {
+		t.Helper()
+		if err := l.Meter(tctx, "api_calls", qty); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	meter(8)
+	eventually(t, "the 80% alert", func() bool { return alerts.count(80) == 1 })
+	// Every flush checks the thresholds again; reaching 100% must not
+	// repeat the alerts already sent in this period.
+	meter(1)
+	meter(1)
+	eventually(t, "the 100% alert", func() bool { return alerts.count(100) == 1 })
+	if n50, n80 := alerts.count(50), alerts.count(80); n50 != 1 || n80 != 1 {
+		t.Fatalf("50%% and 80%% alerts sent %d and %d times, want once each", n50, n80)
+	}
+
+	// A new period starts after the usage so far and alerts again.
+	sub.CurrentPeriodStart = time.Now()
+	sub.CurrentPeriodEnd = sub.CurrentPeriodStart.AddDate(0, 1, 0)
+	if err := s.UpdateSubscription(ctx, sub); err != nil {
+		t.Fatal(err)
+	}
+	meter(6)
+	eventually(t, "the 50% alert of the new period", func() bool { return alerts.count(50) == 2 })
+	alerts.mu.Lock()
+	defer alerts.mu.Unlock()
+	if alerts.sent[80] != 1 || alerts.sent[100] != 1 {
+		t.Errorf("alerts sent = %v, want only 50%% repeated in the new period", alerts.sent)
+	}
+	if alerts.usage[50] != 6 {
+		t.Errorf("50%% alert of the new period reported %d used, want 6", alerts.usage[50])
+	}
+}
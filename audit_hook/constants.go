@@ -15,14 +15,19 @@ const (
 	ActionSubscriptionExpired    = "subscription.expired"
 
 	// Usage actions
-	ActionUsageIngested = "usage.ingested"
-	ActionUsageFlushed  = "usage.flushed"
+	ActionUsageIngested  = "usage.ingested"
+	ActionUsageFlushed   = "usage.flushed"
+	ActionUsageVoided    = "usage.voided"
+	ActionUsageCorrected = "usage.corrected"
+	ActionUsageArchived  = "usage.archived"
+	ActionUsageRestored  = "usage.restored"
 
 	// Entitlement actions
 	ActionEntitlementChecked = "entitlement.checked"
 	ActionEntitlementDenied  = "entitlement.denied"
 	ActionQuotaExceeded      = "quota.exceeded"
 	ActionSoftLimitReached   = "soft_limit.reached"
+	ActionUsageThreshold     = "usage.threshold"
 
 	// Invoice actions
 	ActionInvoiceGenerated = "invoice.generated"
@@ -11,6 +11,7 @@ import (
 
 	log "github.com/xraph/go-utils/log"
 
+	"github.com/xraph/ledger/meter"
 	"github.com/xraph/ledger/plugin"
 )
 
@@ -28,7 +29,13 @@ var (
 	_ plugin.OnInvoicePaid          = (*Extension)(nil)
 	_ plugin.OnInvoiceFailed        = (*Extension)(nil)
 	_ plugin.OnInvoiceVoided        = (*Extension)(nil)
+	_ plugin.OnUsageVoided          = (*Extension)(nil)
+	_ plugin.OnUsageCorrected       = (*Extension)(nil)
+	_ plugin.OnUsageArchived        = (*Extension)(nil)
+	_ plugin.OnUsageRestored        = (*Extension)(nil)
 	_ plugin.OnQuotaExceeded        = (*Extension)(nil)
+	_ plugin.OnSoftLimitReached     = (*Extension)(nil)
+	_ plugin.OnUsageThreshold       = (*Extension)(nil)
 	_ plugin.OnEntitlementChecked   = (*Extension)(nil)
 )
 
@@ -189,6 +196,57 @@ func (e *Extension) OnInvoiceVoided(ctx context.Context, _ interface{}, reason s
 	)
 }
 
+// ──────────────────────────────────────────────────
+// Usage adjustment hooks
+// ──────────────────────────────────────────────────
+
+// OnUsageVoided implements plugin.OnUsageVoided.
+func (e *Extension) OnUsageVoided(ctx context.Context, event interface{}) error {
+	evt, ok := event.(*meter.UsageEvent)
+	if !ok {
+		return nil
+	}
+	return e.record(ctx, ActionUsageVoided, SeverityWarning, OutcomeSuccess,
+		ResourceUsage, evt.ID.String(), CategoryUsage, nil,
+		"tenant_id", evt.TenantID,
+		"feature", evt.FeatureKey,
+		"quantity", evt.Quantity,
+		"void_reason", evt.VoidReason,
+	)
+}
+
+// OnUsageCorrected implements plugin.OnUsageCorrected.
+func (e *Extension) OnUsageCorrected(ctx context.Context, event interface{}) error {
+	evt, ok := event.(*meter.UsageEvent)
+	if !ok {
+		return nil
+	}
+	return e.record(ctx, ActionUsageCorrected, SeverityWarning, OutcomeSuccess,
+		ResourceUsage, evt.ID.String(), CategoryUsage, nil,
+		"tenant_id", evt.TenantID,
+		"feature", evt.FeatureKey,
+		"quantity", evt.Quantity,
+		"reason", evt.Reason,
+	)
+}
+
+// OnUsageArchived implements plugin.OnUsageArchived.
+func (e *Extension) OnUsageArchived(ctx context.Context, appID, name string, events int) error {
+	return e.record(ctx, ActionUsageArchived, SeverityInfo, OutcomeSuccess,
+		ResourceUsage, name, CategoryUsage, nil,
+		"app_id", appID,
+		"events", events,
+	)
+}
+
+// OnUsageRestored implements plugin.OnUsageRestored.
+func (e *Extension) OnUsageRestored(ctx context.Context, name string, restored int) error {
+	return e.record(ctx, ActionUsageRestored, SeverityInfo, OutcomeSuccess,
+		ResourceUsage, name, CategoryUsage, nil,
+		"restored", restored,
+	)
+}
+
 // ──────────────────────────────────────────────────
 // Entitlement lifecycle hooks
 // ──────────────────────────────────────────────────
@@ -204,6 +262,29 @@ func (e *Extension) OnQuotaExceeded(ctx context.Context, tenantID, featureKey st
 	)
 }
 
+// OnSoftLimitReached implements plugin.OnSoftLimitReached.
+func (e *Extension) OnSoftLimitReached(ctx context.Context, tenantID, featureKey string, used, limit int64) error {
+	return e.record(ctx, ActionSoftLimitReached, SeverityWarning, OutcomeSuccess,
+		ResourceEntitlement, featureKey, CategoryAccess, nil,
+		"tenant_id", tenantID,
+		"feature", featureKey,
+		"used", used,
+		"limit", limit,
+	)
+}
+
+// OnUsageThreshold implements plugin.OnUsageThreshold.
+func (e *Extension) OnUsageThreshold(ctx context.Context, tenantID, featureKey string, threshold int, used, limit int64) error {
+	return e.record(ctx, ActionUsageThreshold, SeverityInfo, OutcomeSuccess,
+		ResourceEntitlement, featureKey, CategoryAccess, nil,
+		"tenant_id", tenantID,
+		"feature", featureKey,
+		"threshold", threshold,
+		"used", used,
+		"limit", limit,
+	)
+}
+
 // OnEntitlementChecked implements plugin.OnEntitlementChecked.
 func (e *Extension) OnEntitlementChecked(_ context.Context, _ interface{}) error {
 	// Only audit denied checks to reduce noise
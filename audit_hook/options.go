@@ -54,10 +54,15 @@ func allActions() []string {
 		ActionSubscriptionExpired,
 		ActionUsageIngested,
 		ActionUsageFlushed,
+		ActionUsageVoided,
+		ActionUsageCorrected,
+		ActionUsageArchived,
+		ActionUsageRestored,
 		ActionEntitlementChecked,
 		ActionEntitlementDenied,
 		ActionQuotaExceeded,
 		ActionSoftLimitReached,
+		ActionUsageThreshold,
 		ActionInvoiceGenerated,
 		ActionInvoiceFinalized,
 		ActionInvoicePaid,
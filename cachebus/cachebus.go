@@ -0,0 +1,118 @@
+// Package cachebus broadcasts entitlement cache invalidations between the
+// Ledger instances that serve the same store.
+//
+// Each instance caches entitlement results, catalog lookups and the
+// rolling features of its tenants. An instance that changes a
+// subscription, plan, override or usage drops its own entries and
+// publishes a Message; the other instances receive it through their Bus
+// subscription and drop theirs, instead of serving stale results until
+// the cache TTL runs out.
+//
+// Memory connects instances within one process. The postgres store
+// provides a Bus on LISTEN/NOTIFY. Other transports, such as Redis pub/sub
+// or NATS, implement Bus by encoding messages as JSON and delivering them
+// to every subscriber, the publisher included.
+package cachebus
+
+import (
+	"context"
+	"sync"
+)
+
+// Kind is the change an invalidation message reports.
+type Kind string
+
+const (
+	// KindSubscription reports a change to a tenant's subscription; all
+	// of the tenant's cached results are stale.
+	KindSubscription Kind = "subscription"
+	// KindPlan reports a change to a plan; the results of every tenant
+	// subscribed to it are stale.
+	KindPlan Kind = "plan"
+	// KindOverride reports a change to a tenant's entitlement overrides.
+	KindOverride Kind = "override"
+	// KindUsage reports recorded, voided, corrected or restored usage.
+	KindUsage Kind = "usage"
+	// KindCatalog reports a change to the feature catalog.
+	KindCatalog Kind = "catalog"
+)
+
+// Message describes a change that invalidates cached entitlements.
+type Message struct {
+	Kind     Kind   `json:"kind"`
+	TenantID string `json:"tenant_id,omitempty"`
+	AppID    string `json:"app_id,omitempty"`
+	PlanID   string `json:"plan_id,omitempty"`
+	// Features are the features whose results changed for override and
+	// usage messages; empty means all of the tenant's features.
+	Features []string `json:"features,omitempty"`
+	// Origin identifies the publishing instance, which has already
+	// applied the message and ignores it when it comes back.
+	Origin string `json:"origin,omitempty"`
+}
+
+// Bus delivers invalidation messages to every subscribed instance.
+// Implementations must be safe for concurrent use. Delivery is
+// best-effort: entries whose messages are lost expire with the
+// entitlement cache TTL.
+type Bus interface {
+	// Publish sends msg to every subscriber, including the publisher's
+	// own.
+	Publish(ctx context.Context, msg Message) error
+
+	// Subscribe calls handle with every message published on the bus
+	// until ctx is done or the subscription fails, and returns the error.
+	// Messages are handled one at a time.
+	Subscribe(ctx context.Context, handle func(Message)) error
+}
+
+// Memory is an in-process Bus. Publish calls the subscribers' handlers
+// before it returns.
+type Memory struct {
+	mu   sync.RWMutex
+	subs map[*memorySub]struct{}
+}
+
+type memorySub struct {
+	mu     sync.Mutex // serializes handle calls
+	handle func(Message)
+}
+
+var _ Bus = (*Memory)(nil)
+
+// NewMemory returns an in-process Bus without subscribers.
+func NewMemory() *Memory {
+	return &Memory{subs: make(map[*memorySub]struct{})}
+}
+
+// Publish implements Bus.
+func (m *Memory) Publish(_ context.Context, msg Message) error {
+	m.mu.RLock()
+	subs := make([]*memorySub, 0, len(m.subs))
+	for sub := range m.subs {
+		subs = append(subs, sub)
+	}
+	m.mu.RUnlock()
+
+	for _, sub := range subs {
+		sub.mu.Lock()
+		sub.handle(msg)
+		sub.mu.Unlock()
+	}
+	return nil
+}
+
+// Subscribe implements Bus.
+func (m *Memory) Subscribe(ctx context.Context, handle func(Message)) error {
+	sub := &memorySub{handle: handle}
+	m.mu.Lock()
+	m.subs[sub] = struct{}{}
+	m.mu.Unlock()
+
+	<-ctx.Done()
+
+	m.mu.Lock()
+	delete(m.subs, sub)
+	m.mu.Unlock()
+	return ctx.Err()
+}
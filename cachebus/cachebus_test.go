@@ -0,0 +1,61 @@
+package cachebus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryDelivery(t *testing.T) {
+	bus := NewMemory()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	got := make([]chan Message, 2)
+	done := make([]chan error, 2)
+	for i := range got {
+		got[i] = make(chan Message, 1)
+		done[i] = make(chan error, 1)
+		go func() {
+			done[i] <- bus.Subscribe(ctx, func(msg Message) { got[i] <- msg })
+		}()
+	}
+
+	// Wait for both subscriptions to register.
+	for deadline := time.Now().Add(time.Second); ; {
+		bus.mu.RLock()
+		n := len(bus.subs)
+		bus.mu.RUnlock()
+		if n == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("subscribers = %d, want 2", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	msg := Message{Kind: KindUsage, TenantID: "t", AppID: "a", Features: []string{"api"}, Origin: "i1"}
+	if err := bus.Publish(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	for i := range got {
+		m := <-got[i]
+		if m.Kind != KindUsage || m.TenantID != "t" || m.Origin != "i1" || len(m.Features) != 1 {
+			t.Errorf("subscriber %d got %+v", i, m)
+		}
+	}
+
+	cancel()
+	for i := range done {
+		if err := <-done[i]; !errors.Is(err, context.Canceled) {
+			t.Errorf("subscriber %d returned %v, want context.Canceled", i, err)
+		}
+	}
+	if err := bus.Publish(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	if len(bus.subs) != 0 {
+		t.Errorf("subscribers after cancel = %d, want 0", len(bus.subs))
+	}
+}
@@ -0,0 +1,89 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	log "github.com/xraph/go-utils/log"
+
+	"github.com/xraph/ledger/feature"
+	"github.com/xraph/ledger/meter"
+)
+
+// catalogEntry is a cached catalog lookup. A nil feature records that the
+// key is not in the catalog.
+type catalogEntry struct {
+	feature *feature.Feature
+	expires time.Time
+}
+
+// validateUsage checks a usage event against the feature catalog for strict
+// metering. Catalog lookups that fail for other reasons than a missing
+// feature are logged and the event is accepted, so a store hiccup does not
+// lose usage.
+func (l *Ledger) validateUsage(ctx context.Context, event *meter.UsageEvent) error {
+	f, err := l.catalogFeature(ctx, event.AppID, event.FeatureKey)
+	if errors.Is(err, ErrFeatureNotFound) {
+		return fmt.Errorf("%w: %q", ErrFeatureNotFound, event.FeatureKey)
+	}
+	if err != nil {
+		l.logger.Warn("failed to load catalog feature, accepting usage",
+			log.String("feature", event.FeatureKey),
+			log.Error(err),
+		)
+		return nil
+	}
+
+	if f.Status == feature.StatusArchived {
+		return fmt.Errorf("%w: %q", ErrFeatureArchived, event.FeatureKey)
+	}
+	if f.Type == feature.FeatureMetered && event.Quantity < 0 && !event.Correction {
+		return fmt.Errorf("%w: %d", ErrInvalidQuantity, event.Quantity)
+	}
+	return nil
+}
+
+// catalogFeature returns the app-scoped catalog feature with the given key,
+// falling back to the global one. Lookups, including misses, are cached for
+// the entitlement cache TTL.
+func (l *Ledger) catalogFeature(ctx context.Context, appID, key string) (*feature.Feature, error) {
+	cacheKey := appID + "/" + key
+	now := time.Now()
+
+	l.catalogMu.Lock()
+	entry, ok := l.catalogCache[cacheKey]
+	l.catalogMu.Unlock()
+	if ok && now.Before(entry.expires) {
+		if entry.feature == nil {
+			return nil, ErrFeatureNotFound
+		}
+		return entry.feature, nil
+	}
+
+	f, err := l.store.GetFeatureByKey(ctx, key, appID)
+	if errors.Is(err, ErrFeatureNotFound) && appID != "" {
+		f, err = l.store.GetFeatureByKey(ctx, key, "")
+	}
+	if err != nil && !errors.Is(err, ErrFeatureNotFound) {
+		return nil, err
+	}
+
+	l.catalogMu.Lock()
+	l.catalogCache[cacheKey] = catalogEntry{feature: f, expires: now.Add(l.entitlementCacheTTL)}
+	l.catalogMu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// resetCatalogCache drops every cached catalog lookup after the catalog
+// changes.
+func (l *Ledger) resetCatalogCache() {
+	l.catalogMu.Lock()
+	clear(l.catalogCache)
+	l.catalogMu.Unlock()
+}
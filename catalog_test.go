@@ -0,0 +1,73 @@
+package ledger_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/xraph/ledger"
+	"github.com/xraph/ledger/feature"
+	"github.com/xraph/ledger/store/memory"
+)
+
+func TestStrictMetering(t *testing.T) {
+	ctx := context.Background()
+	l := startLedger(t, memory.New(), ledger.WithStrictMetering(true))
+
+	features := []*feature.Feature{
+		{Key: "api_calls", Name: "API calls", Type: feature.FeatureMetered, Status: feature.StatusActive, AppID: "app"},
+		{Key: "storage", Name: "Storage", Type: feature.FeatureMetered, Status: feature.StatusActive},
+		{Key: "legacy", Name: "Legacy", Type: feature.FeatureMetered, Status: feature.StatusActive, AppID: "app"},
+	}
+	for _, f := range features {
+		if err := l.CreateFeature(ctx, f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.ArchiveFeature(ctx, features[2].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	tctx := tenantContext("t1", "app")
+	tests := []struct {
+		name    string
+		key     string
+		qty     int64
+		wantErr error
+	}{
+		{"App feature", "api_calls", 1, nil},
+		{"Global feature", "storage", 1, nil},
+		{"Unknown feature", "unknown", 1, ledger.ErrFeatureNotFound},
+		{"Archived feature", "legacy", 1, ledger.ErrFeatureArchived},
+		{"Negative quantity", "api_calls", -1, ledger.ErrInvalidQuantity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := l.Meter(tctx, tt.key, tt.qty); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Meter error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// A negative correction is not usage and is accepted.
+	if _, err := l.CorrectUsage(tctx, "api_calls", -1, "refund", ledger.MeterOptions{}); err != nil {
+		t.Fatalf("CorrectUsage error = %v, want nil", err)
+	}
+}
+
+func TestStrictMeteringSeesNewFeatures(t *testing.T) {
+	l := startLedger(t, memory.New(), ledger.WithStrictMetering(true))
+	tctx := tenantContext("t1", "app")
+
+	// The miss is cached, but creating the feature resets the cache.
+	if err := l.Meter(tctx, "api_calls", 1); !errors.Is(err, ledger.ErrFeatureNotFound) {
+		t.Fatalf("Meter error = %v, want ErrFeatureNotFound", err)
+	}
+	f := &feature.Feature{Key: "api_calls", Name: "API calls", Type: feature.FeatureMetered, Status: feature.StatusActive, AppID: "app"}
+	if err := l.CreateFeature(context.Background(), f); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Meter(tctx, "api_calls", 1); err != nil {
+		t.Fatalf("Meter after CreateFeature error = %v, want nil", err)
+	}
+}
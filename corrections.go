@@ -0,0 +1,194 @@
+package ledger
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/xraph/ledger/cachebus"
+	"github.com/xraph/ledger/id"
+	"github.com/xraph/ledger/invoice"
+	"github.com/xraph/ledger/meter"
+	"github.com/xraph/ledger/plan"
+	"github.com/xraph/ledger/subscription"
+)
+
+// VoidUsage voids a recorded usage event of the current tenant. Voided
+// events stay in the store for the audit trail but no longer count towards
+// quotas or invoices. A reason is required.
+func (l *Ledger) VoidUsage(ctx context.Context, eventID id.UsageEventID, reason string) (*meter.UsageEvent, error) {
+	if eventID.IsNil() {
+		return nil, ErrInvalidInput
+	}
+	return l.voidUsage(ctx, meter.VoidOpts{EventID: eventID, Reason: reason})
+}
+
+// VoidUsageByKey voids the current tenant's usage event recorded with the
+// given idempotency key. A reason is required.
+func (l *Ledger) VoidUsageByKey(ctx context.Context, idempotencyKey, reason string) (*meter.UsageEvent, error) {
+	if idempotencyKey == "" {
+		return nil, ErrInvalidInput
+	}
+	return l.voidUsage(ctx, meter.VoidOpts{IdempotencyKey: idempotencyKey, Reason: reason})
+}
+
+func (l *Ledger) voidUsage(ctx context.Context, opts meter.VoidOpts) (*meter.UsageEvent, error) {
+	tenantID := extractTenantID(ctx)
+	appID := extractAppID(ctx)
+	if tenantID == "" || appID == "" || opts.Reason == "" {
+		return nil, ErrInvalidInput
+	}
+
+	opts.VoidedAt = time.Now().UTC()
+	event, err := l.store.VoidUsage(ctx, tenantID, appID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	l.invalidate(ctx, cachebus.Message{Kind: cachebus.KindUsage, TenantID: tenantID, AppID: appID, Features: []string{event.FeatureKey}})
+	l.plugins.EmitUsageVoided(ctx, event)
+	return event, nil
+}
+
+// CorrectUsage records a signed correction of the current tenant's usage of
+// featureKey, e.g. a credit for usage billed in error. Corrections are
+// written to the store immediately, bypassing the meter buffer. They do not
+// count towards quotas and show up as a separate correction line on the
+// invoice covering opts.Timestamp. A non-zero quantity and a reason are
+// required; opts.IdempotencyKey makes retries safe.
+func (l *Ledger) CorrectUsage(ctx context.Context, featureKey string, quantity int64, reason string, opts MeterOptions) (*meter.UsageEvent, error) {
+	if quantity == 0 {
+		return nil, ErrInvalidQuantity
+	}
+
+	event := &meter.UsageEvent{
+		ID:             id.NewUsageEventID(),
+		TenantID:       opts.TenantID,
+		AppID:          opts.AppID,
+		FeatureKey:     featureKey,
+		Quantity:       quantity,
+		Timestamp:      opts.Timestamp,
+		IdempotencyKey: opts.IdempotencyKey,
+		Correction:     true,
+		Reason:         reason,
+	}
+	if event.TenantID == "" {
+		event.TenantID = extractTenantID(ctx)
+	}
+	if event.AppID == "" {
+		event.AppID = extractAppID(ctx)
+	}
+	if event.TenantID == "" || event.AppID == "" || featureKey == "" || reason == "" {
+		return nil, ErrInvalidInput
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	if l.strictMetering {
+		if err := l.validateUsage(ctx, event); err != nil {
+			l.plugins.EmitUsageRejected(ctx, event, err)
+			return nil, err
+		}
+	}
+	if len(opts.Properties) > 0 {
+		event.Metadata = make(map[string]string, len(opts.Properties))
+		for k, v := range opts.Properties {
+			event.Metadata[k] = v
+		}
+	}
+
+	result, err := l.store.IngestBatch(ctx, []*meter.UsageEvent{event})
+	if err != nil {
+		return nil, err
+	}
+	if result.Inserted == 0 {
+		return nil, ErrDuplicateEvent
+	}
+
+	l.plugins.EmitUsageCorrected(ctx, event)
+	l.publishUsage([]*meter.UsageEvent{event})
+	return event, nil
+}
+
+// correctionLineItem prices a net usage correction against tiers as the
+// difference between the price of the corrected usage and of the recorded
+// usage, so a credit can never bill below zero usage. The line is kept even
+// when it nets to zero so the correction stays visible on the invoice.
+// Lines for a dimension group name its values in the description and
+// metadata.
+func (l *Ledger) correctionLineItem(p *plan.Plan, pf *plan.Feature, tiers []plan.PriceTier, used, corr int64, group *meter.GroupTotal) invoice.LineItem {
+	name := pf.Name
+	var metadata map[string]string
+	if group != nil {
+		name += " (" + group.Label() + ")"
+		metadata = group.Dimensions
+	}
+
+	corrected := used + corr
+	if corrected < 0 {
+		corrected = 0
+	}
+	amount := l.priceUsage(p, pf, tiers, corrected).Subtract(l.priceUsage(p, pf, tiers, used))
+	return invoice.LineItem{
+		FeatureKey:  pf.Key,
+		Description: name + " usage correction",
+		Quantity:    corr,
+		UnitAmount:  unitAmount(amount, corr),
+		Amount:      amount,
+		Type:        invoice.LineItemCorrection,
+		Metadata:    metadata,
+	}
+}
+
+// groupCorrectionLineItems prices the corrections of a dimensional feature
+// with timestamps in the subscription's current period, [start, end) as
+// totalled by SumCorrections, the way groupLineItems bills its usage:
+// corrections are grouped by their values for keys and each group is priced
+// with the tiers scoped to those values against the usage recorded in the
+// same group.
+func (l *Ledger) groupCorrectionLineItems(ctx context.Context, sub *subscription.Subscription, p *plan.Plan, pf *plan.Feature, keys []string, usage []*meter.GroupTotal) ([]invoice.LineItem, error) {
+	events, err := l.store.QueryUsage(ctx, sub.TenantID, sub.AppID, meter.QueryOpts{
+		FeatureKey: pf.Key,
+		Start:      sub.CurrentPeriodStart,
+		End:        sub.CurrentPeriodEnd,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[string]int64, len(usage))
+	for _, g := range usage {
+		used[g.Label()] = g.Value
+	}
+
+	var groups []*meter.GroupTotal
+	byLabel := make(map[string]*meter.GroupTotal)
+	for _, e := range events {
+		if !e.Correction || e.VoidedAt != nil || !e.Timestamp.Before(sub.CurrentPeriodEnd) {
+			continue
+		}
+		dims := make(map[string]string, len(keys))
+		for _, k := range keys {
+			dims[k] = e.Metadata[k]
+		}
+		g := &meter.GroupTotal{Dimensions: dims}
+		if existing, ok := byLabel[g.Label()]; ok {
+			existing.Value += e.Quantity
+			continue
+		}
+		g.Value = e.Quantity
+		byLabel[g.Label()] = g
+		groups = append(groups, g)
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Label() < groups[j].Label() })
+
+	var items []invoice.LineItem
+	for _, g := range groups {
+		if g.Value == 0 {
+			continue
+		}
+		tiers := p.Pricing.TiersMatching(pf.Key, g.Dimensions)
+		items = append(items, l.correctionLineItem(p, pf, tiers, used[g.Label()], g.Value, g))
+	}
+	return items, nil
+}
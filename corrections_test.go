@@ -0,0 +1,122 @@
+package ledger_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xraph/ledger"
+	"github.com/xraph/ledger/invoice"
+	"github.com/xraph/ledger/plan"
+	"github.com/xraph/ledger/store/memory"
+	"github.com/xraph/ledger/types"
+)
+
+func TestCorrectionPricedWithDimensionTiers(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	l := startLedger(t, s, ledger.WithMeterConfig(1, 10*time.Millisecond))
+
+	_, sub := subscribe(t, l, "t1",
+		[]plan.Feature{{Key: "tokens", Name: "Tokens", Type: plan.FeatureMetered, Period: plan.PeriodMonthly}},
+		plan.PriceTier{FeatureKey: "tokens", UpTo: -1, UnitAmount: types.USD(1)},
+		plan.PriceTier{FeatureKey: "tokens", UpTo: -1, UnitAmount: types.USD(10), Dimensions: map[string]string{"model": "large"}},
+	)
+
+	tctx := tenantContext("t1", "app")
+	for model, qty := range map[string]int64{"large": 5, "small": 3} {
+		opts := ledger.MeterOptions{Properties: map[string]string{"model": model}}
+		if err := l.MeterWithOptions(tctx, "tokens", qty, opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	eventually(t, "the usage to be flushed", func() bool { return usageCount(t, s, "t1") == 2 })
+
+	opts := ledger.MeterOptions{Properties: map[string]string{"model": "large"}}
+	if _, err := l.CorrectUsage(tctx, "tokens", -2, "billed in error", opts); err != nil {
+		t.Fatal(err)
+	}
+
+	inv, err := l.GenerateInvoice(ctx, sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var corrections []invoice.LineItem
+	for _, li := range inv.LineItems {
+		if li.Type == invoice.LineItemCorrection {
+			corrections = append(corrections, li)
+		}
+	}
+	if len(corrections) != 1 {
+		t.Fatalf("got %d correction lines, want 1: %+v", len(corrections), inv.LineItems)
+	}
+	li := corrections[0]
+	if !li.Amount.Equal(types.USD(-20)) || li.Quantity != -2 || li.Metadata["model"] != "large" {
+		t.Errorf("correction line = %+v, want -2 large tokens credited at the large-model price", li)
+	}
+	// 5 large tokens at 10, 3 small ones at 1, less the 2 large ones.
+	if !inv.Total.Equal(types.USD(33)) {
+		t.Errorf("total = %v, want %v", inv.Total, types.USD(33))
+	}
+}
+
+func TestCorrectionsBilledInHalfOpenPeriod(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	l := startLedger(t, s, ledger.WithMeterConfig(1, 10*time.Millisecond))
+
+	_, sub := subscribe(t, l, "t1",
+		[]plan.Feature{
+			{Key: "tokens", Name: "Tokens", Type: plan.FeatureMetered, Limit: -1, Period: plan.PeriodMonthly},
+			{Key: "exports", Name: "Exports", Type: plan.FeatureMetered, Limit: -1, Period: plan.PeriodMonthly},
+		},
+		plan.PriceTier{FeatureKey: "tokens", UpTo: -1, UnitAmount: types.USD(1)},
+		plan.PriceTier{FeatureKey: "tokens", UpTo: -1, UnitAmount: types.USD(10), Dimensions: map[string]string{"model": "large"}},
+		plan.PriceTier{FeatureKey: "exports", UpTo: -1, UnitAmount: types.USD(2)},
+	)
+	start := time.Now().UTC().Truncate(time.Second).AddDate(0, 0, -1)
+	sub.CurrentPeriodStart = start
+	sub.CurrentPeriodEnd = start.AddDate(0, 1, 0)
+	if err := s.UpdateSubscription(ctx, sub); err != nil {
+		t.Fatal(err)
+	}
+
+	tctx := tenantContext("t1", "app")
+	large := map[string]string{"model": "large"}
+	for key, props := range map[string]map[string]string{"tokens": large, "exports": nil} {
+		opts := ledger.MeterOptions{Timestamp: start.Add(time.Hour), Properties: props}
+		if err := l.MeterWithOptions(tctx, key, 10, opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	eventually(t, "the usage to be flushed", func() bool { return usageCount(t, s, "t1") == 2 })
+
+	// Corrections at the period start belong to it, those at its end to
+	// the next period.
+	for at, qty := range map[time.Time]int64{sub.CurrentPeriodStart: -1, sub.CurrentPeriodEnd: -3} {
+		for key, props := range map[string]map[string]string{"tokens": large, "exports": nil} {
+			opts := ledger.MeterOptions{Timestamp: at, Properties: props}
+			if _, err := l.CorrectUsage(tctx, key, qty, "billed in error", opts); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	inv, err := l.GenerateInvoice(ctx, sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int64{"tokens": -10, "exports": -2}
+	for _, li := range inv.LineItems {
+		if li.Type != invoice.LineItemCorrection {
+			continue
+		}
+		if li.Quantity != -1 || li.Amount.Amount != want[li.FeatureKey] {
+			t.Errorf("%s correction line = %+v, want 1 unit credited for %d", li.FeatureKey, li, want[li.FeatureKey])
+		}
+		delete(want, li.FeatureKey)
+	}
+	if len(want) != 0 {
+		t.Errorf("no correction lines for %v", want)
+	}
+}
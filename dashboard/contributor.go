@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/url"
 	"time"
 
 	"github.com/a-h/templ"
@@ -39,6 +40,10 @@ type Contributor struct {
 	plugins    []plugin.Plugin
 	appID      string
 	pageRoutes map[string]bool
+
+	// usageStreamURL is the usage Server-Sent Events endpoint the usage page
+	// listens on to refresh itself; empty disables live updates.
+	usageStreamURL string
 }
 
 // New creates a new ledger dashboard contributor.
@@ -54,6 +59,13 @@ func New(manifest *contributor.Manifest, engine *ledger.Ledger, s store.Store, p
 	return c
 }
 
+// WithUsageStream makes the usage page refresh whenever usage is flushed,
+// using the usage Server-Sent Events endpoint at streamURL.
+func (c *Contributor) WithUsageStream(streamURL string) *Contributor {
+	c.usageStreamURL = streamURL
+	return c
+}
+
 // buildPageRoutes merges core knownPageRoutes with routes contributed by plugins.
 func (c *Contributor) buildPageRoutes() map[string]bool {
 	routes := make(map[string]bool, len(knownPageRoutes))
@@ -149,6 +161,10 @@ func (c *Contributor) RenderPage(ctx context.Context, route string, params contr
 		return c.handleFeatureSync(ctx, params)
 	case "/subscriptions/sync":
 		return c.handleSubscriptionSync(ctx, params)
+	case "/subscriptions/overrides":
+		return c.handleOverrideCreate(ctx, params)
+	case "/subscriptions/overrides/delete":
+		return c.handleOverrideDelete(ctx, params)
 	case "/invoices/sync":
 		return c.handleInvoiceSync(ctx, params)
 	case "/settings":
@@ -317,7 +333,7 @@ func (c *Contributor) renderPlanForm(ctx context.Context, params contributor.Par
 			newPlan.ID = p.ID
 			newPlan.Entity = p.Entity
 			newPlan.UpdatedAt = time.Now()
-			if err := c.store.UpdatePlan(ctx, newPlan); err != nil {
+			if err := c.engine.UpdatePlan(ctx, newPlan); err != nil {
 				return pages.PlanFormPage(pages.PlanFormData{
 					Plan: newPlan, IsEdit: true, Error: err.Error(), AppID: c.appID,
 				}), nil
@@ -371,6 +387,12 @@ func (c *Contributor) renderSubscriptionDetail(ctx context.Context, params contr
 		return nil, contributor.ErrPageNotFound
 	}
 
+	return c.subscriptionDetailPage(ctx, subID, "")
+}
+
+// subscriptionDetailPage renders the detail page of a subscription, showing
+// overrideErr on its overrides card when an override change failed.
+func (c *Contributor) subscriptionDetailPage(ctx context.Context, subID id.SubscriptionID, overrideErr string) (templ.Component, error) {
 	sub, err := c.store.GetSubscription(ctx, subID)
 	if err != nil {
 		return nil, fmt.Errorf("dashboard: resolve subscription: %w", err)
@@ -385,11 +407,16 @@ func (c *Contributor) renderSubscriptionDetail(ctx context.Context, params contr
 	// Fetch invoices for this subscription.
 	invoices, _ := c.store.ListInvoices(ctx, sub.TenantID, sub.AppID, invoice.ListOpts{Limit: 20}) //nolint:errcheck // best-effort for display
 
+	// Fetch the tenant's entitlement overrides.
+	overrides, _ := c.store.ListOverrides(ctx, sub.TenantID, sub.AppID) //nolint:errcheck // best-effort for display
+
 	data := pages.SubscriptionDetailData{
-		Subscription: sub,
-		Plan:         p,
-		Invoices:     invoices,
-		HasProviders: c.engine.HasProviders(),
+		Subscription:  sub,
+		Plan:          p,
+		Invoices:      invoices,
+		Overrides:     overrides,
+		OverrideError: overrideErr,
+		HasProviders:  c.engine.HasProviders(),
 	}
 
 	pluginSections := c.collectSubscriptionDetailSections(ctx, subID)
@@ -726,7 +753,24 @@ func (c *Contributor) renderUsage(ctx context.Context, params contributor.Params
 		events = nil
 	}
 
-	return pages.UsagePage(events), nil
+	streamURL := c.usageStreamURL
+	if streamURL != "" {
+		q := url.Values{}
+		if tenantID != "" {
+			q.Set("tenant_id", tenantID)
+		}
+		if featureKey != "" {
+			q.Set("feature", featureKey)
+		}
+		if c.appID != "" {
+			q.Set("app_id", c.appID)
+		}
+		if len(q) > 0 {
+			streamURL += "?" + q.Encode()
+		}
+	}
+
+	return pages.UsagePage(events, streamURL), nil
 }
 
 func (c *Contributor) renderSettings(_ context.Context) (templ.Component, error) {
@@ -971,10 +1015,13 @@ func (c *Contributor) handleSubscriptionSync(ctx context.Context, params contrib
 
 	invoices, _ := c.store.ListInvoices(ctx, sub.TenantID, sub.AppID, invoice.ListOpts{Limit: 20}) //nolint:errcheck // best-effort for display
 
+	overrides, _ := c.store.ListOverrides(ctx, sub.TenantID, sub.AppID) //nolint:errcheck // best-effort for display
+
 	data := pages.SubscriptionDetailData{
 		Subscription: sub,
 		Plan:         p,
 		Invoices:     invoices,
+		Overrides:    overrides,
 		HasProviders: c.engine.HasProviders(),
 		SyncResult:   result,
 	}
@@ -990,6 +1037,48 @@ func (c *Contributor) handleSubscriptionSync(ctx context.Context, params contrib
 	}), nil
 }
 
+func (c *Contributor) handleOverrideCreate(ctx context.Context, params contributor.Params) (templ.Component, error) {
+	subID, err := id.ParseSubscriptionID(params.QueryParams["id"])
+	if err != nil {
+		return nil, contributor.ErrPageNotFound
+	}
+
+	sub, err := c.store.GetSubscription(ctx, subID)
+	if err != nil {
+		return nil, fmt.Errorf("dashboard: resolve subscription: %w", err)
+	}
+
+	o, err := pages.ParseOverrideFromFormData(params.FormData)
+	if err == nil {
+		o.TenantID = sub.TenantID
+		o.AppID = sub.AppID
+		err = c.engine.CreateOverride(ctx, o)
+	}
+
+	var overrideErr string
+	if err != nil {
+		overrideErr = err.Error()
+	}
+	return c.subscriptionDetailPage(ctx, subID, overrideErr)
+}
+
+func (c *Contributor) handleOverrideDelete(ctx context.Context, params contributor.Params) (templ.Component, error) {
+	subID, err := id.ParseSubscriptionID(params.QueryParams["id"])
+	if err != nil {
+		return nil, contributor.ErrPageNotFound
+	}
+	overrideID, err := id.ParseOverrideID(params.QueryParams["override"])
+	if err != nil {
+		return nil, contributor.ErrPageNotFound
+	}
+
+	var overrideErr string
+	if err := c.engine.DeleteOverride(ctx, overrideID); err != nil {
+		overrideErr = err.Error()
+	}
+	return c.subscriptionDetailPage(ctx, subID, overrideErr)
+}
+
 func (c *Contributor) handleInvoiceSync(ctx context.Context, params contributor.Params) (templ.Component, error) {
 	invIDStr := params.QueryParams["id"]
 	if invIDStr == "" {
@@ -1044,29 +1133,31 @@ func (c *Contributor) getProviderNames() []string {
 
 // knownPageRoutes is the set of top-level page routes that the dashboard handles.
 var knownPageRoutes = map[string]bool{
-	"/":                     true,
-	"/plans":                true,
-	"/plans/detail":         true,
-	"/plans/new":            true,
-	"/plans/edit":           true,
-	"/plans/sync":           true,
-	"/subscriptions":        true,
-	"/subscriptions/detail": true,
-	"/subscriptions/new":    true,
-	"/subscriptions/sync":   true,
-	"/invoices":             true,
-	"/invoices/detail":      true,
-	"/invoices/sync":        true,
-	"/coupons":              true,
-	"/coupons/detail":       true,
-	"/coupons/new":          true,
-	"/coupons/edit":         true,
-	"/features":             true,
-	"/features/detail":      true,
-	"/features/new":         true,
-	"/features/edit":        true,
-	"/features/sync":        true,
-	"/payment-methods":      true,
-	"/usage":                true,
-	"/settings":             true,
+	"/":                               true,
+	"/plans":                          true,
+	"/plans/detail":                   true,
+	"/plans/new":                      true,
+	"/plans/edit":                     true,
+	"/plans/sync":                     true,
+	"/subscriptions":                  true,
+	"/subscriptions/detail":           true,
+	"/subscriptions/new":              true,
+	"/subscriptions/sync":             true,
+	"/subscriptions/overrides":        true,
+	"/subscriptions/overrides/delete": true,
+	"/invoices":                       true,
+	"/invoices/detail":                true,
+	"/invoices/sync":                  true,
+	"/coupons":                        true,
+	"/coupons/detail":                 true,
+	"/coupons/new":                    true,
+	"/coupons/edit":                   true,
+	"/features":                       true,
+	"/features/detail":                true,
+	"/features/new":                   true,
+	"/features/edit":                  true,
+	"/features/sync":                  true,
+	"/payment-methods":                true,
+	"/usage":                          true,
+	"/settings":                       true,
 }
@@ -34,7 +34,7 @@ func planFormScript() templ.Component {
 			templ_7745c5c3_Var1 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<script>\n\t(function() {\n\t\t// ─── CSS class constants ────────────────────────────────────────────\n\t\tvar INPUT_CLASS = 'flex h-9 w-full rounded-md border border-input bg-transparent px-3 py-1 text-sm ring-offset-background placeholder:text-muted-foreground focus-visible:border-ring focus-visible:ring-ring/50 focus-visible:ring-[3px] disabled:cursor-not-allowed disabled:opacity-50';\n\t\tvar SELECT_CLASS = 'flex h-9 w-full rounded-md border border-input bg-background px-3 py-1 text-sm ring-offset-background focus-visible:border-ring focus-visible:ring-ring/50 focus-visible:ring-[3px]';\n\t\tvar TRASH_SVG = '<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"14\" height=\"14\" viewBox=\"0 0 24 24\" fill=\"none\" stroke=\"currentColor\" stroke-width=\"2\" stroke-linecap=\"round\" stroke-linejoin=\"round\"><path d=\"M3 6h18\"/><path d=\"M19 6v14c0 1-1 2-2 2H7c-1 0-2-1-2-2V6\"/><path d=\"M8 6V4c0-1 1-2 2-2h4c1 0 2 1 2 2v2\"/></svg>';\n\n\t\tfunction escapeHtml(str) {\n\t\t\tif (!str) return '';\n\t\t\treturn str.replace(/&/g, '&amp;').replace(/</g, '&lt;').replace(/>/g, '&gt;').replace(/\"/g, '&quot;');\n\t\t}\n\n\t\t// ─── Feature Management ─────────────────────────────────────────────\n\n\t\twindow.addFeature = function() {\n\t\t\tvar container = document.getElementById('features-container');\n\t\t\tif (!container) return;\n\t\t\tvar items = container.querySelectorAll('[data-oa-item=\"features\"]');\n\t\t\tvar idx = items.length;\n\n\t\t\tvar html = '<div class=\"relative rounded-lg border bg-muted/30 p-4 space-y-3\" data-oa-item=\"features\" data-oa-index=\"' + idx + '\">';\n\t\t\thtml += '<button type=\"button\" class=\"absolute top-2 right-2 text-muted-foreground hover:text-destructive\" onclick=\"removeFeature(this)\" title=\"Remove feature\">' + TRASH_SVG + '</button>';\n\t\t\thtml += '<div class=\"grid grid-cols-1 md:grid-cols-2 gap-3 pr-6\">';\n\n\t\t\t// Key\n\t\t\thtml += '<div class=\"space-y-1\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Key <span class=\"text-destructive\">*</span></label>';\n\t\t\thtml += '<input type=\"text\" name=\"features[' + idx + '].key\" class=\"' + INPUT_CLASS + '\" placeholder=\"api_calls\" />';\n\t\t\thtml += '</div>';\n\n\t\t\t// Name\n\t\t\thtml += '<div class=\"space-y-1\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Name <span class=\"text-destructive\">*</span></label>';\n\t\t\thtml += '<input type=\"text\" name=\"features[' + idx + '].name\" class=\"' + INPUT_CLASS + '\" placeholder=\"API Calls\" />';\n\t\t\thtml += '</div>';\n\n\t\t\t// Type\n\t\t\thtml += '<div class=\"space-y-1\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Type</label>';\n\t\t\thtml += '<select name=\"features[' + idx + '].type\" class=\"' + SELECT_CLASS + '\" onchange=\"onFeatureTypeChange(this,' + idx + ')\">';\n\t\t\thtml += '<option value=\"metered\">Metered</option>';\n\t\t\thtml += '<option value=\"boolean\">Boolean</option>';\n\t\t\thtml += '<option value=\"seat\">Seat</option>';\n\t\t\thtml += '</select>';\n\t\t\thtml += '<p class=\"text-xs text-muted-foreground\" data-feature-desc=\"' + idx + '\">Usage-tracked feature with metering</p>';\n\t\t\thtml += '</div>';\n\n\t\t\t// Limit\n\t\t\thtml += '<div class=\"space-y-1\" data-feature-limit=\"' + idx + '\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Limit</label>';\n\t\t\thtml += '<input type=\"number\" name=\"features[' + idx + '].limit\" class=\"' + INPUT_CLASS + '\" placeholder=\"-1 for unlimited\" />';\n\t\t\thtml += '<p class=\"text-xs text-muted-foreground\">-1 = unlimited</p>';\n\t\t\thtml += '</div>';\n\n\t\t\t// Period\n\t\t\thtml += '<div class=\"space-y-1\" data-feature-period=\"' + idx + '\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Reset Period</label>';\n\t\t\thtml += '<select name=\"features[' + idx + '].period\" class=\"' + SELECT_CLASS + '\">';\n\t\t\thtml += '<option value=\"monthly\">Monthly</option>';\n\t\t\thtml += '<option value=\"yearly\">Yearly</option>';\n\t\t\thtml += '<option value=\"none\">None</option>';\n\t\t\thtml += '</select>';\n\t\t\thtml += '</div>';\n\n\t\t\t// Soft Limit\n\t\t\thtml += '<div class=\"space-y-1\" data-feature-soft=\"' + idx + '\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Soft Limit</label>';\n\t\t\thtml += '<div class=\"flex items-center gap-2\">';\n\t\t\thtml += '<input type=\"checkbox\" name=\"features[' + idx + '].soft_limit\" class=\"h-4 w-4 rounded border-input\" />';\n\t\t\thtml += '<span class=\"text-xs text-muted-foreground\">Allow usage beyond limit</span>';\n\t\t\thtml += '</div>';\n\t\t\thtml += '</div>';\n\n\t\t\thtml += '</div></div>';\n\n\t\t\tvar addBtn = document.getElementById('add-feature-btn');\n\t\t\tif (addBtn) {\n\t\t\t\taddBtn.insertAdjacentHTML('beforebegin', html);\n\t\t\t} else {\n\t\t\t\tcontainer.insertAdjacentHTML('beforeend', html);\n\t\t\t}\n\t\t};\n\n\t\twindow.removeFeature = function(btnEl) {\n\t\t\tvar itemEl = btnEl.closest('[data-oa-item=\"features\"]');\n\t\t\tif (itemEl) {\n\t\t\t\titemEl.remove();\n\t\t\t\treindexItems('features', 'features-container');\n\t\t\t}\n\t\t};\n\n\t\twindow.onFeatureTypeChange = function(selectEl, idx) {\n\t\t\tvar val = selectEl.value;\n\t\t\tvar limitDiv = document.querySelector('[data-feature-limit=\"' + idx + '\"]');\n\t\t\tvar periodDiv = document.querySelector('[data-feature-period=\"' + idx + '\"]');\n\t\t\tvar softDiv = document.querySelector('[data-feature-soft=\"' + idx + '\"]');\n\t\t\tvar descEl = document.querySelector('[data-feature-desc=\"' + idx + '\"]');\n\n\t\t\tif (val === 'boolean') {\n\t\t\t\tif (limitDiv) limitDiv.style.display = 'none';\n\t\t\t\tif (periodDiv) periodDiv.style.display = 'none';\n\t\t\t\tif (softDiv) softDiv.style.display = 'none';\n\t\t\t\tif (descEl) descEl.textContent = 'On/off feature toggle';\n\t\t\t} else if (val === 'seat') {\n\t\t\t\tif (limitDiv) limitDiv.style.display = '';\n\t\t\t\tif (periodDiv) periodDiv.style.display = '';\n\t\t\t\tif (softDiv) softDiv.style.display = 'none';\n\t\t\t\tif (descEl) descEl.textContent = 'Per-seat/user based feature';\n\t\t\t} else {\n\t\t\t\t// metered\n\t\t\t\tif (limitDiv) limitDiv.style.display = '';\n\t\t\t\tif (periodDiv) periodDiv.style.display = '';\n\t\t\t\tif (softDiv) softDiv.style.display = '';\n\t\t\t\tif (descEl) descEl.textContent = 'Usage-tracked feature with metering';\n\t\t\t}\n\t\t};\n\n\t\t// ─── Tier Management ────────────────────────────────────────────────\n\n\t\twindow.addTier = function() {\n\t\t\tvar container = document.getElementById('tiers-container');\n\t\t\tif (!container) return;\n\t\t\tvar items = container.querySelectorAll('[data-oa-item=\"tiers\"]');\n\t\t\tvar idx = items.length;\n\n\t\t\t// Build feature key options from current features\n\t\t\tvar featureOpts = getFeatureKeyOptions();\n\n\t\t\tvar html = '<div class=\"relative rounded-lg border bg-muted/30 p-4 space-y-3\" data-oa-item=\"tiers\" data-oa-index=\"' + idx + '\">';\n\t\t\thtml += '<button type=\"button\" class=\"absolute top-2 right-2 text-muted-foreground hover:text-destructive\" onclick=\"removeTier(this)\" title=\"Remove tier\">' + TRASH_SVG + '</button>';\n\t\t\thtml += '<div class=\"grid grid-cols-1 md:grid-cols-3 gap-3 pr-6\">';\n\n\t\t\t// Feature Key\n\t\t\thtml += '<div class=\"space-y-1\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Feature Key <span class=\"text-destructive\">*</span></label>';\n\t\t\thtml += '<select name=\"tiers[' + idx + '].feature_key\" class=\"' + SELECT_CLASS + '\">';\n\t\t\thtml += featureOpts;\n\t\t\thtml += '</select>';\n\t\t\thtml += '</div>';\n\n\t\t\t// Tier Type\n\t\t\thtml += '<div class=\"space-y-1\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Tier Type</label>';\n\t\t\thtml += '<select name=\"tiers[' + idx + '].type\" class=\"' + SELECT_CLASS + '\">';\n\t\t\thtml += '<option value=\"graduated\">Graduated</option>';\n\t\t\thtml += '<option value=\"volume\">Volume</option>';\n\t\t\thtml += '<option value=\"flat\">Flat</option>';\n\t\t\thtml += '</select>';\n\t\t\thtml += '</div>';\n\n\t\t\t// Up To\n\t\t\thtml += '<div class=\"space-y-1\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Up To</label>';\n\t\t\thtml += '<input type=\"number\" name=\"tiers[' + idx + '].up_to\" class=\"' + INPUT_CLASS + '\" placeholder=\"0 = unlimited\" />';\n\t\t\thtml += '</div>';\n\n\t\t\t// Unit Amount\n\t\t\thtml += '<div class=\"space-y-1\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Unit Amount (cents)</label>';\n\t\t\thtml += '<input type=\"number\" name=\"tiers[' + idx + '].unit_amount\" class=\"' + INPUT_CLASS + '\" placeholder=\"100\" />';\n\t\t\thtml += '</div>';\n\n\t\t\t// Flat Amount\n\t\t\thtml += '<div class=\"space-y-1\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Flat Amount (cents)</label>';\n\t\t\thtml += '<input type=\"number\" name=\"tiers[' + idx + '].flat_amount\" class=\"' + INPUT_CLASS + '\" placeholder=\"0\" />';\n\t\t\thtml += '</div>';\n\n\t\t\t// Priority\n\t\t\thtml += '<div class=\"space-y-1\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Priority</label>';\n\t\t\thtml += '<input type=\"number\" name=\"tiers[' + idx + '].priority\" class=\"' + INPUT_CLASS + '\" value=\"' + idx + '\" />';\n\t\t\thtml += '</div>';\n\n\t\t\thtml += '</div></div>';\n\n\t\t\tvar addBtn = document.getElementById('add-tier-btn');\n\t\t\tif (addBtn) {\n\t\t\t\taddBtn.insertAdjacentHTML('beforebegin', html);\n\t\t\t} else {\n\t\t\t\tcontainer.insertAdjacentHTML('beforeend', html);\n\t\t\t}\n\t\t};\n\n\t\twindow.removeTier = function(btnEl) {\n\t\t\tvar itemEl = btnEl.closest('[data-oa-item=\"tiers\"]');\n\t\t\tif (itemEl) {\n\t\t\t\titemEl.remove();\n\t\t\t\treindexItems('tiers', 'tiers-container');\n\t\t\t}\n\t\t};\n\n\t\t// ─── Metadata Management ────────────────────────────────────────────\n\n\t\twindow.addMetadataRow = function() {\n\t\t\tvar container = document.getElementById('metadata-container');\n\t\t\tif (!container) return;\n\t\t\tvar items = container.querySelectorAll('[data-oa-item=\"metadata\"]');\n\t\t\tvar idx = items.length;\n\n\t\t\tvar html = '<div class=\"relative flex items-start gap-3 p-3 rounded-lg border bg-muted/30\" data-oa-item=\"metadata\" data-oa-index=\"' + idx + '\">';\n\n\t\t\thtml += '<div class=\"flex-1 space-y-1\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Key</label>';\n\t\t\thtml += '<input type=\"text\" name=\"metadata[' + idx + '].key\" class=\"' + INPUT_CLASS + '\" placeholder=\"key\" />';\n\t\t\thtml += '</div>';\n\n\t\t\thtml += '<div class=\"flex-1 space-y-1\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Value</label>';\n\t\t\thtml += '<input type=\"text\" name=\"metadata[' + idx + '].value\" class=\"' + INPUT_CLASS + '\" placeholder=\"value\" />';\n\t\t\thtml += '</div>';\n\n\t\t\thtml += '<button type=\"button\" class=\"mt-5 text-muted-foreground hover:text-destructive\" onclick=\"removeMetadataRow(this)\" title=\"Remove\">' + TRASH_SVG + '</button>';\n\n\t\t\thtml += '</div>';\n\n\t\t\tvar addBtn = document.getElementById('add-metadata-btn');\n\t\t\tif (addBtn) {\n\t\t\t\taddBtn.insertAdjacentHTML('beforebegin', html);\n\t\t\t} else {\n\t\t\t\tcontainer.insertAdjacentHTML('beforeend', html);\n\t\t\t}\n\t\t};\n\n\t\twindow.removeMetadataRow = function(btnEl) {\n\t\t\tvar itemEl = btnEl.closest('[data-oa-item=\"metadata\"]');\n\t\t\tif (itemEl) {\n\t\t\t\titemEl.remove();\n\t\t\t\treindexItems('metadata', 'metadata-container');\n\t\t\t}\n\t\t};\n\n\t\t// ─── Re-indexing ────────────────────────────────────────────────────\n\n\t\tfunction reindexItems(key, containerId) {\n\t\t\tvar container = document.getElementById(containerId);\n\t\t\tif (!container) return;\n\t\t\tvar items = container.querySelectorAll('[data-oa-item=\"' + key + '\"]');\n\t\t\tfor (var i = 0; i < items.length; i++) {\n\t\t\t\tvar oldIndex = items[i].getAttribute('data-oa-index');\n\t\t\t\titems[i].setAttribute('data-oa-index', String(i));\n\n\t\t\t\t// Re-index input/select names\n\t\t\t\tvar inputs = items[i].querySelectorAll('input, select, textarea');\n\t\t\t\tfor (var j = 0; j < inputs.length; j++) {\n\t\t\t\t\tvar name = inputs[j].getAttribute('name');\n\t\t\t\t\tif (name) {\n\t\t\t\t\t\tinputs[j].setAttribute('name', name.replace(key + '[' + oldIndex + ']', key + '[' + i + ']'));\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Re-index data attributes for feature type toggle\n\t\t\t\tif (key === 'features') {\n\t\t\t\t\tvar limitDiv = items[i].querySelector('[data-feature-limit]');\n\t\t\t\t\tif (limitDiv) limitDiv.setAttribute('data-feature-limit', String(i));\n\t\t\t\t\tvar periodDiv = items[i].querySelector('[data-feature-period]');\n\t\t\t\t\tif (periodDiv) periodDiv.setAttribute('data-feature-period', String(i));\n\t\t\t\t\tvar softDiv = items[i].querySelector('[data-feature-soft]');\n\t\t\t\t\tif (softDiv) softDiv.setAttribute('data-feature-soft', String(i));\n\t\t\t\t\tvar descEl = items[i].querySelector('[data-feature-desc]');\n\t\t\t\t\tif (descEl) descEl.setAttribute('data-feature-desc', String(i));\n\n\t\t\t\t\t// Update onchange handler index\n\t\t\t\t\tvar typeSelect = items[i].querySelector('select[name*=\".type\"]');\n\t\t\t\t\tif (typeSelect) {\n\t\t\t\t\t\ttypeSelect.setAttribute('onchange', 'onFeatureTypeChange(this,' + i + ')');\n\t\t\t\t\t}\n\t\t\t\t}\n\t\t\t}\n\t\t}\n\n\t\t// ─── Feature key helpers ────────────────────────────────────────────\n\n\t\tfunction getFeatureKeyOptions() {\n\t\t\tvar container = document.getElementById('features-container');\n\t\t\tif (!container) return '<option value=\"\">No features defined</option>';\n\t\t\tvar items = container.querySelectorAll('[data-oa-item=\"features\"]');\n\t\t\tif (items.length === 0) return '<option value=\"\">No features defined</option>';\n\n\t\t\tvar html = '';\n\t\t\tfor (var i = 0; i < items.length; i++) {\n\t\t\t\tvar keyInput = items[i].querySelector('input[name*=\".key\"]');\n\t\t\t\tif (keyInput && keyInput.value) {\n\t\t\t\t\thtml += '<option value=\"' + escapeHtml(keyInput.value) + '\">' + escapeHtml(keyInput.value) + '</option>';\n\t\t\t\t}\n\t\t\t}\n\t\t\treturn html || '<option value=\"\">No features defined</option>';\n\t\t}\n\n\t\t// ─── App ID scope indicator ─────────────────────────────────────────\n\n\t\twindow.updateAppScopeIndicator = function(inputEl) {\n\t\t\tvar indicator = document.getElementById('app-scope-indicator');\n\t\t\tif (!indicator) return;\n\t\t\tif (inputEl.value && inputEl.value.trim() !== '') {\n\t\t\t\tindicator.innerHTML = '<span class=\"inline-flex items-center rounded-sm border px-2.5 py-0.5 text-xs font-semibold bg-primary text-primary-foreground\">App: ' + escapeHtml(inputEl.value.trim()) + '</span>';\n\t\t\t} else {\n\t\t\t\tindicator.innerHTML = '<span class=\"inline-flex items-center rounded-sm border px-2.5 py-0.5 text-xs font-semibold\">Global</span>';\n\t\t\t}\n\t\t};\n\n\t\t// ─── Sync form data to hidden JSON inputs ───────────────────────────\n\n\t\twindow.syncPlanFormData = function() {\n\t\t\tsyncFeatures();\n\t\t\tsyncTiers();\n\t\t\tsyncMetadata();\n\t\t};\n\n\t\tfunction syncFeatures() {\n\t\t\tvar container = document.getElementById('features-container');\n\t\t\tvar hidden = document.getElementById('features_json');\n\t\t\tif (!container || !hidden) return;\n\n\t\t\tvar items = container.querySelectorAll('[data-oa-item=\"features\"]');\n\t\t\tvar result = [];\n\t\t\tfor (var i = 0; i < items.length; i++) {\n\t\t\t\tvar obj = {};\n\t\t\t\tvar keyEl = items[i].querySelector('input[name*=\".key\"]');\n\t\t\t\tvar nameEl = items[i].querySelector('input[name*=\".name\"]');\n\t\t\t\tvar typeEl = items[i].querySelector('select[name*=\".type\"]');\n\t\t\t\tvar limitEl = items[i].querySelector('input[name*=\".limit\"]');\n\t\t\t\tvar periodEl = items[i].querySelector('select[name*=\".period\"]');\n\t\t\t\tvar softEl = items[i].querySelector('input[name*=\".soft_limit\"]');\n\n\t\t\t\tobj.key = keyEl ? keyEl.value : '';\n\t\t\t\tobj.name = nameEl ? nameEl.value : '';\n\t\t\t\tobj.type = typeEl ? typeEl.value : 'metered';\n\t\t\t\tobj.limit = limitEl ? limitEl.value : '';\n\t\t\t\tobj.period = periodEl ? periodEl.value : 'monthly';\n\t\t\t\tobj.soft_limit = softEl ? softEl.checked : false;\n\n\t\t\t\tif (obj.key) result.push(obj);\n\t\t\t}\n\t\t\thidden.value = JSON.stringify(result);\n\t\t}\n\n\t\tfunction syncTiers() {\n\t\t\tvar container = document.getElementById('tiers-container');\n\t\t\tvar hidden = document.getElementById('tiers_json');\n\t\t\tif (!container || !hidden) return;\n\n\t\t\tvar items = container.querySelectorAll('[data-oa-item=\"tiers\"]');\n\t\t\tvar result = [];\n\t\t\tfor (var i = 0; i < items.length; i++) {\n\t\t\t\tvar obj = {};\n\t\t\t\tvar fkEl = items[i].querySelector('select[name*=\".feature_key\"], input[name*=\".feature_key\"]');\n\t\t\t\tvar typeEl = items[i].querySelector('select[name*=\".type\"]');\n\t\t\t\tvar upToEl = items[i].querySelector('input[name*=\".up_to\"]');\n\t\t\t\tvar unitEl = items[i].querySelector('input[name*=\".unit_amount\"]');\n\t\t\t\tvar flatEl = items[i].querySelector('input[name*=\".flat_amount\"]');\n\t\t\t\tvar prioEl = items[i].querySelector('input[name*=\".priority\"]');\n\n\t\t\t\tobj.feature_key = fkEl ? fkEl.value : '';\n\t\t\t\tobj.type = typeEl ? typeEl.value : 'graduated';\n\t\t\t\tobj.up_to = upToEl ? upToEl.value : '';\n\t\t\t\tobj.unit_amount = unitEl ? unitEl.value : '';\n\t\t\t\tobj.flat_amount = flatEl ? flatEl.value : '';\n\t\t\t\tobj.priority = prioEl ? prioEl.value : String(i);\n\n\t\t\t\tif (obj.feature_key) result.push(obj);\n\t\t\t}\n\t\t\thidden.value = JSON.stringify(result);\n\t\t}\n\n\t\tfunction syncMetadata() {\n\t\t\tvar container = document.getElementById('metadata-container');\n\t\t\tvar hidden = document.getElementById('metadata_json');\n\t\t\tif (!container || !hidden) return;\n\n\t\t\tvar items = container.querySelectorAll('[data-oa-item=\"metadata\"]');\n\t\t\tvar result = [];\n\t\t\tfor (var i = 0; i < items.length; i++) {\n\t\t\t\tvar keyEl = items[i].querySelector('input[name*=\".key\"]');\n\t\t\t\tvar valEl = items[i].querySelector('input[name*=\".value\"]');\n\t\t\t\tvar obj = {\n\t\t\t\t\tkey: keyEl ? keyEl.value : '',\n\t\t\t\t\tvalue: valEl ? valEl.value : ''\n\t\t\t\t};\n\t\t\t\tif (obj.key) result.push(obj);\n\t\t\t}\n\t\t\thidden.value = JSON.stringify(result);\n\t\t}\n\n\t\t// ─── Form submit interception ───────────────────────────────────────\n\n\t\t// Listen for htmx:configRequest to sync data before HTMX sends POST\n\t\tdocument.body.addEventListener('htmx:configRequest', function(event) {\n\t\t\tvar form = event.detail.elt;\n\t\t\tif (form && form.id === 'plan-form') {\n\t\t\t\tsyncPlanFormData();\n\t\t\t}\n\t\t\t// Also handle if triggered from a child element\n\t\t\tif (form && form.closest && form.closest('#plan-form')) {\n\t\t\t\tsyncPlanFormData();\n\t\t\t}\n\t\t});\n\t})();\n\t</script>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<script>\n\t(function() {\n\t\t// ─── CSS class constants ────────────────────────────────────────────\n\t\tvar INPUT_CLASS = 'flex h-9 w-full rounded-md border border-input bg-transparent px-3 py-1 text-sm ring-offset-background placeholder:text-muted-foreground focus-visible:border-ring focus-visible:ring-ring/50 focus-visible:ring-[3px] disabled:cursor-not-allowed disabled:opacity-50';\n\t\tvar SELECT_CLASS = 'flex h-9 w-full rounded-md border border-input bg-background px-3 py-1 text-sm ring-offset-background focus-visible:border-ring focus-visible:ring-ring/50 focus-visible:ring-[3px]';\n\t\tvar TRASH_SVG = '<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"14\" height=\"14\" viewBox=\"0 0 24 24\" fill=\"none\" stroke=\"currentColor\" stroke-width=\"2\" stroke-linecap=\"round\" stroke-linejoin=\"round\"><path d=\"M3 6h18\"/><path d=\"M19 6v14c0 1-1 2-2 2H7c-1 0-2-1-2-2V6\"/><path d=\"M8 6V4c0-1 1-2 2-2h4c1 0 2 1 2 2v2\"/></svg>';\n\n\t\tfunction escapeHtml(str) {\n\t\t\tif (!str) return '';\n\t\t\treturn str.replace(/&/g, '&amp;').replace(/</g, '&lt;').replace(/>/g, '&gt;').replace(/\"/g, '&quot;');\n\t\t}\n\n\t\t// ─── Feature Management ─────────────────────────────────────────────\n\n\t\twindow.addFeature = function() {\n\t\t\tvar container = document.getElementById('features-container');\n\t\t\tif (!container) return;\n\t\t\tvar items = container.querySelectorAll('[data-oa-item=\"features\"]');\n\t\t\tvar idx = items.length;\n\n\t\t\tvar html = '<div class=\"relative rounded-lg border bg-muted/30 p-4 space-y-3\" data-oa-item=\"features\" data-oa-index=\"' + idx + '\">';\n\t\t\thtml += '<button type=\"button\" class=\"absolute top-2 right-2 text-muted-foreground hover:text-destructive\" onclick=\"removeFeature(this)\" title=\"Remove feature\">' + TRASH_SVG + '</button>';\n\t\t\thtml += '<div class=\"grid grid-cols-1 md:grid-cols-2 gap-3 pr-6\">';\n\n\t\t\t// Key\n\t\t\thtml += '<div class=\"space-y-1\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Key <span class=\"text-destructive\">*</span></label>';\n\t\t\thtml += '<input type=\"text\" name=\"features[' + idx + '].key\" class=\"' + INPUT_CLASS + '\" placeholder=\"api_calls\" />';\n\t\t\thtml += '</div>';\n\n\t\t\t// Name\n\t\t\thtml += '<div class=\"space-y-1\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Name <span class=\"text-destructive\">*</span></label>';\n\t\t\thtml += '<input type=\"text\" name=\"features[' + idx + '].name\" class=\"' + INPUT_CLASS + '\" placeholder=\"API Calls\" />';\n\t\t\thtml += '</div>';\n\n\t\t\t// Type\n\t\t\thtml += '<div class=\"space-y-1\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Type</label>';\n\t\t\thtml += '<select name=\"features[' + idx + '].type\" class=\"' + SELECT_CLASS + '\" onchange=\"onFeatureTypeChange(this,' + idx + ')\">';\n\t\t\thtml += '<option value=\"metered\">Metered</option>';\n\t\t\thtml += '<option value=\"boolean\">Boolean</option>';\n\t\t\thtml += '<option value=\"seat\">Seat</option>';\n\t\t\thtml += '</select>';\n\t\t\thtml += '<p class=\"text-xs text-muted-foreground\" data-feature-desc=\"' + idx + '\">Usage-tracked feature with metering</p>';\n\t\t\thtml += '</div>';\n\n\t\t\t// Limit\n\t\t\thtml += '<div class=\"space-y-1\" data-feature-limit=\"' + idx + '\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Limit</label>';\n\t\t\thtml += '<input type=\"number\" name=\"features[' + idx + '].limit\" class=\"' + INPUT_CLASS + '\" placeholder=\"-1 for unlimited\" />';\n\t\t\thtml += '<p class=\"text-xs text-muted-foreground\">-1 = unlimited</p>';\n\t\t\thtml += '</div>';\n\n\t\t\t// Period\n\t\t\thtml += '<div class=\"space-y-1\" data-feature-period=\"' + idx + '\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Reset Period</label>';\n\t\t\thtml += '<select name=\"features[' + idx + '].period\" class=\"' + SELECT_CLASS + '\">';\n\t\t\thtml += '<option value=\"monthly\">Monthly</option>';\n\t\t\thtml += '<option value=\"yearly\">Yearly</option>';\n\t\t\thtml += '<option value=\"none\">None</option>';\n\t\t\thtml += '<option value=\"rolling_24h\">Rolling 24 hours</option>';\n\t\t\thtml += '<option value=\"rolling_30d\">Rolling 30 days</option>';\n\t\t\thtml += '<option value=\"second\">Per second</option>';\n\t\t\thtml += '<option value=\"minute\">Per minute</option>';\n\t\t\thtml += '<option value=\"hour\">Per hour</option>';\n\t\t\thtml += '</select>';\n\t\t\thtml += '</div>';\n\n\t\t\t// Soft Limit\n\t\t\thtml += '<div class=\"space-y-1\" data-feature-soft=\"' + idx + '\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Soft Limit</label>';\n\t\t\thtml += '<div class=\"flex items-center gap-2\">';\n\t\t\thtml += '<input type=\"checkbox\" name=\"features[' + idx + '].soft_limit\" class=\"h-4 w-4 rounded border-input\" />';\n\t\t\thtml += '<span class=\"text-xs text-muted-foreground\">Allow usage beyond limit</span>';\n\t\t\thtml += '</div>';\n\t\t\thtml += '</div>';\n\n\t\t\thtml += '</div></div>';\n\n\t\t\tvar addBtn = document.getElementById('add-feature-btn');\n\t\t\tif (addBtn) {\n\t\t\t\taddBtn.insertAdjacentHTML('beforebegin', html);\n\t\t\t} else {\n\t\t\t\tcontainer.insertAdjacentHTML('beforeend', html);\n\t\t\t}\n\t\t};\n\n\t\twindow.removeFeature = function(btnEl) {\n\t\t\tvar itemEl = btnEl.closest('[data-oa-item=\"features\"]');\n\t\t\tif (itemEl) {\n\t\t\t\titemEl.remove();\n\t\t\t\treindexItems('features', 'features-container');\n\t\t\t}\n\t\t};\n\n\t\twindow.onFeatureTypeChange = function(selectEl, idx) {\n\t\t\tvar val = selectEl.value;\n\t\t\tvar limitDiv = document.querySelector('[data-feature-limit=\"' + idx + '\"]');\n\t\t\tvar periodDiv = document.querySelector('[data-feature-period=\"' + idx + '\"]');\n\t\t\tvar softDiv = document.querySelector('[data-feature-soft=\"' + idx + '\"]');\n\t\t\tvar descEl = document.querySelector('[data-feature-desc=\"' + idx + '\"]');\n\n\t\t\tif (val === 'boolean') {\n\t\t\t\tif (limitDiv) limitDiv.style.display = 'none';\n\t\t\t\tif (periodDiv) periodDiv.style.display = 'none';\n\t\t\t\tif (softDiv) softDiv.style.display = 'none';\n\t\t\t\tif (descEl) descEl.textContent = 'On/off feature toggle';\n\t\t\t} else if (val === 'seat') {\n\t\t\t\tif (limitDiv) limitDiv.style.display = '';\n\t\t\t\tif (periodDiv) periodDiv.style.display = '';\n\t\t\t\tif (softDiv) softDiv.style.display = 'none';\n\t\t\t\tif (descEl) descEl.textContent = 'Per-seat/user based feature';\n\t\t\t} else {\n\t\t\t\t// metered\n\t\t\t\tif (limitDiv) limitDiv.style.display = '';\n\t\t\t\tif (periodDiv) periodDiv.style.display = '';\n\t\t\t\tif (softDiv) softDiv.style.display = '';\n\t\t\t\tif (descEl) descEl.textContent = 'Usage-tracked feature with metering';\n\t\t\t}\n\t\t};\n\n\t\t// ─── Tier Management ────────────────────────────────────────────────\n\n\t\twindow.addTier = function() {\n\t\t\tvar container = document.getElementById('tiers-container');\n\t\t\tif (!container) return;\n\t\t\tvar items = container.querySelectorAll('[data-oa-item=\"tiers\"]');\n\t\t\tvar idx = items.length;\n\n\t\t\t// Build feature key options from current features\n\t\t\tvar featureOpts = getFeatureKeyOptions();\n\n\t\t\tvar html = '<div class=\"relative rounded-lg border bg-muted/30 p-4 space-y-3\" data-oa-item=\"tiers\" data-oa-index=\"' + idx + '\">';\n\t\t\thtml += '<button type=\"button\" class=\"absolute top-2 right-2 text-muted-foreground hover:text-destructive\" onclick=\"removeTier(this)\" title=\"Remove tier\">' + TRASH_SVG + '</button>';\n\t\t\thtml += '<div class=\"grid grid-cols-1 md:grid-cols-3 gap-3 pr-6\">';\n\n\t\t\t// Feature Key\n\t\t\thtml += '<div class=\"space-y-1\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Feature Key <span class=\"text-destructive\">*</span></label>';\n\t\t\thtml += '<select name=\"tiers[' + idx + '].feature_key\" class=\"' + SELECT_CLASS + '\">';\n\t\t\thtml += featureOpts;\n\t\t\thtml += '</select>';\n\t\t\thtml += '</div>';\n\n\t\t\t// Tier Type\n\t\t\thtml += '<div class=\"space-y-1\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Tier Type</label>';\n\t\t\thtml += '<select name=\"tiers[' + idx + '].type\" class=\"' + SELECT_CLASS + '\">';\n\t\t\thtml += '<option value=\"graduated\">Graduated</option>';\n\t\t\thtml += '<option value=\"volume\">Volume</option>';\n\t\t\thtml += '<option value=\"flat\">Flat</option>';\n\t\t\thtml += '</select>';\n\t\t\thtml += '</div>';\n\n\t\t\t// Up To\n\t\t\thtml += '<div class=\"space-y-1\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Up To</label>';\n\t\t\thtml += '<input type=\"number\" name=\"tiers[' + idx + '].up_to\" class=\"' + INPUT_CLASS + '\" placeholder=\"0 = unlimited\" />';\n\t\t\thtml += '</div>';\n\n\t\t\t// Unit Amount\n\t\t\thtml += '<div class=\"space-y-1\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Unit Amount (cents)</label>';\n\t\t\thtml += '<input type=\"number\" name=\"tiers[' + idx + '].unit_amount\" class=\"' + INPUT_CLASS + '\" placeholder=\"100\" />';\n\t\t\thtml += '</div>';\n\n\t\t\t// Flat Amount\n\t\t\thtml += '<div class=\"space-y-1\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Flat Amount (cents)</label>';\n\t\t\thtml += '<input type=\"number\" name=\"tiers[' + idx + '].flat_amount\" class=\"' + INPUT_CLASS + '\" placeholder=\"0\" />';\n\t\t\thtml += '</div>';\n\n\t\t\t// Priority\n\t\t\thtml += '<div class=\"space-y-1\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Priority</label>';\n\t\t\thtml += '<input type=\"number\" name=\"tiers[' + idx + '].priority\" class=\"' + INPUT_CLASS + '\" value=\"' + idx + '\" />';\n\t\t\thtml += '</div>';\n\n\t\t\thtml += '</div></div>';\n\n\t\t\tvar addBtn = document.getElementById('add-tier-btn');\n\t\t\tif (addBtn) {\n\t\t\t\taddBtn.insertAdjacentHTML('beforebegin', html);\n\t\t\t} else {\n\t\t\t\tcontainer.insertAdjacentHTML('beforeend', html);\n\t\t\t}\n\t\t};\n\n\t\twindow.removeTier = function(btnEl) {\n\t\t\tvar itemEl = btnEl.closest('[data-oa-item=\"tiers\"]');\n\t\t\tif (itemEl) {\n\t\t\t\titemEl.remove();\n\t\t\t\treindexItems('tiers', 'tiers-container');\n\t\t\t}\n\t\t};\n\n\t\t// ─── Metadata Management ────────────────────────────────────────────\n\n\t\twindow.addMetadataRow = function() {\n\t\t\tvar container = document.getElementById('metadata-container');\n\t\t\tif (!container) return;\n\t\t\tvar items = container.querySelectorAll('[data-oa-item=\"metadata\"]');\n\t\t\tvar idx = items.length;\n\n\t\t\tvar html = '<div class=\"relative flex items-start gap-3 p-3 rounded-lg border bg-muted/30\" data-oa-item=\"metadata\" data-oa-index=\"' + idx + '\">';\n\n\t\t\thtml += '<div class=\"flex-1 space-y-1\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Key</label>';\n\t\t\thtml += '<input type=\"text\" name=\"metadata[' + idx + '].key\" class=\"' + INPUT_CLASS + '\" placeholder=\"key\" />';\n\t\t\thtml += '</div>';\n\n\t\t\thtml += '<div class=\"flex-1 space-y-1\">';\n\t\t\thtml += '<label class=\"text-xs font-medium text-muted-foreground\">Value</label>';\n\t\t\thtml += '<input type=\"text\" name=\"metadata[' + idx + '].value\" class=\"' + INPUT_CLASS + '\" placeholder=\"value\" />';\n\t\t\thtml += '</div>';\n\n\t\t\thtml += '<button type=\"button\" class=\"mt-5 text-muted-foreground hover:text-destructive\" onclick=\"removeMetadataRow(this)\" title=\"Remove\">' + TRASH_SVG + '</button>';\n\n\t\t\thtml += '</div>';\n\n\t\t\tvar addBtn = document.getElementById('add-metadata-btn');\n\t\t\tif (addBtn) {\n\t\t\t\taddBtn.insertAdjacentHTML('beforebegin', html);\n\t\t\t} else {\n\t\t\t\tcontainer.insertAdjacentHTML('beforeend', html);\n\t\t\t}\n\t\t};\n\n\t\twindow.removeMetadataRow = function(btnEl) {\n\t\t\tvar itemEl = btnEl.closest('[data-oa-item=\"metadata\"]');\n\t\t\tif (itemEl) {\n\t\t\t\titemEl.remove();\n\t\t\t\treindexItems('metadata', 'metadata-container');\n\t\t\t}\n\t\t};\n\n\t\t// ─── Re-indexing ────────────────────────────────────────────────────\n\n\t\tfunction reindexItems(key, containerId) {\n\t\t\tvar container = document.getElementById(containerId);\n\t\t\tif (!container) return;\n\t\t\tvar items = container.querySelectorAll('[data-oa-item=\"' + key + '\"]');\n\t\t\tfor (var i = 0; i < items.length; i++) {\n\t\t\t\tvar oldIndex = items[i].getAttribute('data-oa-index');\n\t\t\t\titems[i].setAttribute('data-oa-index', String(i));\n\n\t\t\t\t// Re-index input/select names\n\t\t\t\tvar inputs = items[i].querySelectorAll('input, select, textarea');\n\t\t\t\tfor (var j = 0; j < inputs.length; j++) {\n\t\t\t\t\tvar name = inputs[j].getAttribute('name');\n\t\t\t\t\tif (name) {\n\t\t\t\t\t\tinputs[j].setAttribute('name', name.replace(key + '[' + oldIndex + ']', key + '[' + i + ']'));\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\t// Re-index data attributes for feature type toggle\n\t\t\t\tif (key === 'features') {\n\t\t\t\t\tvar limitDiv = items[i].querySelector('[data-feature-limit]');\n\t\t\t\t\tif (limitDiv) limitDiv.setAttribute('data-feature-limit', String(i));\n\t\t\t\t\tvar periodDiv = items[i].querySelector('[data-feature-period]');\n\t\t\t\t\tif (periodDiv) periodDiv.setAttribute('data-feature-period', String(i));\n\t\t\t\t\tvar softDiv = items[i].querySelector('[data-feature-soft]');\n\t\t\t\t\tif (softDiv) softDiv.setAttribute('data-feature-soft', String(i));\n\t\t\t\t\tvar descEl = items[i].querySelector('[data-feature-desc]');\n\t\t\t\t\tif (descEl) descEl.setAttribute('data-feature-desc', String(i));\n\n\t\t\t\t\t// Update onchange handler index\n\t\t\t\t\tvar typeSelect = items[i].querySelector('select[name*=\".type\"]');\n\t\t\t\t\tif (typeSelect) {\n\t\t\t\t\t\ttypeSelect.setAttribute('onchange', 'onFeatureTypeChange(this,' + i + ')');\n\t\t\t\t\t}\n\t\t\t\t}\n\t\t\t}\n\t\t}\n\n\t\t// ─── Feature key helpers ────────────────────────────────────────────\n\n\t\tfunction getFeatureKeyOptions() {\n\t\t\tvar container = document.getElementById('features-container');\n\t\t\tif (!container) return '<option value=\"\">No features defined</option>';\n\t\t\tvar items = container.querySelectorAll('[data-oa-item=\"features\"]');\n\t\t\tif (items.length === 0) return '<option value=\"\">No features defined</option>';\n\n\t\t\tvar html = '';\n\t\t\tfor (var i = 0; i < items.length; i++) {\n\t\t\t\tvar keyInput = items[i].querySelector('input[name*=\".key\"]');\n\t\t\t\tif (keyInput && keyInput.value) {\n\t\t\t\t\thtml += '<option value=\"' + escapeHtml(keyInput.value) + '\">' + escapeHtml(keyInput.value) + '</option>';\n\t\t\t\t}\n\t\t\t}\n\t\t\treturn html || '<option value=\"\">No features defined</option>';\n\t\t}\n\n\t\t// ─── App ID scope indicator ─────────────────────────────────────────\n\n\t\twindow.updateAppScopeIndicator = function(inputEl) {\n\t\t\tvar indicator = document.getElementById('app-scope-indicator');\n\t\t\tif (!indicator) return;\n\t\t\tif (inputEl.value && inputEl.value.trim() !== '') {\n\t\t\t\tindicator.innerHTML = '<span class=\"inline-flex items-center rounded-sm border px-2.5 py-0.5 text-xs font-semibold bg-primary text-primary-foreground\">App: ' + escapeHtml(inputEl.value.trim()) + '</span>';\n\t\t\t} else {\n\t\t\t\tindicator.innerHTML = '<span class=\"inline-flex items-center rounded-sm border px-2.5 py-0.5 text-xs font-semibold\">Global</span>';\n\t\t\t}\n\t\t};\n\n\t\t// ─── Sync form data to hidden JSON inputs ───────────────────────────\n\n\t\twindow.syncPlanFormData = function() {\n\t\t\tsyncFeatures();\n\t\t\tsyncTiers();\n\t\t\tsyncMetadata();\n\t\t};\n\n\t\tfunction syncFeatures() {\n\t\t\tvar container = document.getElementById('features-container');\n\t\t\tvar hidden = document.getElementById('features_json');\n\t\t\tif (!container || !hidden) return;\n\n\t\t\tvar items = container.querySelectorAll('[data-oa-item=\"features\"]');\n\t\t\tvar result = [];\n\t\t\tfor (var i = 0; i < items.length; i++) {\n\t\t\t\tvar obj = {};\n\t\t\t\tvar keyEl = items[i].querySelector('input[name*=\".key\"]');\n\t\t\t\tvar nameEl = items[i].querySelector('input[name*=\".name\"]');\n\t\t\t\tvar typeEl = items[i].querySelector('select[name*=\".type\"]');\n\t\t\t\tvar limitEl = items[i].querySelector('input[name*=\".limit\"]');\n\t\t\t\tvar periodEl = items[i].querySelector('select[name*=\".period\"]');\n\t\t\t\tvar softEl = items[i].querySelector('input[name*=\".soft_limit\"]');\n\n\t\t\t\tobj.key = keyEl ? keyEl.value : '';\n\t\t\t\tobj.name = nameEl ? nameEl.value : '';\n\t\t\t\tobj.type = typeEl ? typeEl.value : 'metered';\n\t\t\t\tobj.limit = limitEl ? limitEl.value : '';\n\t\t\t\tobj.period = periodEl ? periodEl.value : 'monthly';\n\t\t\t\tobj.soft_limit = softEl ? softEl.checked : false;\n\n\t\t\t\tif (obj.key) result.push(obj);\n\t\t\t}\n\t\t\thidden.value = JSON.stringify(result);\n\t\t}\n\n\t\tfunction syncTiers() {\n\t\t\tvar container = document.getElementById('tiers-container');\n\t\t\tvar hidden = document.getElementById('tiers_json');\n\t\t\tif (!container || !hidden) return;\n\n\t\t\tvar items = container.querySelectorAll('[data-oa-item=\"tiers\"]');\n\t\t\tvar result = [];\n\t\t\tfor (var i = 0; i < items.length; i++) {\n\t\t\t\tvar obj = {};\n\t\t\t\tvar fkEl = items[i].querySelector('select[name*=\".feature_key\"], input[name*=\".feature_key\"]');\n\t\t\t\tvar typeEl = items[i].querySelector('select[name*=\".type\"]');\n\t\t\t\tvar upToEl = items[i].querySelector('input[name*=\".up_to\"]');\n\t\t\t\tvar unitEl = items[i].querySelector('input[name*=\".unit_amount\"]');\n\t\t\t\tvar flatEl = items[i].querySelector('input[name*=\".flat_amount\"]');\n\t\t\t\tvar prioEl = items[i].querySelector('input[name*=\".priority\"]');\n\n\t\t\t\tobj.feature_key = fkEl ? fkEl.value : '';\n\t\t\t\tobj.type = typeEl ? typeEl.value : 'graduated';\n\t\t\t\tobj.up_to = upToEl ? upToEl.value : '';\n\t\t\t\tobj.unit_amount = unitEl ? unitEl.value : '';\n\t\t\t\tobj.flat_amount = flatEl ? flatEl.value : '';\n\t\t\t\tobj.priority = prioEl ? prioEl.value : String(i);\n\n\t\t\t\tif (obj.feature_key) result.push(obj);\n\t\t\t}\n\t\t\thidden.value = JSON.stringify(result);\n\t\t}\n\n\t\tfunction syncMetadata() {\n\t\t\tvar container = document.getElementById('metadata-container');\n\t\t\tvar hidden = document.getElementById('metadata_json');\n\t\t\tif (!container || !hidden) return;\n\n\t\t\tvar items = container.querySelectorAll('[data-oa-item=\"metadata\"]');\n\t\t\tvar result = [];\n\t\t\tfor (var i = 0; i < items.length; i++) {\n\t\t\t\tvar keyEl = items[i].querySelector('input[name*=\".key\"]');\n\t\t\t\tvar valEl = items[i].querySelector('input[name*=\".value\"]');\n\t\t\t\tvar obj = {\n\t\t\t\t\tkey: keyEl ? keyEl.value : '',\n\t\t\t\t\tvalue: valEl ? valEl.value : ''\n\t\t\t\t};\n\t\t\t\tif (obj.key) result.push(obj);\n\t\t\t}\n\t\t\thidden.value = JSON.stringify(result);\n\t\t}\n\n\t\t// ─── Form submit interception ───────────────────────────────────────\n\n\t\t// Listen for htmx:configRequest to sync data before HTMX sends POST\n\t\tdocument.body.addEventListener('htmx:configRequest', function(event) {\n\t\t\tvar form = event.detail.elt;\n\t\t\tif (form && form.id === 'plan-form') {\n\t\t\t\tsyncPlanFormData();\n\t\t\t}\n\t\t\t// Also handle if triggered from a child element\n\t\t\tif (form && form.closest && form.closest('#plan-form')) {\n\t\t\t\tsyncPlanFormData();\n\t\t\t}\n\t\t});\n\t})();\n\t</script>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/xraph/ledger/coupon"
+	"github.com/xraph/ledger/entitlement"
 	"github.com/xraph/ledger/feature"
 	"github.com/xraph/ledger/id"
 	"github.com/xraph/ledger/invoice"
@@ -55,6 +56,18 @@ func formatLimit(limit int64) string {
 	return fmt.Sprintf("%d", limit)
 }
 
+// formatOverride returns a display string for an override's change to the
+// plan limit.
+func formatOverride(o *entitlement.Override) string {
+	if o.Mode == entitlement.OverrideSet {
+		return "Set to " + formatLimit(o.Value)
+	}
+	if o.Value < 0 {
+		return fmt.Sprintf("%d", o.Value)
+	}
+	return fmt.Sprintf("+%d", o.Value)
+}
+
 // OverviewStats holds aggregate stats for the overview page.
 type OverviewStats struct {
 	TotalPlans          int
@@ -73,12 +86,14 @@ type PlanDetailData struct {
 
 // SubscriptionDetailData holds all data for the subscription detail view.
 type SubscriptionDetailData struct {
-	Subscription *subscription.Subscription
-	Plan         *plan.Plan
-	Invoices     []*invoice.Invoice
-	HasProviders bool
-	SyncResult   *provider.SyncResult
-	SyncError    string
+	Subscription  *subscription.Subscription
+	Plan          *plan.Plan
+	Invoices      []*invoice.Invoice
+	Overrides     []*entitlement.Override
+	OverrideError string
+	HasProviders  bool
+	SyncResult    *provider.SyncResult
+	SyncError     string
 }
 
 // InvoiceDetailData holds all data for the invoice detail view.
@@ -597,3 +612,42 @@ func ParseSubscriptionFromFormData(fd map[string]string) (*subscription.Subscrip
 
 	return sub, nil
 }
+
+// ─── Override Form Parsing Helpers ───────────────────────────────────────────
+
+// ParseOverrideFromFormData constructs an *entitlement.Override from form
+// data. Tenant and app are set by the caller from the subscription.
+func ParseOverrideFromFormData(fd map[string]string) (*entitlement.Override, error) {
+	o := &entitlement.Override{
+		FeatureKey: strings.TrimSpace(fd["feature_key"]),
+		Mode:       entitlement.OverrideMode(strings.TrimSpace(fd["mode"])),
+		Reason:     strings.TrimSpace(fd["reason"]),
+	}
+
+	if o.FeatureKey == "" {
+		return nil, fmt.Errorf("feature key is required")
+	}
+	if o.Mode == "" {
+		o.Mode = entitlement.OverrideAdd
+	}
+
+	v := strings.TrimSpace(fd["value"])
+	if v == "" {
+		return nil, fmt.Errorf("value is required")
+	}
+	value, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value: %w", err)
+	}
+	o.Value = value
+
+	if v := strings.TrimSpace(fd["expires_at"]); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expiry date: %w", err)
+		}
+		o.ExpiresAt = &t
+	}
+
+	return o, nil
+}
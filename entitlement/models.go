@@ -1,5 +1,11 @@
 package entitlement
 
+import (
+	"time"
+
+	"github.com/xraph/ledger/id"
+)
+
 type Result struct {
 	Allowed   bool   `json:"allowed"`
 	Feature   string `json:"feature"`
@@ -9,3 +15,89 @@ type Result struct {
 	SoftLimit bool   `json:"soft_limit"`
 	Reason    string `json:"reason,omitempty"`
 }
+
+// QuotaKey identifies a tenant's usage of a feature in one usage window,
+// such as a hard-limit counter or the usage alerts sent for the window.
+// WindowStart is zero for features that never reset.
+type QuotaKey struct {
+	TenantID    string    `json:"tenant_id"`
+	AppID       string    `json:"app_id"`
+	FeatureKey  string    `json:"feature_key"`
+	WindowStart time.Time `json:"window_start"`
+}
+
+// Reservation holds quota for a long-running job until it is committed
+// with the usage actually incurred or released. Reservations not settled
+// by ExpiresAt are released automatically.
+type Reservation struct {
+	ID          id.ReservationID `json:"id"`
+	TenantID    string           `json:"tenant_id"`
+	AppID       string           `json:"app_id"`
+	FeatureKey  string           `json:"feature_key"`
+	WindowStart time.Time        `json:"window_start"`
+	Quantity    int64            `json:"quantity"`
+	ExpiresAt   time.Time        `json:"expires_at"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+// Key returns the counter the reservation holds quota in.
+func (r *Reservation) Key() QuotaKey {
+	return QuotaKey{TenantID: r.TenantID, AppID: r.AppID, FeatureKey: r.FeatureKey, WindowStart: r.WindowStart}
+}
+
+// OverrideMode is how an override changes a plan feature's limit.
+type OverrideMode string
+
+const (
+	// OverrideAdd adds Value to the plan's limit. Unlimited features stay
+	// unlimited.
+	OverrideAdd OverrideMode = "add"
+	// OverrideSet replaces the plan's limit with Value, -1 meaning
+	// unlimited. It also grants catalog features the plan lacks.
+	OverrideSet OverrideMode = "set"
+)
+
+// Override changes the limit of one feature for a single tenant on top of
+// its plan, such as a grant of extra usage agreed by sales. Overrides
+// apply until ExpiresAt, or indefinitely when it is nil.
+type Override struct {
+	ID         id.OverrideID `json:"id"`
+	TenantID   string        `json:"tenant_id"`
+	AppID      string        `json:"app_id"`
+	FeatureKey string        `json:"feature_key"`
+	Mode       OverrideMode  `json:"mode"`
+	Value      int64         `json:"value"`
+	ExpiresAt  *time.Time    `json:"expires_at,omitempty"`
+	Reason     string        `json:"reason,omitempty"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+// Active reports whether the override applies at t.
+func (o *Override) Active(t time.Time) bool {
+	return o.ExpiresAt == nil || t.Before(*o.ExpiresAt)
+}
+
+// ApplyOverrides returns limit changed by the overrides of featureKey that
+// are active at t. The most recent set override replaces the limit, then
+// add overrides are added to it; limits never drop below zero.
+func ApplyOverrides(limit int64, overrides []*Override, featureKey string, t time.Time) int64 {
+	var set *Override
+	for _, o := range overrides {
+		if o.FeatureKey == featureKey && o.Mode == OverrideSet && o.Active(t) &&
+			(set == nil || o.CreatedAt.After(set.CreatedAt)) {
+			set = o
+		}
+	}
+	if set != nil {
+		limit = set.Value
+	}
+	if limit == -1 {
+		return -1
+	}
+	for _, o := range overrides {
+		if o.FeatureKey == featureKey && o.Mode == OverrideAdd && o.Active(t) {
+			limit += o.Value
+		}
+	}
+	return max(0, limit)
+}
@@ -3,6 +3,8 @@ package entitlement
 import (
 	"context"
 	"time"
+
+	"github.com/xraph/ledger/id"
 )
 
 type Store interface {
@@ -10,4 +12,42 @@ type Store interface {
 	SetCached(ctx context.Context, tenantID, appID, featureKey string, result *Result, ttl time.Duration) error
 	Invalidate(ctx context.Context, tenantID, appID string) error
 	InvalidateFeature(ctx context.Context, tenantID, appID, featureKey string) error
+
+	// SeedQuota creates a hard-limit counter holding used unless it
+	// already exists. Counters are dropped by PurgeQuotas once expiresAt
+	// has passed; a zero expiresAt keeps them.
+	SeedQuota(ctx context.Context, key QuotaKey, used int64, expiresAt time.Time) error
+	// AddQuota atomically adds qty to a counter if the total stays within
+	// limit, or unconditionally when limit is negative. It returns the
+	// counter's value and whether qty was added, or
+	// ErrQuotaCounterNotFound when the counter has not been seeded.
+	AddQuota(ctx context.Context, key QuotaKey, qty, limit int64) (int64, bool, error)
+	// PurgeQuotas deletes the counters that expired before before.
+	PurgeQuotas(ctx context.Context, before time.Time) (int64, error)
+
+	CreateReservation(ctx context.Context, r *Reservation) error
+	// TakeReservation atomically deletes and returns a reservation of the
+	// tenant, so only one caller can settle it. Reservations of other
+	// tenants are not found.
+	TakeReservation(ctx context.Context, tenantID, appID string, reservationID id.ReservationID) (*Reservation, error)
+	// ExpiredReservations returns up to limit reservations that expired
+	// before before.
+	ExpiredReservations(ctx context.Context, before time.Time, limit int) ([]*Reservation, error)
+
+	CreateOverride(ctx context.Context, o *Override) error
+	GetOverride(ctx context.Context, overrideID id.OverrideID) (*Override, error)
+	// ListOverrides returns a tenant's overrides, expired ones included,
+	// newest first.
+	ListOverrides(ctx context.Context, tenantID, appID string) ([]*Override, error)
+	DeleteOverride(ctx context.Context, overrideID id.OverrideID) error
+
+	// RecordUsageAlert atomically records that the usage alert at
+	// threshold percent was sent for key's usage window and reports
+	// whether it was not recorded before. Records are dropped by
+	// PurgeUsageAlerts once expiresAt has passed; a zero expiresAt keeps
+	// them.
+	RecordUsageAlert(ctx context.Context, key QuotaKey, threshold int, expiresAt time.Time) (bool, error)
+	// PurgeUsageAlerts deletes the alert records that expired before
+	// before.
+	PurgeUsageAlerts(ctx context.Context, before time.Time) (int64, error)
 }
@@ -0,0 +1,110 @@
+package entitlement
+
+import (
+	"time"
+
+	"github.com/xraph/ledger/id"
+	"github.com/xraph/ledger/meter"
+)
+
+// Rule names the check that decided an entitlement.
+type Rule string
+
+const (
+	RuleMissingContext Rule = "missing_context"
+	RuleNoSubscription Rule = "no_subscription"
+	RulePlanNotFound   Rule = "plan_not_found"
+	RuleNotInPlan      Rule = "feature_not_in_plan"
+	RuleEnabled        Rule = "feature_enabled"
+	RuleDisabled       Rule = "feature_disabled"
+	RuleUnlimited      Rule = "unlimited"
+	RuleWithinLimit    Rule = "within_limit"
+	RuleSoftLimit      Rule = "over_soft_limit"
+	RuleHardLimit      Rule = "quota_exceeded"
+)
+
+// UsageSource is where the usage of a checked feature was read from.
+type UsageSource string
+
+const (
+	// UsageSeats is the tenant's current seat count.
+	UsageSeats UsageSource = "seats"
+	// UsageRateCounter is the rate counter of a rolling period.
+	UsageRateCounter UsageSource = "rate_counter"
+	// UsageLifetime is the running total kept for features that never
+	// reset.
+	UsageLifetime UsageSource = "lifetime"
+	// UsageEvents is the usage events in the window, aggregated by the
+	// store or a plugin aggregator.
+	UsageEvents UsageSource = "events"
+)
+
+// Trace records how an entitlement check for one feature is decided, from
+// the cache lookup through the subscription, plan, overrides and usage to
+// the rule that allowed or denied it. Sections after the deciding step are
+// nil.
+type Trace struct {
+	TenantID  string    `json:"tenant_id"`
+	AppID     string    `json:"app_id"`
+	Feature   string    `json:"feature"`
+	CheckedAt time.Time `json:"checked_at"`
+
+	// Cached is the result in the entitlement cache, nil on a miss.
+	// Entitled returns it until it expires; the rest of the trace is
+	// evaluated afresh.
+	Cached *Result `json:"cached,omitempty"`
+
+	Subscription *TraceSubscription `json:"subscription,omitempty"`
+	Plan         *TracePlan         `json:"plan,omitempty"`
+	Match        *TraceFeature      `json:"match,omitempty"`
+	// Overrides are the tenant's active overrides of the feature, newest
+	// first.
+	Overrides []*Override `json:"overrides,omitempty"`
+	Usage     *TraceUsage `json:"usage,omitempty"`
+
+	Rule   Rule    `json:"rule"`
+	Result *Result `json:"result"`
+	// Steps describe the check in order, one line per step.
+	Steps []string `json:"steps"`
+}
+
+// TraceSubscription is the subscription consulted by a check.
+type TraceSubscription struct {
+	ID          id.SubscriptionID `json:"id"`
+	Status      string            `json:"status"`
+	PeriodStart time.Time         `json:"period_start"`
+	PeriodEnd   time.Time         `json:"period_end"`
+}
+
+// TracePlan is the plan consulted by a check.
+type TracePlan struct {
+	ID   id.PlanID `json:"id"`
+	Slug string    `json:"slug"`
+	Name string    `json:"name"`
+}
+
+// TraceFeature is the feature matched by a check. PlanLimit is the limit
+// set by the plan and Limit the one in effect after overrides; InPlan is
+// false for features granted by an override alone.
+type TraceFeature struct {
+	Key       string `json:"key"`
+	Type      string `json:"type"`
+	Period    string `json:"period,omitempty"`
+	Limiter   string `json:"limiter,omitempty"`
+	InPlan    bool   `json:"in_plan"`
+	PlanLimit int64  `json:"plan_limit"`
+	Limit     int64  `json:"limit"`
+	SoftLimit bool   `json:"soft_limit"`
+}
+
+// TraceUsage is the usage a check compared against the limit. The window
+// is zero for seats, rate counters and features that never reset. Groups
+// break Used down by the feature's dimensions when it has any.
+type TraceUsage struct {
+	Source      UsageSource         `json:"source"`
+	Aggregation meter.Aggregation   `json:"aggregation"`
+	WindowStart time.Time           `json:"window_start"`
+	WindowEnd   time.Time           `json:"window_end"`
+	Used        int64               `json:"used"`
+	Groups      []*meter.GroupTotal `json:"groups,omitempty"`
+}
@@ -34,10 +34,13 @@ var (
 	ErrNoActiveSubscription = errors.New("ledger: no active subscription")
 
 	// Metering errors
-	ErrMeterBufferFull = errors.New("ledger: meter buffer full")
-	ErrInvalidQuantity = errors.New("ledger: invalid usage quantity")
-	ErrDuplicateEvent  = errors.New("ledger: duplicate usage event")
-	ErrEventTooOld     = errors.New("ledger: usage event too old")
+	ErrMeterBufferFull        = errors.New("ledger: meter buffer full")
+	ErrInvalidQuantity        = errors.New("ledger: invalid usage quantity")
+	ErrDuplicateEvent         = errors.New("ledger: duplicate usage event")
+	ErrEventTooOld            = errors.New("ledger: usage event too old")
+	ErrUnsupportedAggregation = errors.New("ledger: unsupported usage aggregation")
+	ErrUsageEventNotFound     = errors.New("ledger: usage event not found")
+	ErrUsageEventVoided       = errors.New("ledger: usage event already voided")
 
 	// Entitlement errors
 	ErrQuotaExceeded    = errors.New("ledger: quota exceeded")
@@ -45,6 +48,11 @@ var (
 	ErrHardLimitReached = errors.New("ledger: hard limit reached")
 	ErrSoftLimitReached = errors.New("ledger: soft limit reached (warning)")
 	ErrNoEntitlement    = errors.New("ledger: no entitlement for feature")
+	ErrOverrideNotFound = errors.New("ledger: entitlement override not found")
+
+	// Quota reservation errors
+	ErrQuotaCounterNotFound = errors.New("ledger: quota counter not found")
+	ErrReservationNotFound  = errors.New("ledger: reservation not found")
 
 	// Invoice errors
 	ErrInvoiceNotFound   = errors.New("ledger: invoice not found")
@@ -130,7 +138,9 @@ func IsNotFound(err error) bool {
 		errors.Is(err, ErrSubscriptionNotFound) ||
 		errors.Is(err, ErrFeatureNotFound) ||
 		errors.Is(err, ErrInvoiceNotFound) ||
-		errors.Is(err, ErrCouponNotFound)
+		errors.Is(err, ErrCouponNotFound) ||
+		errors.Is(err, ErrUsageEventNotFound) ||
+		errors.Is(err, ErrOverrideNotFound)
 }
 
 // IsQuotaError returns true if the error is related to quota/limits.
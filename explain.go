@@ -0,0 +1,182 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/xraph/ledger/entitlement"
+	"github.com/xraph/ledger/meter"
+	"github.com/xraph/ledger/plan"
+	"github.com/xraph/ledger/subscription"
+)
+
+// ExplainEntitlement checks featureKey for the current tenant the way
+// Entitled does and returns the full decision trace: the cache lookup, the
+// subscription and plan consulted, the matched feature and overrides, the
+// usage window and breakdown, and the rule that decided. It is meant for
+// support and debugging; the check is always evaluated afresh, and nothing
+// is cached, emitted to plugins or taken from token buckets.
+func (l *Ledger) ExplainEntitlement(ctx context.Context, featureKey string) (*entitlement.Trace, error) {
+	now := time.Now()
+	trace := &entitlement.Trace{
+		TenantID:  extractTenantID(ctx),
+		AppID:     extractAppID(ctx),
+		Feature:   featureKey,
+		CheckedAt: now.UTC(),
+	}
+	step := func(format string, args ...any) {
+		trace.Steps = append(trace.Steps, fmt.Sprintf(format, args...))
+	}
+	deny := func(rule entitlement.Rule, reason string) (*entitlement.Trace, error) {
+		trace.Rule = rule
+		trace.Result = &entitlement.Result{Allowed: false, Feature: featureKey, Reason: reason}
+		step("denied: %s", reason)
+		return trace, nil
+	}
+	tenantID, appID := trace.TenantID, trace.AppID
+	if tenantID == "" || appID == "" {
+		return deny(entitlement.RuleMissingContext, "missing tenant or app context")
+	}
+
+	if cached, err := l.store.GetCached(ctx, tenantID, appID, featureKey); err == nil {
+		trace.Cached = cached
+		step("cache hit: allowed=%t used=%d limit=%d; Entitled returns it until it expires", cached.Allowed, cached.Used, cached.Limit)
+	} else {
+		step("cache miss")
+	}
+
+	sub, err := l.store.GetActiveSubscription(ctx, tenantID, appID)
+	if err != nil {
+		return deny(entitlement.RuleNoSubscription, "no active subscription")
+	}
+	trace.Subscription = &entitlement.TraceSubscription{
+		ID:          sub.ID,
+		Status:      string(sub.Status),
+		PeriodStart: sub.CurrentPeriodStart,
+		PeriodEnd:   sub.CurrentPeriodEnd,
+	}
+	step("subscription %s (%s), period %s to %s", sub.ID, sub.Status,
+		sub.CurrentPeriodStart.Format(time.RFC3339), sub.CurrentPeriodEnd.Format(time.RFC3339))
+
+	p, err := l.store.GetPlan(ctx, sub.PlanID)
+	if err != nil {
+		return deny(entitlement.RulePlanNotFound, "plan not found")
+	}
+	trace.Plan = &entitlement.TracePlan{ID: p.ID, Slug: p.Slug, Name: p.Name}
+	step("plan %s (%s)", p.Slug, p.ID)
+
+	overrides, err := l.store.ListOverrides(ctx, tenantID, appID)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range overrides {
+		if o.FeatureKey == featureKey && o.Active(now) {
+			trace.Overrides = append(trace.Overrides, o)
+			step("override %s: %s %d (%s)", o.ID, o.Mode, o.Value, o.Reason)
+		}
+	}
+
+	feat := l.planFeature(ctx, appID, p, featureKey, overrides, now)
+	if feat == nil {
+		return deny(entitlement.RuleNotInPlan, "feature not in plan")
+	}
+	match := &entitlement.TraceFeature{
+		Key:       feat.Key,
+		Type:      string(feat.Type),
+		Period:    string(feat.Period),
+		Limit:     feat.Limit,
+		SoftLimit: feat.SoftLimit,
+	}
+	if feat.Period.Rolling() {
+		match.Limiter = string(feat.Limiter)
+	}
+	if pf := p.FindFeature(featureKey); pf != nil {
+		match.InPlan = true
+		match.PlanLimit = pf.Limit
+		step("feature %s: %s, period %q, plan limit %d, effective limit %d", feat.Key, feat.Type, feat.Period, pf.Limit, feat.Limit)
+	} else {
+		step("feature %s: not in plan, granted by override with limit %d", feat.Key, feat.Limit)
+	}
+	trace.Match = match
+
+	var used int64
+	switch feat.Type {
+	case plan.FeatureBoolean:
+	case plan.FeatureSeat:
+		used, err = l.store.CurrentSeats(ctx, tenantID, appID, featureKey, now.UTC())
+		if err != nil {
+			return nil, err
+		}
+		trace.Usage = &entitlement.TraceUsage{Source: entitlement.UsageSeats, Used: used}
+		step("usage: %d seats", used)
+	default:
+		usage, err := l.explainUsage(ctx, sub, p, feat, now)
+		if err != nil {
+			return nil, err
+		}
+		used = usage.Used
+		trace.Usage = usage
+		if usage.WindowStart.IsZero() && usage.WindowEnd.IsZero() {
+			step("usage: %d from %s (%s)", used, usage.Source, usage.Aggregation.Kind())
+		} else {
+			step("usage: %d from %s (%s) in %s to %s", used, usage.Source, usage.Aggregation.Kind(),
+				usage.WindowStart.Format(time.RFC3339), usage.WindowEnd.Format(time.RFC3339))
+		}
+		for _, g := range usage.Groups {
+			step("usage group %s: %d", g.Label(), g.Value)
+		}
+	}
+
+	trace.Result, trace.Rule = decide(feat, used)
+	if trace.Result.Allowed {
+		step("allowed: %s", trace.Rule)
+	} else {
+		step("denied: %s", trace.Rule)
+	}
+	return trace, nil
+}
+
+// explainUsage reads the usage of a metered feature as Entitled does,
+// broken down by the feature's dimensions when its usage comes from the
+// events.
+func (l *Ledger) explainUsage(ctx context.Context, sub *subscription.Subscription, p *plan.Plan, feat *plan.Feature, now time.Time) (*entitlement.TraceUsage, error) {
+	tenantID, appID := sub.TenantID, sub.AppID
+	usage := &entitlement.TraceUsage{Aggregation: l.aggregationFor(ctx, appID, feat)}
+
+	used, counted, err := l.rollingUsage(ctx, tenantID, appID, feat, now)
+	if err != nil {
+		return nil, err
+	}
+	if counted {
+		usage.Source = entitlement.UsageRateCounter
+		usage.Used = used
+		return usage, nil
+	}
+
+	start, end := usageWindow(sub, p, feat.Period, now)
+	usage.Used, err = l.aggregateUsage(ctx, tenantID, appID, feat, usage.Aggregation, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if lifetimeQuota(feat, usage.Aggregation, start, end) {
+		usage.Source = entitlement.UsageLifetime
+		return usage, nil
+	}
+	usage.Source = entitlement.UsageEvents
+	usage.WindowStart, usage.WindowEnd = start, end
+
+	keys := dimensionKeys(l.catalogFeatureFor(ctx, appID, feat), p, feat)
+	if len(keys) > 0 && usage.Aggregation.Kind().IsBuiltin() {
+		usage.Groups, err = l.store.AggregateGroups(ctx, tenantID, appID, usage.Aggregation, meter.QueryOpts{
+			FeatureKey: feat.Key,
+			Start:      start,
+			End:        end,
+			GroupBy:    keys,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return usage, nil
+}
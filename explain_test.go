@@ -0,0 +1,80 @@
+package ledger_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xraph/ledger"
+	"github.com/xraph/ledger/entitlement"
+	"github.com/xraph/ledger/plan"
+	"github.com/xraph/ledger/store/memory"
+)
+
+func TestExplainMatchesEntitled(t *testing.T) {
+	s := memory.New()
+	l := startLedger(t, s, ledger.WithMeterConfig(1, 5*time.Millisecond))
+	subscribe(t, l, "t1", []plan.Feature{
+		{Key: "sso", Name: "SSO", Type: plan.FeatureBoolean, Limit: 1},
+		{Key: "audit", Name: "Audit log", Type: plan.FeatureBoolean, Limit: 0},
+		{Key: "api_calls", Name: "API calls", Type: plan.FeatureMetered, Limit: 3, Period: plan.PeriodMonthly},
+		{Key: "exports", Name: "Exports", Type: plan.FeatureMetered, Limit: 10, Period: plan.PeriodMonthly},
+		{Key: "storage", Name: "Storage", Type: plan.FeatureMetered, Limit: 2, Period: plan.PeriodMonthly, SoftLimit: true},
+		{Key: "builds", Name: "Builds", Type: plan.FeatureMetered, Limit: -1, Period: plan.PeriodMonthly},
+		{Key: "seats", Name: "Seats", Type: plan.FeatureSeat, Limit: 5},
+	})
+
+	tctx := tenantContext("t1", "app")
+	for key, qty := range map[string]int64{"api_calls": 3, "exports": 4, "storage": 5} {
+		if err := l.Meter(tctx, key, qty); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.SetSeats(tctx, "seats", 2); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "the usage to be flushed", func() bool { return usageCount(t, s, "t1") == 3 })
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		key      string
+		wantRule entitlement.Rule
+	}{
+		{"Missing context", context.Background(), "sso", entitlement.RuleMissingContext},
+		{"No subscription", tenantContext("t2", "app"), "sso", entitlement.RuleNoSubscription},
+		{"Not in plan", tctx, "unknown", entitlement.RuleNotInPlan},
+		{"Enabled", tctx, "sso", entitlement.RuleEnabled},
+		{"Disabled", tctx, "audit", entitlement.RuleDisabled},
+		{"Hard limit", tctx, "api_calls", entitlement.RuleHardLimit},
+		{"Within limit", tctx, "exports", entitlement.RuleWithinLimit},
+		{"Soft limit", tctx, "storage", entitlement.RuleSoftLimit},
+		{"Unlimited", tctx, "builds", entitlement.RuleUnlimited},
+		{"Seats", tctx, "seats", entitlement.RuleWithinLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trace, err := l.ExplainEntitlement(tt.ctx, tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := l.Entitled(tt.ctx, tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(trace.Result, got) {
+				t.Errorf("trace result = %+v, Entitled = %+v", trace.Result, got)
+			}
+			if trace.Rule != tt.wantRule {
+				t.Errorf("rule = %q, want %q", trace.Rule, tt.wantRule)
+			}
+			if trace.Usage != nil && trace.Usage.Used != got.Used {
+				t.Errorf("trace usage = %d, Entitled used %d", trace.Usage.Used, got.Used)
+			}
+			if len(trace.Steps) == 0 {
+				t.Error("trace has no steps")
+			}
+		})
+	}
+}
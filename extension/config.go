@@ -1,6 +1,11 @@
 package extension
 
-import "time"
+import (
+	"time"
+
+	ledger "github.com/xraph/ledger"
+	"github.com/xraph/ledger/meter/archive"
+)
 
 // Config holds the Ledger extension configuration.
 // Fields can be set programmatically via Option functions or loaded from
@@ -23,6 +28,47 @@ type Config struct {
 	// even if the batch size has not been reached (default: 5s).
 	MeterFlushInterval time.Duration `json:"meter_flush_interval" mapstructure:"meter_flush_interval" yaml:"meter_flush_interval"`
 
+	// MeterWALDir enables the on-disk write-ahead log for metered usage.
+	// Events are persisted under this directory before Meter returns and
+	// replayed on start, giving at-least-once usage capture (default: disabled).
+	MeterWALDir string `json:"meter_wal_dir" mapstructure:"meter_wal_dir" yaml:"meter_wal_dir"`
+
+	// MeterBufferSize is the capacity of the in-memory meter buffer
+	// (default: 10000).
+	MeterBufferSize int `json:"meter_buffer_size" mapstructure:"meter_buffer_size" yaml:"meter_buffer_size"`
+
+	// MeterWorkers is the number of meter flush workers. Events are sharded
+	// across them by tenant (default: 1).
+	MeterWorkers int `json:"meter_workers" mapstructure:"meter_workers" yaml:"meter_workers"`
+
+	// MeterOverflowPolicy is what Meter does when the buffer is full: "reject",
+	// "block" (until the request context is done), "spill" (to MeterSpillDir)
+	// or "drop" (default: "reject").
+	MeterOverflowPolicy string `json:"meter_overflow_policy" mapstructure:"meter_overflow_policy" yaml:"meter_overflow_policy"`
+
+	// MeterSpillDir is where the "spill" overflow policy writes events the
+	// buffer has no room for.
+	MeterSpillDir string `json:"meter_spill_dir" mapstructure:"meter_spill_dir" yaml:"meter_spill_dir"`
+
+	// StrictMetering validates usage events against the feature catalog and
+	// rejects unknown or archived features and negative metered quantities.
+	StrictMetering bool `json:"strict_metering" mapstructure:"strict_metering" yaml:"strict_metering"`
+
+	// UsageArchiveDir is the directory usage retention writes archive files
+	// to. Required when UsageRetention is set.
+	UsageArchiveDir string `json:"usage_archive_dir" mapstructure:"usage_archive_dir" yaml:"usage_archive_dir"`
+
+	// UsageArchiveFormat is the archive file format: "ndjson" or "parquet"
+	// (default: "ndjson").
+	UsageArchiveFormat string `json:"usage_archive_format" mapstructure:"usage_archive_format" yaml:"usage_archive_format"`
+
+	// UsageRetention maps app IDs to how long their raw usage events stay in
+	// the store before they are archived (default: disabled).
+	UsageRetention map[string]time.Duration `json:"usage_retention" mapstructure:"usage_retention" yaml:"usage_retention"`
+
+	// UsageRetentionInterval is how often retention runs (default: 1h).
+	UsageRetentionInterval time.Duration `json:"usage_retention_interval" mapstructure:"usage_retention_interval" yaml:"usage_retention_interval"`
+
 	// EntitlementCacheTTL controls how long entitlement check results are
 	// cached in-process before re-evaluating against the store (default: 30s).
 	EntitlementCacheTTL time.Duration `json:"entitlement_cache_ttl" mapstructure:"entitlement_cache_ttl" yaml:"entitlement_cache_ttl"`
@@ -45,8 +91,15 @@ type Config struct {
 // DefaultConfig returns a Config with sensible defaults.
 func DefaultConfig() Config {
 	return Config{
+		BasePath:            "/ledger",
 		MeterBatchSize:      100,
 		MeterFlushInterval:  5 * time.Second,
+		MeterBufferSize:     10000,
+		MeterWorkers:        1,
+		MeterOverflowPolicy: string(ledger.OverflowReject),
 		EntitlementCacheTTL: 30 * time.Second,
+
+		UsageArchiveFormat:     string(archive.FormatNDJSON),
+		UsageRetentionInterval: time.Hour,
 	}
 }
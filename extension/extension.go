@@ -12,6 +12,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/xraph/forge"
 	dashboard "github.com/xraph/forge/extensions/dashboard"
@@ -21,6 +22,7 @@ import (
 
 	ledger "github.com/xraph/ledger"
 	ledgerdash "github.com/xraph/ledger/dashboard"
+	"github.com/xraph/ledger/meter/archive"
 	"github.com/xraph/ledger/plugin"
 	"github.com/xraph/ledger/store"
 	"github.com/xraph/ledger/store/memory"
@@ -119,6 +121,12 @@ func (e *Extension) Register(fapp forge.App) error {
 	eng := ledger.New(e.store, opts...)
 	e.engine = eng
 
+	if !e.config.DisableRoutes {
+		if err := e.registerRoutes(fapp.Router()); err != nil {
+			return fmt.Errorf("ledger: register routes: %w", err)
+		}
+	}
+
 	return vessel.Provide(fapp.Container(), func() (*ledger.Ledger, error) {
 		return e.engine, nil
 	})
@@ -162,7 +170,7 @@ func (e *Extension) Health(ctx context.Context) error {
 
 // buildLedgerOpts constructs ledger.Option values from the resolved config.
 func (e *Extension) buildLedgerOpts() []ledger.Option {
-	opts := make([]ledger.Option, 0, len(e.ledgerOpts)+3)
+	opts := make([]ledger.Option, 0, len(e.ledgerOpts)+4)
 
 	// Apply config-derived options.
 	if e.config.MeterBatchSize > 0 || e.config.MeterFlushInterval > 0 {
@@ -182,6 +190,51 @@ func (e *Extension) buildLedgerOpts() []ledger.Option {
 		opts = append(opts, ledger.WithEntitlementCacheTTL(e.config.EntitlementCacheTTL))
 	}
 
+	if e.config.MeterWALDir != "" {
+		opts = append(opts, ledger.WithMeterWAL(e.config.MeterWALDir, 0))
+	}
+
+	if e.config.MeterBufferSize > 0 {
+		opts = append(opts, ledger.WithMeterBufferSize(e.config.MeterBufferSize))
+	}
+
+	if e.config.MeterWorkers > 0 {
+		opts = append(opts, ledger.WithMeterWorkers(e.config.MeterWorkers))
+	}
+
+	if e.config.MeterOverflowPolicy != "" {
+		opts = append(opts, ledger.WithMeterOverflow(ledger.OverflowPolicy(e.config.MeterOverflowPolicy)))
+	}
+
+	if e.config.MeterSpillDir != "" {
+		opts = append(opts, ledger.WithMeterSpillDir(e.config.MeterSpillDir))
+	}
+
+	if e.config.StrictMetering {
+		opts = append(opts, ledger.WithStrictMetering(true))
+	}
+
+	if e.config.UsageArchiveDir != "" {
+		opts = append(opts, ledger.WithUsageArchive(archive.Dir(e.config.UsageArchiveDir)))
+	}
+
+	apps := make([]string, 0, len(e.config.UsageRetention))
+	for appID := range e.config.UsageRetention {
+		apps = append(apps, appID)
+	}
+	sort.Strings(apps)
+	for _, appID := range apps {
+		opts = append(opts, ledger.WithRetentionPolicy(ledger.RetentionPolicy{
+			AppID:     appID,
+			Retention: e.config.UsageRetention[appID],
+			Format:    archive.Format(e.config.UsageArchiveFormat),
+		}))
+	}
+
+	if e.config.UsageRetentionInterval > 0 {
+		opts = append(opts, ledger.WithRetentionInterval(e.config.UsageRetentionInterval))
+	}
+
 	// Append any pass-through ledger options.
 	opts = append(opts, e.ledgerOpts...)
 
@@ -222,6 +275,16 @@ func (e *Extension) loadConfiguration() error {
 		forge.F("grove_database", e.config.GroveDatabase),
 		forge.F("meter_batch_size", e.config.MeterBatchSize),
 		forge.F("meter_flush_interval", e.config.MeterFlushInterval),
+		forge.F("meter_wal_dir", e.config.MeterWALDir),
+		forge.F("meter_buffer_size", e.config.MeterBufferSize),
+		forge.F("meter_workers", e.config.MeterWorkers),
+		forge.F("meter_overflow_policy", e.config.MeterOverflowPolicy),
+		forge.F("meter_spill_dir", e.config.MeterSpillDir),
+		forge.F("strict_metering", e.config.StrictMetering),
+		forge.F("usage_archive_dir", e.config.UsageArchiveDir),
+		forge.F("usage_archive_format", e.config.UsageArchiveFormat),
+		forge.F("usage_retention", e.config.UsageRetention),
+		forge.F("usage_retention_interval", e.config.UsageRetentionInterval),
 		forge.F("entitlement_cache_ttl", e.config.EntitlementCacheTTL),
 	)
 
@@ -271,9 +334,27 @@ func (e *Extension) mergeWithDefaults(cfg Config) Config {
 	if cfg.MeterFlushInterval == 0 {
 		cfg.MeterFlushInterval = defaults.MeterFlushInterval
 	}
+	if cfg.MeterBufferSize == 0 {
+		cfg.MeterBufferSize = defaults.MeterBufferSize
+	}
+	if cfg.MeterWorkers == 0 {
+		cfg.MeterWorkers = defaults.MeterWorkers
+	}
+	if cfg.MeterOverflowPolicy == "" {
+		cfg.MeterOverflowPolicy = defaults.MeterOverflowPolicy
+	}
 	if cfg.EntitlementCacheTTL == 0 {
 		cfg.EntitlementCacheTTL = defaults.EntitlementCacheTTL
 	}
+	if cfg.BasePath == "" {
+		cfg.BasePath = defaults.BasePath
+	}
+	if cfg.UsageArchiveFormat == "" {
+		cfg.UsageArchiveFormat = defaults.UsageArchiveFormat
+	}
+	if cfg.UsageRetentionInterval == 0 {
+		cfg.UsageRetentionInterval = defaults.UsageRetentionInterval
+	}
 	return cfg
 }
 
@@ -287,6 +368,9 @@ func (e *Extension) mergeConfigurations(yamlConfig, programmaticConfig Config) C
 	if programmaticConfig.DisableMigrate {
 		yamlConfig.DisableMigrate = true
 	}
+	if programmaticConfig.StrictMetering {
+		yamlConfig.StrictMetering = true
+	}
 
 	// String fields: YAML takes precedence.
 	if yamlConfig.BasePath == "" && programmaticConfig.BasePath != "" {
@@ -298,6 +382,24 @@ func (e *Extension) mergeConfigurations(yamlConfig, programmaticConfig Config) C
 	if yamlConfig.AppID == "" && programmaticConfig.AppID != "" {
 		yamlConfig.AppID = programmaticConfig.AppID
 	}
+	if yamlConfig.MeterWALDir == "" && programmaticConfig.MeterWALDir != "" {
+		yamlConfig.MeterWALDir = programmaticConfig.MeterWALDir
+	}
+	if yamlConfig.MeterOverflowPolicy == "" && programmaticConfig.MeterOverflowPolicy != "" {
+		yamlConfig.MeterOverflowPolicy = programmaticConfig.MeterOverflowPolicy
+	}
+	if yamlConfig.MeterSpillDir == "" && programmaticConfig.MeterSpillDir != "" {
+		yamlConfig.MeterSpillDir = programmaticConfig.MeterSpillDir
+	}
+	if yamlConfig.UsageArchiveDir == "" && programmaticConfig.UsageArchiveDir != "" {
+		yamlConfig.UsageArchiveDir = programmaticConfig.UsageArchiveDir
+	}
+	if yamlConfig.UsageArchiveFormat == "" && programmaticConfig.UsageArchiveFormat != "" {
+		yamlConfig.UsageArchiveFormat = programmaticConfig.UsageArchiveFormat
+	}
+	if yamlConfig.UsageRetention == nil && programmaticConfig.UsageRetention != nil {
+		yamlConfig.UsageRetention = programmaticConfig.UsageRetention
+	}
 
 	// Duration/int fields: YAML takes precedence, programmatic fills gaps.
 	if yamlConfig.MeterBatchSize == 0 && programmaticConfig.MeterBatchSize != 0 {
@@ -306,9 +408,18 @@ func (e *Extension) mergeConfigurations(yamlConfig, programmaticConfig Config) C
 	if yamlConfig.MeterFlushInterval == 0 && programmaticConfig.MeterFlushInterval != 0 {
 		yamlConfig.MeterFlushInterval = programmaticConfig.MeterFlushInterval
 	}
+	if yamlConfig.MeterBufferSize == 0 && programmaticConfig.MeterBufferSize != 0 {
+		yamlConfig.MeterBufferSize = programmaticConfig.MeterBufferSize
+	}
+	if yamlConfig.MeterWorkers == 0 && programmaticConfig.MeterWorkers != 0 {
+		yamlConfig.MeterWorkers = programmaticConfig.MeterWorkers
+	}
 	if yamlConfig.EntitlementCacheTTL == 0 && programmaticConfig.EntitlementCacheTTL != 0 {
 		yamlConfig.EntitlementCacheTTL = programmaticConfig.EntitlementCacheTTL
 	}
+	if yamlConfig.UsageRetentionInterval == 0 && programmaticConfig.UsageRetentionInterval != 0 {
+		yamlConfig.UsageRetentionInterval = programmaticConfig.UsageRetentionInterval
+	}
 
 	// Fill remaining zeros with defaults.
 	return e.mergeWithDefaults(yamlConfig)
@@ -335,13 +446,17 @@ func (e *Extension) resolveGroveDB(fapp forge.App) (*grove.DB, error) {
 // LocalContributor that renders ledger pages, widgets, and settings in the
 // Forge dashboard using templ + ForgeUI.
 func (e *Extension) DashboardContributor() contributor.LocalContributor {
-	return ledgerdash.New(
+	c := ledgerdash.New(
 		ledgerdash.NewManifest(e.engine, e.plugins),
 		e.engine,
 		e.store,
 		e.plugins,
 		e.config.AppID,
 	)
+	if !e.config.DisableRoutes {
+		c.WithUsageStream(e.usageStreamPath())
+	}
+	return c
 }
 
 // buildStoreFromGroveDB constructs the appropriate store backend
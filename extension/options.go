@@ -4,6 +4,7 @@ import (
 	"time"
 
 	ledger "github.com/xraph/ledger"
+	"github.com/xraph/ledger/meter/archive"
 	"github.com/xraph/ledger/plugin"
 	"github.com/xraph/ledger/store"
 )
@@ -69,6 +70,62 @@ func WithMeterFlushInterval(d time.Duration) Option {
 	return func(e *Extension) { e.config.MeterFlushInterval = d }
 }
 
+// WithMeterWALDir enables the meter write-ahead log under dir.
+func WithMeterWALDir(dir string) Option {
+	return func(e *Extension) { e.config.MeterWALDir = dir }
+}
+
+// WithMeterBufferSize sets the capacity of the in-memory meter buffer.
+func WithMeterBufferSize(size int) Option {
+	return func(e *Extension) { e.config.MeterBufferSize = size }
+}
+
+// WithMeterWorkers sets the number of tenant-sharded meter flush workers.
+func WithMeterWorkers(n int) Option {
+	return func(e *Extension) { e.config.MeterWorkers = n }
+}
+
+// WithMeterOverflowPolicy sets what Meter does when the buffer is full.
+func WithMeterOverflowPolicy(policy ledger.OverflowPolicy) Option {
+	return func(e *Extension) { e.config.MeterOverflowPolicy = string(policy) }
+}
+
+// WithMeterSpillDir sets where the spill overflow policy writes events.
+func WithMeterSpillDir(dir string) Option {
+	return func(e *Extension) { e.config.MeterSpillDir = dir }
+}
+
+// WithStrictMetering validates usage events against the feature catalog.
+func WithStrictMetering() Option {
+	return func(e *Extension) { e.config.StrictMetering = true }
+}
+
+// WithUsageArchiveDir sets the directory usage retention archives to.
+func WithUsageArchiveDir(dir string) Option {
+	return func(e *Extension) { e.config.UsageArchiveDir = dir }
+}
+
+// WithUsageArchiveFormat sets the usage archive file format.
+func WithUsageArchiveFormat(format archive.Format) Option {
+	return func(e *Extension) { e.config.UsageArchiveFormat = string(format) }
+}
+
+// WithUsageRetention archives an app's usage events once they are older
+// than retention.
+func WithUsageRetention(appID string, retention time.Duration) Option {
+	return func(e *Extension) {
+		if e.config.UsageRetention == nil {
+			e.config.UsageRetention = make(map[string]time.Duration)
+		}
+		e.config.UsageRetention[appID] = retention
+	}
+}
+
+// WithUsageRetentionInterval sets how often usage retention runs.
+func WithUsageRetentionInterval(d time.Duration) Option {
+	return func(e *Extension) { e.config.UsageRetentionInterval = d }
+}
+
 // WithEntitlementCacheTTL sets the entitlement check cache duration.
 func WithEntitlementCacheTTL(d time.Duration) Option {
 	return func(e *Extension) { e.config.EntitlementCacheTTL = d }
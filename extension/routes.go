@@ -0,0 +1,96 @@
+package extension
+
+import (
+	"strings"
+	"time"
+
+	"github.com/xraph/forge"
+
+	ledger "github.com/xraph/ledger"
+)
+
+// usageStreamKeepAlive is how often an idle usage stream sends a comment so
+// proxies do not close the connection.
+const usageStreamKeepAlive = 15 * time.Second
+
+// usageStreamPath returns the path of the usage stream endpoint.
+func (e *Extension) usageStreamPath() string {
+	return strings.TrimSuffix(e.config.BasePath, "/") + "/usage/stream"
+}
+
+// registerRoutes mounts the ledger HTTP routes under the configured base path.
+func (e *Extension) registerRoutes(router forge.Router) error {
+	return router.EventStream(e.usageStreamPath(), e.streamUsage,
+		forge.WithSummary("Stream flushed usage events and counters"),
+		forge.WithTags("ledger", "usage"),
+		forge.WithMiddleware(requireTenant),
+	)
+}
+
+// requestTenant returns the tenant set on the request context by auth
+// middleware, or "" when the request is not authenticated as a tenant.
+func requestTenant(ctx forge.Context) string {
+	tenantID, _ := ctx.Request().Context().Value("tenant_id").(string)
+	return tenantID
+}
+
+// requireTenant answers 401 to requests without an authenticated tenant and
+// 403 to those whose tenant_id query parameter names another tenant. It
+// runs before the event stream is opened, while the status can still be
+// set.
+func requireTenant(next forge.Handler) forge.Handler {
+	return func(ctx forge.Context) error {
+		tenantID := requestTenant(ctx)
+		if tenantID == "" {
+			return forge.Unauthorized("ledger: authenticated tenant required")
+		}
+		if q := ctx.Request().URL.Query().Get("tenant_id"); q != "" && q != tenantID {
+			return forge.Forbidden("ledger: tenant_id does not match the authenticated tenant")
+		}
+		return next(ctx)
+	}
+}
+
+// streamUsage sends every flushed usage batch matching the request as a
+// "usage" Server-Sent Event carrying a ledger.UsageUpdate. The tenant is
+// always the one authenticated on the request context (see requireTenant);
+// app_id defaults to the configured app and repeated feature parameters
+// restrict the stream to those features.
+func (e *Extension) streamUsage(ctx forge.Context, stream forge.Stream) error {
+	query := ctx.Request().URL.Query()
+	filter := ledger.UsageFilter{
+		TenantID:    requestTenant(ctx),
+		AppID:       query.Get("app_id"),
+		FeatureKeys: query["feature"],
+	}
+	if filter.TenantID == "" {
+		// An empty filter would stream every tenant's usage.
+		return forge.Unauthorized("ledger: authenticated tenant required")
+	}
+	if filter.AppID == "" {
+		filter.AppID = e.config.AppID
+	}
+
+	updates, err := e.engine.SubscribeUsage(stream.Context(), filter)
+	if err != nil {
+		return err
+	}
+
+	keepAlive := time.NewTicker(usageStreamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			if err := stream.SendJSON("usage", update); err != nil {
+				return err
+			}
+		case <-keepAlive.C:
+			if err := stream.SendComment("keep-alive"); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -6,6 +6,7 @@ package feature
 
 import (
 	"github.com/xraph/ledger/id"
+	"github.com/xraph/ledger/meter"
 	"github.com/xraph/ledger/types"
 )
 
@@ -34,9 +35,14 @@ const (
 type Period string
 
 const (
-	PeriodMonthly Period = "monthly"
-	PeriodYearly  Period = "yearly"
-	PeriodNone    Period = "none"
+	PeriodMonthly    Period = "monthly"
+	PeriodYearly     Period = "yearly"
+	PeriodNone       Period = "none"
+	PeriodSecond     Period = "second"
+	PeriodMinute     Period = "minute"
+	PeriodHour       Period = "hour"
+	PeriodRolling24h Period = "rolling_24h"
+	PeriodRolling30d Period = "rolling_30d"
 )
 
 // Feature is a standalone, reusable feature definition in the catalog.
@@ -57,6 +63,23 @@ type Feature struct {
 	ProviderID   string            `json:"provider_id,omitempty"`
 	ProviderName string            `json:"provider_name,omitempty"`
 	Metadata     map[string]string `json:"metadata,omitempty"`
+
+	// Aggregation selects how usage is reduced for entitlement checks and
+	// invoicing: a built-in meter.AggregationType or the name of a registered
+	// plugin.UsageAggregator. Empty means sum. AggregationProperty is the
+	// metadata key counted by meter.AggregateUnique.
+	Aggregation         meter.AggregationType `json:"aggregation,omitempty"`
+	AggregationProperty string                `json:"aggregation_property,omitempty"`
+
+	// Dimensions names the usage event metadata keys (e.g. "region",
+	// "model") that usage is broken down by on invoices and that price
+	// tiers can be scoped to.
+	Dimensions []string `json:"dimensions,omitempty"`
+}
+
+// UsageAggregation returns the feature's aggregation settings.
+func (f *Feature) UsageAggregation() meter.Aggregation {
+	return meter.Aggregation{Type: f.Aggregation, Property: f.AggregationProperty}
 }
 
 // ListOpts configures feature listing queries.
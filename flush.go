@@ -0,0 +1,305 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	log "github.com/xraph/go-utils/log"
+
+	"github.com/xraph/ledger/meter"
+)
+
+// replayWAL flushes events recovered from the WAL in batches. Batches that
+// fail stay in the WAL and are replayed again on the next start.
+func (l *Ledger) replayWAL(ctx context.Context) {
+	events := l.wal.Replay()
+	if len(events) == 0 {
+		return
+	}
+
+	l.logger.Info("replaying meter WAL", log.Int("events", len(events)))
+	for start := 0; start < len(events); start += l.meterBatchSize {
+		end := min(start+l.meterBatchSize, len(events))
+		l.flushMeterBatch(ctx, events[start:end])
+	}
+}
+
+// meterFlushWorker flushes the usage events of one shard to the store.
+func (l *Ledger) meterFlushWorker(ctx context.Context, shard int) {
+	defer l.wg.Done()
+
+	buffer := l.meterShards[shard]
+	batch := make([]*meter.UsageEvent, 0, l.meterBatchSize)
+	var oldest time.Time
+	ticker := time.NewTicker(l.meterFlushInterval)
+	defer ticker.Stop()
+
+	add := func(q queuedEvent) {
+		if len(batch) == 0 {
+			oldest = q.queued
+		}
+		batch = append(batch, q.event)
+	}
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		l.flushMeterBatch(ctx, batch)
+		l.reportShardFlush(ctx, shard, oldest)
+		batch = make([]*meter.UsageEvent, 0, l.meterBatchSize)
+	}
+
+	for {
+		select {
+		case <-l.stopChan:
+			// Final flush, including whatever is still buffered
+			for drained := false; !drained; {
+				select {
+				case q := <-buffer:
+					add(q)
+					if len(batch) >= l.meterBatchSize {
+						flush()
+					}
+				default:
+					drained = true
+				}
+			}
+			flush()
+			return
+
+		case q := <-buffer:
+			add(q)
+			if len(batch) >= l.meterBatchSize {
+				flush()
+			}
+
+		case <-ticker.C:
+			flush()
+			if shard == 0 && l.spill != nil {
+				l.drainSpill()
+			}
+		}
+	}
+}
+
+// reportShardFlush records how long the oldest event of a flushed batch
+// waited and reports it to plugins along with the shard's remaining depth.
+func (l *Ledger) reportShardFlush(ctx context.Context, shard int, oldest time.Time) {
+	lag := time.Since(oldest)
+	l.meterStats.lag.Store(int64(lag))
+	l.plugins.EmitUsageShardFlushed(ctx, shard, len(l.meterShards[shard]), lag)
+}
+
+// drainSpill moves events spilled by OverflowSpill back into the shards of
+// their tenants, oldest first, for as long as the buffer is at most half
+// full and their shards have room. Events stay in the spill log until their
+// shard (and the WAL, when one is configured) holds them, and new events are
+// spilled while it is not empty, so each tenant's events are still flushed
+// by its own worker in the order they were metered.
+func (l *Ledger) drainSpill() {
+	for {
+		if depth, capacity := l.meterDepth(); depth > capacity/2 {
+			return
+		}
+		batch, err := l.spill.Pending(l.meterBatchSize)
+		if err != nil {
+			l.logger.Warn("failed to read meter spill log", log.Error(err))
+			return
+		}
+		if len(batch) == 0 {
+			return
+		}
+
+		// Once a shard is full, its later events wait behind the first one
+		// that did not fit.
+		var moved []*meter.UsageEvent
+		full := make(map[chan queuedEvent]bool)
+		for _, event := range batch {
+			shard := l.meterShard(event.TenantID)
+			if full[shard] {
+				continue
+			}
+			err := l.offer(shard, event)
+			if err != nil && !errors.Is(err, ErrDuplicateEvent) {
+				if !errors.Is(err, ErrMeterBufferFull) {
+					l.logger.Warn("failed to move spilled meter event", log.Error(err))
+				}
+				full[shard] = true
+				continue
+			}
+			moved = append(moved, event)
+		}
+		if len(moved) == 0 {
+			return
+		}
+		if err := l.spill.Ack(moved); err != nil {
+			l.logger.Warn("failed to truncate meter spill log", log.Error(err))
+			return
+		}
+	}
+}
+
+// flushedUsage publishes newly stored events to usage subscribers, adds
+// them to the hard-limit counters, invalidates the cached entitlements they
+// change and checks them against the usage alert thresholds. Events the
+// store rejected as duplicates were already handled when they were first
+// stored.
+func (l *Ledger) flushedUsage(ctx context.Context, inserted []*meter.UsageEvent) {
+	if len(inserted) == 0 {
+		return
+	}
+	l.countQuotas(ctx, inserted)
+	l.publishUsage(inserted)
+	l.invalidateUsage(ctx, inserted)
+	l.queueUsageAlerts(inserted)
+}
+
+func (l *Ledger) flushMeterBatch(ctx context.Context, batch []*meter.UsageEvent) {
+	start := time.Now()
+
+	result, err := l.ingestWithRetry(ctx, batch)
+	if err != nil {
+		l.deadLetter(ctx, batch, err)
+		return
+	}
+
+	if l.wal != nil {
+		if err := l.wal.Ack(batch); err != nil {
+			l.logger.Warn("failed to truncate meter WAL", log.Error(err))
+		}
+	}
+
+	l.meterStats.flushed.Add(int64(len(batch)))
+	l.meterStats.flushes.Add(1)
+
+	elapsed := time.Since(start)
+	l.plugins.EmitUsageFlushed(ctx, len(batch), elapsed)
+	l.flushedUsage(ctx, result.InsertedEvents(batch))
+
+	l.logger.Debug("flushed meter batch",
+		log.Int("batch_size", len(batch)),
+		log.Int("duplicates", result.Duplicates),
+		log.Int64("elapsed_ms", elapsed.Milliseconds()),
+	)
+}
+
+// ingestWithRetry writes batch to the store, retrying failures with
+// exponential backoff until the retry budget is spent or ctx is done.
+func (l *Ledger) ingestWithRetry(ctx context.Context, batch []*meter.UsageEvent) (*meter.IngestResult, error) {
+	backoff := l.meterRetryBackoff
+	for attempt := 1; ; attempt++ {
+		result, err := l.store.IngestBatch(ctx, batch)
+		if err == nil || attempt > l.meterMaxRetries {
+			return result, err
+		}
+
+		l.logger.Warn("meter batch flush failed, retrying",
+			log.Error(err),
+			log.Int("attempt", attempt),
+			log.Duration("backoff", backoff),
+		)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, l.meterRetryMaxBackoff)
+	}
+}
+
+// deadLetter hands a batch that exhausted its retries to the dead-letter
+// sink and reports the outcome to plugins.
+func (l *Ledger) deadLetter(ctx context.Context, batch []*meter.UsageEvent, cause error) {
+	l.logger.Error("failed to flush meter batch",
+		log.Error(cause),
+		log.Int("batch_size", len(batch)),
+	)
+
+	deadLettered := 0
+	if l.deadLetterSink != nil {
+		if err := l.deadLetterSink.DeadLetter(ctx, batch, cause); err != nil {
+			l.logger.Error("failed to dead-letter meter batch",
+				log.Error(err),
+				log.Int("batch_size", len(batch)),
+			)
+		} else {
+			deadLettered = len(batch)
+			if l.wal != nil {
+				if err := l.wal.Ack(batch); err != nil {
+					l.logger.Warn("failed to truncate meter WAL", log.Error(err))
+				}
+			}
+		}
+	}
+
+	l.meterStats.dropped.Add(int64(len(batch) - deadLettered))
+	l.plugins.EmitUsageDropped(ctx, len(batch)-deadLettered, deadLettered, cause)
+}
+
+// meterCounters accumulates the meter statistics reported by MeterStats.
+type meterCounters struct {
+	flushed atomic.Int64
+	dropped atomic.Int64
+	flushes atomic.Int64
+	lag     atomic.Int64 // time.Duration
+}
+
+// MeterStats is a snapshot of the meter buffer and its flush workers.
+type MeterStats struct {
+	// Workers is the number of flush workers, one per buffer shard.
+	Workers int
+	// Capacity is the total buffer capacity across shards.
+	Capacity int
+	// Pending is the number of events buffered across shards.
+	Pending int
+	// ShardDepth is the number of events buffered in each shard.
+	ShardDepth []int
+	// Spilled is the number of events waiting in the spill log.
+	Spilled int
+	// Flushed is the number of events written to the store since New.
+	Flushed int64
+	// Dropped is the number of events discarded by OverflowDrop or lost by
+	// failed flushes without a dead-letter sink.
+	Dropped int64
+	// FlushCount is the number of batches written to the store since New.
+	FlushCount int64
+	// FlushLag is how long the oldest event of the most recently flushed
+	// batch waited between Meter and the end of its flush.
+	FlushLag time.Duration
+}
+
+// MeterStats returns the current buffer depth, flush lag and flush counters.
+func (l *Ledger) MeterStats() MeterStats {
+	stats := MeterStats{
+		Workers:    len(l.meterShards),
+		ShardDepth: make([]int, len(l.meterShards)),
+		Flushed:    l.meterStats.flushed.Load(),
+		Dropped:    l.meterStats.dropped.Load(),
+		FlushCount: l.meterStats.flushes.Load(),
+		FlushLag:   time.Duration(l.meterStats.lag.Load()),
+	}
+	for i, shard := range l.meterShards {
+		stats.ShardDepth[i] = len(shard)
+		stats.Pending += len(shard)
+		stats.Capacity += cap(shard)
+	}
+	if l.spill != nil {
+		stats.Spilled = l.spill.Len()
+	}
+	return stats
+}
+
+// meterDepth returns the number of buffered events and the buffer capacity
+// across shards.
+func (l *Ledger) meterDepth() (depth, capacity int) {
+	for _, shard := range l.meterShards {
+		depth += len(shard)
+		capacity += cap(shard)
+	}
+	return depth, capacity
+}
@@ -0,0 +1,245 @@
+package ledger_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xraph/ledger"
+	"github.com/xraph/ledger/meter"
+	"github.com/xraph/ledger/store/memory"
+)
+
+var errStoreDown = errors.New("store down")
+
+// flakyStore fails the first failures IngestBatch calls.
+type flakyStore struct {
+	*memory.Store
+	mu       sync.Mutex
+	failures int
+	calls    int
+}
+
+func (s *flakyStore) IngestBatch(ctx context.Context, events []*meter.UsageEvent) (*meter.IngestResult, error) {
+	s.mu.Lock()
+	s.calls++
+	fail := s.calls <= s.failures
+	s.mu.Unlock()
+	if fail {
+		return nil, errStoreDown
+	}
+	return s.Store.IngestBatch(ctx, events)
+}
+
+func (s *flakyStore) attempts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+// deadLetters records the batches handed to it.
+type deadLetters struct {
+	mu      sync.Mutex
+	batches [][]*meter.UsageEvent
+	causes  []error
+}
+
+func (d *deadLetters) DeadLetter(_ context.Context, events []*meter.UsageEvent, cause error) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.batches = append(d.batches, events)
+	d.causes = append(d.causes, cause)
+	return nil
+}
+
+func (d *deadLetters) len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.batches)
+}
+
+func usageCount(t *testing.T, s *memory.Store, tenantID string) int {
+	t.Helper()
+	events, err := s.QueryUsage(context.Background(), tenantID, "app", meter.QueryOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(events)
+}
+
+func TestFlushRetriesFailedBatch(t *testing.T) {
+	s := &flakyStore{Store: memory.New(), failures: 2}
+	sink := &deadLetters{}
+	l := startLedger(t, s,
+		ledger.WithMeterConfig(10, 10*time.Millisecond),
+		ledger.WithMeterRetry(3, time.Millisecond, 2*time.Millisecond),
+		ledger.WithDeadLetterSink(sink),
+	)
+
+	ctx := tenantContext("t1", "app")
+	for range 3 {
+		if err := l.Meter(ctx, "api_calls", 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	eventually(t, "the batch to be flushed", func() bool { return usageCount(t, s.Store, "t1") == 3 })
+	if got := s.attempts(); got != 3 {
+		t.Errorf("IngestBatch called %d times, want 3", got)
+	}
+	if sink.len() != 0 {
+		t.Errorf("dead-lettered %d batches, want 0", sink.len())
+	}
+	if stats := l.MeterStats(); stats.Flushed != 3 || stats.FlushCount != 1 {
+		t.Errorf("stats = %+v, want 3 events in 1 flush", stats)
+	}
+}
+
+func TestFlushDeadLettersExhaustedBatch(t *testing.T) {
+	s := &flakyStore{Store: memory.New(), failures: 1 << 30}
+	sink := &deadLetters{}
+	l := startLedger(t, s,
+		ledger.WithMeterConfig(10, 10*time.Millisecond),
+		ledger.WithMeterRetry(2, time.Millisecond, time.Millisecond),
+		ledger.WithDeadLetterSink(sink),
+	)
+
+	if err := l.Meter(tenantContext("t1", "app"), "api_calls", 5); err != nil {
+		t.Fatal(err)
+	}
+
+	eventually(t, "the batch to be dead-lettered", func() bool { return sink.len() == 1 })
+	if got := s.attempts(); got != 3 {
+		t.Errorf("IngestBatch called %d times, want 1 attempt and 2 retries", got)
+	}
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if len(sink.batches[0]) != 1 || sink.batches[0][0].Quantity != 5 {
+		t.Errorf("dead-lettered batch = %+v, want the metered event", sink.batches[0])
+	}
+	if !errors.Is(sink.causes[0], errStoreDown) {
+		t.Errorf("cause = %v, want the store error", sink.causes[0])
+	}
+}
+
+// orderStore records the quantities of each tenant's events in the order
+// they reach the store.
+type orderStore struct {
+	*memory.Store
+	mu  sync.Mutex
+	seq map[string][]int64
+}
+
+func (s *orderStore) IngestBatch(ctx context.Context, events []*meter.UsageEvent) (*meter.IngestResult, error) {
+	s.mu.Lock()
+	for _, e := range events {
+		s.seq[e.TenantID] = append(s.seq[e.TenantID], e.Quantity)
+	}
+	s.mu.Unlock()
+	return s.Store.IngestBatch(ctx, events)
+}
+
+func (s *orderStore) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, seq := range s.seq {
+		n += len(seq)
+	}
+	return n
+}
+
+func TestShardedFlushKeepsTenantOrder(t *testing.T) {
+	s := &orderStore{Store: memory.New(), seq: make(map[string][]int64)}
+	l := startLedger(t, s,
+		ledger.WithMeterConfig(3, 5*time.Millisecond),
+		ledger.WithMeterWorkers(4),
+	)
+
+	tenants := []string{"t1", "t2", "t3", "t4", "t5"}
+	const perTenant = 40
+	for i := range perTenant {
+		for _, tenantID := range tenants {
+			if err := l.Meter(tenantContext(tenantID, "app"), "api_calls", int64(i+1)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	eventually(t, "every event to be flushed", func() bool { return s.count() == len(tenants)*perTenant })
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, tenantID := range tenants {
+		for i, q := range s.seq[tenantID] {
+			if q != int64(i+1) {
+				t.Fatalf("tenant %s: event %d has quantity %d, want %d", tenantID, i, q, i+1)
+			}
+		}
+	}
+}
+
+func TestMeterStatsShards(t *testing.T) {
+	s := newGatedStore()
+	l := startGated(t, s,
+		ledger.WithMeterWorkers(2),
+		ledger.WithMeterBufferSize(8),
+	)
+
+	// The first event keeps its worker busy; the next two wait in the
+	// same shard, since a tenant's events all hash to one worker.
+	fillBuffer(t, l, s)
+	if err := l.Meter(tenantContext("t1", "app"), "api_calls", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := l.MeterStats()
+	if stats.Workers != 2 || stats.Capacity != 8 || stats.Pending != 2 {
+		t.Fatalf("stats = %+v, want 2 workers, capacity 8 and 2 pending", stats)
+	}
+	if len(stats.ShardDepth) != 2 || stats.ShardDepth[0]+stats.ShardDepth[1] != 2 ||
+		(stats.ShardDepth[0] != 0 && stats.ShardDepth[1] != 0) {
+		t.Errorf("ShardDepth = %v, want both events in one shard", stats.ShardDepth)
+	}
+
+	s.open()
+	eventually(t, "the shard to be flushed", func() bool { return l.MeterStats().Pending == 0 })
+}
+
+func TestSpillKeepsTenantOrder(t *testing.T) {
+	s := &orderStore{Store: memory.New(), seq: make(map[string][]int64)}
+	l := startLedger(t, s,
+		ledger.WithMeterConfig(3, time.Millisecond),
+		ledger.WithMeterWorkers(4),
+		ledger.WithMeterBufferSize(20),
+		ledger.WithMeterOverflow(ledger.OverflowSpill),
+		ledger.WithMeterSpillDir(t.TempDir()),
+	)
+
+	tenants := []string{"t1", "t2", "t3", "t4", "t5"}
+	const perTenant = 20
+	spilled := false
+	for i := range perTenant {
+		for _, tenantID := range tenants {
+			if err := l.Meter(tenantContext(tenantID, "app"), "api_calls", int64(i+1)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		spilled = spilled || l.MeterStats().Spilled > 0
+	}
+	if !spilled {
+		t.Fatal("no event was spilled")
+	}
+
+	eventually(t, "every event to be flushed", func() bool { return s.count() == len(tenants)*perTenant })
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, tenantID := range tenants {
+		for i, q := range s.seq[tenantID] {
+			if q != int64(i+1) {
+				t.Fatalf("tenant %s: event %d has quantity %d, want %d", tenantID, i, q, i+1)
+			}
+		}
+	}
+}
@@ -34,6 +34,8 @@ const (
 	PrefixLineItem     Prefix = "li"    // Invoice line item
 	PrefixCoupon       Prefix = "cpn"   // Discount coupon
 	PrefixPayment      Prefix = "pay"   // Payment record
+	PrefixReservation  Prefix = "rsv"   // Quota reservation
+	PrefixOverride     Prefix = "ovr"   // Entitlement override
 )
 
 // ID is the primary identifier type for all Ledger entities.
@@ -144,6 +146,12 @@ type CouponID = ID
 // PaymentID is a type-safe identifier for payments (prefix: "pay").
 type PaymentID = ID
 
+// ReservationID is a type-safe identifier for quota reservations (prefix: "rsv").
+type ReservationID = ID
+
+// OverrideID is a type-safe identifier for entitlement overrides (prefix: "ovr").
+type OverrideID = ID
+
 // AnyID is a type alias that accepts any valid prefix.
 type AnyID = ID
 
@@ -181,6 +189,12 @@ func NewCouponID() ID { return New(PrefixCoupon) }
 // NewPaymentID generates a new unique payment ID.
 func NewPaymentID() ID { return New(PrefixPayment) }
 
+// NewReservationID generates a new unique quota reservation ID.
+func NewReservationID() ID { return New(PrefixReservation) }
+
+// NewOverrideID generates a new unique entitlement override ID.
+func NewOverrideID() ID { return New(PrefixOverride) }
+
 // ──────────────────────────────────────────────────
 // Convenience parsers
 // ──────────────────────────────────────────────────
@@ -215,6 +229,12 @@ func ParseCouponID(s string) (ID, error) { return ParseWithPrefix(s, PrefixCoupo
 // ParsePaymentID parses a string and validates the "pay" prefix.
 func ParsePaymentID(s string) (ID, error) { return ParseWithPrefix(s, PrefixPayment) }
 
+// ParseReservationID parses a string and validates the "rsv" prefix.
+func ParseReservationID(s string) (ID, error) { return ParseWithPrefix(s, PrefixReservation) }
+
+// ParseOverrideID parses a string and validates the "ovr" prefix.
+func ParseOverrideID(s string) (ID, error) { return ParseWithPrefix(s, PrefixOverride) }
+
 // ParseAny parses a string into an ID without type checking the prefix.
 func ParseAny(s string) (ID, error) { return Parse(s) }
 
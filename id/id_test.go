@@ -23,6 +23,8 @@ func TestConstructors(t *testing.T) {
 		{"LineItemID", id.NewLineItemID, "li_"},
 		{"CouponID", id.NewCouponID, "cpn_"},
 		{"PaymentID", id.NewPaymentID, "pay_"},
+		{"ReservationID", id.NewReservationID, "rsv_"},
+		{"OverrideID", id.NewOverrideID, "ovr_"},
 	}
 
 	for _, tt := range tests {
@@ -61,6 +63,8 @@ func TestParseRoundTrip(t *testing.T) {
 		{"LineItemID", id.NewLineItemID, id.ParseLineItemID},
 		{"CouponID", id.NewCouponID, id.ParseCouponID},
 		{"PaymentID", id.NewPaymentID, id.ParsePaymentID},
+		{"ReservationID", id.NewReservationID, id.ParseReservationID},
+		{"OverrideID", id.NewOverrideID, id.ParseOverrideID},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,203 @@
+package ledger
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	log "github.com/xraph/go-utils/log"
+
+	"github.com/xraph/ledger/cachebus"
+	"github.com/xraph/ledger/id"
+	"github.com/xraph/ledger/meter"
+	"github.com/xraph/ledger/subscription"
+)
+
+const (
+	// cacheBusMinBackoff and cacheBusMaxBackoff bound the wait before a
+	// failed cache bus subscription is retried.
+	cacheBusMinBackoff = time.Second
+	cacheBusMaxBackoff = time.Minute
+)
+
+// invalidate drops the cache entries msg makes stale and, with a cache
+// bus, has the other instances drop theirs.
+func (l *Ledger) invalidate(ctx context.Context, msg cachebus.Message) {
+	l.applyInvalidation(ctx, msg)
+	if l.cacheBus != nil {
+		l.publish(ctx, msg)
+	}
+}
+
+// publish sends msg to the other instances. Failures are logged; their
+// entries expire with the cache TTL.
+func (l *Ledger) publish(ctx context.Context, msg cachebus.Message) {
+	msg.Origin = l.instanceID
+	if err := l.cacheBus.Publish(ctx, msg); err != nil {
+		l.logger.Warn("failed to publish cache invalidation",
+			log.String("kind", string(msg.Kind)),
+			log.String("tenant_id", msg.TenantID),
+			log.Error(err),
+		)
+	}
+}
+
+// invalidateUsage drops the cached results of the features whose usage a
+// flushed batch changed. The messages for the other instances are left to
+// the cache bus publisher, so the flush never waits on the bus.
+func (l *Ledger) invalidateUsage(ctx context.Context, batch []*meter.UsageEvent) {
+	changed := make(map[tenantApp][]string)
+	for _, e := range batch {
+		t := tenantApp{tenantID: e.TenantID, appID: e.AppID}
+		if !slices.Contains(changed[t], e.FeatureKey) {
+			changed[t] = append(changed[t], e.FeatureKey)
+		}
+	}
+	for t, keys := range changed {
+		l.applyInvalidation(ctx, cachebus.Message{Kind: cachebus.KindUsage, TenantID: t.tenantID, AppID: t.appID, Features: keys})
+	}
+	if l.cacheBus == nil {
+		return
+	}
+
+	l.busMu.Lock()
+	for t, keys := range changed {
+		for _, key := range keys {
+			if !slices.Contains(l.busPending[t], key) {
+				l.busPending[t] = append(l.busPending[t], key)
+			}
+		}
+	}
+	l.busMu.Unlock()
+
+	select {
+	case l.busWake <- struct{}{}:
+	default:
+	}
+}
+
+// cacheBusPublisher publishes the usage invalidations queued by the flush
+// path, one message per tenant, until the Ledger stops. Features flushed
+// while messages are being published are merged and sent together; Stop
+// publishes whatever is left.
+func (l *Ledger) cacheBusPublisher(ctx context.Context) {
+	defer l.wg.Done()
+
+	for {
+		select {
+		case <-l.stopChan:
+			return
+		case <-ctx.Done():
+			return
+		case <-l.busWake:
+		}
+		l.publishUsageInvalidations(ctx)
+	}
+}
+
+// publishUsageInvalidations publishes the queued usage invalidations.
+func (l *Ledger) publishUsageInvalidations(ctx context.Context) {
+	l.busMu.Lock()
+	pending := l.busPending
+	l.busPending = make(map[tenantApp][]string)
+	l.busMu.Unlock()
+
+	for t, keys := range pending {
+		l.publish(ctx, cachebus.Message{Kind: cachebus.KindUsage, TenantID: t.tenantID, AppID: t.appID, Features: keys})
+	}
+}
+
+// applyInvalidation drops this instance's cache entries made stale by msg.
+// Store failures are ignored; the entries expire with the cache TTL.
+func (l *Ledger) applyInvalidation(ctx context.Context, msg cachebus.Message) {
+	switch msg.Kind {
+	case cachebus.KindSubscription:
+		_ = l.store.Invalidate(ctx, msg.TenantID, msg.AppID) //nolint:errcheck // best-effort cache invalidation
+		l.forgetRateRules(msg.TenantID, msg.AppID)
+
+	case cachebus.KindOverride, cachebus.KindUsage:
+		if len(msg.Features) == 0 {
+			_ = l.store.Invalidate(ctx, msg.TenantID, msg.AppID) //nolint:errcheck // best-effort cache invalidation
+		}
+		for _, key := range msg.Features {
+			_ = l.store.InvalidateFeature(ctx, msg.TenantID, msg.AppID, key) //nolint:errcheck // best-effort cache invalidation
+		}
+		// Overrides can add or lift rolling limits.
+		if msg.Kind == cachebus.KindOverride {
+			l.forgetRateRules(msg.TenantID, msg.AppID)
+		}
+
+	case cachebus.KindPlan:
+		planID, err := id.ParsePlanID(msg.PlanID)
+		if err != nil {
+			return
+		}
+		subs, err := l.store.ListSubscriptions(ctx, "", msg.AppID, subscription.ListOpts{PlanID: planID})
+		if err != nil {
+			l.logger.Warn("failed to list subscriptions of updated plan",
+				log.String("plan_id", msg.PlanID),
+				log.Error(err),
+			)
+			return
+		}
+		for _, sub := range subs {
+			_ = l.store.Invalidate(ctx, sub.TenantID, sub.AppID) //nolint:errcheck // best-effort cache invalidation
+			l.forgetRateRules(sub.TenantID, sub.AppID)
+		}
+
+	case cachebus.KindCatalog:
+		l.resetCatalogCache()
+	}
+}
+
+// cacheBusWorker applies the invalidations published by other instances
+// until the Ledger stops. When the subscription fails, messages may have
+// been missed, so the in-process caches are dropped before it resubscribes;
+// cached results in the store expire with the cache TTL.
+func (l *Ledger) cacheBusWorker(ctx context.Context) {
+	defer l.wg.Done()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-l.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	handle := func(msg cachebus.Message) {
+		if msg.Origin != l.instanceID {
+			l.applyInvalidation(ctx, msg)
+		}
+	}
+
+	backoff := cacheBusMinBackoff
+	for {
+		subscribed := time.Now()
+		err := l.cacheBus.Subscribe(ctx, handle)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(subscribed) > cacheBusMaxBackoff {
+			backoff = cacheBusMinBackoff
+		}
+		l.logger.Warn("cache bus subscription failed",
+			log.Error(err),
+			log.Duration("retry_in", backoff),
+		)
+
+		l.resetCatalogCache()
+		l.rateMu.Lock()
+		clear(l.rateRules)
+		l.rateMu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, cacheBusMaxBackoff)
+	}
+}
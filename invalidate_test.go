@@ -0,0 +1,103 @@
+package ledger_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xraph/ledger"
+	"github.com/xraph/ledger/cachebus"
+	"github.com/xraph/ledger/entitlement"
+	"github.com/xraph/ledger/plan"
+	"github.com/xraph/ledger/store/memory"
+)
+
+// stalledBus holds every Publish call until open is called.
+type stalledBus struct {
+	*cachebus.Memory
+	release chan struct{}
+	once    sync.Once
+}
+
+func (b *stalledBus) Publish(ctx context.Context, msg cachebus.Message) error {
+	<-b.release
+	return b.Memory.Publish(ctx, msg)
+}
+
+func (b *stalledBus) open() {
+	b.once.Do(func() { close(b.release) })
+}
+
+func TestUsageInvalidationReachesOtherInstances(t *testing.T) {
+	ctx := context.Background()
+	bus := cachebus.NewMemory()
+	a := startLedger(t, memory.New(), ledger.WithCacheBus(bus), ledger.WithMeterConfig(1, 5*time.Millisecond))
+	other := memory.New()
+	startLedger(t, other, ledger.WithCacheBus(bus))
+
+	for _, key := range []string{"api_calls", "storage"} {
+		if err := other.SetCached(ctx, "t1", "app", key, &entitlement.Result{Allowed: true, Feature: key}, time.Hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Meter until the other instance is subscribed and drops the entry.
+	tctx := tenantContext("t1", "app")
+	eventually(t, "the other instance to drop the cached result", func() bool {
+		if err := a.Meter(tctx, "api_calls", 1); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+		_, err := other.GetCached(ctx, "t1", "app", "api_calls")
+		return err != nil
+	})
+	if _, err := other.GetCached(ctx, "t1", "app", "storage"); err != nil {
+		t.Errorf("untouched feature was invalidated: %v", err)
+	}
+}
+
+func TestFlushDoesNotWaitForCacheBus(t *testing.T) {
+	bus := &stalledBus{Memory: cachebus.NewMemory(), release: make(chan struct{})}
+	s := memory.New()
+	l := startLedger(t, s, ledger.WithCacheBus(bus), ledger.WithMeterConfig(1, 5*time.Millisecond))
+	t.Cleanup(bus.open) // runs before the Ledger is stopped
+
+	tctx := tenantContext("t1", "app")
+	for range 3 {
+		if err := l.Meter(tctx, "api_calls", 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	eventually(t, "the usage to be flushed while the bus is stalled", func() bool { return usageCount(t, s, "t1") == 3 })
+}
+
+func TestPlanInvalidationReachesItsSubscribers(t *testing.T) {
+	ctx := context.Background()
+	bus := cachebus.NewMemory()
+	other := memory.New()
+	l := startLedger(t, other, ledger.WithCacheBus(bus))
+	features := []plan.Feature{{Key: "api_calls", Name: "API calls", Type: plan.FeatureMetered, Limit: 10, Period: plan.PeriodMonthly}}
+	updated, _ := subscribe(t, l, "t1", features)
+	subscribe(t, l, "t2", features)
+
+	for _, tenantID := range []string{"t1", "t2"} {
+		if err := other.SetCached(ctx, tenantID, "app", "api_calls", &entitlement.Result{Allowed: true, Feature: "api_calls"}, time.Hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Publish as another instance until this one is subscribed.
+	msg := cachebus.Message{Kind: cachebus.KindPlan, AppID: "app", PlanID: updated.ID.String(), Origin: "elsewhere"}
+	eventually(t, "the plan's subscriber to drop the cached result", func() bool {
+		if err := bus.Publish(ctx, msg); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+		_, err := other.GetCached(ctx, "t1", "app", "api_calls")
+		return err != nil
+	})
+	if _, err := other.GetCached(ctx, "t2", "app", "api_calls"); err != nil {
+		t.Errorf("subscriber of another plan was invalidated: %v", err)
+	}
+}
@@ -57,10 +57,11 @@ type LineItem struct {
 type LineItemType string
 
 const (
-	LineItemBase     LineItemType = "base"
-	LineItemUsage    LineItemType = "usage"
-	LineItemOverage  LineItemType = "overage"
-	LineItemSeat     LineItemType = "seat"
-	LineItemDiscount LineItemType = "discount"
-	LineItemTax      LineItemType = "tax"
+	LineItemBase       LineItemType = "base"
+	LineItemUsage      LineItemType = "usage"
+	LineItemOverage    LineItemType = "overage"
+	LineItemCorrection LineItemType = "correction"
+	LineItemSeat       LineItemType = "seat"
+	LineItemDiscount   LineItemType = "discount"
+	LineItemTax        LineItemType = "tax"
 )
@@ -0,0 +1,198 @@
+package ledger_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xraph/ledger"
+	"github.com/xraph/ledger/invoice"
+	"github.com/xraph/ledger/plan"
+	"github.com/xraph/ledger/store/memory"
+	"github.com/xraph/ledger/types"
+)
+
+// lineItems returns the invoice's line items for featureKey.
+func lineItems(inv *invoice.Invoice, featureKey string) []invoice.LineItem {
+	var items []invoice.LineItem
+	for _, li := range inv.LineItems {
+		if li.FeatureKey == featureKey {
+			items = append(items, li)
+		}
+	}
+	return items
+}
+
+func TestInvoiceZeroIncludedFeature(t *testing.T) {
+	s := memory.New()
+	l := startLedger(t, s, ledger.WithMeterConfig(1, 5*time.Millisecond))
+	_, sub := subscribe(t, l, "t1", []plan.Feature{
+		{Key: "exports", Name: "Exports", Type: plan.FeatureMetered, Limit: 0, Period: plan.PeriodMonthly},
+		{Key: "api_calls", Name: "API calls", Type: plan.FeatureMetered, Limit: -1, Period: plan.PeriodMonthly},
+	},
+		plan.PriceTier{FeatureKey: "exports", Type: plan.TierGraduated, UpTo: -1, UnitAmount: types.USD(2)},
+		plan.PriceTier{FeatureKey: "api_calls", Type: plan.TierGraduated, UpTo: 10, UnitAmount: types.USD(1)},
+		plan.PriceTier{FeatureKey: "api_calls", Type: plan.TierGraduated, UpTo: -1, UnitAmount: types.USD(3)},
+	)
+
+	tctx := tenantContext("t1", "app")
+	for key, qty := range map[string]int64{"exports": 5, "api_calls": 15} {
+		if err := l.Meter(tctx, key, qty); err != nil {
+			t.Fatal(err)
+		}
+	}
+	eventually(t, "the usage to be flushed", func() bool { return usageCount(t, s, "t1") == 2 })
+
+	inv, err := l.GenerateInvoice(context.Background(), sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing is included, so every export is overage.
+	items := lineItems(inv, "exports")
+	if len(items) != 1 {
+		t.Fatalf("exports lines = %+v, want a single overage line", items)
+	}
+	if li := items[0]; li.Type != invoice.LineItemOverage || li.Quantity != 5 || li.UnitAmount.Amount != 2 || li.Amount.Amount != 10 {
+		t.Errorf("exports line = %+v, want 5 overage units at 2 for 10", li)
+	}
+
+	// Graduated tiers price 10 units at 1 and 5 at 3; no single unit
+	// price gives the amount, so none is claimed.
+	items = lineItems(inv, "api_calls")
+	if len(items) != 1 {
+		t.Fatalf("api_calls lines = %+v, want a single usage line", items)
+	}
+	if li := items[0]; li.Type != invoice.LineItemUsage || li.Quantity != 15 || li.UnitAmount.Amount != 0 || li.Amount.Amount != 25 {
+		t.Errorf("api_calls line = %+v, want 15 units for 25 without a unit amount", li)
+	}
+}
+
+// perUnitPricing is a pricing strategy that charges a fixed amount per
+// unit in its currency, ignoring the tiers.
+type perUnitPricing struct {
+	name     string
+	unit     int64
+	currency string
+}
+
+func (p *perUnitPricing) Name() string         { return p.name }
+func (p *perUnitPricing) StrategyName() string { return p.name }
+
+func (p *perUnitPricing) Compute(_ []interface{}, usage, _ int64, _ string) interface{} {
+	return types.Money{Amount: p.unit * usage, Currency: p.currency}
+}
+
+func TestInvoicePricingStrategies(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	l := startLedger(t, s,
+		ledger.WithMeterConfig(1, 5*time.Millisecond),
+		ledger.WithPlugin(&perUnitPricing{name: "per_call", unit: 7, currency: "usd"}),
+		ledger.WithPlugin(&perUnitPricing{name: "euro_only", unit: 7, currency: "eur"}),
+	)
+	unregistered := map[string]string{plan.MetadataPricingStrategy: "unregistered"}
+	invalid := map[string]string{plan.MetadataPricingStrategy: "euro_only"}
+	p, sub := subscribe(t, l, "t1", []plan.Feature{
+		{Key: "api_calls", Name: "API calls", Type: plan.FeatureMetered, Limit: 10, Period: plan.PeriodMonthly},
+		{Key: "exports", Name: "Exports", Type: plan.FeatureMetered, Limit: 10, Period: plan.PeriodMonthly, Metadata: unregistered},
+		{Key: "storage", Name: "Storage", Type: plan.FeatureMetered, Limit: 10, Period: plan.PeriodMonthly, Metadata: invalid},
+	},
+		plan.PriceTier{FeatureKey: "api_calls", Type: "per_call", UpTo: -1, UnitAmount: types.USD(1)},
+		plan.PriceTier{FeatureKey: "exports", Type: plan.TierGraduated, UpTo: -1, UnitAmount: types.USD(2)},
+		plan.PriceTier{FeatureKey: "storage", Type: plan.TierGraduated, UpTo: -1, UnitAmount: types.USD(3)},
+	)
+
+	tctx := tenantContext("t1", "app")
+	for _, key := range []string{"api_calls", "exports", "storage"} {
+		if err := l.Meter(tctx, key, 15); err != nil {
+			t.Fatal(err)
+		}
+	}
+	eventually(t, "the usage to be flushed", func() bool { return usageCount(t, s, "t1") == 3 })
+
+	inv, err := l.GenerateInvoice(ctx, sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		key  string
+		want int64
+	}{
+		{"Registered strategy", "api_calls", 15 * 7},
+		{"Unregistered strategy", "exports", 15 * 2},
+		{"Strategy in another currency", "storage", 15 * 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var billed int64
+			for _, li := range lineItems(inv, tt.key) {
+				billed += li.Amount.Amount
+			}
+			if billed != tt.want {
+				t.Errorf("invoice bills %d for %s, want %d", billed, tt.key, tt.want)
+			}
+
+			preview, err := l.PreviewUsageCost(ctx, p.ID, tt.key, 15)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if preview.Amount != billed || preview.Currency != "usd" {
+				t.Errorf("preview = %+v, want the %d usd billed", preview, billed)
+			}
+		})
+	}
+}
+
+func TestInvoiceLinePerDimensionGroup(t *testing.T) {
+	s := memory.New()
+	l := startLedger(t, s, ledger.WithMeterConfig(1, 5*time.Millisecond))
+	_, sub := subscribe(t, l, "t1",
+		[]plan.Feature{{Key: "tokens", Name: "Tokens", Type: plan.FeatureMetered, Limit: 4, Period: plan.PeriodMonthly}},
+		plan.PriceTier{FeatureKey: "tokens", UpTo: -1, UnitAmount: types.USD(1)},
+		plan.PriceTier{FeatureKey: "tokens", UpTo: -1, UnitAmount: types.USD(10), Dimensions: map[string]string{"model": "large"}},
+	)
+
+	tctx := tenantContext("t1", "app")
+	for model, qty := range map[string]int64{"large": 5, "small": 3} {
+		opts := ledger.MeterOptions{Properties: map[string]string{"model": model}}
+		for range 2 {
+			if err := l.MeterWithOptions(tctx, "tokens", qty, opts); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	eventually(t, "the usage to be flushed", func() bool { return usageCount(t, s, "t1") == 4 })
+
+	inv, err := l.GenerateInvoice(context.Background(), sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The limit of 4 is shared by the groups in label order, so the large
+	// group uses it up; each group is priced with its own tiers.
+	type line struct {
+		model    string
+		kind     invoice.LineItemType
+		quantity int64
+		amount   int64
+	}
+	want := []line{
+		{"large", invoice.LineItemUsage, 4, 40},
+		{"large", invoice.LineItemOverage, 6, 60},
+		{"small", invoice.LineItemOverage, 6, 6},
+	}
+	var got []line
+	for _, li := range lineItems(inv, "tokens") {
+		got = append(got, line{li.Metadata["model"], li.Type, li.Quantity, li.Amount.Amount})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("token lines = %+v, want %+v", got, want)
+	}
+	if inv.Total.Amount != 106 {
+		t.Errorf("total = %v, want 106", inv.Total)
+	}
+}
@@ -2,18 +2,26 @@ package ledger
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	log "github.com/xraph/go-utils/log"
 
+	"github.com/xraph/ledger/cachebus"
 	"github.com/xraph/ledger/entitlement"
 	"github.com/xraph/ledger/feature"
 	"github.com/xraph/ledger/id"
 	"github.com/xraph/ledger/invoice"
 	"github.com/xraph/ledger/meter"
+	"github.com/xraph/ledger/meter/archive"
+	"github.com/xraph/ledger/meter/ratelimit"
+	"github.com/xraph/ledger/meter/wal"
 	"github.com/xraph/ledger/plan"
 	"github.com/xraph/ledger/plugin"
 	"github.com/xraph/ledger/provider"
@@ -29,9 +37,79 @@ type Ledger struct {
 	logger  log.Logger
 
 	// Background workers
-	meterBuffer chan *meter.UsageEvent
+	meterShards []chan queuedEvent       // one buffer per flush worker
+	meterMu     sync.Mutex               // guards meterSlots
+	meterSlots  map[chan queuedEvent]int // buffer slots held for WAL appends in progress
 	stopChan    chan struct{}
 	wg          sync.WaitGroup
+	meterStats  meterCounters
+
+	// Flush retry and dead-letter handling
+	meterMaxRetries      int
+	meterRetryBackoff    time.Duration
+	meterRetryMaxBackoff time.Duration
+	deadLetterSink       meter.DeadLetterSink
+
+	// Optional write-ahead log for metered usage
+	walDir         string
+	walSegmentSize int64
+	wal            *wal.Log
+
+	// Buffer capacity and what Meter does when the buffer is full
+	meterBufferSize  int
+	meterWorkers     int
+	meterOverflow    OverflowPolicy
+	meterSpillDir    string
+	meterDropHandler MeterDropHandler
+	spill            *wal.Log
+
+	// Strict metering validates events against the feature catalog, whose
+	// lookups are cached for entitlementCacheTTL
+	strictMetering bool
+	catalogMu      sync.Mutex
+	catalogCache   map[string]catalogEntry
+
+	// Serializes AddSeat and RemoveSeat read-modify-writes
+	seatMu sync.Mutex
+
+	// Live usage subscriptions fed by the flush path
+	usageMu   sync.Mutex
+	usageSubs map[*usageSubscription]struct{}
+
+	// Per-app retention: old events are moved to the usage archive every
+	// retentionInterval
+	usageArchive      archive.Storage
+	retention         []RetentionPolicy
+	retentionInterval time.Duration
+
+	// Counters behind rolling and rate-limit periods, each tenant's rolling
+	// plan features, cached for entitlementCacheTTL, and the lock that seeds
+	// and deduplicates counts
+	rateCounter ratelimit.Counter
+	rateMu      sync.Mutex
+	rateRules   map[string]rateEntry
+	rateCountMu sync.Mutex
+
+	// How often expired reservations are released and expired hard-limit
+	// counters purged
+	quotaSweepInterval time.Duration
+
+	// Default usage alert thresholds, and the tenants and features whose
+	// usage was flushed since the alert worker last ran
+	usageThresholds []int
+	alertMu         sync.Mutex
+	alertPending    map[tenantApp][]string
+	alertWake       chan struct{}
+
+	// Optional bus sharing cache invalidations with the other instances,
+	// which tell this instance's messages apart by instanceID, and the
+	// features of each tenant whose usage was flushed since the cache bus
+	// publisher last ran
+	cacheBus   cachebus.Bus
+	instanceID string
+	busMu      sync.Mutex
+	busPending map[tenantApp][]string
+	busWake    chan struct{}
 
 	// Configuration
 	meterBatchSize      int
@@ -45,17 +123,42 @@ func New(s store.Store, opts ...Option) *Ledger {
 		store:               s,
 		plugins:             plugin.NewRegistry(),
 		logger:              log.NewNoopLogger(),
-		meterBuffer:         make(chan *meter.UsageEvent, 10000),
 		stopChan:            make(chan struct{}),
+		meterBufferSize:     10000,
+		meterWorkers:        1,
+		meterOverflow:       OverflowReject,
+		catalogCache:        make(map[string]catalogEntry),
+		meterSlots:          make(map[chan queuedEvent]int),
+		usageSubs:           make(map[*usageSubscription]struct{}),
+		retentionInterval:   time.Hour,
+		rateCounter:         ratelimit.NewMemory(),
+		rateRules:           make(map[string]rateEntry),
+		quotaSweepInterval:  time.Minute,
+		alertPending:        make(map[tenantApp][]string),
+		alertWake:           make(chan struct{}, 1),
+		instanceID:          rand.Text(),
+		busPending:          make(map[tenantApp][]string),
+		busWake:             make(chan struct{}, 1),
 		meterBatchSize:      100,
 		meterFlushInterval:  5 * time.Second,
 		entitlementCacheTTL: 30 * time.Second,
+
+		meterMaxRetries:      3,
+		meterRetryBackoff:    100 * time.Millisecond,
+		meterRetryMaxBackoff: 5 * time.Second,
 	}
 
 	for _, opt := range opts {
 		opt(l)
 	}
 
+	// Split the buffer capacity evenly across the flush workers' shards.
+	l.meterShards = make([]chan queuedEvent, l.meterWorkers)
+	shardSize := (l.meterBufferSize + l.meterWorkers - 1) / l.meterWorkers
+	for i := range l.meterShards {
+		l.meterShards[i] = make(chan queuedEvent, shardSize)
+	}
+
 	return l
 }
 
@@ -92,11 +195,201 @@ func WithEntitlementCacheTTL(ttl time.Duration) Option {
 	}
 }
 
+// WithMeterRetry configures how failed meter flushes are retried: up to
+// maxRetries further attempts, waiting backoff before the first retry and
+// doubling it each time up to maxBackoff. A maxRetries of 0 disables retries.
+func WithMeterRetry(maxRetries int, backoff, maxBackoff time.Duration) Option {
+	return func(l *Ledger) {
+		l.meterMaxRetries = maxRetries
+		l.meterRetryBackoff = backoff
+		l.meterRetryMaxBackoff = maxBackoff
+	}
+}
+
+// WithDeadLetterSink sets where meter batches go once their flush retries
+// are exhausted. Without a sink such batches are dropped (and kept only by
+// the meter WAL, when enabled).
+func WithDeadLetterSink(sink meter.DeadLetterSink) Option {
+	return func(l *Ledger) {
+		l.deadLetterSink = sink
+	}
+}
+
+// WithMeterWAL enables a durable write-ahead log for metered usage under
+// dir. Events are written to disk before Meter returns, replayed on Start
+// and removed once flushed to the store. A segmentSize <= 0 uses
+// wal.DefaultSegmentSize.
+func WithMeterWAL(dir string, segmentSize int64) Option {
+	return func(l *Ledger) {
+		l.walDir = dir
+		l.walSegmentSize = segmentSize
+	}
+}
+
+// OverflowPolicy decides what Meter does with an event when the meter buffer
+// is full.
+type OverflowPolicy string
+
+const (
+	// OverflowReject returns ErrMeterBufferFull straight away. It is the
+	// default.
+	OverflowReject OverflowPolicy = "reject"
+	// OverflowBlock waits for room in the buffer until the context is done,
+	// then returns ErrMeterBufferFull wrapping the context error.
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowSpill writes the event to an on-disk spill log (see
+	// WithMeterSpillDir) that the flush worker drains back into the buffer
+	// once it has room again. Events metered while the log is not empty are
+	// spilled too, so each tenant's usage is still flushed in order.
+	OverflowSpill OverflowPolicy = "spill"
+	// OverflowDrop discards the event, reports it to the drop handler and to
+	// plugins as dropped usage, and returns nil.
+	OverflowDrop OverflowPolicy = "drop"
+)
+
+// MeterDropHandler is called with each event discarded by OverflowDrop.
+type MeterDropHandler func(ctx context.Context, event *meter.UsageEvent)
+
+// WithMeterBufferSize sets the capacity of the in-memory meter buffer
+// (default: 10000). Values <= 0 are ignored.
+func WithMeterBufferSize(size int) Option {
+	return func(l *Ledger) {
+		if size > 0 {
+			l.meterBufferSize = size
+		}
+	}
+}
+
+// WithMeterWorkers sets the number of meter flush workers (default: 1).
+// Events are sharded across the workers by tenant, so each tenant's events
+// are still flushed in order, and each worker batches its shard
+// independently using the WithMeterConfig batch size. The buffer capacity is
+// split evenly between the shards. Values <= 0 are ignored.
+func WithMeterWorkers(n int) Option {
+	return func(l *Ledger) {
+		if n > 0 {
+			l.meterWorkers = n
+		}
+	}
+}
+
+// WithMeterOverflow sets what Meter does when the meter buffer is full
+// (default: OverflowReject).
+func WithMeterOverflow(policy OverflowPolicy) Option {
+	return func(l *Ledger) {
+		l.meterOverflow = policy
+	}
+}
+
+// WithMeterSpillDir sets the directory of the spill log used by
+// OverflowSpill. It is required by that policy.
+func WithMeterSpillDir(dir string) Option {
+	return func(l *Ledger) {
+		l.meterSpillDir = dir
+	}
+}
+
+// WithMeterDropHandler sets the callback invoked for every event discarded by
+// OverflowDrop.
+func WithMeterDropHandler(fn MeterDropHandler) Option {
+	return func(l *Ledger) {
+		l.meterDropHandler = fn
+	}
+}
+
+// WithStrictMetering validates every usage event against the feature catalog
+// before it is buffered. The key must name an app-scoped or global catalog
+// feature that is not archived, and metered features only accept
+// non-negative quantities. Rejected events are returned as errors from
+// Meter and reported to OnUsageRejected plugins.
+func WithStrictMetering(strict bool) Option {
+	return func(l *Ledger) {
+		l.strictMetering = strict
+	}
+}
+
+// WithUsageArchive sets where archived usage events are written, e.g.
+// archive.Dir for a local directory. It is required by retention policies,
+// ArchiveUsage and RestoreUsage.
+func WithUsageArchive(storage archive.Storage) Option {
+	return func(l *Ledger) {
+		l.usageArchive = storage
+	}
+}
+
+// WithRetentionPolicy archives an app's usage events once they are older
+// than the policy's retention window. Policies run when the Ledger starts
+// and then every retention interval; a later policy for the same app
+// replaces an earlier one.
+func WithRetentionPolicy(policy RetentionPolicy) Option {
+	return func(l *Ledger) {
+		l.retention = slices.DeleteFunc(l.retention, func(p RetentionPolicy) bool {
+			return p.AppID == policy.AppID
+		})
+		l.retention = append(l.retention, policy)
+	}
+}
+
+// WithRetentionInterval sets how often retention policies run. The default
+// is one hour.
+func WithRetentionInterval(d time.Duration) Option {
+	return func(l *Ledger) {
+		if d > 0 {
+			l.retentionInterval = d
+		}
+	}
+}
+
+// WithRateCounter sets the counter behind rolling and rate-limit periods.
+// The default keeps counters in process; instances that share tenants need
+// a shared Counter, such as one backed by Redis, to enforce one limit.
+func WithRateCounter(c ratelimit.Counter) Option {
+	return func(l *Ledger) {
+		if c != nil {
+			l.rateCounter = c
+		}
+	}
+}
+
+// WithQuotaSweepInterval sets how often reservations past their TTL are
+// released. The default is one minute.
+func WithQuotaSweepInterval(d time.Duration) Option {
+	return func(l *Ledger) {
+		if d > 0 {
+			l.quotaSweepInterval = d
+		}
+	}
+}
+
+// WithUsageThresholds sets the usage alert thresholds, in percent of the
+// limit, of plan features that set no Thresholds of their own, for example
+// WithUsageThresholds(50, 80, 100). There are none by default.
+func WithUsageThresholds(percents ...int) Option {
+	return func(l *Ledger) {
+		l.usageThresholds = slices.Clone(percents)
+	}
+}
+
+// WithCacheBus shares cache invalidations with the other Ledger instances
+// on bus, so that changes made through one instance drop the cached
+// entitlements, catalog lookups and rate rules of all of them. Without a
+// bus, other instances serve stale entries until the cache TTL runs out.
+func WithCacheBus(bus cachebus.Bus) Option {
+	return func(l *Ledger) {
+		l.cacheBus = bus
+	}
+}
+
 // Store returns the underlying ledger store.
 func (l *Ledger) Store() store.Store { return l.store }
 
 // Start begins background workers.
 func (l *Ledger) Start(ctx context.Context) error {
+	// Check the configuration before anything is opened or replayed
+	if err := l.validate(); err != nil {
+		return err
+	}
+
 	// Migrate database
 	if err := l.store.Migrate(ctx); err != nil {
 		return err
@@ -105,12 +398,57 @@ func (l *Ledger) Start(ctx context.Context) error {
 	// Initialize plugins
 	l.plugins.EmitInit(ctx, l)
 
-	// Start meter flush worker
+	// Open the meter WAL and the spill log; whatever the spill log holds is
+	// drained by the flush worker
+	if l.walDir != "" {
+		w, err := wal.Open(l.walDir, l.walSegmentSize)
+		if err != nil {
+			return err
+		}
+		l.wal = w
+	}
+	if l.meterOverflow == OverflowSpill {
+		w, err := wal.Open(l.meterSpillDir, l.walSegmentSize)
+		if err != nil {
+			l.closeLogs()
+			return err
+		}
+		l.spill = w
+	}
+
+	// Flush anything left in the WAL by a previous run
+	if l.wal != nil {
+		l.replayWAL(ctx)
+	}
+
+	// Start one meter flush worker per shard
+	for shard := range l.meterShards {
+		l.wg.Add(1)
+		go l.meterFlushWorker(ctx, shard)
+	}
+
+	if len(l.retention) > 0 {
+		l.wg.Add(1)
+		go l.retentionWorker(ctx)
+	}
+
 	l.wg.Add(1)
-	go l.meterFlushWorker(ctx)
+	go l.quotaWorker(ctx)
+
+	l.wg.Add(1)
+	go l.usageAlertWorker(ctx)
+
+	if l.cacheBus != nil {
+		l.wg.Add(2)
+		go l.cacheBusWorker(ctx)
+		go l.cacheBusPublisher(ctx)
+	}
 
 	l.logger.Info("ledger started",
 		log.Int("batch_size", l.meterBatchSize),
+		log.Int("buffer_size", l.meterBufferSize),
+		log.Int("flush_workers", l.meterWorkers),
+		log.String("overflow", string(l.meterOverflow)),
 		log.Duration("flush_interval", l.meterFlushInterval),
 		log.Duration("cache_ttl", l.entitlementCacheTTL),
 	)
@@ -118,6 +456,55 @@ func (l *Ledger) Start(ctx context.Context) error {
 	return nil
 }
 
+// validate checks the options Start depends on.
+func (l *Ledger) validate() error {
+	switch l.meterOverflow {
+	case OverflowReject, OverflowBlock, OverflowDrop:
+	case OverflowSpill:
+		if l.meterSpillDir == "" {
+			return fmt.Errorf("%w: overflow policy %q needs a spill directory", ErrInvalidInput, l.meterOverflow)
+		}
+	default:
+		return fmt.Errorf("%w: unknown overflow policy %q", ErrInvalidInput, l.meterOverflow)
+	}
+
+	if len(l.retention) > 0 {
+		if l.usageArchive == nil {
+			return fmt.Errorf("%w: retention policies need a usage archive", ErrInvalidInput)
+		}
+		if err := l.checkUsageRollups(); err != nil {
+			return err
+		}
+	}
+	for _, p := range l.retention {
+		if err := p.validate(); err != nil {
+			return err
+		}
+	}
+	for _, t := range l.usageThresholds {
+		if t <= 0 {
+			return fmt.Errorf("%w: usage threshold %d%%", ErrInvalidInput, t)
+		}
+	}
+	return nil
+}
+
+// closeLogs closes the meter WAL and spill log, if open.
+func (l *Ledger) closeLogs() {
+	if l.wal != nil {
+		if err := l.wal.Close(); err != nil {
+			l.logger.Error("failed to close meter WAL", log.Error(err))
+		}
+		l.wal = nil
+	}
+	if l.spill != nil {
+		if err := l.spill.Close(); err != nil {
+			l.logger.Error("failed to close meter spill log", log.Error(err))
+		}
+		l.spill = nil
+	}
+}
+
 // Health checks the health of the Ledger by pinging its store.
 func (l *Ledger) Health(ctx context.Context) error {
 	return l.store.Ping(ctx)
@@ -127,8 +514,13 @@ func (l *Ledger) Health(ctx context.Context) error {
 func (l *Ledger) Stop() error {
 	close(l.stopChan)
 	l.wg.Wait()
+	l.closeLogs()
 
+	// Publish the usage invalidations of the final flushes
 	ctx := context.Background()
+	if l.cacheBus != nil {
+		l.publishUsageInvalidations(ctx)
+	}
 	l.plugins.EmitShutdown(ctx)
 
 	return l.store.Close()
@@ -163,12 +555,32 @@ func (l *Ledger) GetPlanBySlug(ctx context.Context, slug, appID string) (*plan.P
 	return l.store.GetPlanBySlug(ctx, slug, appID)
 }
 
+// UpdatePlan updates a plan and drops the cached entitlements of every
+// tenant subscribed to it.
+func (l *Ledger) UpdatePlan(ctx context.Context, p *plan.Plan) error {
+	old, err := l.store.GetPlan(ctx, p.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := l.store.UpdatePlan(ctx, p); err != nil {
+		return err
+	}
+	l.invalidate(ctx, cachebus.Message{Kind: cachebus.KindPlan, AppID: p.AppID, PlanID: p.ID.String()})
+
+	l.plugins.EmitPlanUpdated(ctx, old, p)
+	return nil
+}
+
 // ──────────────────────────────────────────────────
 // Feature Catalog Management
 // ──────────────────────────────────────────────────
 
 // CreateFeature creates a new catalog feature.
 func (l *Ledger) CreateFeature(ctx context.Context, f *feature.Feature) error {
+	if f.Aggregation == meter.AggregateUnique && f.AggregationProperty == "" {
+		return fmt.Errorf("%w: unique aggregation needs a property", ErrInvalidInput)
+	}
 	if f.ID == (id.FeatureID{}) {
 		f.ID = id.NewFeatureID()
 	}
@@ -177,6 +589,7 @@ func (l *Ledger) CreateFeature(ctx context.Context, f *feature.Feature) error {
 	if err := l.store.CreateFeature(ctx, f); err != nil {
 		return err
 	}
+	l.invalidate(ctx, cachebus.Message{Kind: cachebus.KindCatalog})
 
 	l.plugins.EmitFeatureCreated(ctx, f)
 	return nil
@@ -204,6 +617,9 @@ func (l *Ledger) ListGlobalFeatures(ctx context.Context, opts feature.ListOpts)
 
 // UpdateFeature updates a catalog feature.
 func (l *Ledger) UpdateFeature(ctx context.Context, f *feature.Feature) error {
+	if f.Aggregation == meter.AggregateUnique && f.AggregationProperty == "" {
+		return fmt.Errorf("%w: unique aggregation needs a property", ErrInvalidInput)
+	}
 	old, err := l.store.GetFeature(ctx, f.ID)
 	if err != nil {
 		return err
@@ -212,6 +628,7 @@ func (l *Ledger) UpdateFeature(ctx context.Context, f *feature.Feature) error {
 	if err := l.store.UpdateFeature(ctx, f); err != nil {
 		return err
 	}
+	l.invalidate(ctx, cachebus.Message{Kind: cachebus.KindCatalog})
 
 	l.plugins.EmitFeatureUpdated(ctx, old, f)
 	return nil
@@ -222,6 +639,7 @@ func (l *Ledger) DeleteFeature(ctx context.Context, featureID id.FeatureID) erro
 	if err := l.store.DeleteFeature(ctx, featureID); err != nil {
 		return err
 	}
+	l.invalidate(ctx, cachebus.Message{Kind: cachebus.KindCatalog})
 
 	l.plugins.EmitFeatureDeleted(ctx, featureID.String())
 	return nil
@@ -232,6 +650,7 @@ func (l *Ledger) ArchiveFeature(ctx context.Context, featureID id.FeatureID) err
 	if err := l.store.ArchiveFeature(ctx, featureID); err != nil {
 		return err
 	}
+	l.invalidate(ctx, cachebus.Message{Kind: cachebus.KindCatalog})
 
 	l.plugins.EmitFeatureArchived(ctx, featureID.String())
 	return nil
@@ -259,7 +678,7 @@ func (l *Ledger) CreateSubscription(ctx context.Context, sub *subscription.Subsc
 	}
 
 	// Invalidate entitlement cache for tenant
-	_ = l.store.Invalidate(ctx, sub.TenantID, sub.AppID) //nolint:errcheck // best-effort cache invalidation
+	l.invalidate(ctx, cachebus.Message{Kind: cachebus.KindSubscription, TenantID: sub.TenantID, AppID: sub.AppID})
 
 	l.plugins.EmitSubscriptionCreated(ctx, sub)
 	return nil
@@ -292,96 +711,12 @@ func (l *Ledger) CancelSubscription(ctx context.Context, subID id.SubscriptionID
 	}
 
 	// Invalidate entitlement cache
-	_ = l.store.Invalidate(ctx, sub.TenantID, sub.AppID) //nolint:errcheck // best-effort cache invalidation
+	l.invalidate(ctx, cachebus.Message{Kind: cachebus.KindSubscription, TenantID: sub.TenantID, AppID: sub.AppID})
 
 	l.plugins.EmitSubscriptionCanceled(ctx, sub)
 	return nil
 }
 
-// ──────────────────────────────────────────────────
-// Usage Metering
-// ──────────────────────────────────────────────────
-
-// Meter records a usage event (non-blocking).
-func (l *Ledger) Meter(ctx context.Context, featureKey string, quantity int64) error {
-	// Extract tenant and app from context
-	tenantID := extractTenantID(ctx)
-	appID := extractAppID(ctx)
-
-	if tenantID == "" || appID == "" {
-		return ErrInvalidInput
-	}
-
-	event := &meter.UsageEvent{
-		ID:         id.NewUsageEventID(),
-		TenantID:   tenantID,
-		AppID:      appID,
-		FeatureKey: featureKey,
-		Quantity:   quantity,
-		Timestamp:  time.Now(),
-	}
-
-	select {
-	case l.meterBuffer <- event:
-		return nil
-	default:
-		return ErrMeterBufferFull
-	}
-}
-
-// meterFlushWorker flushes usage events to the store.
-func (l *Ledger) meterFlushWorker(ctx context.Context) {
-	defer l.wg.Done()
-
-	batch := make([]*meter.UsageEvent, 0, l.meterBatchSize)
-	ticker := time.NewTicker(l.meterFlushInterval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-l.stopChan:
-			// Final flush
-			if len(batch) > 0 {
-				l.flushMeterBatch(ctx, batch)
-			}
-			return
-
-		case event := <-l.meterBuffer:
-			batch = append(batch, event)
-			if len(batch) >= l.meterBatchSize {
-				l.flushMeterBatch(ctx, batch)
-				batch = make([]*meter.UsageEvent, 0, l.meterBatchSize)
-			}
-
-		case <-ticker.C:
-			if len(batch) > 0 {
-				l.flushMeterBatch(ctx, batch)
-				batch = make([]*meter.UsageEvent, 0, l.meterBatchSize)
-			}
-		}
-	}
-}
-
-func (l *Ledger) flushMeterBatch(ctx context.Context, batch []*meter.UsageEvent) {
-	start := time.Now()
-
-	if err := l.store.IngestBatch(ctx, batch); err != nil {
-		l.logger.Error("failed to flush meter batch",
-			log.Error(err),
-			log.Int("batch_size", len(batch)),
-		)
-		return
-	}
-
-	elapsed := time.Since(start)
-	l.plugins.EmitUsageFlushed(ctx, len(batch), elapsed)
-
-	l.logger.Debug("flushed meter batch",
-		log.Int("batch_size", len(batch)),
-		log.Int64("elapsed_ms", elapsed.Milliseconds()),
-	)
-}
-
 // ──────────────────────────────────────────────────
 // Entitlements
 // ──────────────────────────────────────────────────
@@ -424,8 +759,13 @@ func (l *Ledger) Entitled(ctx context.Context, featureKey string) (*entitlement.
 		}, nil
 	}
 
-	// Find feature in plan
-	feat := p.FindFeature(featureKey)
+	// Find feature in plan, with the tenant's overrides applied
+	overrides, err := l.store.ListOverrides(ctx, tenantID, appID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	feat := l.planFeature(ctx, appID, p, featureKey, overrides, now)
 	if feat == nil {
 		return &entitlement.Result{
 			Allowed: false,
@@ -436,48 +776,236 @@ func (l *Ledger) Entitled(ctx context.Context, featureKey string) (*entitlement.
 
 	// Boolean feature
 	if feat.Type == plan.FeatureBoolean {
-		result := &entitlement.Result{
-			Allowed: feat.Limit > 0,
-			Feature: featureKey,
-			Limit:   feat.Limit,
+		return l.booleanResult(ctx, tenantID, appID, feat), nil
+	}
+
+	// Seat feature
+	if feat.Type == plan.FeatureSeat {
+		seats, err := l.store.CurrentSeats(ctx, tenantID, appID, featureKey, time.Now().UTC())
+		if err != nil {
+			return nil, err
 		}
-		_ = l.store.SetCached(ctx, tenantID, appID, featureKey, result, l.entitlementCacheTTL) //nolint:errcheck // best-effort cache set
-		return result, nil
+		return l.meteredResult(ctx, tenantID, appID, feat, seats), nil
 	}
 
-	// Metered/seat feature
-	used, err := l.store.Aggregate(ctx, tenantID, appID, featureKey, feat.Period)
+	// Metered feature
+	used, counted, err := l.rollingUsage(ctx, tenantID, appID, feat, now)
 	if err != nil {
 		return nil, err
 	}
+	if !counted {
+		start, end := usageWindow(sub, p, feat.Period, now)
+		used, err = l.aggregateUsage(ctx, tenantID, appID, feat, l.aggregationFor(ctx, appID, feat), start, end)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return l.meteredResult(ctx, tenantID, appID, feat, used), nil
+}
+
+// EntitledMany checks several features for the tenant and app in context.
+// Cached results are reused; for the rest the subscription, plan and catalog
+// are loaded once and metered usage is aggregated with one AggregateMulti
+// call per usage window rather than one query per feature. The result holds
+// an entry for every requested key.
+func (l *Ledger) EntitledMany(ctx context.Context, featureKeys ...string) (map[string]*entitlement.Result, error) {
+	results := make(map[string]*entitlement.Result, len(featureKeys))
+	deny := func(keys []string, reason string) map[string]*entitlement.Result {
+		for _, key := range keys {
+			results[key] = &entitlement.Result{
+				Allowed: false,
+				Feature: key,
+				Reason:  reason,
+			}
+		}
+		return results
+	}
+
+	tenantID := extractTenantID(ctx)
+	appID := extractAppID(ctx)
+	if tenantID == "" || appID == "" {
+		return deny(featureKeys, "missing tenant or app context"), nil
+	}
+
+	// Check cache first
+	var misses []string
+	for _, key := range featureKeys {
+		if _, done := results[key]; done {
+			continue
+		}
+		if cached, err := l.store.GetCached(ctx, tenantID, appID, key); err == nil {
+			results[key] = cached
+			continue
+		}
+		results[key] = nil
+		misses = append(misses, key)
+	}
+	if len(misses) == 0 {
+		return results, nil
+	}
+
+	sub, err := l.store.GetActiveSubscription(ctx, tenantID, appID)
+	if err != nil {
+		return deny(misses, "no active subscription"), nil
+	}
+	p, err := l.store.GetPlan(ctx, sub.PlanID)
+	if err != nil {
+		return deny(misses, "plan not found"), nil
+	}
+
+	overrides, err := l.store.ListOverrides(ctx, tenantID, appID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	var metered []*plan.Feature
+	feats := make(map[string]*plan.Feature, len(misses))
+	for _, key := range misses {
+		feat := l.planFeature(ctx, appID, p, key, overrides, now)
+		feats[key] = feat
+		switch {
+		case feat == nil:
+			deny([]string{key}, "feature not in plan")
+		case feat.Type == plan.FeatureBoolean:
+			results[key] = l.booleanResult(ctx, tenantID, appID, feat)
+		case feat.Type == plan.FeatureSeat:
+			seats, err := l.store.CurrentSeats(ctx, tenantID, appID, key, time.Now().UTC())
+			if err != nil {
+				return nil, err
+			}
+			results[key] = l.meteredResult(ctx, tenantID, appID, feat, seats)
+		default:
+			metered = append(metered, feat)
+		}
+	}
+
+	// Group built-in aggregations by usage window and read lifetime quotas
+	// together; plugin aggregators are evaluated per feature.
+	type window struct{ start, end time.Time }
+	windows := make(map[window]map[string]meter.Aggregation)
+	var lifetime []string
+	aggs := l.aggregationsFor(ctx, appID, metered)
+	for _, feat := range metered {
+		used, counted, err := l.rollingUsage(ctx, tenantID, appID, feat, now)
+		if err != nil {
+			return nil, err
+		}
+		if counted {
+			results[feat.Key] = l.meteredResult(ctx, tenantID, appID, feat, used)
+			continue
+		}
+		start, end := usageWindow(sub, p, feat.Period, now)
+		agg := aggs[feat.Key]
+		if lifetimeQuota(feat, agg, start, end) {
+			lifetime = append(lifetime, feat.Key)
+			continue
+		}
+		if !agg.Kind().IsBuiltin() {
+			used, err := l.aggregateUsage(ctx, tenantID, appID, feat, agg, start, end)
+			if err != nil {
+				return nil, err
+			}
+			results[feat.Key] = l.meteredResult(ctx, tenantID, appID, feat, used)
+			continue
+		}
+		w := window{start, end}
+		if windows[w] == nil {
+			windows[w] = make(map[string]meter.Aggregation)
+		}
+		windows[w][feat.Key] = agg
+	}
+
+	for w, features := range windows {
+		used, err := l.store.AggregateMulti(ctx, tenantID, appID, features, w.start, w.end)
+		if err != nil {
+			return nil, err
+		}
+		for key := range features {
+			results[key] = l.meteredResult(ctx, tenantID, appID, feats[key], used[key])
+		}
+	}
+
+	if len(lifetime) > 0 {
+		totals, err := l.store.LifetimeUsage(ctx, tenantID, appID, lifetime)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range lifetime {
+			results[key] = l.meteredResult(ctx, tenantID, appID, feats[key], totals[key].Value(aggs[key]))
+		}
+	}
+
+	return results, nil
+}
+
+// booleanResult builds and caches the entitlement result of a boolean
+// feature.
+func (l *Ledger) booleanResult(ctx context.Context, tenantID, appID string, feat *plan.Feature) *entitlement.Result {
+	result, _ := decide(feat, 0)
+	_ = l.store.SetCached(ctx, tenantID, appID, feat.Key, result, l.entitlementCacheTTL) //nolint:errcheck // best-effort cache set
+	return result
+}
+
+// meteredResult builds, caches and reports the entitlement result of a
+// metered feature with the given usage or a seat feature with the given
+// seat count.
+func (l *Ledger) meteredResult(ctx context.Context, tenantID, appID string, feat *plan.Feature, used int64) *entitlement.Result {
+	result, rule := decide(feat, used)
+	if rule == entitlement.RuleHardLimit {
+		l.plugins.EmitQuotaExceeded(ctx, tenantID, feat.Key, used, feat.Limit)
+	}
+
+	// Rolling windows move on every check, so their results are not cached.
+	if !feat.Period.Rolling() {
+		_ = l.store.SetCached(ctx, tenantID, appID, feat.Key, result, l.entitlementCacheTTL) //nolint:errcheck // best-effort cache set
+	}
+	l.plugins.EmitEntitlementChecked(ctx, result)
+
+	return result
+}
+
+// decide checks feat against used, the usage of a metered feature or the
+// seat count of a seat feature, and returns the result and the rule that
+// decided it. Boolean features ignore used.
+func decide(feat *plan.Feature, used int64) (*entitlement.Result, entitlement.Rule) {
+	if feat.Type == plan.FeatureBoolean {
+		result := &entitlement.Result{
+			Allowed: feat.Limit > 0,
+			Feature: feat.Key,
+			Limit:   feat.Limit,
+		}
+		if !result.Allowed {
+			return result, entitlement.RuleDisabled
+		}
+		return result, entitlement.RuleEnabled
+	}
 
 	result := &entitlement.Result{
-		Feature:   featureKey,
+		Feature:   feat.Key,
 		Used:      used,
 		Limit:     feat.Limit,
 		Remaining: max(0, feat.Limit-used),
 		SoftLimit: feat.SoftLimit,
 	}
-
 	switch {
 	case feat.Limit == -1:
 		result.Allowed = true
 		result.Remaining = -1
+		return result, entitlement.RuleUnlimited
 	case used < feat.Limit:
 		result.Allowed = true
+		return result, entitlement.RuleWithinLimit
 	case feat.SoftLimit:
 		result.Allowed = true
 		result.Reason = "over soft limit"
+		return result, entitlement.RuleSoftLimit
 	default:
 		result.Allowed = false
 		result.Reason = "quota exceeded"
-		l.plugins.EmitQuotaExceeded(ctx, tenantID, featureKey, used, feat.Limit)
+		return result, entitlement.RuleHardLimit
 	}
-
-	_ = l.store.SetCached(ctx, tenantID, appID, featureKey, result, l.entitlementCacheTTL) //nolint:errcheck // best-effort cache set
-	l.plugins.EmitEntitlementChecked(ctx, result)
-
-	return result, nil
 }
 
 // Remaining returns the remaining quota for a feature.
@@ -536,27 +1064,94 @@ func (l *Ledger) GenerateInvoice(ctx context.Context, subID id.SubscriptionID) (
 		inv.Subtotal = inv.Subtotal.Add(p.Pricing.BaseAmount)
 	}
 
-	// Add metered usage charges
-	for _, pf := range p.Features {
-		if pf.Type == plan.FeatureMetered {
-			used, err := l.store.Aggregate(ctx, sub.TenantID, sub.AppID, pf.Key, pf.Period)
+	// Corrections recorded within the invoiced period
+	var metered []string
+	for i := range p.Features {
+		if p.Features[i].Type == plan.FeatureMetered {
+			metered = append(metered, p.Features[i].Key)
+		}
+	}
+	corrections, err := l.store.SumCorrections(ctx, sub.TenantID, sub.AppID, metered, sub.CurrentPeriodStart, sub.CurrentPeriodEnd)
+	if err != nil {
+		return nil, fmt.Errorf("sum usage corrections: %w", err)
+	}
+
+	// Add metered usage and seat charges
+	for i := range p.Features {
+		pf := &p.Features[i]
+		if pf.Type == plan.FeatureSeat {
+			li, err := l.seatLineItem(ctx, sub, p, pf)
 			if err != nil {
-				return nil, fmt.Errorf("aggregate usage for feature %q: %w", pf.Key, err)
+				return nil, fmt.Errorf("bill seats for feature %q: %w", pf.Key, err)
+			}
+			if li != nil {
+				li.ID = id.NewLineItemID()
+				li.InvoiceID = inv.ID
+				inv.LineItems = append(inv.LineItems, *li)
+				inv.Subtotal = inv.Subtotal.Add(li.Amount)
+			}
+		}
+		if pf.Type == plan.FeatureMetered {
+			// Bill usage recorded within the invoiced period; lifetime
+			// counters bill everything recorded up to the period end.
+			start := sub.CurrentPeriodStart
+			if pf.Period == plan.PeriodNone {
+				start = time.Time{}
+			}
+			cf := l.catalogFeatureFor(ctx, sub.AppID, pf)
+			agg := meter.Aggregation{}
+			if cf != nil {
+				agg = cf.UsageAggregation()
 			}
-			if used > pf.Limit && pf.Limit > 0 {
-				overage := used - pf.Limit
-				// Would calculate overage charges based on pricing tiers
-				// For now, just note the overage
-				inv.LineItems = append(inv.LineItems, invoice.LineItem{
-					ID:          id.NewLineItemID(),
-					InvoiceID:   inv.ID,
-					FeatureKey:  pf.Key,
-					Description: pf.Name + " overage",
-					Quantity:    overage,
-					UnitAmount:  types.Zero(p.Currency),
-					Amount:      types.Zero(p.Currency),
-					Type:        invoice.LineItemOverage,
+
+			var (
+				used   int64
+				items  []invoice.LineItem
+				groups []*meter.GroupTotal
+			)
+			keys := dimensionKeys(cf, p, pf)
+			dimensional := len(keys) > 0 && agg.Kind().IsBuiltin()
+			if dimensional {
+				groups, err = l.store.AggregateGroups(ctx, sub.TenantID, sub.AppID, agg, meter.QueryOpts{
+					FeatureKey: pf.Key,
+					Start:      start,
+					End:        sub.CurrentPeriodEnd,
+					GroupBy:    keys,
 				})
+				if err != nil {
+					return nil, fmt.Errorf("aggregate usage for feature %q: %w", pf.Key, err)
+				}
+				used, items = l.groupLineItems(p, pf, groups)
+			} else {
+				used, err = l.aggregateUsage(ctx, sub.TenantID, sub.AppID, pf, agg, start, sub.CurrentPeriodEnd)
+				if err != nil {
+					return nil, fmt.Errorf("aggregate usage for feature %q: %w", pf.Key, err)
+				}
+				items = l.usageLineItems(p, pf, used)
+			}
+			for _, li := range items {
+				li.ID = id.NewLineItemID()
+				li.InvoiceID = inv.ID
+				inv.LineItems = append(inv.LineItems, li)
+				inv.Subtotal = inv.Subtotal.Add(li.Amount)
+			}
+
+			// Corrections are priced with the tiers of the usage they adjust.
+			if corr := corrections[pf.Key]; corr != 0 {
+				if dimensional {
+					items, err = l.groupCorrectionLineItems(ctx, sub, p, pf, keys, groups)
+					if err != nil {
+						return nil, fmt.Errorf("price usage corrections for feature %q: %w", pf.Key, err)
+					}
+				} else {
+					items = []invoice.LineItem{l.correctionLineItem(p, pf, p.Pricing.TiersFor(pf.Key), used, corr, nil)}
+				}
+				for _, li := range items {
+					li.ID = id.NewLineItemID()
+					li.InvoiceID = inv.ID
+					inv.LineItems = append(inv.LineItems, li)
+					inv.Subtotal = inv.Subtotal.Add(li.Amount)
+				}
 			}
 		}
 	}
@@ -573,10 +1168,360 @@ func (l *Ledger) GenerateInvoice(ctx context.Context, subID id.SubscriptionID) (
 	return inv, nil
 }
 
+// PreviewUsageCost returns what usage units of featureKey would cost on the
+// given plan, using the same pricing path as GenerateInvoice. Nothing is
+// persisted.
+func (l *Ledger) PreviewUsageCost(ctx context.Context, planID id.PlanID, featureKey string, usage int64) (types.Money, error) {
+	if usage < 0 {
+		return types.Money{}, ErrInvalidQuantity
+	}
+
+	p, err := l.store.GetPlan(ctx, planID)
+	if err != nil {
+		return types.Money{}, err
+	}
+
+	pf := p.FindFeature(featureKey)
+	if pf == nil {
+		return types.Money{}, ErrFeatureNotFound
+	}
+
+	total := types.Zero(p.Currency)
+	for _, li := range l.usageLineItems(p, pf, usage) {
+		total = total.Add(li.Amount)
+	}
+	return total, nil
+}
+
+// usageLineItems prices a metered feature's usage against the plan's tiers.
+// Usage up to the feature limit (all of it for unlimited features, none for
+// a limit of zero) is billed as a usage line and anything above it as an
+// overage line; the two amounts always add up to the price of the
+// full quantity. Zero-amount usage lines are omitted so included allowances
+// do not clutter the invoice.
+func (l *Ledger) usageLineItems(p *plan.Plan, pf *plan.Feature, used int64) []invoice.LineItem {
+	if used <= 0 {
+		return nil
+	}
+
+	included := used
+	if pf.Limit >= 0 {
+		included = min(used, pf.Limit)
+	}
+	return l.pricedLineItems(p, pf, p.Pricing.TiersFor(pf.Key), used, included, nil)
+}
+
+// groupLineItems prices a metered feature's usage broken down by dimension
+// values. Each group is priced with the tiers scoped to its values (see
+// plan.Pricing.TiersMatching) and billed as its own usage and overage lines
+// carrying the values as metadata. The feature limit is an allowance shared
+// by all groups, consumed in label order. It returns the total usage along
+// with the line items.
+func (l *Ledger) groupLineItems(p *plan.Plan, pf *plan.Feature, groups []*meter.GroupTotal) (int64, []invoice.LineItem) {
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Label() < groups[j].Label() })
+
+	var (
+		used  int64
+		items []invoice.LineItem
+	)
+	remaining := pf.Limit
+	for _, g := range groups {
+		if g.Value <= 0 {
+			continue
+		}
+		used += g.Value
+
+		included := g.Value
+		if pf.Limit >= 0 {
+			included = min(g.Value, remaining)
+			remaining -= included
+		}
+		tiers := p.Pricing.TiersMatching(pf.Key, g.Dimensions)
+		items = append(items, l.pricedLineItems(p, pf, tiers, g.Value, included, g)...)
+	}
+	return used, items
+}
+
+// pricedLineItems bills used units against tiers, the first included of them
+// as a usage line and the rest as an overage line; the two amounts always
+// add up to the price of the full quantity. Zero-amount usage lines are
+// omitted so included allowances do not clutter the invoice. Lines for a
+// dimension group name its values in the description and metadata.
+func (l *Ledger) pricedLineItems(p *plan.Plan, pf *plan.Feature, tiers []plan.PriceTier, used, included int64, group *meter.GroupTotal) []invoice.LineItem {
+	name := pf.Name
+	var metadata map[string]string
+	if group != nil {
+		name += " (" + group.Label() + ")"
+		metadata = group.Dimensions
+	}
+
+	var items []invoice.LineItem
+
+	usageAmount := l.priceUsage(p, pf, tiers, included)
+	if included > 0 && usageAmount.IsPositive() {
+		items = append(items, invoice.LineItem{
+			FeatureKey:  pf.Key,
+			Description: name + " usage",
+			Quantity:    included,
+			UnitAmount:  unitAmount(usageAmount, included),
+			Amount:      usageAmount,
+			Type:        invoice.LineItemUsage,
+			Metadata:    metadata,
+		})
+	}
+
+	if overage := used - included; overage > 0 {
+		overageAmount := l.priceUsage(p, pf, tiers, used).Subtract(usageAmount)
+		items = append(items, invoice.LineItem{
+			FeatureKey:  pf.Key,
+			Description: name + " overage",
+			Quantity:    overage,
+			UnitAmount:  unitAmount(overageAmount, overage),
+			Amount:      overageAmount,
+			Type:        invoice.LineItemOverage,
+			Metadata:    metadata,
+		})
+	}
+
+	return items
+}
+
+// unitAmount returns the price of one of qty units billed at amount, or
+// zero when amount does not split evenly across them, as with graduated
+// tiers, so that Quantity times UnitAmount never disagrees with Amount.
+func unitAmount(amount types.Money, qty int64) types.Money {
+	if qty == 0 || amount.Amount%qty != 0 {
+		return types.Zero(amount.Currency)
+	}
+	return amount.Divide(qty)
+}
+
+// priceUsage prices qty units of a metered feature. If the plan names a
+// pricing strategy for the feature (see plan.StrategyFor) and a plugin with
+// that name is registered, its Compute result is used; otherwise, or when
+// the strategy does not return a Money in the plan currency, the built-in
+// tier engine prices the usage.
+func (l *Ledger) priceUsage(p *plan.Plan, pf *plan.Feature, tiers []plan.PriceTier, qty int64) types.Money {
+	name := p.StrategyFor(pf.Key)
+	if name == "" {
+		return plan.ComputeTiers(tiers, qty, p.Currency)
+	}
+
+	strategy := l.plugins.GetPricingStrategy(name)
+	if strategy == nil {
+		l.logger.Warn("pricing strategy not registered, using built-in tiers",
+			log.String("strategy", name),
+			log.String("feature", pf.Key),
+		)
+		return plan.ComputeTiers(tiers, qty, p.Currency)
+	}
+
+	args := make([]interface{}, len(tiers))
+	for i := range tiers {
+		args[i] = tiers[i]
+	}
+
+	var amount types.Money
+	switch v := strategy.Compute(args, qty, pf.Limit, p.Currency).(type) {
+	case types.Money:
+		amount = v
+	case *types.Money:
+		if v != nil {
+			amount = *v
+		}
+	}
+	if !strings.EqualFold(amount.Currency, p.Currency) {
+		l.logger.Warn("pricing strategy returned invalid amount, using built-in tiers",
+			log.String("strategy", name),
+			log.String("feature", pf.Key),
+		)
+		return plan.ComputeTiers(tiers, qty, p.Currency)
+	}
+	amount.Currency = strings.ToLower(amount.Currency)
+	return amount
+}
+
+// aggregateUsage reduces a tenant's usage of pf in [start, end) with agg.
+// Built-in types run in the store; any other type is resolved as a
+// registered plugin.UsageAggregator, which receives the window's events
+// (*meter.UsageEvent) oldest first.
+func (l *Ledger) aggregateUsage(ctx context.Context, tenantID, appID string, pf *plan.Feature, agg meter.Aggregation, start, end time.Time) (int64, error) {
+	if lifetimeQuota(pf, agg, start, end) {
+		totals, err := l.store.LifetimeUsage(ctx, tenantID, appID, []string{pf.Key})
+		if err != nil {
+			return 0, err
+		}
+		return totals[pf.Key].Value(agg), nil
+	}
+	if agg.Kind().IsBuiltin() {
+		return l.store.Aggregate(ctx, tenantID, appID, pf.Key, agg, start, end)
+	}
+
+	aggregator := l.plugins.GetUsageAggregator(string(agg.Type))
+	if aggregator == nil {
+		return 0, fmt.Errorf("%w: %q", ErrUnsupportedAggregation, agg.Type)
+	}
+
+	events, err := l.store.QueryUsage(ctx, tenantID, appID, meter.QueryOpts{
+		FeatureKey: pf.Key,
+		Start:      start,
+		End:        end,
+	})
+	if err != nil {
+		return 0, err
+	}
+	args := make([]interface{}, 0, len(events))
+	for i := len(events) - 1; i >= 0; i-- {
+		if !events[i].Counted() || (!end.IsZero() && !events[i].Timestamp.Before(end)) {
+			continue
+		}
+		args = append(args, events[i])
+	}
+	return aggregator.Aggregate(ctx, args)
+}
+
+// lifetimeQuota reports whether usage of pf over [start, end) is read from
+// the store's lifetime totals rather than the events: the feature never
+// resets, the window is unbounded, and agg is a sum or count. Lifetime
+// totals still hold usage that has since been purged or archived.
+func lifetimeQuota(pf *plan.Feature, agg meter.Aggregation, start, end time.Time) bool {
+	return pf.Period == plan.PeriodNone && start.IsZero() && end.IsZero() && agg.Lifetime()
+}
+
+// aggregationFor returns the aggregation declared on the catalog feature
+// behind pf. Features without a catalog entry sum.
+func (l *Ledger) aggregationFor(ctx context.Context, appID string, pf *plan.Feature) meter.Aggregation {
+	f := l.catalogFeatureFor(ctx, appID, pf)
+	if f == nil {
+		return meter.Aggregation{}
+	}
+	return f.UsageAggregation()
+}
+
+// catalogFeatureFor returns the catalog feature behind pf: the linked
+// CatalogID if set, else the app-scoped feature with the same key, else the
+// global one. It returns nil when there is none or the lookup fails.
+func (l *Ledger) catalogFeatureFor(ctx context.Context, appID string, pf *plan.Feature) *feature.Feature {
+	var (
+		f   *feature.Feature
+		err error
+	)
+	if pf.CatalogID != (id.FeatureID{}) {
+		f, err = l.store.GetFeature(ctx, pf.CatalogID)
+	} else {
+		f, err = l.store.GetFeatureByKey(ctx, pf.Key, appID)
+		if errors.Is(err, ErrFeatureNotFound) && appID != "" {
+			f, err = l.store.GetFeatureByKey(ctx, pf.Key, "")
+		}
+	}
+	if err != nil {
+		if !errors.Is(err, ErrFeatureNotFound) {
+			l.logger.Warn("failed to load catalog feature, summing usage",
+				log.String("feature", pf.Key),
+				log.Error(err),
+			)
+		}
+		return nil
+	}
+	return f
+}
+
+// dimensionKeys returns the metadata keys usage of pf is broken down by on
+// invoices: the dimensions declared on its catalog feature f (which may be
+// nil) plus any keys the plan's price tiers are scoped to, sorted.
+func dimensionKeys(f *feature.Feature, p *plan.Plan, pf *plan.Feature) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	add := func(k string) {
+		if k != "" && !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	if f != nil {
+		for _, k := range f.Dimensions {
+			add(k)
+		}
+	}
+	for _, k := range p.Pricing.DimensionKeys(pf.Key) {
+		add(k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// aggregationsFor resolves aggregationFor for several plan features with two
+// catalog listings instead of one lookup per feature.
+func (l *Ledger) aggregationsFor(ctx context.Context, appID string, features []*plan.Feature) map[string]meter.Aggregation {
+	result := make(map[string]meter.Aggregation, len(features))
+	if len(features) == 0 {
+		return result
+	}
+
+	scoped, err := l.store.ListFeatures(ctx, appID, feature.ListOpts{})
+	global, gerr := l.store.ListGlobalFeatures(ctx, feature.ListOpts{})
+	byID := make(map[id.FeatureID]*feature.Feature, len(scoped)+len(global))
+	byKey := make(map[string]*feature.Feature, len(scoped)+len(global))
+	for _, f := range global {
+		byID[f.ID] = f
+		byKey[f.Key] = f
+	}
+	for _, f := range scoped {
+		byID[f.ID] = f
+		byKey[f.Key] = f
+	}
+
+	for _, pf := range features {
+		var f *feature.Feature
+		if pf.CatalogID != (id.FeatureID{}) {
+			f = byID[pf.CatalogID]
+		} else {
+			f = byKey[pf.Key]
+		}
+		switch {
+		case f != nil:
+			result[pf.Key] = f.UsageAggregation()
+		case err != nil || gerr != nil || pf.CatalogID != (id.FeatureID{}):
+			// Listing failed or the catalog entry lives elsewhere.
+			result[pf.Key] = l.aggregationFor(ctx, appID, pf)
+		default:
+			result[pf.Key] = meter.Aggregation{}
+		}
+	}
+	return result
+}
+
 // ──────────────────────────────────────────────────
 // Helpers
 // ──────────────────────────────────────────────────
 
+// usageWindow returns the [start, end) window over which usage of a feature
+// with the given reset period is counted at now. Features that reset with the
+// plan's billing period use the subscription's current period as-is; other
+// periods are anchored on the subscription's period start, so a tenant who
+// subscribed on the 17th has quotas reset on the 17th.
+func usageWindow(sub *subscription.Subscription, p *plan.Plan, period plan.Period, now time.Time) (start, end time.Time) {
+	if period == plan.PeriodNone {
+		return time.Time{}, time.Time{}
+	}
+
+	billing := plan.PeriodMonthly
+	if p.Pricing != nil && p.Pricing.BillingPeriod != "" {
+		billing = p.Pricing.BillingPeriod
+	}
+	if period == billing && !sub.CurrentPeriodStart.IsZero() &&
+		!now.Before(sub.CurrentPeriodStart) && now.Before(sub.CurrentPeriodEnd) {
+		return sub.CurrentPeriodStart, sub.CurrentPeriodEnd
+	}
+	return period.Window(sub.CurrentPeriodStart, now)
+}
+
+// tenantApp identifies a tenant of an app.
+type tenantApp struct {
+	tenantID string
+	appID    string
+}
+
 func extractTenantID(ctx context.Context) string {
 	// Would extract from context (e.g., from Forge scope)
 	// For now, check context value
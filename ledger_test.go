@@ -0,0 +1,246 @@
+package ledger_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xraph/ledger"
+	"github.com/xraph/ledger/entitlement"
+	"github.com/xraph/ledger/id"
+	"github.com/xraph/ledger/meter"
+	"github.com/xraph/ledger/meter/wal"
+	"github.com/xraph/ledger/plan"
+	"github.com/xraph/ledger/store"
+	"github.com/xraph/ledger/store/memory"
+	"github.com/xraph/ledger/subscription"
+)
+
+// tenantContext returns a context carrying the tenant and app that
+// context-scoped Ledger calls act on.
+func tenantContext(tenantID, appID string) context.Context {
+	ctx := context.WithValue(context.Background(), "tenant_id", tenantID) //nolint:staticcheck // the Ledger reads string keys
+	return context.WithValue(ctx, "app_id", appID)                        //nolint:staticcheck // the Ledger reads string keys
+}
+
+// startLedger starts a Ledger on s that is stopped when the test ends.
+func startLedger(t *testing.T, s store.Store, opts ...ledger.Option) *ledger.Ledger {
+	t.Helper()
+	l := ledger.New(s, opts...)
+	if err := l.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { _ = l.Stop() })
+	return l
+}
+
+// subscribe creates a USD plan of app "app" with the given features and
+// tiers and subscribes tenantID to it for the month starting now.
+func subscribe(t *testing.T, l *ledger.Ledger, tenantID string, features []plan.Feature, tiers ...plan.PriceTier) (*plan.Plan, *subscription.Subscription) {
+	t.Helper()
+	ctx := context.Background()
+	p := &plan.Plan{
+		Name:     "Test",
+		Slug:     "test-" + tenantID,
+		Currency: "usd",
+		Status:   plan.StatusActive,
+		AppID:    "app",
+		Features: features,
+		Pricing:  &plan.Pricing{Tiers: tiers},
+	}
+	if err := l.CreatePlan(ctx, p); err != nil {
+		t.Fatalf("CreatePlan: %v", err)
+	}
+	sub := &subscription.Subscription{TenantID: tenantID, PlanID: p.ID, Status: subscription.StatusActive, AppID: "app"}
+	if err := l.CreateSubscription(ctx, sub); err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+	return p, sub
+}
+
+// eventually fails the test unless cond holds within a second.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); !cond(); {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStartValidatesBeforeReplay(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	w, err := wal.Open(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := &meter.UsageEvent{
+		ID: id.NewUsageEventID(), TenantID: "t1", AppID: "app", FeatureKey: "api_calls",
+		Quantity: 3, Timestamp: time.Now().UTC(),
+	}
+	if err := w.Append(event); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Spill without a spill directory is rejected before the WAL is
+	// replayed.
+	store := memory.New()
+	l := ledger.New(store,
+		ledger.WithMeterWAL(dir, 0),
+		ledger.WithMeterOverflow(ledger.OverflowSpill),
+	)
+	if err := l.Start(ctx); !errors.Is(err, ledger.ErrInvalidInput) {
+		t.Fatalf("Start error = %v, want ErrInvalidInput", err)
+	}
+	events, err := store.QueryUsage(ctx, "t1", "app", meter.QueryOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("invalid config ingested %d replayed events", len(events))
+	}
+
+	// A valid configuration replays the event.
+	l = ledger.New(store, ledger.WithMeterWAL(dir, 0))
+	if err := l.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Stop()
+	events, err = store.QueryUsage(ctx, "t1", "app", meter.QueryOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].ID != event.ID {
+		t.Fatalf("replayed %d events, want the logged one", len(events))
+	}
+}
+
+func TestEntitledManyMatchesEntitled(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	l := startLedger(t, s, ledger.WithMeterConfig(1, 5*time.Millisecond))
+	subscribe(t, l, "t1", []plan.Feature{
+		{Key: "sso", Name: "SSO", Type: plan.FeatureBoolean, Limit: 1},
+		{Key: "audit", Name: "Audit log", Type: plan.FeatureBoolean, Limit: 0},
+		{Key: "api_calls", Name: "API calls", Type: plan.FeatureMetered, Limit: 3, Period: plan.PeriodMonthly},
+		{Key: "exports", Name: "Exports", Type: plan.FeatureMetered, Limit: 10, Period: plan.PeriodMonthly},
+		{Key: "builds", Name: "Builds", Type: plan.FeatureMetered, Limit: -1, Period: plan.PeriodMonthly},
+		{Key: "projects", Name: "Projects", Type: plan.FeatureMetered, Limit: 5, Period: plan.PeriodNone},
+	})
+
+	tctx := tenantContext("t1", "app")
+	for key, qty := range map[string]int64{"api_calls": 3, "exports": 4, "builds": 7, "projects": 2} {
+		if err := l.Meter(tctx, key, qty); err != nil {
+			t.Fatal(err)
+		}
+	}
+	eventually(t, "the usage to be flushed", func() bool { return usageCount(t, s, "t1") == 4 })
+
+	keys := []string{"sso", "audit", "api_calls", "exports", "builds", "projects", "unknown"}
+	want := make(map[string]*entitlement.Result, len(keys))
+	for _, key := range keys {
+		res, err := l.Entitled(tctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want[key] = res
+	}
+
+	// Drop the results Entitled cached so EntitledMany computes its own.
+	if err := s.Invalidate(ctx, "t1", "app"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := l.EntitledMany(tctx, keys...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range keys {
+		if !reflect.DeepEqual(got[key], want[key]) {
+			t.Errorf("%s: EntitledMany = %+v, Entitled = %+v", key, got[key], want[key])
+		}
+	}
+	if len(got) != len(keys) {
+		t.Errorf("EntitledMany returned %d results, want %d", len(got), len(keys))
+	}
+}
+
+func TestLifetimeQuotaOutlivesPeriodsAndPurges(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	l := startLedger(t, s)
+	_, sub := subscribe(t, l, "t1", []plan.Feature{
+		{Key: "exports", Name: "Exports", Type: plan.FeatureMetered, Limit: 10, Period: plan.PeriodNone},
+		{Key: "api_calls", Name: "API calls", Type: plan.FeatureMetered, Limit: 10, Period: plan.PeriodMonthly},
+	})
+
+	now := time.Now().UTC()
+	var events []*meter.UsageEvent
+	for _, at := range []time.Time{now.AddDate(0, -2, 0), now.AddDate(0, 0, -1)} {
+		for key, qty := range map[string]int64{"exports": 3, "api_calls": 2} {
+			events = append(events, &meter.UsageEvent{
+				ID: id.NewUsageEventID(), TenantID: "t1", AppID: "app", FeatureKey: key, Quantity: qty, Timestamp: at,
+			})
+		}
+	}
+	if _, err := s.IngestBatch(ctx, events); err != nil {
+		t.Fatal(err)
+	}
+
+	tctx := tenantContext("t1", "app")
+	// used drops the cached results and returns the usage Entitled reports.
+	used := func(key string) int64 {
+		t.Helper()
+		if err := s.Invalidate(ctx, "t1", "app"); err != nil {
+			t.Fatal(err)
+		}
+		res, err := l.Entitled(tctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Used
+	}
+	if got := used("exports"); got != 6 {
+		t.Fatalf("exports used = %d, want 6 across both periods", got)
+	}
+
+	// A new billing period resets the monthly quota only.
+	sub.CurrentPeriodStart = now.Add(-time.Hour)
+	sub.CurrentPeriodEnd = sub.CurrentPeriodStart.AddDate(0, 1, 0)
+	if err := s.UpdateSubscription(ctx, sub); err != nil {
+		t.Fatal(err)
+	}
+	if got := used("api_calls"); got != 0 {
+		t.Errorf("api_calls used = %d in the new period, want 0", got)
+	}
+	if got := used("exports"); got != 6 {
+		t.Errorf("exports used = %d in the new period, want 6", got)
+	}
+
+	// Voiding an event gives its usage back; purging events does not.
+	var export id.UsageEventID
+	for _, e := range events {
+		if e.FeatureKey == "exports" {
+			export = e.ID
+		}
+	}
+	if _, err := l.VoidUsage(tctx, export, "duplicate export"); err != nil {
+		t.Fatal(err)
+	}
+	if got := used("exports"); got != 3 {
+		t.Errorf("exports used = %d after a void, want 3", got)
+	}
+	if n, err := s.PurgeUsage(ctx, now); err != nil || n != 4 {
+		t.Fatalf("PurgeUsage = %d, %v, want 4 events purged", n, err)
+	}
+	if got := used("exports"); got != 3 {
+		t.Errorf("exports used = %d after a purge, want 3", got)
+	}
+}
@@ -0,0 +1,256 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	log "github.com/xraph/go-utils/log"
+
+	"github.com/xraph/ledger/id"
+	"github.com/xraph/ledger/meter"
+	"github.com/xraph/ledger/meter/wal"
+)
+
+// Meter records a usage event (non-blocking).
+func (l *Ledger) Meter(ctx context.Context, featureKey string, quantity int64) error {
+	return l.MeterWithOptions(ctx, featureKey, quantity, MeterOptions{})
+}
+
+// MeterOptions customizes a usage event recorded with MeterWithOptions.
+// Zero-valued fields fall back to the defaults used by Meter.
+type MeterOptions struct {
+	// Timestamp is when the usage happened (default: now). Background jobs
+	// use it to report usage after the fact.
+	Timestamp time.Time
+
+	// IdempotencyKey deduplicates retries of the same event.
+	IdempotencyKey string
+
+	// Properties are arbitrary key/value pairs stored with the event.
+	Properties map[string]string
+
+	// TenantID and AppID override the tenant and app taken from ctx.
+	TenantID string
+	AppID    string
+}
+
+// MeterWithOptions records a usage event with an explicit timestamp,
+// idempotency key, properties or tenant/app (non-blocking).
+func (l *Ledger) MeterWithOptions(ctx context.Context, featureKey string, quantity int64, opts MeterOptions) error {
+	event := &meter.UsageEvent{
+		TenantID:       opts.TenantID,
+		AppID:          opts.AppID,
+		FeatureKey:     featureKey,
+		Quantity:       quantity,
+		Timestamp:      opts.Timestamp,
+		IdempotencyKey: opts.IdempotencyKey,
+	}
+	if len(opts.Properties) > 0 {
+		event.Metadata = make(map[string]string, len(opts.Properties))
+		for k, v := range opts.Properties {
+			event.Metadata[k] = v
+		}
+	}
+	return l.MeterEvent(ctx, event)
+}
+
+// MeterEvent records a prepared usage event. Missing fields are filled in:
+// ID, Timestamp (now) and TenantID/AppID (from ctx). It does not block unless
+// the buffer is full and the overflow policy is OverflowBlock.
+func (l *Ledger) MeterEvent(ctx context.Context, event *meter.UsageEvent) error {
+	if err := l.meterEvent(ctx, event); !errors.Is(err, errMeterDropped) {
+		return err
+	}
+	return nil
+}
+
+// errMeterDropped reports an event dropped under OverflowDrop. MeterEvent
+// succeeds for it, but Consume and CommitReservation fail so the quota
+// they took is not charged for usage that is never recorded.
+var errMeterDropped = fmt.Errorf("%w: event dropped", ErrMeterBufferFull)
+
+// meterEvent records a usage event like MeterEvent, but returns
+// errMeterDropped when the overflow policy drops it.
+func (l *Ledger) meterEvent(ctx context.Context, event *meter.UsageEvent) error {
+	if event.TenantID == "" {
+		event.TenantID = extractTenantID(ctx)
+	}
+	if event.AppID == "" {
+		event.AppID = extractAppID(ctx)
+	}
+	if event.TenantID == "" || event.AppID == "" || event.FeatureKey == "" {
+		return ErrInvalidInput
+	}
+
+	// Retries carry the idempotency key or event ID of the first attempt,
+	// so the rate counter counts them once.
+	dedupe := event.IdempotencyKey
+	if event.ID.IsNil() {
+		event.ID = id.NewUsageEventID()
+	} else if dedupe == "" {
+		dedupe = event.ID.String()
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	if l.strictMetering {
+		if err := l.validateUsage(ctx, event); err != nil {
+			l.plugins.EmitUsageRejected(ctx, event, err)
+			return err
+		}
+	}
+
+	if err := l.enqueue(ctx, event); err != nil {
+		return err
+	}
+	if event.Counted() {
+		l.countRate(ctx, event, dedupe)
+	}
+	return nil
+}
+
+// enqueue hands an event to its flush worker's shard, through the WAL when
+// one is configured.
+func (l *Ledger) enqueue(ctx context.Context, event *meter.UsageEvent) error {
+	shard := l.meterShard(event.TenantID)
+	if l.wal != nil && l.meterOverflow == OverflowBlock {
+		return l.enqueueBlocking(ctx, shard, event)
+	}
+	if l.spill != nil && l.spill.Len() > 0 {
+		// Spilled events are drained into their shards oldest first; later
+		// events join them so each tenant's usage stays in order.
+		return l.overflow(ctx, shard, event)
+	}
+	if err := l.offer(shard, event); !errors.Is(err, ErrMeterBufferFull) {
+		return err
+	}
+	return l.overflow(ctx, shard, event)
+}
+
+// offer hands an event to shard, through the WAL when one is configured,
+// without waiting. It returns ErrMeterBufferFull when the shard is full.
+func (l *Ledger) offer(shard chan queuedEvent, event *meter.UsageEvent) error {
+	if l.wal != nil {
+		return l.enqueueDurable(shard, event)
+	}
+	select {
+	case shard <- queuedEvent{event: event, queued: time.Now()}:
+		return nil
+	default:
+		return ErrMeterBufferFull
+	}
+}
+
+// queuedEvent is a buffered usage event and the time it was buffered, from
+// which the flush lag is measured.
+type queuedEvent struct {
+	event  *meter.UsageEvent
+	queued time.Time
+}
+
+// meterShard returns the buffer of the flush worker that owns tenantID. All
+// of a tenant's events hash to the same shard, which keeps them in order.
+func (l *Ledger) meterShard(tenantID string) chan queuedEvent {
+	if len(l.meterShards) == 1 {
+		return l.meterShards[0]
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(tenantID)) //nolint:errcheck // hash writes never fail
+	n := uint32(len(l.meterShards))  //nolint:gosec // the shard count always fits in uint32
+	return l.meterShards[h.Sum32()%n]
+}
+
+// enqueueDurable writes event to the WAL before handing it to the flush
+// worker. A buffer slot is held while the event is logged, so it is only
+// logged when the buffer is guaranteed to accept it; otherwise a rejected
+// event would be replayed on the next start. Appends themselves run
+// concurrently and share fsyncs.
+func (l *Ledger) enqueueDurable(shard chan queuedEvent, event *meter.UsageEvent) error {
+	l.meterMu.Lock()
+	if len(shard)+l.meterSlots[shard] >= cap(shard) {
+		l.meterMu.Unlock()
+		return ErrMeterBufferFull
+	}
+	l.meterSlots[shard]++
+	l.meterMu.Unlock()
+
+	err := l.wal.Append(event)
+
+	l.meterMu.Lock()
+	defer l.meterMu.Unlock()
+	l.meterSlots[shard]--
+	if err != nil {
+		return walError(err)
+	}
+	shard <- queuedEvent{event: event, queued: time.Now()} // the held slot guarantees room
+	return nil
+}
+
+// walError wraps a failed WAL append. An event whose ID is still in the WAL
+// is reported as a duplicate.
+func walError(err error) error {
+	if errors.Is(err, wal.ErrDuplicate) {
+		return ErrDuplicateEvent
+	}
+	return fmt.Errorf("write meter WAL: %w", err)
+}
+
+// enqueueBlocking writes event to the WAL and waits for room in the buffer.
+// If ctx ends first the event is taken back out of the WAL; only a crash
+// while waiting can leave it there to be replayed.
+func (l *Ledger) enqueueBlocking(ctx context.Context, shard chan queuedEvent, event *meter.UsageEvent) error {
+	if err := l.wal.Append(event); err != nil {
+		return walError(err)
+	}
+	err := l.sendBlocking(ctx, shard, event)
+	if err != nil {
+		if ackErr := l.wal.Ack([]*meter.UsageEvent{event}); ackErr != nil {
+			l.logger.Warn("failed to truncate meter WAL", log.Error(ackErr))
+		}
+	}
+	return err
+}
+
+// sendBlocking waits for room in the buffer until ctx is done or the ledger
+// stops.
+func (l *Ledger) sendBlocking(ctx context.Context, shard chan queuedEvent, event *meter.UsageEvent) error {
+	select {
+	case shard <- queuedEvent{event: event, queued: time.Now()}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %w", ErrMeterBufferFull, ctx.Err())
+	case <-l.stopChan:
+		return ErrMeterBufferFull
+	}
+}
+
+// overflow applies the overflow policy to an event the buffer has no room
+// for.
+func (l *Ledger) overflow(ctx context.Context, shard chan queuedEvent, event *meter.UsageEvent) error {
+	switch l.meterOverflow {
+	case OverflowBlock:
+		return l.sendBlocking(ctx, shard, event)
+
+	case OverflowSpill:
+		if l.spill == nil {
+			return ErrMeterBufferFull
+		}
+		if err := l.spill.Append(event); err != nil {
+			return fmt.Errorf("write meter spill log: %w", err)
+		}
+		return nil
+
+	case OverflowDrop:
+		if l.meterDropHandler != nil {
+			l.meterDropHandler(ctx, event)
+		}
+		l.meterStats.dropped.Add(1)
+		l.plugins.EmitUsageDropped(ctx, 1, 0, ErrMeterBufferFull)
+		return errMeterDropped
+	}
+	return ErrMeterBufferFull
+}
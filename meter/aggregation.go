@@ -0,0 +1,46 @@
+package meter
+
+// AggregationType names the function used to reduce a feature's usage
+// events in a window to a single value.
+type AggregationType string
+
+const (
+	// AggregateSum adds up event quantities. It is the default.
+	AggregateSum AggregationType = "sum"
+	// AggregateCount counts events, ignoring their quantities.
+	AggregateCount AggregationType = "count"
+	// AggregateMax takes the largest quantity, e.g. peak concurrent connections.
+	AggregateMax AggregationType = "max"
+	// AggregateUnique counts the distinct values of a metadata property,
+	// e.g. distinct active users. Events without the property are ignored.
+	AggregateUnique AggregationType = "unique"
+	// AggregateLast takes the quantity of the most recent event.
+	AggregateLast AggregationType = "last"
+)
+
+// IsBuiltin reports whether t is implemented natively by every store. Any
+// other value is treated as the name of a plugin.UsageAggregator.
+func (t AggregationType) IsBuiltin() bool {
+	switch t {
+	case "", AggregateSum, AggregateCount, AggregateMax, AggregateUnique, AggregateLast:
+		return true
+	}
+	return false
+}
+
+// Aggregation describes how Store.Aggregate reduces usage events. The zero
+// value sums quantities.
+type Aggregation struct {
+	Type AggregationType `json:"type,omitempty"`
+	// Property is the metadata key whose distinct values AggregateUnique
+	// counts. It is ignored by the other types.
+	Property string `json:"property,omitempty"`
+}
+
+// Kind returns the aggregation type, defaulting to AggregateSum.
+func (a Aggregation) Kind() AggregationType {
+	if a.Type == "" {
+		return AggregateSum
+	}
+	return a.Type
+}
@@ -0,0 +1,175 @@
+// Package archive moves usage events out of the store into compressed
+// archive files and reads them back.
+//
+// Archives hold the events exactly as they were stored, including voided
+// events and corrections, either as gzip-compressed NDJSON or as Apache
+// Parquet with gzip-compressed columns. Files are written through a Storage,
+// which Dir implements for a local directory; other backends (object
+// storage, for instance) only need to implement Create, Open and List.
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/xraph/ledger/meter"
+)
+
+// Format is the encoding of an archive file.
+type Format string
+
+const (
+	// FormatNDJSON is newline-delimited JSON, one event per line, gzipped.
+	FormatNDJSON Format = "ndjson"
+	// FormatParquet is Apache Parquet with gzip-compressed columns, for
+	// loading archives straight into analytics tools.
+	FormatParquet Format = "parquet"
+)
+
+// Ext returns the file extension of archives in the format.
+func (f Format) Ext() string {
+	if f == FormatParquet {
+		return ".parquet"
+	}
+	return ".ndjson.gz"
+}
+
+// FormatOf returns the format of an archive file from its name.
+func FormatOf(name string) (Format, error) {
+	switch {
+	case strings.HasSuffix(name, FormatNDJSON.Ext()):
+		return FormatNDJSON, nil
+	case strings.HasSuffix(name, FormatParquet.Ext()):
+		return FormatParquet, nil
+	}
+	return "", fmt.Errorf("ledger/archive: unknown archive format: %s", name)
+}
+
+// Encode writes events to w in the given format.
+func Encode(w io.Writer, format Format, events []*meter.UsageEvent) error {
+	switch format {
+	case FormatNDJSON:
+		return encodeNDJSON(w, events)
+	case FormatParquet:
+		return encodeParquet(w, events)
+	}
+	return fmt.Errorf("ledger/archive: unknown format %q", format)
+}
+
+// Decode reads back the events of an archive written by Encode.
+func Decode(r io.Reader, format Format) ([]*meter.UsageEvent, error) {
+	switch format {
+	case FormatNDJSON:
+		return decodeNDJSON(r)
+	case FormatParquet:
+		return decodeParquet(r)
+	}
+	return nil, fmt.Errorf("ledger/archive: unknown format %q", format)
+}
+
+// Storage keeps archive files. Names are slash-separated paths relative to
+// the storage root.
+type Storage interface {
+	// Create returns a writer for a new archive file. The file must only
+	// become visible to Open and List once the writer is closed without
+	// error.
+	Create(ctx context.Context, name string) (io.WriteCloser, error)
+	// Open returns a reader for an archive file.
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+	// List returns the names of the archive files under prefix, sorted.
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// Dir returns a Storage that keeps archive files under a local directory.
+func Dir(path string) Storage { return dirStorage(path) }
+
+type dirStorage string
+
+func (d dirStorage) Create(_ context.Context, name string) (io.WriteCloser, error) {
+	path := filepath.Join(string(d), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("ledger/archive: create dir: %w", err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return nil, fmt.Errorf("ledger/archive: create file: %w", err)
+	}
+	return &dirFile{File: f, path: path}, nil
+}
+
+func (d dirStorage) Open(_ context.Context, name string) (io.ReadCloser, error) {
+	f, err := os.Open(filepath.Join(string(d), filepath.FromSlash(name))) //nolint:gosec // name is relative to the archive directory
+	if err != nil {
+		return nil, fmt.Errorf("ledger/archive: open file: %w", err)
+	}
+	return f, nil
+}
+
+func (d dirStorage) List(_ context.Context, prefix string) ([]string, error) {
+	var names []string
+	err := filepath.WalkDir(string(d), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".tmp-") {
+			return nil
+		}
+		rel, err := filepath.Rel(string(d), path)
+		if err != nil {
+			return err
+		}
+		if name := filepath.ToSlash(rel); strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ledger/archive: list files: %w", err)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// dirFile is an archive file being written to a temporary file, which is
+// synced and renamed into place on Close unless a write failed.
+type dirFile struct {
+	*os.File
+	path string
+	err  error
+}
+
+func (f *dirFile) Write(p []byte) (int, error) {
+	n, err := f.File.Write(p)
+	if err != nil && f.err == nil {
+		f.err = err
+	}
+	return n, err
+}
+
+func (f *dirFile) Close() error {
+	err := f.err
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.File.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), f.path)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name()) //nolint:errcheck // best-effort cleanup of the partial file
+		return fmt.Errorf("ledger/archive: write file: %w", err)
+	}
+	return nil
+}
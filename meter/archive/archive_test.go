@@ -0,0 +1,105 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xraph/ledger/id"
+	"github.com/xraph/ledger/meter"
+)
+
+func testEvents() []*meter.UsageEvent {
+	at := time.Date(2024, 3, 1, 10, 30, 0, 123456789, time.UTC)
+	voided := at.Add(time.Hour)
+	return []*meter.UsageEvent{
+		{
+			ID: id.NewUsageEventID(), TenantID: "t1", AppID: "app", FeatureKey: "api_calls",
+			Quantity: 5, Timestamp: at, IdempotencyKey: "req-1",
+			Metadata: map[string]string{"region": "eu"},
+		},
+		{
+			ID: id.NewUsageEventID(), TenantID: "t1", AppID: "app", FeatureKey: "api_calls",
+			Quantity: 3, Timestamp: at.Add(time.Minute),
+			VoidedAt: &voided, VoidReason: "duplicate",
+		},
+		{
+			ID: id.NewUsageEventID(), TenantID: "t2", AppID: "app", FeatureKey: "storage",
+			Quantity: -2, Timestamp: at.Add(2 * time.Minute),
+			Correction: true, Reason: "overcount",
+		},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []Format{FormatNDJSON, FormatParquet} {
+		t.Run(string(format), func(t *testing.T) {
+			want := testEvents()
+			var buf bytes.Buffer
+			if err := Encode(&buf, format, want); err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+			got, err := Decode(&buf, format)
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("got %d events, want %d", len(got), len(want))
+			}
+			for i := range want {
+				if !got[i].Timestamp.Equal(want[i].Timestamp) {
+					t.Errorf("event %d: timestamp %v, want %v", i, got[i].Timestamp, want[i].Timestamp)
+				}
+				if (got[i].VoidedAt == nil) != (want[i].VoidedAt == nil) ||
+					got[i].VoidedAt != nil && !got[i].VoidedAt.Equal(*want[i].VoidedAt) {
+					t.Errorf("event %d: voided at %v, want %v", i, got[i].VoidedAt, want[i].VoidedAt)
+				}
+				g, w := *got[i], *want[i]
+				g.Timestamp, w.Timestamp = time.Time{}, time.Time{}
+				g.VoidedAt, w.VoidedAt = nil, nil
+				if !reflect.DeepEqual(g, w) {
+					t.Errorf("event %d:\n got %+v\nwant %+v", i, g, w)
+				}
+			}
+		})
+	}
+}
+
+func TestDir(t *testing.T) {
+	ctx := context.Background()
+	store := Dir(t.TempDir())
+
+	for _, name := range []string{"app/b.ndjson.gz", "app/a.parquet", "other/c.ndjson.gz"} {
+		w, err := store.Create(ctx, name)
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if _, err := w.Write([]byte(name)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+	}
+
+	names, err := store.List(ctx, "app/")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if want := []string{"app/a.parquet", "app/b.ndjson.gz"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("List = %v, want %v", names, want)
+	}
+
+	r, err := store.Open(ctx, "app/a.parquet")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil || string(data) != "app/a.parquet" {
+		t.Fatalf("read %q, %v", data, err)
+	}
+}
@@ -0,0 +1,45 @@
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/xraph/ledger/meter"
+)
+
+func encodeNDJSON(w io.Writer, events []*meter.UsageEvent) error {
+	zw := gzip.NewWriter(w)
+	enc := json.NewEncoder(zw)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return fmt.Errorf("ledger/archive: encode event: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("ledger/archive: compress: %w", err)
+	}
+	return nil
+}
+
+func decodeNDJSON(r io.Reader) ([]*meter.UsageEvent, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("ledger/archive: decompress: %w", err)
+	}
+	defer zr.Close()
+
+	var events []*meter.UsageEvent
+	dec := json.NewDecoder(bufio.NewReader(zr))
+	for {
+		var e meter.UsageEvent
+		if err := dec.Decode(&e); err == io.EOF {
+			return events, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("ledger/archive: decode event: %w", err)
+		}
+		events = append(events, &e)
+	}
+}
@@ -0,0 +1,646 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/xraph/ledger/id"
+	"github.com/xraph/ledger/meter"
+)
+
+// Parquet archives use a flat schema with one column per UsageEvent field
+// and a single row group per file. Each column chunk is one PLAIN-encoded,
+// gzip-compressed v1 data page; optional columns hold nulls for empty
+// values. The reader accepts files with that layout only, which covers every
+// file written by Encode.
+
+const parquetMagic = "PAR1"
+
+// Parquet physical types, repetitions, encodings, codecs and converted
+// types used by the archive schema.
+const (
+	pqBoolean   = 0
+	pqInt64     = 2
+	pqByteArray = 6
+
+	pqRequired = 0
+	pqOptional = 1
+
+	pqPlain = 0
+	pqRLE   = 3
+
+	pqUncompressed = 0
+	pqGzip         = 2
+
+	pqUTF8            = 0
+	pqTimestampMillis = 9
+	pqTimestampMicros = 10
+
+	pqDataPage = 0
+)
+
+type pqKind int
+
+const (
+	pqString pqKind = iota
+	pqInt
+	pqTime
+	pqBool
+)
+
+// pqColumn maps one UsageEvent field to a column. get returns the value and
+// whether it is set; set stores a decoded value.
+type pqColumn struct {
+	name     string
+	kind     pqKind
+	optional bool
+	get      func(e *meter.UsageEvent) (any, bool)
+	set      func(e *meter.UsageEvent, v any) error
+}
+
+func (c pqColumn) physical() int32 {
+	switch c.kind {
+	case pqInt, pqTime:
+		return pqInt64
+	case pqBool:
+		return pqBoolean
+	}
+	return pqByteArray
+}
+
+func stringColumn(name string, optional bool, field func(e *meter.UsageEvent) *string) pqColumn {
+	return pqColumn{
+		name:     name,
+		kind:     pqString,
+		optional: optional,
+		get: func(e *meter.UsageEvent) (any, bool) {
+			v := *field(e)
+			return v, v != "" || !optional
+		},
+		set: func(e *meter.UsageEvent, v any) error {
+			*field(e) = v.(string)
+			return nil
+		},
+	}
+}
+
+var pqColumns = []pqColumn{
+	{
+		name: "id",
+		kind: pqString,
+		get:  func(e *meter.UsageEvent) (any, bool) { return e.ID.String(), true },
+		set: func(e *meter.UsageEvent, v any) error {
+			evtID, err := id.ParseUsageEventID(v.(string))
+			e.ID = evtID
+			return err
+		},
+	},
+	stringColumn("tenant_id", false, func(e *meter.UsageEvent) *string { return &e.TenantID }),
+	stringColumn("app_id", false, func(e *meter.UsageEvent) *string { return &e.AppID }),
+	stringColumn("feature_key", false, func(e *meter.UsageEvent) *string { return &e.FeatureKey }),
+	{
+		name: "quantity",
+		kind: pqInt,
+		get:  func(e *meter.UsageEvent) (any, bool) { return e.Quantity, true },
+		set: func(e *meter.UsageEvent, v any) error {
+			e.Quantity = v.(int64)
+			return nil
+		},
+	},
+	{
+		name: "timestamp",
+		kind: pqTime,
+		get:  func(e *meter.UsageEvent) (any, bool) { return e.Timestamp, true },
+		set: func(e *meter.UsageEvent, v any) error {
+			e.Timestamp = v.(time.Time)
+			return nil
+		},
+	},
+	stringColumn("idempotency_key", true, func(e *meter.UsageEvent) *string { return &e.IdempotencyKey }),
+	{
+		name:     "metadata",
+		kind:     pqString,
+		optional: true,
+		get: func(e *meter.UsageEvent) (any, bool) {
+			if len(e.Metadata) == 0 {
+				return nil, false
+			}
+			raw, err := json.Marshal(e.Metadata)
+			if err != nil {
+				return nil, false
+			}
+			return string(raw), true
+		},
+		set: func(e *meter.UsageEvent, v any) error {
+			return json.Unmarshal([]byte(v.(string)), &e.Metadata)
+		},
+	},
+	{
+		name: "correction",
+		kind: pqBool,
+		get:  func(e *meter.UsageEvent) (any, bool) { return e.Correction, true },
+		set: func(e *meter.UsageEvent, v any) error {
+			e.Correction = v.(bool)
+			return nil
+		},
+	},
+	stringColumn("reason", true, func(e *meter.UsageEvent) *string { return &e.Reason }),
+	{
+		name:     "voided_at",
+		kind:     pqTime,
+		optional: true,
+		get: func(e *meter.UsageEvent) (any, bool) {
+			if e.VoidedAt == nil {
+				return nil, false
+			}
+			return *e.VoidedAt, true
+		},
+		set: func(e *meter.UsageEvent, v any) error {
+			t := v.(time.Time)
+			e.VoidedAt = &t
+			return nil
+		},
+	},
+	stringColumn("void_reason", true, func(e *meter.UsageEvent) *string { return &e.VoidReason }),
+}
+
+// ──────────────────────────────────────────────────
+// Writing
+// ──────────────────────────────────────────────────
+
+type pqChunk struct {
+	offset       int64
+	compressed   int64
+	uncompressed int64
+}
+
+func encodeParquet(w io.Writer, events []*meter.UsageEvent) error {
+	var file bytes.Buffer
+	file.WriteString(parquetMagic)
+
+	chunks := make([]pqChunk, len(pqColumns))
+	for i, col := range pqColumns {
+		page, err := encodePage(col, events)
+		if err != nil {
+			return err
+		}
+		var compressed bytes.Buffer
+		zw := gzip.NewWriter(&compressed)
+		if _, err := zw.Write(page); err != nil {
+			return fmt.Errorf("ledger/archive: compress column %s: %w", col.name, err)
+		}
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("ledger/archive: compress column %s: %w", col.name, err)
+		}
+
+		var h tWriter
+		h.begin()
+		h.i32(1, pqDataPage)
+		h.i32(2, int32(len(page)))        //nolint:gosec // archive files are bounded by the batch size
+		h.i32(3, int32(compressed.Len())) //nolint:gosec // archive files are bounded by the batch size
+		h.structField(5)
+		h.i32(1, int32(len(events))) //nolint:gosec // archive files are bounded by the batch size
+		h.i32(2, pqPlain)
+		h.i32(3, pqRLE)
+		h.i32(4, pqRLE)
+		h.end()
+		h.end()
+
+		chunks[i] = pqChunk{
+			offset:       int64(file.Len()),
+			compressed:   int64(len(h.buf) + compressed.Len()),
+			uncompressed: int64(len(h.buf) + len(page)),
+		}
+		file.Write(h.buf)
+		file.Write(compressed.Bytes())
+	}
+
+	footer := encodeFooter(chunks, int64(len(events)))
+	file.Write(footer)
+	file.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer)))) //nolint:gosec // footer size is small
+	file.WriteString(parquetMagic)
+
+	if _, err := w.Write(file.Bytes()); err != nil {
+		return fmt.Errorf("ledger/archive: write: %w", err)
+	}
+	return nil
+}
+
+// encodePage returns the uncompressed body of a column's data page: the
+// definition levels of an optional column followed by its PLAIN values.
+func encodePage(col pqColumn, events []*meter.UsageEvent) ([]byte, error) {
+	var page []byte
+	values := make([]any, 0, len(events))
+	levels := make([]bool, len(events))
+	for i, e := range events {
+		v, ok := col.get(e)
+		levels[i] = ok
+		if ok {
+			values = append(values, v)
+		}
+	}
+	if col.optional {
+		rle := encodeLevels(levels)
+		page = binary.LittleEndian.AppendUint32(page, uint32(len(rle))) //nolint:gosec // bounded by the batch size
+		page = append(page, rle...)
+	}
+
+	switch col.kind {
+	case pqString:
+		for _, v := range values {
+			s := v.(string)
+			page = binary.LittleEndian.AppendUint32(page, uint32(len(s))) //nolint:gosec // bounded by the batch size
+			page = append(page, s...)
+		}
+	case pqInt:
+		for _, v := range values {
+			page = binary.LittleEndian.AppendUint64(page, uint64(v.(int64))) //nolint:gosec // two's complement round-trips
+		}
+	case pqTime:
+		for _, v := range values {
+			page = binary.LittleEndian.AppendUint64(page, uint64(v.(time.Time).UnixNano())) //nolint:gosec // two's complement round-trips
+		}
+	case pqBool:
+		packed := make([]byte, (len(values)+7)/8)
+		for i, v := range values {
+			if v.(bool) {
+				packed[i/8] |= 1 << (i % 8)
+			}
+		}
+		page = append(page, packed...)
+	default:
+		return nil, fmt.Errorf("ledger/archive: unknown column kind for %s", col.name)
+	}
+	return page, nil
+}
+
+// encodeLevels encodes 1-bit definition levels as RLE runs of the
+// RLE/bit-packing hybrid encoding.
+func encodeLevels(levels []bool) []byte {
+	var out []byte
+	for i := 0; i < len(levels); {
+		j := i
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		out = binary.AppendUvarint(out, uint64(j-i)<<1)
+		if levels[i] {
+			out = append(out, 1)
+		} else {
+			out = append(out, 0)
+		}
+		i = j
+	}
+	return out
+}
+
+// encodeFooter returns the thrift-encoded FileMetaData.
+func encodeFooter(chunks []pqChunk, rows int64) []byte {
+	var w tWriter
+	w.begin()
+	w.i32(1, 1)
+
+	w.list(2, tStructType, len(pqColumns)+1)
+	w.begin()
+	w.binary(4, "schema")
+	w.i32(5, int32(len(pqColumns)))
+	w.end()
+	for _, col := range pqColumns {
+		w.begin()
+		w.i32(1, col.physical())
+		if col.optional {
+			w.i32(3, pqOptional)
+		} else {
+			w.i32(3, pqRequired)
+		}
+		w.binary(4, col.name)
+		switch col.kind {
+		case pqString:
+			w.i32(6, pqUTF8)
+			w.structField(10)
+			w.structField(1) // STRING
+			w.end()
+			w.end()
+		case pqTime:
+			w.structField(10)
+			w.structField(8) // TIMESTAMP
+			w.bool(1, true)
+			w.structField(2)
+			w.structField(3) // NANOS
+			w.end()
+			w.end()
+			w.end()
+			w.end()
+		case pqInt, pqBool:
+		}
+		w.end()
+	}
+
+	w.i64(3, rows)
+
+	var total int64
+	for _, c := range chunks {
+		total += c.uncompressed
+	}
+	w.list(4, tStructType, 1)
+	w.begin()
+	w.list(1, tStructType, len(chunks))
+	for i, col := range pqColumns {
+		c := chunks[i]
+		w.begin()
+		w.i64(2, c.offset)
+		w.structField(3)
+		w.i32(1, col.physical())
+		w.list(2, tI32, 2)
+		w.i32Elem(pqPlain)
+		w.i32Elem(pqRLE)
+		w.list(3, tBinary, 1)
+		w.binaryElem(col.name)
+		w.i32(4, pqGzip)
+		w.i64(5, rows)
+		w.i64(6, c.uncompressed)
+		w.i64(7, c.compressed)
+		w.i64(9, c.offset)
+		w.end()
+		w.end()
+	}
+	w.i64(2, total)
+	w.i64(3, rows)
+	w.end()
+
+	w.binary(6, "github.com/xraph/ledger")
+	w.end()
+	return w.buf
+}
+
+// ──────────────────────────────────────────────────
+// Reading
+// ──────────────────────────────────────────────────
+
+var errParquet = errors.New("ledger/archive: unsupported or malformed parquet file")
+
+func decodeParquet(r io.Reader) ([]*meter.UsageEvent, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("ledger/archive: read: %w", err)
+	}
+	n := len(data)
+	if n < 12 || string(data[:4]) != parquetMagic || string(data[n-4:]) != parquetMagic {
+		return nil, fmt.Errorf("%w: missing magic", errParquet)
+	}
+	footerLen := int(binary.LittleEndian.Uint32(data[n-8:]))
+	if footerLen > n-12 {
+		return nil, fmt.Errorf("%w: bad footer length", errParquet)
+	}
+	footer := &tReader{buf: data[n-8-footerLen : n-8]}
+	meta, err := footer.readStruct()
+	if err != nil {
+		return nil, err
+	}
+
+	// Leaf schema elements by name; the first element is the root.
+	schema := make(map[string]tStruct)
+	for i, el := range meta.list(2) {
+		if s, ok := el.(tStruct); ok && i > 0 {
+			schema[s.str(4)] = s
+		}
+	}
+
+	var events []*meter.UsageEvent
+	for _, rg := range meta.list(4) {
+		group, ok := rg.(tStruct)
+		if !ok {
+			return nil, errParquet
+		}
+		rows := int(group.int(3))
+		base := len(events)
+		for range rows {
+			events = append(events, &meter.UsageEvent{})
+		}
+
+		for _, cc := range group.list(1) {
+			chunk, ok := cc.(tStruct)
+			if !ok {
+				return nil, errParquet
+			}
+			cm := chunk.sub(3)
+			path := cm.list(3)
+			if len(path) != 1 {
+				return nil, fmt.Errorf("%w: nested column", errParquet)
+			}
+			name := string(path[0].([]byte))
+			col, ok := columnByName(name)
+			if !ok {
+				continue // not a UsageEvent field
+			}
+			if _, ok := cm[11]; ok {
+				return nil, fmt.Errorf("%w: dictionary-encoded column %s", errParquet, name)
+			}
+			el := schema[name]
+			values, err := readChunk(data, cm, el, col)
+			if err != nil {
+				return nil, fmt.Errorf("ledger/archive: column %s: %w", name, err)
+			}
+			if len(values) != rows {
+				return nil, fmt.Errorf("%w: column %s has %d values for %d rows", errParquet, name, len(values), rows)
+			}
+			for i, v := range values {
+				if v == nil {
+					continue
+				}
+				if err := col.set(events[base+i], v); err != nil {
+					return nil, fmt.Errorf("ledger/archive: column %s: %w", name, err)
+				}
+			}
+		}
+	}
+	return events, nil
+}
+
+func columnByName(name string) (pqColumn, bool) {
+	for _, col := range pqColumns {
+		if col.name == name {
+			return col, true
+		}
+	}
+	return pqColumn{}, false
+}
+
+// readChunk decodes every value of a column chunk, with nil for nulls.
+func readChunk(data []byte, cm, el tStruct, col pqColumn) ([]any, error) {
+	if cm.int(1) != int64(col.physical()) {
+		return nil, fmt.Errorf("%w: unexpected physical type %d", errParquet, cm.int(1))
+	}
+	optional := el.int(3) == pqOptional
+	unit := timeUnit(el)
+
+	total := int(cm.int(5))
+	pos := int(cm.int(9))
+	values := make([]any, 0, total)
+	for len(values) < total {
+		if pos < 0 || pos >= len(data) {
+			return nil, errParquet
+		}
+		hr := &tReader{buf: data[pos:]}
+		header, err := hr.readStruct()
+		if err != nil {
+			return nil, err
+		}
+		pos += hr.pos
+		size := int(header.int(3))
+		if size < 0 || pos+size > len(data) {
+			return nil, errParquet
+		}
+		body := data[pos : pos+size]
+		pos += size
+
+		if header.int(1) != pqDataPage {
+			return nil, fmt.Errorf("%w: page type %d", errParquet, header.int(1))
+		}
+		dph := header.sub(5)
+		if dph.int(2) != pqPlain {
+			return nil, fmt.Errorf("%w: encoding %d", errParquet, dph.int(2))
+		}
+		switch cm.int(4) {
+		case pqUncompressed:
+		case pqGzip:
+			zr, err := gzip.NewReader(bytes.NewReader(body))
+			if err != nil {
+				return nil, err
+			}
+			if body, err = io.ReadAll(zr); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("%w: compression codec %d", errParquet, cm.int(4))
+		}
+
+		page, err := decodePage(body, int(dph.int(1)), optional, unit, col)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, page...)
+	}
+	return values, nil
+}
+
+// timeUnit returns the duration of one tick of a timestamp column.
+func timeUnit(el tStruct) time.Duration {
+	if unit := el.sub(10).sub(8).sub(2); unit != nil {
+		switch {
+		case unit[1] != nil:
+			return time.Millisecond
+		case unit[2] != nil:
+			return time.Microsecond
+		}
+		return time.Nanosecond
+	}
+	switch el.int(6) {
+	case pqTimestampMillis:
+		return time.Millisecond
+	case pqTimestampMicros:
+		return time.Microsecond
+	}
+	return time.Nanosecond
+}
+
+func decodePage(body []byte, n int, optional bool, unit time.Duration, col pqColumn) ([]any, error) {
+	defined := make([]bool, n)
+	for i := range defined {
+		defined[i] = true
+	}
+	if optional {
+		if len(body) < 4 {
+			return nil, errParquet
+		}
+		size := int(binary.LittleEndian.Uint32(body))
+		if 4+size > len(body) {
+			return nil, errParquet
+		}
+		if err := decodeLevels(body[4:4+size], defined); err != nil {
+			return nil, err
+		}
+		body = body[4+size:]
+	}
+
+	values := make([]any, n)
+	pos, bit := 0, 0
+	for i := range values {
+		if !defined[i] {
+			continue
+		}
+		switch col.kind {
+		case pqString:
+			if pos+4 > len(body) {
+				return nil, errParquet
+			}
+			size := int(binary.LittleEndian.Uint32(body[pos:]))
+			pos += 4
+			if size < 0 || pos+size > len(body) {
+				return nil, errParquet
+			}
+			values[i] = string(body[pos : pos+size])
+			pos += size
+		case pqInt, pqTime:
+			if pos+8 > len(body) {
+				return nil, errParquet
+			}
+			v := int64(binary.LittleEndian.Uint64(body[pos:])) //nolint:gosec // two's complement round-trips
+			pos += 8
+			if col.kind == pqTime {
+				values[i] = time.Unix(0, v*int64(unit)).UTC()
+			} else {
+				values[i] = v
+			}
+		case pqBool:
+			if bit/8 >= len(body) {
+				return nil, errParquet
+			}
+			values[i] = body[bit/8]&(1<<(bit%8)) != 0
+			bit++
+		}
+	}
+	return values, nil
+}
+
+// decodeLevels decodes 1-bit definition levels in the RLE/bit-packing
+// hybrid encoding into defined.
+func decodeLevels(buf []byte, defined []bool) error {
+	r := &tReader{buf: buf}
+	for i := 0; i < len(defined); {
+		header, err := r.uvarint()
+		if err != nil {
+			return errParquet
+		}
+		if header&1 == 0 {
+			// RLE run: one value repeated header>>1 times.
+			v, err := r.byte()
+			if err != nil {
+				return errParquet
+			}
+			for run := header >> 1; run > 0 && i < len(defined); run-- {
+				defined[i] = v&1 == 1
+				i++
+			}
+			continue
+		}
+		// Bit-packed run of (header>>1)*8 values.
+		for groups := header >> 1; groups > 0; groups-- {
+			b, err := r.byte()
+			if err != nil {
+				return errParquet
+			}
+			for k := 0; k < 8 && i < len(defined); k++ {
+				defined[i] = b&(1<<k) != 0
+				i++
+			}
+		}
+	}
+	return nil
+}
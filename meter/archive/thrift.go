@@ -0,0 +1,271 @@
+package archive
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// A minimal Thrift compact protocol codec, enough to write and read the
+// Parquet file footer and page headers. Structs are decoded generically into
+// a field ID -> value map; integers decode to int64, binaries to []byte,
+// lists to []any and structs to tStruct.
+
+// Compact protocol type IDs.
+const (
+	tStop       = 0
+	tBoolTrue   = 1
+	tBoolFalse  = 2
+	tByte       = 3
+	tI16        = 4
+	tI32        = 5
+	tI64        = 6
+	tDouble     = 7
+	tBinary     = 8
+	tList       = 9
+	tSet        = 10
+	tMap        = 11
+	tStructType = 12
+)
+
+type tStruct map[int16]any
+
+// tWriter encodes compact protocol structs.
+type tWriter struct {
+	buf  []byte
+	last []int16 // last field ID of each open struct
+}
+
+func (w *tWriter) begin() { w.last = append(w.last, 0) }
+
+func (w *tWriter) end() {
+	w.buf = append(w.buf, tStop)
+	w.last = w.last[:len(w.last)-1]
+}
+
+func (w *tWriter) field(fid int16, typ byte) {
+	last := &w.last[len(w.last)-1]
+	if delta := fid - *last; delta > 0 && delta <= 15 {
+		w.buf = append(w.buf, byte(delta)<<4|typ)
+	} else {
+		w.buf = append(w.buf, typ)
+		w.buf = binary.AppendVarint(w.buf, int64(fid))
+	}
+	*last = fid
+}
+
+func (w *tWriter) i32(fid int16, v int32) {
+	w.field(fid, tI32)
+	w.buf = binary.AppendVarint(w.buf, int64(v))
+}
+
+func (w *tWriter) i64(fid int16, v int64) {
+	w.field(fid, tI64)
+	w.buf = binary.AppendVarint(w.buf, v)
+}
+
+func (w *tWriter) bool(fid int16, v bool) {
+	if v {
+		w.field(fid, tBoolTrue)
+	} else {
+		w.field(fid, tBoolFalse)
+	}
+}
+
+func (w *tWriter) binary(fid int16, v string) {
+	w.field(fid, tBinary)
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(v)))
+	w.buf = append(w.buf, v...)
+}
+
+// structField opens a nested struct field; close it with end.
+func (w *tWriter) structField(fid int16) {
+	w.field(fid, tStructType)
+	w.begin()
+}
+
+// list writes a list field header; the caller writes n elements of typ.
+func (w *tWriter) list(fid int16, typ byte, n int) {
+	w.field(fid, tList)
+	if n < 15 {
+		w.buf = append(w.buf, byte(n)<<4|typ)
+	} else {
+		w.buf = append(w.buf, 0xf0|typ)
+		w.buf = binary.AppendUvarint(w.buf, uint64(n))
+	}
+}
+
+func (w *tWriter) i32Elem(v int32) { w.buf = binary.AppendVarint(w.buf, int64(v)) }
+
+func (w *tWriter) binaryElem(v string) {
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(v)))
+	w.buf = append(w.buf, v...)
+}
+
+var errThrift = errors.New("ledger/archive: malformed thrift data")
+
+// tReader decodes compact protocol structs.
+type tReader struct {
+	buf []byte
+	pos int
+}
+
+func (r *tReader) byte() (byte, error) {
+	if r.pos >= len(r.buf) {
+		return 0, errThrift
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *tReader) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	if n <= 0 {
+		return 0, errThrift
+	}
+	r.pos += n
+	return v, nil
+}
+
+func (r *tReader) varint() (int64, error) {
+	v, n := binary.Varint(r.buf[r.pos:])
+	if n <= 0 {
+		return 0, errThrift
+	}
+	r.pos += n
+	return v, nil
+}
+
+func (r *tReader) readStruct() (tStruct, error) {
+	s := tStruct{}
+	var last int16
+	for {
+		b, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		if b == tStop {
+			return s, nil
+		}
+		typ := b & 0x0f
+		fid := last + int16(b>>4)
+		if b>>4 == 0 {
+			v, err := r.varint()
+			if err != nil {
+				return nil, err
+			}
+			fid = int16(v)
+		}
+		last = fid
+
+		var v any
+		switch typ {
+		case tBoolTrue:
+			v = true
+		case tBoolFalse:
+			v = false
+		default:
+			if v, err = r.value(typ); err != nil {
+				return nil, err
+			}
+		}
+		s[fid] = v
+	}
+}
+
+func (r *tReader) value(typ byte) (any, error) {
+	switch typ {
+	case tBoolTrue, tBoolFalse:
+		// Booleans inside containers take a byte of their own.
+		b, err := r.byte()
+		return b == tBoolTrue, err
+	case tByte:
+		b, err := r.byte()
+		return int64(int8(b)), err
+	case tI16, tI32, tI64:
+		return r.varint()
+	case tDouble:
+		if r.pos+8 > len(r.buf) {
+			return nil, errThrift
+		}
+		v := binary.LittleEndian.Uint64(r.buf[r.pos:])
+		r.pos += 8
+		return v, nil
+	case tBinary:
+		n, err := r.uvarint()
+		if err != nil || uint64(len(r.buf)-r.pos) < n {
+			return nil, errThrift
+		}
+		v := r.buf[r.pos : r.pos+int(n)]
+		r.pos += int(n)
+		return v, nil
+	case tList, tSet:
+		h, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		n := uint64(h >> 4)
+		if n == 15 {
+			if n, err = r.uvarint(); err != nil {
+				return nil, err
+			}
+		}
+		if n > uint64(len(r.buf)) {
+			return nil, errThrift
+		}
+		list := make([]any, 0, n)
+		for range n {
+			v, err := r.value(h & 0x0f)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case tMap:
+		n, err := r.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return nil, nil
+		}
+		types, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		for range n {
+			if _, err := r.value(types >> 4); err != nil {
+				return nil, err
+			}
+			if _, err := r.value(types & 0x0f); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	case tStructType:
+		return r.readStruct()
+	}
+	return nil, fmt.Errorf("%w: unknown type %d", errThrift, typ)
+}
+
+func (s tStruct) int(fid int16) int64 {
+	v, _ := s[fid].(int64)
+	return v
+}
+
+func (s tStruct) str(fid int16) string {
+	v, _ := s[fid].([]byte)
+	return string(v)
+}
+
+func (s tStruct) list(fid int16) []any {
+	v, _ := s[fid].([]any)
+	return v
+}
+
+func (s tStruct) sub(fid int16) tStruct {
+	v, _ := s[fid].(tStruct)
+	return v
+}
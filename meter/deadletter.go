@@ -0,0 +1,10 @@
+package meter
+
+import "context"
+
+// DeadLetterSink receives usage batches that could not be written to the
+// store after the meter flush retry budget was exhausted, so they can be
+// inspected and re-ingested later instead of being lost.
+type DeadLetterSink interface {
+	DeadLetter(ctx context.Context, events []*UsageEvent, cause error) error
+}
@@ -0,0 +1,30 @@
+package meter
+
+// LifetimeTotal is a tenant's running usage total of a feature since its
+// first event, kept by the store as events are ingested. It backs quotas
+// that never reset (plan.PeriodNone): voiding an event lowers it, but
+// purging or archiving events does not, and corrections are left out as in
+// every quota.
+type LifetimeTotal struct {
+	Quantity int64 `json:"quantity"`
+	Events   int64 `json:"events"`
+}
+
+// Lifetime reports whether an aggregation can be answered from lifetime
+// totals. Only sum and count can; max, unique and last need the events.
+func (a Aggregation) Lifetime() bool {
+	switch a.Kind() {
+	case AggregateSum, AggregateCount:
+		return true
+	}
+	return false
+}
+
+// Value returns the total reduced with agg: the event count for
+// AggregateCount and the quantity otherwise.
+func (t LifetimeTotal) Value(agg Aggregation) int64 {
+	if agg.Kind() == AggregateCount {
+		return t.Events
+	}
+	return t.Quantity
+}
@@ -1,6 +1,8 @@
 package meter
 
 import (
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/xraph/ledger/id"
@@ -15,4 +17,105 @@ type UsageEvent struct {
 	Timestamp      time.Time         `json:"timestamp"`
 	IdempotencyKey string            `json:"idempotency_key,omitempty"`
 	Metadata       map[string]string `json:"metadata,omitempty"`
+
+	// Correction marks a signed adjustment recorded to fix wrong usage.
+	// Corrections are billed but do not count towards quotas; Reason says
+	// why the correction was made.
+	Correction bool   `json:"correction,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+
+	// Quota marks usage already added to its feature's hard-limit counter
+	// by Consume or a committed reservation. Stores need not keep it.
+	Quota bool `json:"quota,omitempty"`
+
+	// VoidedAt is set once the event has been voided. Voided events are kept
+	// for the audit trail but excluded from every aggregation.
+	VoidedAt   *time.Time `json:"voided_at,omitempty"`
+	VoidReason string     `json:"void_reason,omitempty"`
+}
+
+// Counted reports whether the event counts towards usage aggregations:
+// it is neither voided nor a correction.
+func (e *UsageEvent) Counted() bool {
+	return e.VoidedAt == nil && !e.Correction
+}
+
+// VoidOpts selects the usage event to void, by EventID or, when EventID is
+// nil, by IdempotencyKey, and records why and when it was voided.
+type VoidOpts struct {
+	EventID        id.UsageEventID
+	IdempotencyKey string
+	Reason         string
+	VoidedAt       time.Time
+}
+
+// IngestResult reports the outcome of an IngestBatch call. Events whose ID,
+// or whose idempotency key within the same app and tenant, was already
+// stored are skipped and counted as duplicates.
+type IngestResult struct {
+	Inserted   int `json:"inserted"`
+	Duplicates int `json:"duplicates"`
+
+	// InsertedIDs lists the IDs of the inserted events, so callers can act
+	// on new usage only. The bundled stores always fill it in.
+	InsertedIDs []id.UsageEventID `json:"inserted_ids,omitempty"`
+}
+
+// InsertedEvents returns the events of batch that r reports as inserted.
+// Results without InsertedIDs, e.g. from stores that do not fill it in,
+// are taken to cover the whole batch when anything was inserted.
+func (r *IngestResult) InsertedEvents(batch []*UsageEvent) []*UsageEvent {
+	if len(r.InsertedIDs) == 0 {
+		if r.Inserted > 0 {
+			return batch
+		}
+		return nil
+	}
+	if len(r.InsertedIDs) == len(batch) {
+		return batch
+	}
+	inserted := make(map[string]struct{}, len(r.InsertedIDs))
+	for _, evtID := range r.InsertedIDs {
+		inserted[evtID.String()] = struct{}{}
+	}
+	events := make([]*UsageEvent, 0, len(r.InsertedIDs))
+	for _, e := range batch {
+		if _, ok := inserted[e.ID.String()]; ok {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// MatchDimensions reports whether metadata holds every value in dims.
+func MatchDimensions(metadata, dims map[string]string) bool {
+	for k, v := range dims {
+		if got, ok := metadata[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// GroupTotal is the aggregated usage of one group of an AggregateGroups
+// query, identified by its dimension values.
+type GroupTotal struct {
+	Dimensions map[string]string `json:"dimensions"`
+	Value      int64             `json:"value"`
+}
+
+// Label formats the group's dimensions as "key=value" pairs sorted by key,
+// e.g. "model=gpt-large, region=eu".
+func (g *GroupTotal) Label() string {
+	keys := make([]string, 0, len(g.Dimensions))
+	for k := range g.Dimensions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + g.Dimensions[k]
+	}
+	return strings.Join(pairs, ", ")
 }
@@ -0,0 +1,181 @@
+// Package ratelimit keeps the usage counters behind rolling and rate-limit
+// periods, so entitlement checks on them never scan usage events.
+//
+// Two evaluations are provided on top of a Counter. Sliding windows add
+// usage to fixed buckets as long as the window and estimate the trailing
+// window from the current bucket and the overlapping share of the previous
+// one. Token buckets hold up to a capacity of tokens, refill continuously
+// and lose a token per unit of usage.
+//
+// Memory implements Counter for a single process. Deployments running
+// several instances share a Counter backed by Redis or a similar store:
+// Add maps to INCRBY plus PEXPIRE, Get to MGET and Take to a short script
+// that refills and takes tokens atomically.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Counter stores fixed-window buckets and token buckets by key.
+// Implementations must be safe for concurrent use.
+type Counter interface {
+	// Add adds n to the bucket key, which is kept for at least ttl.
+	Add(ctx context.Context, key string, n int64, ttl time.Duration) error
+
+	// Get returns the values of the buckets, zero for missing or expired
+	// ones.
+	Get(ctx context.Context, keys ...string) ([]int64, error)
+
+	// Take refills the token bucket key at capacity tokens per interval up
+	// to capacity, then removes n tokens without going below zero, and
+	// returns the whole tokens left. A bucket seen for the first time is
+	// full; n of zero only reads it.
+	Take(ctx context.Context, key string, capacity int64, interval time.Duration, n int64, now time.Time) (int64, error)
+}
+
+// SlidingAdd records n units at time at in the sliding window of the given
+// length.
+func SlidingAdd(ctx context.Context, c Counter, key string, length time.Duration, n int64, at time.Time) error {
+	return c.Add(ctx, bucketKey(key, at.UnixNano()/int64(length)), n, 2*length)
+}
+
+// SlidingUsage returns the usage in the window of the given length ending
+// at now. Usage in the previous bucket is assumed to be spread evenly over
+// it, so the result is an estimate that is exact at bucket boundaries.
+func SlidingUsage(ctx context.Context, c Counter, key string, length time.Duration, now time.Time) (int64, error) {
+	bucket := now.UnixNano() / int64(length)
+	values, err := c.Get(ctx, bucketKey(key, bucket), bucketKey(key, bucket-1))
+	if err != nil {
+		return 0, err
+	}
+	elapsed := now.UnixNano() - bucket*int64(length)
+	overlap := 1 - float64(elapsed)/float64(length)
+	return values[0] + int64(math.Round(float64(values[1])*overlap)), nil
+}
+
+// SlidingStart returns the start of the fixed bucket holding at in the
+// sliding window of the given length.
+func SlidingStart(length time.Duration, at time.Time) time.Time {
+	return time.Unix(0, at.UnixNano()/int64(length)*int64(length)).UTC()
+}
+
+// bucketKey returns the key of a fixed bucket, numbered from the Unix epoch.
+func bucketKey(key string, bucket int64) string {
+	return key + ":" + strconv.FormatInt(bucket, 10)
+}
+
+// Memory is an in-process Counter. Expired buckets and refilled token
+// buckets are dropped as it is used.
+type Memory struct {
+	mu        sync.Mutex
+	buckets   map[string]memoryBucket
+	tokens    map[string]*tokenBucket
+	nextSweep time.Time
+}
+
+type memoryBucket struct {
+	value   int64
+	expires time.Time
+}
+
+type tokenBucket struct {
+	tokens   float64
+	capacity float64
+	interval time.Duration
+	last     time.Time
+}
+
+// sweepInterval is how often Memory drops expired state.
+const sweepInterval = time.Minute
+
+var _ Counter = (*Memory)(nil)
+
+// NewMemory returns an empty in-process Counter.
+func NewMemory() *Memory {
+	return &Memory{
+		buckets: make(map[string]memoryBucket),
+		tokens:  make(map[string]*tokenBucket),
+	}
+}
+
+// Add implements Counter.
+func (m *Memory) Add(_ context.Context, key string, n int64, ttl time.Duration) error {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep(now)
+
+	b := m.buckets[key]
+	if !b.expires.After(now) {
+		b.value = 0
+	}
+	b.value += n
+	b.expires = now.Add(ttl)
+	m.buckets[key] = b
+	return nil
+}
+
+// Get implements Counter.
+func (m *Memory) Get(_ context.Context, keys ...string) ([]int64, error) {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	values := make([]int64, len(keys))
+	for i, key := range keys {
+		if b, ok := m.buckets[key]; ok && b.expires.After(now) {
+			values[i] = b.value
+		}
+	}
+	return values, nil
+}
+
+// Take implements Counter.
+func (m *Memory) Take(_ context.Context, key string, capacity int64, interval time.Duration, n int64, now time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep(now)
+
+	b, ok := m.tokens[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(capacity), last: now}
+		m.tokens[key] = b
+	}
+	b.capacity, b.interval = float64(capacity), interval
+	b.refill(now)
+	b.tokens = max(0, b.tokens-float64(n))
+	return int64(b.tokens), nil
+}
+
+// refill adds the tokens earned since the last update, up to capacity.
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 && b.interval > 0 {
+		b.tokens = min(b.capacity, b.tokens+b.capacity*float64(elapsed)/float64(b.interval))
+		b.last = now
+	}
+}
+
+// sweep drops expired buckets and full token buckets at most once per
+// sweepInterval. The caller holds m.mu.
+func (m *Memory) sweep(now time.Time) {
+	if now.Before(m.nextSweep) {
+		return
+	}
+	m.nextSweep = now.Add(sweepInterval)
+	for key, b := range m.buckets {
+		if !b.expires.After(now) {
+			delete(m.buckets, key)
+		}
+	}
+	for key, b := range m.tokens {
+		b.refill(now)
+		if b.tokens >= b.capacity {
+			delete(m.tokens, key)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSlidingWindow(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemory()
+	minute := time.Unix(0, 0).Add(1000 * time.Minute)
+
+	// 60 units in the previous minute, 10 in the current one.
+	if err := SlidingAdd(ctx, c, "k", time.Minute, 60, minute.Add(-30*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if err := SlidingAdd(ctx, c, "k", time.Minute, 10, minute.Add(5*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		now  time.Time
+		want int64
+	}{
+		{minute, 10 + 60},
+		{minute.Add(15 * time.Second), 10 + 45},
+		{minute.Add(45 * time.Second), 10 + 15},
+		{minute.Add(time.Minute), 10},
+		{minute.Add(2 * time.Minute), 0},
+	}
+	for _, tt := range tests {
+		got, err := SlidingUsage(ctx, c, "k", time.Minute, tt.now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("usage at +%v = %d, want %d", tt.now.Sub(minute), got, tt.want)
+		}
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemory()
+	now := time.Now()
+
+	take := func(n int64, at time.Time) int64 {
+		t.Helper()
+		left, err := c.Take(ctx, "k", 10, time.Second, n, at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return left
+	}
+
+	if got := take(0, now); got != 10 {
+		t.Fatalf("new bucket has %d tokens, want 10", got)
+	}
+	if got := take(8, now); got != 2 {
+		t.Fatalf("after taking 8: %d tokens, want 2", got)
+	}
+	if got := take(5, now); got != 0 {
+		t.Fatalf("overdrawn bucket has %d tokens, want 0", got)
+	}
+	if got := take(0, now.Add(500*time.Millisecond)); got != 5 {
+		t.Fatalf("after half an interval: %d tokens, want 5", got)
+	}
+	if got := take(0, now.Add(time.Hour)); got != 10 {
+		t.Fatalf("refill exceeded capacity: %d tokens", got)
+	}
+}
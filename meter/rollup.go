@@ -0,0 +1,106 @@
+package meter
+
+import "time"
+
+// Granularity is the bucket size of a usage rollup.
+type Granularity string
+
+const (
+	// GranularityRaw marks a span that must be read from raw usage events.
+	GranularityRaw Granularity = ""
+	// GranularityHour buckets usage by UTC hour.
+	GranularityHour Granularity = "hour"
+	// GranularityDay buckets usage by UTC day.
+	GranularityDay Granularity = "day"
+)
+
+// Rollable reports whether an aggregation can be answered from rollup
+// buckets. Sum, count and max combine across buckets; unique and last do not.
+func (a Aggregation) Rollable() bool {
+	switch a.Kind() {
+	case AggregateSum, AggregateCount, AggregateMax:
+		return true
+	}
+	return false
+}
+
+// BucketStart returns the start of the UTC bucket of size g containing t.
+func BucketStart(g Granularity, t time.Time) time.Time {
+	t = t.UTC()
+	switch g {
+	case GranularityDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case GranularityHour:
+		return t.Truncate(time.Hour)
+	}
+	return t
+}
+
+// Span is a [Start, End) slice of an aggregation window served by one source:
+// daily rollups, hourly rollups or raw events. A zero Start or End is open.
+type Span struct {
+	Granularity Granularity
+	Start       time.Time
+	End         time.Time
+}
+
+// SplitWindow splits the window [start, end) so that whole UTC days are read
+// from daily rollups, the remaining whole hours from hourly rollups and only
+// the sub-hour edges from raw events. A zero start or end leaves that side
+// of the window open. Empty spans are omitted.
+func SplitWindow(start, end time.Time) []Span {
+	// Hour-aligned inner window [hs, he) and day-aligned [ds, de) inside it.
+	// Zero values stand for an open side and are carried through.
+	hs, he := ceilBucket(GranularityHour, start), floorBucket(GranularityHour, end)
+	if !start.IsZero() && !end.IsZero() && !hs.Before(he) {
+		return []Span{{Granularity: GranularityRaw, Start: start, End: end}}
+	}
+	ds, de := ceilBucket(GranularityDay, hs), floorBucket(GranularityDay, he)
+	if !hs.IsZero() && !he.IsZero() && !ds.Before(de) {
+		// No whole day inside the window: hours only.
+		ds, de = he, he
+	}
+
+	var spans []Span
+	add := func(g Granularity, from, to time.Time) {
+		if from.Before(to) {
+			spans = append(spans, Span{Granularity: g, Start: from, End: to})
+		}
+	}
+	if !start.IsZero() {
+		add(GranularityRaw, start, hs)
+		add(GranularityHour, hs, ds)
+	}
+	if ds.IsZero() || de.IsZero() || ds.Before(de) {
+		spans = append(spans, Span{Granularity: GranularityDay, Start: ds, End: de})
+	}
+	if !end.IsZero() {
+		add(GranularityHour, de, he)
+		add(GranularityRaw, he, end)
+	}
+	return spans
+}
+
+// ceilBucket returns the start of the first bucket of size g at or after t.
+// A zero t stays zero.
+func ceilBucket(g Granularity, t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	b := BucketStart(g, t)
+	if b.Equal(t) {
+		return b
+	}
+	if g == GranularityDay {
+		return b.AddDate(0, 0, 1)
+	}
+	return b.Add(time.Hour)
+}
+
+// floorBucket is BucketStart that keeps a zero t zero.
+func floorBucket(g Granularity, t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return BucketStart(g, t)
+}
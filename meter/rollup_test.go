@@ -0,0 +1,80 @@
+package meter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSplitWindow(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 3, day, hour, minute, 0, 0, time.UTC)
+	}
+	var open time.Time
+
+	tests := []struct {
+		name       string
+		start, end time.Time
+		want       []Span
+	}{
+		{
+			"Within one hour", at(1, 10, 5), at(1, 10, 50),
+			[]Span{{GranularityRaw, at(1, 10, 5), at(1, 10, 50)}},
+		},
+		{
+			"Hours only", at(1, 10, 30), at(1, 14, 15),
+			[]Span{
+				{GranularityRaw, at(1, 10, 30), at(1, 11, 0)},
+				{GranularityHour, at(1, 11, 0), at(1, 14, 0)},
+				{GranularityRaw, at(1, 14, 0), at(1, 14, 15)},
+			},
+		},
+		{
+			"Days and hours", at(1, 22, 30), at(4, 3, 0),
+			[]Span{
+				{GranularityRaw, at(1, 22, 30), at(1, 23, 0)},
+				{GranularityHour, at(1, 23, 0), at(2, 0, 0)},
+				{GranularityDay, at(2, 0, 0), at(4, 0, 0)},
+				{GranularityHour, at(4, 0, 0), at(4, 3, 0)},
+			},
+		},
+		{
+			"Aligned days", at(1, 0, 0), at(31, 0, 0),
+			[]Span{{GranularityDay, at(1, 0, 0), at(31, 0, 0)}},
+		},
+		{
+			"Open start", open, at(2, 5, 10),
+			[]Span{
+				{GranularityDay, open, at(2, 0, 0)},
+				{GranularityHour, at(2, 0, 0), at(2, 5, 0)},
+				{GranularityRaw, at(2, 5, 0), at(2, 5, 10)},
+			},
+		},
+		{
+			"Open end", at(1, 23, 0), open,
+			[]Span{
+				{GranularityHour, at(1, 23, 0), at(2, 0, 0)},
+				{GranularityDay, at(2, 0, 0), open},
+			},
+		},
+		{
+			"Fully open", open, open,
+			[]Span{{GranularityDay, open, open}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitWindow(tt.start, tt.end)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d spans %v, want %d %v", len(got), got, len(tt.want), tt.want)
+			}
+			for i := range got {
+				if got[i].Granularity != tt.want[i].Granularity ||
+					!got[i].Start.Equal(tt.want[i].Start) ||
+					!got[i].End.Equal(tt.want[i].End) {
+					t.Errorf("span %d: got %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package meter
+
+import (
+	"math"
+	"time"
+)
+
+// SeatReading is a value of a seat gauge: the number of seats a tenant holds
+// for a seat feature from Timestamp until the next reading.
+type SeatReading struct {
+	TenantID   string    `json:"tenant_id"`
+	AppID      string    `json:"app_id"`
+	FeatureKey string    `json:"feature_key"`
+	Seats      int64     `json:"seats"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// MaxSeats returns the highest seat count of a window that starts with
+// initial seats and sees the given readings.
+func MaxSeats(initial int64, readings []*SeatReading) int64 {
+	peak := initial
+	for _, r := range readings {
+		peak = max(peak, r.Seats)
+	}
+	return peak
+}
+
+// AverageSeats returns the time-weighted average seat count over
+// [start, end), rounded to the nearest seat, for a window that starts with
+// initial seats and sees the given readings, oldest first.
+func AverageSeats(initial int64, readings []*SeatReading, start, end time.Time) int64 {
+	total := end.Sub(start)
+	if total <= 0 {
+		return MaxSeats(initial, readings)
+	}
+
+	var weighted float64
+	seats, from := initial, start
+	for _, r := range readings {
+		at := r.Timestamp
+		if at.Before(start) {
+			at = start
+		}
+		if at.After(end) {
+			break
+		}
+		weighted += float64(seats) * float64(at.Sub(from))
+		seats, from = r.Seats, at
+	}
+	weighted += float64(seats) * float64(end.Sub(from))
+	return int64(math.Round(weighted / float64(total)))
+}
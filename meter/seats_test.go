@@ -0,0 +1,36 @@
+package meter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSeatUsage(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2024, 4, d, 0, 0, 0, 0, time.UTC)
+	}
+	start, end := day(1), day(31) // 30 days
+
+	tests := []struct {
+		name     string
+		initial  int64
+		readings []*SeatReading
+		max, avg int64
+	}{
+		{"No readings", 4, nil, 4, 4},
+		{"Added mid-period", 2, []*SeatReading{{Seats: 8, Timestamp: day(16)}}, 8, 5},
+		{"Short spike", 1, []*SeatReading{{Seats: 10, Timestamp: day(10)}, {Seats: 1, Timestamp: day(11)}}, 10, 1},
+		{"Reading at start", 0, []*SeatReading{{Seats: 3, Timestamp: start}}, 3, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MaxSeats(tt.initial, tt.readings); got != tt.max {
+				t.Errorf("MaxSeats() = %d, want %d", got, tt.max)
+			}
+			if got := AverageSeats(tt.initial, tt.readings, start, end); got != tt.avg {
+				t.Errorf("AverageSeats() = %d, want %d", got, tt.avg)
+			}
+		})
+	}
+}
@@ -4,21 +4,68 @@ import (
 	"context"
 	"time"
 
-	"github.com/xraph/ledger/plan"
+	"github.com/xraph/ledger/id"
 )
 
 type Store interface {
-	IngestBatch(ctx context.Context, events []*UsageEvent) error
-	Aggregate(ctx context.Context, tenantID, appID, featureKey string, period plan.Period) (int64, error)
-	AggregateMulti(ctx context.Context, tenantID, appID string, featureKeys []string, period plan.Period) (map[string]int64, error)
+	IngestBatch(ctx context.Context, events []*UsageEvent) (*IngestResult, error)
+	// Aggregate and AggregateMulti reduce usage with timestamps in
+	// [start, end) using the given built-in aggregation. A zero start or end
+	// leaves that side of the range open. AggregateMulti is keyed by feature.
+	Aggregate(ctx context.Context, tenantID, appID, featureKey string, agg Aggregation, start, end time.Time) (int64, error)
+	AggregateMulti(ctx context.Context, tenantID, appID string, features map[string]Aggregation, start, end time.Time) (map[string]int64, error)
+	// AggregateGroups reduces the usage of opts.FeatureKey with timestamps in
+	// [opts.Start, opts.End) separately for every combination of the
+	// opts.GroupBy metadata values. Events missing a grouped key fall in the
+	// group with an empty value for it.
+	AggregateGroups(ctx context.Context, tenantID, appID string, agg Aggregation, opts QueryOpts) ([]*GroupTotal, error)
 	Query(ctx context.Context, tenantID, appID string, opts QueryOpts) ([]*UsageEvent, error)
 	Purge(ctx context.Context, before time.Time) (int64, error)
+	// Void marks one event as voided, excluding it from every aggregation,
+	// and returns it.
+	Void(ctx context.Context, tenantID, appID string, opts VoidOpts) (*UsageEvent, error)
+	// SumCorrections totals the correction events of each feature with
+	// timestamps in [start, end). Aggregate and AggregateMulti ignore
+	// corrections.
+	SumCorrections(ctx context.Context, tenantID, appID string, featureKeys []string, start, end time.Time) (map[string]int64, error)
+	// Lifetime returns the running totals of the given features, keyed by
+	// feature. Features without usage are missing from the map.
+	Lifetime(ctx context.Context, tenantID, appID string, featureKeys []string) (map[string]LifetimeTotal, error)
+
+	// Scan returns up to limit events of an app with timestamps before
+	// before, oldest first by timestamp and ID, for archiving.
+	Scan(ctx context.Context, appID string, before time.Time, limit int) ([]*UsageEvent, error)
+	// Delete removes events by ID. Rollups keep the deleted usage, so
+	// aggregations over archived periods are unchanged.
+	Delete(ctx context.Context, eventIDs []id.UsageEventID) (int64, error)
+	// Restore inserts archived events back, skipping those already stored,
+	// without refreshing the rollups or lifetime totals that still hold
+	// their usage.
+	Restore(ctx context.Context, events []*UsageEvent) (*IngestResult, error)
+
+	// RecordSeats appends a reading to a seat feature's gauge.
+	RecordSeats(ctx context.Context, r *SeatReading) error
+	// CurrentSeats returns the latest seat reading of a feature at or before
+	// at, or 0 when there is none.
+	CurrentSeats(ctx context.Context, tenantID, appID, featureKey string, at time.Time) (int64, error)
+	// SeatReadings returns the readings of a feature with timestamps in
+	// [start, end), oldest first.
+	SeatReadings(ctx context.Context, tenantID, appID, featureKey string, start, end time.Time) ([]*SeatReading, error)
 }
 
+// QueryOpts filters usage events. Start and End bound their timestamps to
+// [Start, End), the window Aggregate reduces; a zero Start or End leaves
+// that side open.
 type QueryOpts struct {
 	FeatureKey string
 	Start      time.Time
 	End        time.Time
 	Limit      int
 	Offset     int
+
+	// Dimensions restricts the query to events whose metadata holds every
+	// given value.
+	Dimensions map[string]string
+	// GroupBy lists the metadata keys AggregateGroups groups usage by.
+	GroupBy []string
 }
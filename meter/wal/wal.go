@@ -0,0 +1,445 @@
+// Package wal provides a segmented, on-disk write-ahead log for usage events.
+//
+// Events are appended (and fsynced) before Ledger.Meter acknowledges them and
+// acknowledged again once the meter buffer has flushed them to the store.
+// Segments whose events have all been flushed are removed, so the log only
+// ever holds usage that may not have reached the store yet. Events found on
+// disk when the log is opened are returned by Replay so they can be flushed
+// again, which makes usage capture at-least-once.
+//
+// Concurrent appends are group-committed: records queued while a sync is in
+// progress are written and synced together by the next one.
+package wal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/xraph/ledger/meter"
+)
+
+// DefaultSegmentSize is the size in bytes after which the active segment is
+// sealed and a new one is started.
+const DefaultSegmentSize int64 = 16 << 20
+
+const segmentExt = ".wal"
+
+var (
+	// ErrDuplicate is returned by Append for an event whose ID is already
+	// in the log and not yet acknowledged.
+	ErrDuplicate = errors.New("ledger/wal: event already pending")
+
+	// ErrCorrupt is returned when a segment holds an undecodable record
+	// that is not its last one, which a crash mid-write cannot explain.
+	ErrCorrupt = errors.New("ledger/wal: corrupt segment")
+)
+
+// Log is a write-ahead log of usage events stored as NDJSON segment files in
+// a single directory. It is safe for concurrent use.
+type Log struct {
+	dir         string
+	segmentSize int64
+
+	// ioMu serializes writes to, rotations and truncations of the active
+	// segment. It is taken before mu.
+	ioMu sync.Mutex
+
+	mu         sync.Mutex
+	active     *os.File
+	activeSeq  uint64
+	activeSize int64
+	pending    map[uint64]int      // segment sequence -> unflushed events
+	owner      map[string]uint64   // event ID -> segment sequence
+	queued     map[string]struct{} // event IDs appended but not yet synced
+	next       *commit             // records waiting for the next sync
+	committing bool                // a sync is in progress
+	recovered  []*meter.UsageEvent
+}
+
+// commit is a group of records written and synced together. The first
+// appender leads it; the others wait for done, or for lead when the
+// previous commit hands leadership to them.
+type commit struct {
+	buf  []byte
+	ids  []string
+	lead chan struct{}
+	done chan struct{}
+	err  error
+}
+
+// Open opens (creating if needed) the log in dir and loads every event still
+// on disk. A segmentSize <= 0 uses DefaultSegmentSize.
+func Open(dir string, segmentSize int64) (*Log, error) {
+	if segmentSize <= 0 {
+		segmentSize = DefaultSegmentSize
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("ledger/wal: create dir: %w", err)
+	}
+
+	w := &Log{
+		dir:         dir,
+		segmentSize: segmentSize,
+		pending:     make(map[uint64]int),
+		owner:       make(map[string]uint64),
+		queued:      make(map[string]struct{}),
+	}
+
+	seqs, err := w.segments()
+	if err != nil {
+		return nil, err
+	}
+	for _, seq := range seqs {
+		events, err := readSegment(w.path(seq))
+		if err != nil {
+			return nil, err
+		}
+		if len(events) == 0 {
+			_ = os.Remove(w.path(seq)) //nolint:errcheck // best-effort cleanup of empty segment
+			continue
+		}
+		// An ID logged again after it was acknowledged is replayed once.
+		for _, e := range events {
+			key := e.ID.String()
+			if _, ok := w.owner[key]; ok {
+				continue
+			}
+			w.owner[key] = seq
+			w.pending[seq]++
+			w.recovered = append(w.recovered, e)
+		}
+		if w.pending[seq] == 0 {
+			_ = os.Remove(w.path(seq)) //nolint:errcheck // best-effort cleanup of replayed duplicates
+		}
+	}
+
+	next := uint64(1)
+	if len(seqs) > 0 {
+		next = seqs[len(seqs)-1] + 1
+	}
+	if err := w.openSegment(next); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Replay returns the events that were on disk when the log was opened and
+// have not been acknowledged since. Each event is returned only once.
+func (w *Log) Replay() []*meter.UsageEvent {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	events := w.recovered
+	w.recovered = nil
+	return events
+}
+
+// Pending reads back up to limit unacknowledged events from disk, oldest
+// segment first, including events recovered when the log was opened. A
+// limit <= 0 returns every pending event. Unlike Replay, events are returned
+// again on every call until they are acknowledged.
+func (w *Log) Pending(limit int) ([]*meter.UsageEvent, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	seqs := make([]uint64, 0, len(w.pending))
+	for seq := range w.pending {
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+
+	var events []*meter.UsageEvent
+	for _, seq := range seqs {
+		segment, err := readSegment(w.path(seq))
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range segment {
+			if owner, ok := w.owner[e.ID.String()]; !ok || owner != seq {
+				continue
+			}
+			events = append(events, e)
+			if limit > 0 && len(events) >= limit {
+				return events, nil
+			}
+		}
+	}
+	return events, nil
+}
+
+// Len returns the number of unacknowledged events in the log.
+func (w *Log) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.owner)
+}
+
+// Append durably records an event. It returns only after the record has been
+// written and synced to disk, together with the records appended
+// concurrently. An event whose ID is still pending is rejected with
+// ErrDuplicate, since acknowledging it once would leave the other copy in
+// the log forever.
+func (w *Log) Append(e *meter.UsageEvent) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("ledger/wal: encode event: %w", err)
+	}
+	line = append(line, '\n')
+	key := e.ID.String()
+
+	w.mu.Lock()
+	if w.active == nil {
+		w.mu.Unlock()
+		return errors.New("ledger/wal: log is closed")
+	}
+	if _, ok := w.owner[key]; ok {
+		w.mu.Unlock()
+		return ErrDuplicate
+	}
+	if _, ok := w.queued[key]; ok {
+		w.mu.Unlock()
+		return ErrDuplicate
+	}
+	w.queued[key] = struct{}{}
+
+	c := w.next
+	if c == nil {
+		c = &commit{lead: make(chan struct{}, 1), done: make(chan struct{})}
+		w.next = c
+	}
+	c.buf = append(c.buf, line...)
+	c.ids = append(c.ids, key)
+
+	leader := !w.committing
+	w.committing = true
+	w.mu.Unlock()
+
+	if leader {
+		w.sync()
+	}
+	select {
+	case <-c.done:
+	case <-c.lead:
+		w.sync()
+	}
+	return c.err
+}
+
+// sync writes and syncs the waiting records, then hands leadership to the
+// records queued in the meantime, if any.
+func (w *Log) sync() {
+	w.ioMu.Lock()
+	defer w.ioMu.Unlock()
+
+	w.mu.Lock()
+	c := w.next
+	w.next = nil
+	var err error
+	switch {
+	case w.active == nil:
+		err = errors.New("ledger/wal: log is closed")
+	case w.activeSize > 0 && w.activeSize+int64(len(c.buf)) > w.segmentSize:
+		err = w.rotate()
+	}
+	file, seq := w.active, w.activeSeq
+	w.mu.Unlock()
+
+	// Only the records are written outside mu; ioMu keeps Ack from
+	// truncating the segment meanwhile.
+	if err == nil {
+		if _, werr := file.Write(c.buf); werr != nil {
+			err = fmt.Errorf("ledger/wal: write: %w", werr)
+		} else if serr := file.Sync(); serr != nil {
+			err = fmt.Errorf("ledger/wal: sync: %w", serr)
+		}
+	}
+
+	w.mu.Lock()
+	for _, key := range c.ids {
+		delete(w.queued, key)
+		if err == nil {
+			w.owner[key] = seq
+		}
+	}
+	if err == nil {
+		w.activeSize += int64(len(c.buf))
+		w.pending[seq] += len(c.ids)
+	}
+	if w.next != nil {
+		w.next.lead <- struct{}{}
+	} else {
+		w.committing = false
+	}
+	w.mu.Unlock()
+
+	c.err = err
+	close(c.done)
+}
+
+// Ack marks events as flushed to the store. Sealed segments with no pending
+// events left are deleted, and the active segment is truncated once all of
+// its events have been flushed.
+func (w *Log) Ack(events []*meter.UsageEvent) error {
+	w.ioMu.Lock()
+	defer w.ioMu.Unlock()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	touched := make(map[uint64]struct{})
+	for _, e := range events {
+		key := e.ID.String()
+		seq, ok := w.owner[key]
+		if !ok {
+			continue
+		}
+		delete(w.owner, key)
+		w.pending[seq]--
+		touched[seq] = struct{}{}
+	}
+
+	var errs []error
+	for seq := range touched {
+		if w.pending[seq] > 0 {
+			continue
+		}
+		delete(w.pending, seq)
+		if seq == w.activeSeq {
+			if err := w.truncateActive(); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if err := os.Remove(w.path(seq)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("ledger/wal: remove segment: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes the active segment. Unflushed events stay on disk and are
+// replayed the next time the log is opened.
+func (w *Log) Close() error {
+	w.ioMu.Lock()
+	defer w.ioMu.Unlock()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.active == nil {
+		return nil
+	}
+	err := w.active.Close()
+	w.active = nil
+	if w.activeSize == 0 {
+		_ = os.Remove(w.path(w.activeSeq)) //nolint:errcheck // best-effort cleanup of empty segment
+	}
+	return err
+}
+
+// rotate seals the active segment and opens the next one. Must be called
+// with w.ioMu and w.mu held.
+func (w *Log) rotate() error {
+	if err := w.active.Close(); err != nil {
+		return fmt.Errorf("ledger/wal: close segment: %w", err)
+	}
+	if w.pending[w.activeSeq] == 0 {
+		_ = os.Remove(w.path(w.activeSeq)) //nolint:errcheck // best-effort cleanup of flushed segment
+	}
+	return w.openSegment(w.activeSeq + 1)
+}
+
+// truncateActive empties the active segment in place. Must be called with
+// w.ioMu and w.mu held.
+func (w *Log) truncateActive() error {
+	if w.active == nil {
+		return nil
+	}
+	if err := w.active.Truncate(0); err != nil {
+		return fmt.Errorf("ledger/wal: truncate segment: %w", err)
+	}
+	if _, err := w.active.Seek(0, 0); err != nil {
+		return fmt.Errorf("ledger/wal: truncate segment: %w", err)
+	}
+	w.activeSize = 0
+	return nil
+}
+
+func (w *Log) openSegment(seq uint64) error {
+	f, err := os.OpenFile(w.path(seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("ledger/wal: open segment: %w", err)
+	}
+	w.active = f
+	w.activeSeq = seq
+	w.activeSize = 0
+	return nil
+}
+
+func (w *Log) path(seq uint64) string {
+	return filepath.Join(w.dir, fmt.Sprintf("%020d%s", seq, segmentExt))
+}
+
+// segments returns the sequence numbers of the segment files in the log
+// directory in ascending order.
+func (w *Log) segments() ([]uint64, error) {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return nil, fmt.Errorf("ledger/wal: read dir: %w", err)
+	}
+	var seqs []uint64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	return seqs, nil
+}
+
+// readSegment decodes every complete record in a segment. A torn final
+// record, left by a crash mid-write, is ignored; an undecodable record
+// followed by others returns ErrCorrupt.
+func readSegment(path string) ([]*meter.UsageEvent, error) {
+	f, err := os.Open(path) //nolint:gosec // path is built from the configured WAL directory
+	if err != nil {
+		return nil, fmt.Errorf("ledger/wal: open segment: %w", err)
+	}
+	defer f.Close()
+
+	var events []*meter.UsageEvent
+	torn := 0 // line number of an undecodable record, if any
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if torn > 0 {
+			return nil, fmt.Errorf("%w: %s line %d", ErrCorrupt, filepath.Base(path), torn)
+		}
+		var e meter.UsageEvent
+		if err := json.Unmarshal(line, &e); err != nil {
+			torn = n
+			continue
+		}
+		events = append(events, &e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ledger/wal: read segment: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,318 @@
+package wal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xraph/ledger/id"
+	"github.com/xraph/ledger/meter"
+)
+
+func newEvents(n int) []*meter.UsageEvent {
+	at := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	events := make([]*meter.UsageEvent, n)
+	for i := range events {
+		events[i] = &meter.UsageEvent{
+			ID: id.NewUsageEventID(), TenantID: "t1", AppID: "app", FeatureKey: "api_calls",
+			Quantity: int64(i + 1), Timestamp: at.Add(time.Duration(i) * time.Second),
+		}
+	}
+	return events
+}
+
+func openLog(t *testing.T, dir string, segmentSize int64) *Log {
+	t.Helper()
+	w, err := Open(dir, segmentSize)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	return w
+}
+
+func appendAll(t *testing.T, w *Log, events []*meter.UsageEvent) {
+	t.Helper()
+	for _, e := range events {
+		if err := w.Append(e); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+}
+
+func ids(events []*meter.UsageEvent) []string {
+	out := make([]string, len(events))
+	for i, e := range events {
+		out[i] = e.ID.String()
+	}
+	return out
+}
+
+func sameIDs(t *testing.T, got, want []*meter.UsageEvent) {
+	t.Helper()
+	g, w := ids(got), ids(want)
+	if len(g) != len(w) {
+		t.Fatalf("got %d events, want %d", len(g), len(w))
+	}
+	for i := range g {
+		if g[i] != w[i] {
+			t.Fatalf("event %d: got %s, want %s", i, g[i], w[i])
+		}
+	}
+}
+
+func segmentFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	names, err := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return names
+}
+
+func TestAppendAckPending(t *testing.T) {
+	w := openLog(t, t.TempDir(), 0)
+	defer w.Close()
+
+	events := newEvents(5)
+	appendAll(t, w, events)
+	if w.Len() != 5 {
+		t.Fatalf("Len = %d, want 5", w.Len())
+	}
+
+	got, err := w.Pending(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameIDs(t, got, events[:3])
+
+	if err := w.Ack(events[:2]); err != nil {
+		t.Fatal(err)
+	}
+	got, err = w.Pending(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameIDs(t, got, events[2:])
+
+	// Acknowledging everything truncates the active segment.
+	if err := w.Ack(events); err != nil {
+		t.Fatal(err)
+	}
+	if w.Len() != 0 {
+		t.Fatalf("Len after Ack = %d, want 0", w.Len())
+	}
+	info, err := os.Stat(w.path(w.activeSeq))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != 0 {
+		t.Errorf("active segment size = %d, want 0", info.Size())
+	}
+}
+
+func TestReopenReplays(t *testing.T) {
+	dir := t.TempDir()
+	w := openLog(t, dir, 0)
+	events := newEvents(4)
+	appendAll(t, w, events)
+	if err := w.Ack(events[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Append(newEvents(1)[0]); err == nil {
+		t.Fatal("Append after Close succeeded")
+	}
+
+	// The acknowledged event shares its segment with pending ones, so it is
+	// still on disk and replayed as well: capture is at-least-once.
+	w = openLog(t, dir, 0)
+	defer w.Close()
+	got := w.Replay()
+	sameIDs(t, got, events)
+	if again := w.Replay(); len(again) != 0 {
+		t.Errorf("second Replay returned %d events, want 0", len(again))
+	}
+
+	// Replayed events stay pending until they are acknowledged.
+	pending, err := w.Pending(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameIDs(t, pending, events)
+	if err := w.Ack(got); err != nil {
+		t.Fatal(err)
+	}
+	if files := segmentFiles(t, dir); len(files) != 1 {
+		t.Errorf("segments after Ack = %v, want only the active one", files)
+	}
+}
+
+func TestSegmentRotation(t *testing.T) {
+	dir := t.TempDir()
+	// Small enough that every record starts a new segment.
+	w := openLog(t, dir, 64)
+	defer w.Close()
+
+	events := newEvents(4)
+	appendAll(t, w, events)
+	if files := segmentFiles(t, dir); len(files) != 4 {
+		t.Fatalf("got %d segments, want 4", len(files))
+	}
+
+	got, err := w.Pending(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameIDs(t, got, events)
+
+	// Sealed segments are removed once their events are acknowledged.
+	if err := w.Ack(events[:2]); err != nil {
+		t.Fatal(err)
+	}
+	if files := segmentFiles(t, dir); len(files) != 2 {
+		t.Fatalf("got %d segments after Ack, want 2", len(files))
+	}
+}
+
+func TestTornTail(t *testing.T) {
+	dir := t.TempDir()
+	w := openLog(t, dir, 0)
+	events := newEvents(2)
+	appendAll(t, w, events)
+	path := w.path(w.activeSeq)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A crash mid-write leaves half a record at the end of the segment.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"id":"usage_01h`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	w = openLog(t, dir, 0)
+	defer w.Close()
+	sameIDs(t, w.Replay(), events)
+
+	// New records go to a new segment, so the torn one stays last.
+	more := newEvents(1)
+	appendAll(t, w, more)
+	got, err := w.Pending(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameIDs(t, got, append(events, more...))
+}
+
+func TestCorruptSegment(t *testing.T) {
+	dir := t.TempDir()
+	w := openLog(t, dir, 0)
+	appendAll(t, w, newEvents(1))
+	path := w.path(w.activeSeq)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A bad record followed by good ones is not a torn write.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, append([]byte("not json\n"), data...), 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open(dir, 0); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("Open error = %v, want ErrCorrupt", err)
+	}
+}
+
+func TestDuplicateAppend(t *testing.T) {
+	dir := t.TempDir()
+	w := openLog(t, dir, 0)
+	defer w.Close()
+
+	e := newEvents(1)[0]
+	appendAll(t, w, []*meter.UsageEvent{e})
+	if err := w.Append(e); !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("second Append error = %v, want ErrDuplicate", err)
+	}
+	if err := w.Ack([]*meter.UsageEvent{e}); err != nil {
+		t.Fatal(err)
+	}
+	if w.Len() != 0 {
+		t.Fatalf("Len = %d, want 0", w.Len())
+	}
+
+	// Once acknowledged, the ID may be logged again. The segment now holds
+	// it twice; reopening counts it once, so one Ack frees it.
+	if err := w.Append(e); err != nil {
+		t.Fatalf("Append after Ack: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	w = openLog(t, dir, 0)
+	defer w.Close()
+	sameIDs(t, w.Replay(), []*meter.UsageEvent{e})
+	if err := w.Ack([]*meter.UsageEvent{e}); err != nil {
+		t.Fatal(err)
+	}
+	if files := segmentFiles(t, dir); len(files) != 1 {
+		t.Errorf("segments after Ack = %v, want only the active one", files)
+	}
+}
+
+func TestConcurrentAppend(t *testing.T) {
+	dir := t.TempDir()
+	w := openLog(t, dir, 4<<10)
+
+	events := newEvents(200)
+	var wg sync.WaitGroup
+	errs := make(chan error, len(events))
+	for _, e := range events {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- w.Append(e)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	if w.Len() != len(events) {
+		t.Fatalf("Len = %d, want %d", w.Len(), len(events))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	w = openLog(t, dir, 4<<10)
+	defer w.Close()
+	got := w.Replay()
+	if len(got) != len(events) {
+		t.Fatalf("replayed %d events, want %d", len(got), len(events))
+	}
+	seen := make(map[string]bool)
+	for _, e := range got {
+		seen[e.ID.String()] = true
+	}
+	for _, e := range events {
+		if !seen[e.ID.String()] {
+			t.Fatalf("event %s was not replayed", e.ID)
+		}
+	}
+}
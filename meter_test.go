@@ -0,0 +1,202 @@
+package ledger_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xraph/ledger"
+	"github.com/xraph/ledger/meter"
+	"github.com/xraph/ledger/store/memory"
+)
+
+// gatedStore holds every IngestBatch call until open is called, so the
+// flush worker stays busy and the meter buffer fills up.
+type gatedStore struct {
+	*memory.Store
+	entered chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func newGatedStore() *gatedStore {
+	return &gatedStore{Store: memory.New(), entered: make(chan struct{}, 1), release: make(chan struct{})}
+}
+
+func (s *gatedStore) IngestBatch(ctx context.Context, events []*meter.UsageEvent) (*meter.IngestResult, error) {
+	select {
+	case s.entered <- struct{}{}:
+	default:
+	}
+	<-s.release
+	return s.Store.IngestBatch(ctx, events)
+}
+
+// open lets held and future IngestBatch calls through.
+func (s *gatedStore) open() {
+	s.once.Do(func() { close(s.release) })
+}
+
+// fillBuffer meters one event of tenant t1 for the busy flush worker and
+// one for the single buffer slot.
+func fillBuffer(t *testing.T, l *ledger.Ledger, s *gatedStore) {
+	t.Helper()
+	ctx := tenantContext("t1", "app")
+	if err := l.Meter(ctx, "api_calls", 1); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-s.entered:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the flush to start")
+	}
+	if err := l.Meter(ctx, "api_calls", 1); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func startGated(t *testing.T, s *gatedStore, opts ...ledger.Option) *ledger.Ledger {
+	t.Helper()
+	opts = append([]ledger.Option{
+		ledger.WithMeterConfig(1, time.Hour),
+		ledger.WithMeterBufferSize(1),
+	}, opts...)
+	l := startLedger(t, s, opts...)
+	t.Cleanup(s.open) // runs before the Ledger is stopped
+	return l
+}
+
+func TestOverflowReject(t *testing.T) {
+	s := newGatedStore()
+	l := startGated(t, s)
+
+	ctx := tenantContext("t1", "app")
+	fillBuffer(t, l, s)
+	if err := l.Meter(ctx, "api_calls", 1); !errors.Is(err, ledger.ErrMeterBufferFull) {
+		t.Fatalf("Meter error = %v, want ErrMeterBufferFull", err)
+	}
+}
+
+func TestOverflowBlock(t *testing.T) {
+	s := newGatedStore()
+	l := startGated(t, s, ledger.WithMeterOverflow(ledger.OverflowBlock))
+
+	ctx := tenantContext("t1", "app")
+	fillBuffer(t, l, s)
+
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	err := l.Meter(timeout, "api_calls", 1)
+	if !errors.Is(err, ledger.ErrMeterBufferFull) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Meter error = %v, want ErrMeterBufferFull wrapping the deadline", err)
+	}
+
+	// Without a deadline Meter waits for the worker to make room.
+	done := make(chan error, 1)
+	go func() { done <- l.Meter(ctx, "api_calls", 1) }()
+	select {
+	case err := <-done:
+		t.Fatalf("Meter returned %v before the buffer had room", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	s.open()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "the blocked event to be flushed", func() bool { return usageCount(t, s.Store, "t1") == 3 })
+}
+
+func TestOverflowDrop(t *testing.T) {
+	s := newGatedStore()
+	var (
+		mu      sync.Mutex
+		dropped []*meter.UsageEvent
+	)
+	l := startGated(t, s,
+		ledger.WithMeterOverflow(ledger.OverflowDrop),
+		ledger.WithMeterDropHandler(func(_ context.Context, event *meter.UsageEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			dropped = append(dropped, event)
+		}),
+	)
+
+	ctx := tenantContext("t1", "app")
+	fillBuffer(t, l, s)
+	if err := l.Meter(ctx, "api_calls", 7); err != nil {
+		t.Fatalf("Meter error = %v, want nil", err)
+	}
+	mu.Lock()
+	if len(dropped) != 1 || dropped[0].Quantity != 7 {
+		t.Errorf("drop handler got %+v, want the overflowing event", dropped)
+	}
+	mu.Unlock()
+	if got := l.MeterStats().Dropped; got != 1 {
+		t.Errorf("Dropped = %d, want 1", got)
+	}
+
+	s.open()
+	eventually(t, "the buffered events to be flushed", func() bool { return usageCount(t, s.Store, "t1") == 2 })
+}
+
+func TestOverflowSpill(t *testing.T) {
+	s := newGatedStore()
+	l := startGated(t, s,
+		ledger.WithMeterConfig(1, 5*time.Millisecond),
+		ledger.WithMeterOverflow(ledger.OverflowSpill),
+		ledger.WithMeterSpillDir(t.TempDir()),
+	)
+
+	ctx := tenantContext("t1", "app")
+	fillBuffer(t, l, s)
+	for range 3 {
+		if err := l.Meter(ctx, "api_calls", 1); err != nil {
+			t.Fatalf("Meter error = %v, want nil", err)
+		}
+	}
+	if got := l.MeterStats().Spilled; got != 3 {
+		t.Fatalf("Spilled = %d, want 3", got)
+	}
+
+	s.open()
+	eventually(t, "the spill log to be drained", func() bool {
+		return l.MeterStats().Spilled == 0 && usageCount(t, s.Store, "t1") == 5
+	})
+	// Later drains must not write the spilled events again.
+	time.Sleep(20 * time.Millisecond)
+	if got := l.MeterStats().Flushed; got != 5 {
+		t.Errorf("Flushed = %d, want each of the 5 events once", got)
+	}
+}
+
+func TestMeterWithOptions(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	l := startLedger(t, s, ledger.WithMeterConfig(1, 5*time.Millisecond))
+
+	at := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	opts := ledger.MeterOptions{
+		Timestamp:      at,
+		IdempotencyKey: "req-1",
+		Properties:     map[string]string{"region": "eu"},
+		TenantID:       "t2",
+	}
+	if err := l.MeterWithOptions(tenantContext("t1", "app"), "api_calls", 3, opts); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "the event to be flushed", func() bool { return usageCount(t, s, "t2") == 1 })
+
+	events, err := s.QueryUsage(ctx, "t2", "app", meter.QueryOpts{FeatureKey: "api_calls"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := events[0]
+	if e.Quantity != 3 || !e.Timestamp.Equal(at) || e.IdempotencyKey != "req-1" || e.Metadata["region"] != "eu" {
+		t.Errorf("stored event = %+v, want 3 units at %v keyed req-1 in region eu", e, at)
+	}
+	if n := usageCount(t, s, "t1"); n != 0 {
+		t.Errorf("context tenant got %d events, want the explicit tenant to win", n)
+	}
+}
@@ -22,6 +22,8 @@ var (
 	_ plugin.OnSubscriptionExpired  = (*MetricsExtension)(nil)
 	_ plugin.OnUsageIngested        = (*MetricsExtension)(nil)
 	_ plugin.OnUsageFlushed         = (*MetricsExtension)(nil)
+	_ plugin.OnUsageShardFlushed    = (*MetricsExtension)(nil)
+	_ plugin.OnUsageRejected        = (*MetricsExtension)(nil)
 	_ plugin.OnEntitlementChecked   = (*MetricsExtension)(nil)
 	_ plugin.OnQuotaExceeded        = (*MetricsExtension)(nil)
 	_ plugin.OnInvoiceGenerated     = (*MetricsExtension)(nil)
@@ -68,6 +70,9 @@ type MetricsExtension struct {
 	UsageEventsIngested Counter
 	UsageBatchSize      Histogram
 	UsageFlushLatency   Histogram
+	UsageBufferDepth    Histogram
+	UsageFlushLag       Histogram
+	UsageRejected       Counter
 
 	// Entitlement metrics
 	EntitlementChecks      Counter
@@ -116,6 +121,9 @@ func NewMetricsExtension(factory MetricFactory) *MetricsExtension {
 		UsageEventsIngested: factory.Counter("ledger.usage.events.ingested"),
 		UsageBatchSize:      factory.Histogram("ledger.usage.batch.size"),
 		UsageFlushLatency:   factory.Histogram("ledger.usage.flush.latency_ms"),
+		UsageBufferDepth:    factory.Histogram("ledger.usage.buffer.depth"),
+		UsageFlushLag:       factory.Histogram("ledger.usage.flush.lag_ms"),
+		UsageRejected:       factory.Counter("ledger.usage.rejected"),
 
 		// Entitlement metrics
 		EntitlementChecks:      factory.Counter("ledger.entitlement.checks"),
@@ -222,6 +230,19 @@ func (m *MetricsExtension) OnUsageFlushed(_ context.Context, _ int, elapsed time
 	return nil
 }
 
+// OnUsageShardFlushed implements plugin.OnUsageShardFlushed.
+func (m *MetricsExtension) OnUsageShardFlushed(_ context.Context, _, depth int, lag time.Duration) error {
+	m.UsageBufferDepth.Observe(float64(depth))
+	m.UsageFlushLag.Observe(float64(lag.Milliseconds()))
+	return nil
+}
+
+// OnUsageRejected implements plugin.OnUsageRejected.
+func (m *MetricsExtension) OnUsageRejected(_ context.Context, _ interface{}, _ error) error {
+	m.UsageRejected.Inc()
+	return nil
+}
+
 // ──────────────────────────────────────────────────
 // Entitlement lifecycle hooks
 // ──────────────────────────────────────────────────
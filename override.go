@@ -0,0 +1,132 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/xraph/ledger/cachebus"
+	"github.com/xraph/ledger/entitlement"
+	"github.com/xraph/ledger/id"
+	"github.com/xraph/ledger/plan"
+)
+
+// CreateOverride records an exception to a tenant's plan for one feature:
+// OverrideAdd grants extra quota on top of the plan limit, OverrideSet
+// replaces the limit. Overrides are merged into Entitled, EntitledMany and
+// Consume until they expire. A set override of a catalog feature that is
+// not in the tenant's plan grants the feature with the catalog's type and
+// period. Empty TenantID and AppID are taken from ctx.
+func (l *Ledger) CreateOverride(ctx context.Context, o *entitlement.Override) error {
+	if o.TenantID == "" {
+		o.TenantID = extractTenantID(ctx)
+	}
+	if o.AppID == "" {
+		o.AppID = extractAppID(ctx)
+	}
+	if o.TenantID == "" || o.AppID == "" || o.FeatureKey == "" {
+		return ErrInvalidInput
+	}
+	switch o.Mode {
+	case entitlement.OverrideAdd:
+	case entitlement.OverrideSet:
+		if o.Value < -1 {
+			return fmt.Errorf("%w: override limit %d", ErrInvalidInput, o.Value)
+		}
+	default:
+		return fmt.Errorf("%w: unknown override mode %q", ErrInvalidInput, o.Mode)
+	}
+	if o.ExpiresAt != nil && !o.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf("%w: override already expired", ErrInvalidInput)
+	}
+
+	if o.ID.IsNil() {
+		o.ID = id.NewOverrideID()
+	}
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = time.Now().UTC()
+	}
+	if err := l.store.CreateOverride(ctx, o); err != nil {
+		return err
+	}
+	l.forgetOverride(ctx, o)
+	return nil
+}
+
+// ListOverrides returns a tenant's overrides, expired ones included,
+// newest first.
+func (l *Ledger) ListOverrides(ctx context.Context, tenantID, appID string) ([]*entitlement.Override, error) {
+	return l.store.ListOverrides(ctx, tenantID, appID)
+}
+
+// DeleteOverride removes an override; the tenant's plan limit applies
+// again from the next entitlement check.
+func (l *Ledger) DeleteOverride(ctx context.Context, overrideID id.OverrideID) error {
+	o, err := l.store.GetOverride(ctx, overrideID)
+	if err != nil {
+		return err
+	}
+	if err := l.store.DeleteOverride(ctx, overrideID); err != nil {
+		return err
+	}
+	l.forgetOverride(ctx, o)
+	return nil
+}
+
+// forgetOverride drops the cached results and rate rules an override
+// changed.
+func (l *Ledger) forgetOverride(ctx context.Context, o *entitlement.Override) {
+	l.invalidate(ctx, cachebus.Message{Kind: cachebus.KindOverride, TenantID: o.TenantID, AppID: o.AppID, Features: []string{o.FeatureKey}})
+}
+
+// planFeature returns the feature key of p with the tenant's overrides
+// active at now applied to its limit. Features missing from the plan are
+// granted by an active set override when the catalog defines them. It
+// returns nil when the tenant has no such feature.
+func (l *Ledger) planFeature(ctx context.Context, appID string, p *plan.Plan, key string, overrides []*entitlement.Override, now time.Time) *plan.Feature {
+	feat := p.FindFeature(key)
+	if !slices.ContainsFunc(overrides, func(o *entitlement.Override) bool { return o.FeatureKey == key }) {
+		return feat
+	}
+
+	if feat != nil {
+		f := *feat
+		feat = &f
+	} else {
+		granted := slices.ContainsFunc(overrides, func(o *entitlement.Override) bool {
+			return o.FeatureKey == key && o.Mode == entitlement.OverrideSet && o.Active(now)
+		})
+		if !granted {
+			return nil
+		}
+		cf, err := l.catalogFeature(ctx, appID, key)
+		if err != nil {
+			return nil
+		}
+		feat = &plan.Feature{
+			CatalogID: cf.ID,
+			Key:       cf.Key,
+			Name:      cf.Name,
+			Type:      plan.FeatureType(cf.Type),
+			Period:    plan.Period(cf.Period),
+			SoftLimit: cf.SoftLimit,
+		}
+	}
+	feat.Limit = entitlement.ApplyOverrides(feat.Limit, overrides, key, now)
+	return feat
+}
+
+// overrideKeys returns the feature keys of p and of the overrides.
+func overrideKeys(p *plan.Plan, overrides []*entitlement.Override) []string {
+	keys := make([]string, 0, len(p.Features)+len(overrides))
+	for i := range p.Features {
+		keys = append(keys, p.Features[i].Key)
+	}
+	for _, o := range overrides {
+		if !slices.Contains(keys, o.FeatureKey) {
+			keys = append(keys, o.FeatureKey)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,88 @@
+package ledger_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xraph/ledger"
+	"github.com/xraph/ledger/entitlement"
+	"github.com/xraph/ledger/feature"
+	"github.com/xraph/ledger/plan"
+	"github.com/xraph/ledger/store/memory"
+)
+
+func TestOverridePrecedence(t *testing.T) {
+	ctx := context.Background()
+	l := startLedger(t, memory.New())
+	subscribe(t, l, "t1", []plan.Feature{{
+		Key: "api_calls", Name: "API calls", Type: plan.FeatureMetered, Limit: 100, Period: plan.PeriodMonthly,
+	}})
+	tctx := tenantContext("t1", "app")
+
+	wantLimit := func(what, key string, want int64) {
+		t.Helper()
+		res, err := l.Entitled(tctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Allowed || res.Limit != want {
+			t.Fatalf("%s: %s = %+v, want allowed with limit %d", what, key, res, want)
+		}
+	}
+	override := func(key string, mode entitlement.OverrideMode, value int64) *entitlement.Override {
+		t.Helper()
+		o := &entitlement.Override{FeatureKey: key, Mode: mode, Value: value}
+		if err := l.CreateOverride(tctx, o); err != nil {
+			t.Fatal(err)
+		}
+		return o
+	}
+
+	wantLimit("plan", "api_calls", 100)
+	add := override("api_calls", entitlement.OverrideAdd, 50)
+	wantLimit("add override", "api_calls", 150)
+	set := override("api_calls", entitlement.OverrideSet, 20)
+	wantLimit("set and add overrides", "api_calls", 70)
+
+	if err := l.DeleteOverride(ctx, add.ID); err != nil {
+		t.Fatal(err)
+	}
+	wantLimit("set override", "api_calls", 20)
+	if err := l.DeleteOverride(ctx, set.ID); err != nil {
+		t.Fatal(err)
+	}
+	wantLimit("deleted overrides", "api_calls", 100)
+
+	// A set override grants a catalog feature the plan lacks.
+	f := &feature.Feature{Key: "exports", Name: "Exports", Type: feature.FeatureMetered, Period: feature.PeriodMonthly, Status: feature.StatusActive, AppID: "app"}
+	if err := l.CreateFeature(ctx, f); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := l.Entitled(tctx, "exports"); err != nil || res.Allowed {
+		t.Fatalf("exports without override = %+v, %v, want denied", res, err)
+	}
+	override("exports", entitlement.OverrideSet, 5)
+	wantLimit("granted feature", "exports", 5)
+}
+
+func TestOverrideExpires(t *testing.T) {
+	l := startLedger(t, memory.New(), ledger.WithEntitlementCacheTTL(time.Millisecond))
+	subscribe(t, l, "t1", []plan.Feature{{
+		Key: "api_calls", Name: "API calls", Type: plan.FeatureMetered, Limit: 100, Period: plan.PeriodMonthly,
+	}})
+	tctx := tenantContext("t1", "app")
+
+	expires := time.Now().Add(50 * time.Millisecond)
+	o := &entitlement.Override{FeatureKey: "api_calls", Mode: entitlement.OverrideSet, Value: 1000, ExpiresAt: &expires}
+	if err := l.CreateOverride(tctx, o); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := l.Entitled(tctx, "api_calls"); err != nil || res.Limit != 1000 {
+		t.Fatalf("Entitled = %+v, %v, want the override's limit", res, err)
+	}
+	eventually(t, "the override to expire", func() bool {
+		res, err := l.Entitled(tctx, "api_calls")
+		return err == nil && res.Limit == 100
+	})
+}
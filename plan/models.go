@@ -41,6 +41,19 @@ type Feature struct {
 	Period    Period            `json:"period"`
 	SoftLimit bool              `json:"soft_limit"`
 	Metadata  map[string]string `json:"metadata,omitempty"`
+
+	// SeatBilling selects how a seat feature's gauge is billed over an
+	// invoice period; empty means SeatBillingMax.
+	SeatBilling SeatBilling `json:"seat_billing,omitempty"`
+
+	// Limiter selects how a rolling period is enforced; empty means
+	// LimiterSliding. Other periods ignore it.
+	Limiter Limiter `json:"limiter,omitempty"`
+
+	// Thresholds are the percentages of Limit at which usage alerts are
+	// sent, such as 50, 80 and 100. Empty means the Ledger's default
+	// thresholds.
+	Thresholds []int `json:"thresholds,omitempty"`
 }
 
 type FeatureType string
@@ -51,12 +64,45 @@ const (
 	FeatureSeat    FeatureType = "seat"
 )
 
+// SeatBilling is how the seat count of a period is reduced for billing.
+type SeatBilling string
+
+const (
+	// SeatBillingMax bills the highest seat count held during the period.
+	SeatBillingMax SeatBilling = "max"
+	// SeatBillingAverage bills the time-weighted average seat count, rounded
+	// to the nearest seat.
+	SeatBillingAverage SeatBilling = "average"
+)
+
 type Period string
 
 const (
 	PeriodMonthly Period = "monthly"
 	PeriodYearly  Period = "yearly"
 	PeriodNone    Period = "none"
+
+	// Rolling periods count usage in the trailing window that ends now
+	// instead of windows anchored on the subscription. The short ones
+	// express rate limits.
+	PeriodSecond     Period = "second"
+	PeriodMinute     Period = "minute"
+	PeriodHour       Period = "hour"
+	PeriodRolling24h Period = "rolling_24h"
+	PeriodRolling30d Period = "rolling_30d"
+)
+
+// Limiter is how usage of a feature with a rolling period is checked
+// against its limit.
+type Limiter string
+
+const (
+	// LimiterSliding counts the usage in the trailing window.
+	LimiterSliding Limiter = "sliding"
+	// LimiterTokenBucket gives the feature a bucket of Limit tokens that
+	// refills continuously over the period; each unit of usage takes a
+	// token, so bursts up to Limit are allowed after idle time.
+	LimiterTokenBucket Limiter = "token_bucket"
 )
 
 type Pricing struct {
@@ -83,6 +129,11 @@ type PriceTier struct {
 	UnitAmount types.Money `json:"unit_amount"`
 	FlatAmount types.Money `json:"flat_amount"`
 	Priority   int         `json:"priority"`
+
+	// Dimensions scopes the tier to usage whose dimensions hold these
+	// values, e.g. {"model": "gpt-large"}. Tiers without dimensions price
+	// any usage no scoped tier matches.
+	Dimensions map[string]string `json:"dimensions,omitempty"`
 }
 
 func (p *Plan) FindFeature(key string) *Feature {
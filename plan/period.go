@@ -0,0 +1,82 @@
+package plan
+
+import "time"
+
+// Length returns the length of a rolling period's window, or 0 for periods
+// whose windows are anchored on a date.
+func (p Period) Length() time.Duration {
+	switch p {
+	case PeriodSecond:
+		return time.Second
+	case PeriodMinute:
+		return time.Minute
+	case PeriodHour:
+		return time.Hour
+	case PeriodRolling24h:
+		return 24 * time.Hour
+	case PeriodRolling30d:
+		return 30 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+// Rolling reports whether the period is a trailing window ending now.
+func (p Period) Rolling() bool {
+	return p.Length() > 0
+}
+
+// months returns the length of the period in calendar months, or 0 for
+// periods that never reset.
+func (p Period) months() int {
+	switch p {
+	case PeriodMonthly:
+		return 1
+	case PeriodYearly:
+		return 12
+	default:
+		return 0
+	}
+}
+
+// Window returns the [start, end) usage window of the period that contains
+// now, with windows anchored on anchor: a monthly period anchored on the 17th
+// runs from the 17th to the 17th. Anchor days that do not exist in a month are
+// clamped to its last day. A zero anchor yields calendar windows (the 1st of
+// the month, or January 1st) in UTC. PeriodNone has no window and returns
+// two zero times, meaning "all time". Rolling periods return the trailing
+// window [now-Length, now) and ignore the anchor.
+func (p Period) Window(anchor, now time.Time) (start, end time.Time) {
+	if d := p.Length(); d > 0 {
+		return now.Add(-d), now
+	}
+	step := p.months()
+	if step == 0 {
+		return time.Time{}, time.Time{}
+	}
+	if anchor.IsZero() {
+		now = now.UTC()
+		anchor = time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	elapsed := (now.Year()-anchor.Year())*12 + int(now.Month()) - int(anchor.Month())
+	n := elapsed / step
+	if elapsed < 0 && elapsed%step != 0 {
+		n-- // floor division for anchors in the future
+	}
+
+	start = addMonths(anchor, n*step)
+	if start.After(now) {
+		n--
+		start = addMonths(anchor, n*step)
+	}
+	return start, addMonths(anchor, (n+1)*step)
+}
+
+// addMonths adds n calendar months to t, clamping the day of month instead
+// of overflowing into the following month like time.AddDate does.
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(t.Day(), lastDay)-1)
+}
@@ -0,0 +1,41 @@
+package plan
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriodWindow(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name      string
+		period    Period
+		anchor    time.Time
+		now       time.Time
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{"Monthly before anchor day", PeriodMonthly, day(2024, 1, 17), day(2024, 3, 10), day(2024, 2, 17), day(2024, 3, 17)},
+		{"Monthly on anchor day", PeriodMonthly, day(2024, 1, 17), day(2024, 3, 17), day(2024, 3, 17), day(2024, 4, 17)},
+		{"Monthly clamps short months", PeriodMonthly, day(2024, 1, 31), day(2024, 2, 29), day(2024, 2, 29), day(2024, 3, 31)},
+		{"Yearly anchored", PeriodYearly, day(2023, 6, 1), day(2024, 5, 31), day(2023, 6, 1), day(2024, 6, 1)},
+		{"Anchor in the future", PeriodMonthly, day(2024, 5, 17), day(2024, 3, 20), day(2024, 3, 17), day(2024, 4, 17)},
+		{"Calendar month without anchor", PeriodMonthly, time.Time{}, day(2024, 3, 20), day(2024, 3, 1), day(2024, 4, 1)},
+		{"Calendar year without anchor", PeriodYearly, time.Time{}, day(2024, 3, 20), day(2024, 1, 1), day(2025, 1, 1)},
+		{"No period", PeriodNone, day(2024, 1, 17), day(2024, 3, 20), time.Time{}, time.Time{}},
+		{"Rolling 24h", PeriodRolling24h, day(2024, 1, 17), day(2024, 3, 20), day(2024, 3, 19), day(2024, 3, 20)},
+		{"Rolling 30d", PeriodRolling30d, time.Time{}, day(2024, 3, 31), day(2024, 3, 1), day(2024, 3, 31)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := tt.period.Window(tt.anchor, tt.now)
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("got [%v, %v), want [%v, %v)", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
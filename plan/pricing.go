@@ -0,0 +1,232 @@
+package plan
+
+import (
+	"math"
+	"sort"
+
+	"github.com/xraph/ledger/types"
+)
+
+// MetadataPricingStrategy is the Plan or Feature metadata key that names a
+// registered plugin.PricingStrategy to price a metered feature with.
+const MetadataPricingStrategy = "pricing_strategy"
+
+// IsBuiltin reports whether t is one of the tier models handled by
+// ComputeTiers. Any other value is treated as the name of a pricing strategy.
+func (t TierType) IsBuiltin() bool {
+	switch t {
+	case "", TierGraduated, TierVolume, TierFlat:
+		return true
+	}
+	return false
+}
+
+// StrategyFor returns the name of the pricing strategy that should price
+// featureKey, or "" when the built-in tier engine applies. A non-built-in
+// tier Type wins over the feature's "pricing_strategy" metadata, which in
+// turn wins over the plan's.
+func (p *Plan) StrategyFor(featureKey string) string {
+	for _, t := range p.Pricing.TiersFor(featureKey) {
+		if !t.Type.IsBuiltin() {
+			return string(t.Type)
+		}
+	}
+	if f := p.FindFeature(featureKey); f != nil {
+		if name := f.Metadata[MetadataPricingStrategy]; name != "" {
+			return name
+		}
+	}
+	return p.Metadata[MetadataPricingStrategy]
+}
+
+// TiersFor returns the price tiers without dimensions that apply to
+// featureKey, ordered for evaluation by ascending UpTo with unbounded tiers
+// (UpTo <= 0) last. When the feature has tiers of several Types, the tiers
+// of each Type form a tier set and only the set holding the lowest Priority
+// applies. The receiver's slice is never modified.
+func (p *Pricing) TiersFor(featureKey string) []PriceTier {
+	if p == nil {
+		return nil
+	}
+	var tiers []PriceTier
+	for _, t := range p.Tiers {
+		if t.FeatureKey == featureKey && len(t.Dimensions) == 0 {
+			tiers = append(tiers, t)
+		}
+	}
+	return sortTiers(tiers)
+}
+
+// TiersMatching returns the price tiers of featureKey that apply to usage
+// with the given dimension values, ordered like TiersFor. Tiers sharing the
+// same Dimensions form one tier list; the list with the most dimensions
+// that all match dims wins, earlier tiers breaking ties. Without a matching
+// scoped list the tiers without dimensions apply.
+func (p *Pricing) TiersMatching(featureKey string, dims map[string]string) []PriceTier {
+	if p == nil {
+		return nil
+	}
+	var best map[string]string
+	for _, t := range p.Tiers {
+		if t.FeatureKey != featureKey || len(t.Dimensions) <= len(best) {
+			continue
+		}
+		if matchDimensions(t.Dimensions, dims) {
+			best = t.Dimensions
+		}
+	}
+	if best == nil {
+		return p.TiersFor(featureKey)
+	}
+
+	var tiers []PriceTier
+	for _, t := range p.Tiers {
+		if t.FeatureKey == featureKey && sameDimensions(t.Dimensions, best) {
+			tiers = append(tiers, t)
+		}
+	}
+	return sortTiers(tiers)
+}
+
+// DimensionKeys returns the sorted dimension keys that featureKey's tiers
+// are scoped by.
+func (p *Pricing) DimensionKeys(featureKey string) []string {
+	if p == nil {
+		return nil
+	}
+	seen := make(map[string]struct{})
+	var keys []string
+	for _, t := range p.Tiers {
+		if t.FeatureKey != featureKey {
+			continue
+		}
+		for k := range t.Dimensions {
+			if _, ok := seen[k]; !ok {
+				seen[k] = struct{}{}
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// matchDimensions reports whether dims holds every value in scope.
+func matchDimensions(scope, dims map[string]string) bool {
+	for k, v := range scope {
+		if got, ok := dims[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+func sameDimensions(a, b map[string]string) bool {
+	return len(a) == len(b) && matchDimensions(a, b)
+}
+
+// sortTiers keeps the tier set, the tiers of one Type, holding the lowest
+// Priority, earlier tiers breaking ties, and orders its brackets by
+// ascending UpTo with unbounded tiers last. Priority only breaks ties
+// between brackets with the same UpTo, so graduated brackets are always
+// evaluated in order.
+func sortTiers(tiers []PriceTier) []PriceTier {
+	if len(tiers) == 0 {
+		return tiers
+	}
+	set := tierModel(tiers[0])
+	best := tiers[0].Priority
+	for _, t := range tiers[1:] {
+		if t.Priority < best {
+			set, best = tierModel(t), t.Priority
+		}
+	}
+
+	kept := tiers[:0]
+	for _, t := range tiers {
+		if tierModel(t) == set {
+			kept = append(kept, t)
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		if bi, bj := upperBound(kept[i]), upperBound(kept[j]); bi != bj {
+			return bi < bj
+		}
+		return kept[i].Priority < kept[j].Priority
+	})
+	return kept
+}
+
+// tierModel returns the Type a tier is priced with; tiers without a Type
+// are graduated.
+func tierModel(t PriceTier) TierType {
+	if t.Type == "" {
+		return TierGraduated
+	}
+	return t.Type
+}
+
+// ComputeTiers prices usage against an ordered tier list (see TiersFor) and
+// returns the charge in currency. The pricing model is taken from the first
+// tier's Type:
+//
+//   - TierGraduated: each unit is priced at the rate of the bracket it falls
+//     in, and every bracket that receives units adds its FlatAmount.
+//   - TierVolume: all units are priced at the rate of the bracket the total
+//     falls in, plus that bracket's FlatAmount.
+//   - TierFlat: the FlatAmount of the bracket the total falls in is charged,
+//     regardless of the exact quantity.
+//
+// A tier with UpTo of -1 (or 0) is unbounded. Usage beyond the last bounded
+// tier is priced by the last tier. Zero or negative usage costs nothing.
+func ComputeTiers(tiers []PriceTier, usage int64, currency string) types.Money {
+	total := types.Zero(currency)
+	if len(tiers) == 0 || usage <= 0 {
+		return total
+	}
+
+	switch tiers[0].Type {
+	case TierVolume:
+		t := bracketFor(tiers, usage)
+		total.Amount = t.UnitAmount.Amount*usage + t.FlatAmount.Amount
+	case TierFlat:
+		total.Amount = bracketFor(tiers, usage).FlatAmount.Amount
+	default: // TierGraduated
+		var prev int64
+		for i, t := range tiers {
+			if usage <= prev {
+				break
+			}
+			end := upperBound(t)
+			if i == len(tiers)-1 || end > usage {
+				end = usage
+			}
+			units := end - prev
+			if units <= 0 {
+				continue
+			}
+			total.Amount += t.UnitAmount.Amount*units + t.FlatAmount.Amount
+			prev = end
+		}
+	}
+	return total
+}
+
+// bracketFor returns the first tier whose upper bound contains qty, or the
+// last tier when qty exceeds every bound.
+func bracketFor(tiers []PriceTier, qty int64) PriceTier {
+	for _, t := range tiers {
+		if qty <= upperBound(t) {
+			return t
+		}
+	}
+	return tiers[len(tiers)-1]
+}
+
+// upperBound normalizes UpTo so unbounded tiers sort and compare last.
+func upperBound(t PriceTier) int64 {
+	if t.UpTo <= 0 {
+		return math.MaxInt64
+	}
+	return t.UpTo
+}
@@ -0,0 +1,136 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/xraph/ledger/types"
+)
+
+func TestTiersFor(t *testing.T) {
+	p := &Pricing{
+		Tiers: []PriceTier{
+			{FeatureKey: "api_calls", UpTo: -1, UnitAmount: types.USD(1)},
+			{FeatureKey: "storage", UpTo: 10, UnitAmount: types.USD(5)},
+			{FeatureKey: "api_calls", UpTo: 1000, UnitAmount: types.USD(3)},
+			{FeatureKey: "api_calls", UpTo: 100, UnitAmount: types.USD(9), Priority: 1},
+		},
+	}
+
+	tiers := p.TiersFor("api_calls")
+	if len(tiers) != 3 {
+		t.Fatalf("got %d tiers, want 3", len(tiers))
+	}
+	// Brackets are evaluated by UpTo whatever their priorities.
+	wantUpTo := []int64{100, 1000, -1}
+	for i, tier := range tiers {
+		if tier.UpTo != wantUpTo[i] {
+			t.Errorf("tier %d: UpTo got %d, want %d", i, tier.UpTo, wantUpTo[i])
+		}
+	}
+
+	// Of two tier sets, the one holding the lowest Priority applies.
+	mixed := &Pricing{
+		Tiers: []PriceTier{
+			{FeatureKey: "seats", Type: TierVolume, UpTo: -1, UnitAmount: types.USD(4), Priority: 2},
+			{FeatureKey: "seats", Type: TierGraduated, UpTo: -1, UnitAmount: types.USD(5), Priority: 3},
+			{FeatureKey: "seats", Type: TierGraduated, UpTo: 10, UnitAmount: types.USD(8), Priority: 1},
+			{FeatureKey: "seats", Type: TierVolume, UpTo: 10, UnitAmount: types.USD(6), Priority: 2},
+		},
+	}
+	tiers = mixed.TiersFor("seats")
+	if len(tiers) != 2 || tiers[0].UnitAmount.Amount != 8 || tiers[1].UnitAmount.Amount != 5 {
+		t.Fatalf("mixed tier sets: got %+v, want the graduated set [8 5]", tiers)
+	}
+	if got := ComputeTiers(tiers, 12, "USD"); !got.Equal(types.USD(10*8 + 2*5)) {
+		t.Errorf("mixed tier sets: charge got %v, want %v", got, types.USD(90))
+	}
+
+	if got := (*Pricing)(nil).TiersFor("api_calls"); got != nil {
+		t.Errorf("nil pricing: got %v, want nil", got)
+	}
+}
+
+func TestComputeTiers(t *testing.T) {
+	graduated := []PriceTier{
+		{Type: TierGraduated, UpTo: 100, UnitAmount: types.Zero("usd")},
+		{Type: TierGraduated, UpTo: 1000, UnitAmount: types.USD(2), FlatAmount: types.USD(500)},
+		{Type: TierGraduated, UpTo: -1, UnitAmount: types.USD(1)},
+	}
+	volume := []PriceTier{
+		{Type: TierVolume, UpTo: 100, UnitAmount: types.USD(10)},
+		{Type: TierVolume, UpTo: 1000, UnitAmount: types.USD(8), FlatAmount: types.USD(100)},
+		{Type: TierVolume, UpTo: -1, UnitAmount: types.USD(5)},
+	}
+	flat := []PriceTier{
+		{Type: TierFlat, UpTo: 10, FlatAmount: types.USD(1000)},
+		{Type: TierFlat, UpTo: 50, FlatAmount: types.USD(4000)},
+	}
+
+	tests := []struct {
+		name     string
+		tiers    []PriceTier
+		usage    int64
+		expected types.Money
+	}{
+		{"No tiers", nil, 500, types.USD(0)},
+		{"Zero usage", graduated, 0, types.USD(0)},
+		{"Graduated within free tier", graduated, 80, types.USD(0)},
+		{"Graduated second tier", graduated, 150, types.USD(50*2 + 500)},
+		{"Graduated all tiers", graduated, 1200, types.USD(900*2 + 500 + 200)},
+		{"Volume first bracket", volume, 100, types.USD(1000)},
+		{"Volume second bracket", volume, 101, types.USD(101*8 + 100)},
+		{"Volume unbounded bracket", volume, 2000, types.USD(10000)},
+		{"Flat first bracket", flat, 3, types.USD(1000)},
+		{"Flat second bracket", flat, 11, types.USD(4000)},
+		{"Flat beyond last bracket", flat, 99, types.USD(4000)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ComputeTiers(tt.tiers, tt.usage, "USD")
+			if !got.Equal(tt.expected) {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTiersMatching(t *testing.T) {
+	p := &Pricing{
+		Tiers: []PriceTier{
+			{FeatureKey: "tokens", UpTo: -1, UnitAmount: types.USD(1)},
+			{FeatureKey: "tokens", UpTo: -1, UnitAmount: types.USD(10), Dimensions: map[string]string{"model": "gpt-large"}},
+			{FeatureKey: "tokens", UpTo: 1000, UnitAmount: types.USD(20), Dimensions: map[string]string{"model": "gpt-large", "region": "eu"}},
+			{FeatureKey: "tokens", UpTo: -1, UnitAmount: types.USD(15), Dimensions: map[string]string{"model": "gpt-large", "region": "eu"}},
+		},
+	}
+
+	tests := []struct {
+		name string
+		dims map[string]string
+		want []int64 // unit amounts in evaluation order
+	}{
+		{"No dimensions", nil, []int64{1}},
+		{"Unscoped value", map[string]string{"model": "gpt-small"}, []int64{1}},
+		{"Single dimension", map[string]string{"model": "gpt-large", "region": "us"}, []int64{10}},
+		{"Most specific wins", map[string]string{"model": "gpt-large", "region": "eu"}, []int64{20, 15}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tiers := p.TiersMatching("tokens", tt.dims)
+			if len(tiers) != len(tt.want) {
+				t.Fatalf("got %d tiers, want %d", len(tiers), len(tt.want))
+			}
+			for i, tier := range tiers {
+				if tier.UnitAmount.Amount != tt.want[i] {
+					t.Errorf("tier %d: unit amount got %d, want %d", i, tier.UnitAmount.Amount, tt.want[i])
+				}
+			}
+		})
+	}
+
+	if got := p.DimensionKeys("tokens"); len(got) != 2 || got[0] != "model" || got[1] != "region" {
+		t.Errorf("DimensionKeys: got %v, want [model region]", got)
+	}
+}
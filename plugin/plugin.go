@@ -124,6 +124,60 @@ type OnUsageFlushed interface {
 	OnUsageFlushed(ctx context.Context, count int, elapsed time.Duration) error
 }
 
+// OnUsageDropped is called when a usage batch could not be flushed to the
+// store after all retries. deadLettered events were handed to the meter
+// dead-letter sink; dropped events were not (they survive only in the meter
+// WAL, when enabled).
+type OnUsageDropped interface {
+	Plugin
+	OnUsageDropped(ctx context.Context, dropped, deadLettered int, err error) error
+}
+
+// OnUsageShardFlushed is called after a meter flush worker writes a batch to
+// the store. depth is the number of events still buffered for the worker's
+// shard and lag is how long the oldest event of the batch waited between
+// Meter and the end of the flush.
+type OnUsageShardFlushed interface {
+	Plugin
+	OnUsageShardFlushed(ctx context.Context, shard, depth int, lag time.Duration) error
+}
+
+// OnUsageRejected is called when strict metering rejects a usage event
+// before it is buffered. event is the rejected *meter.UsageEvent and err
+// says why (unknown or archived feature, invalid quantity).
+type OnUsageRejected interface {
+	Plugin
+	OnUsageRejected(ctx context.Context, event interface{}, err error) error
+}
+
+// OnUsageVoided is called when a recorded usage event is voided. event is
+// the voided *meter.UsageEvent.
+type OnUsageVoided interface {
+	Plugin
+	OnUsageVoided(ctx context.Context, event interface{}) error
+}
+
+// OnUsageCorrected is called when a signed correction event is recorded.
+// event is the correction *meter.UsageEvent.
+type OnUsageCorrected interface {
+	Plugin
+	OnUsageCorrected(ctx context.Context, event interface{}) error
+}
+
+// OnUsageArchived is called after a retention run moves events of appID
+// out of the store into the archive file name.
+type OnUsageArchived interface {
+	Plugin
+	OnUsageArchived(ctx context.Context, appID, name string, events int) error
+}
+
+// OnUsageRestored is called after the events of the archive file name are
+// restored into the store; restored excludes events already stored.
+type OnUsageRestored interface {
+	Plugin
+	OnUsageRestored(ctx context.Context, name string, restored int) error
+}
+
 // ──────────────────────────────────────────────────
 // Entitlement hooks
 // ──────────────────────────────────────────────────
@@ -146,6 +200,14 @@ type OnSoftLimitReached interface {
 	OnSoftLimitReached(ctx context.Context, tenantID, featureKey string, used, limit int64) error
 }
 
+// OnUsageThreshold is called once per usage window when a tenant's usage
+// of a feature reaches one of its alert thresholds, a percentage of the
+// limit.
+type OnUsageThreshold interface {
+	Plugin
+	OnUsageThreshold(ctx context.Context, tenantID, featureKey string, threshold int, used, limit int64) error
+}
+
 // ──────────────────────────────────────────────────
 // Invoice lifecycle hooks
 // ──────────────────────────────────────────────────
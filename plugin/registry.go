@@ -33,9 +33,17 @@ type Registry struct {
 	onSubscriptionExpired  []OnSubscriptionExpired
 	onUsageIngested        []OnUsageIngested
 	onUsageFlushed         []OnUsageFlushed
+	onUsageDropped         []OnUsageDropped
+	onUsageShardFlushed    []OnUsageShardFlushed
+	onUsageRejected        []OnUsageRejected
+	onUsageVoided          []OnUsageVoided
+	onUsageCorrected       []OnUsageCorrected
+	onUsageArchived        []OnUsageArchived
+	onUsageRestored        []OnUsageRestored
 	onEntitlementChecked   []OnEntitlementChecked
 	onQuotaExceeded        []OnQuotaExceeded
 	onSoftLimitReached     []OnSoftLimitReached
+	onUsageThreshold       []OnUsageThreshold
 	onInvoiceGenerated     []OnInvoiceGenerated
 	onInvoiceFinalized     []OnInvoiceFinalized
 	onInvoicePaid          []OnInvoicePaid
@@ -127,6 +135,27 @@ func (r *Registry) Register(p Plugin) error {
 	if v, ok := p.(OnUsageFlushed); ok {
 		r.onUsageFlushed = append(r.onUsageFlushed, v)
 	}
+	if v, ok := p.(OnUsageDropped); ok {
+		r.onUsageDropped = append(r.onUsageDropped, v)
+	}
+	if v, ok := p.(OnUsageShardFlushed); ok {
+		r.onUsageShardFlushed = append(r.onUsageShardFlushed, v)
+	}
+	if v, ok := p.(OnUsageRejected); ok {
+		r.onUsageRejected = append(r.onUsageRejected, v)
+	}
+	if v, ok := p.(OnUsageVoided); ok {
+		r.onUsageVoided = append(r.onUsageVoided, v)
+	}
+	if v, ok := p.(OnUsageCorrected); ok {
+		r.onUsageCorrected = append(r.onUsageCorrected, v)
+	}
+	if v, ok := p.(OnUsageArchived); ok {
+		r.onUsageArchived = append(r.onUsageArchived, v)
+	}
+	if v, ok := p.(OnUsageRestored); ok {
+		r.onUsageRestored = append(r.onUsageRestored, v)
+	}
 	if v, ok := p.(OnEntitlementChecked); ok {
 		r.onEntitlementChecked = append(r.onEntitlementChecked, v)
 	}
@@ -136,6 +165,9 @@ func (r *Registry) Register(p Plugin) error {
 	if v, ok := p.(OnSoftLimitReached); ok {
 		r.onSoftLimitReached = append(r.onSoftLimitReached, v)
 	}
+	if v, ok := p.(OnUsageThreshold); ok {
+		r.onUsageThreshold = append(r.onUsageThreshold, v)
+	}
 	if v, ok := p.(OnInvoiceGenerated); ok {
 		r.onInvoiceGenerated = append(r.onInvoiceGenerated, v)
 	}
@@ -479,6 +511,132 @@ func (r *Registry) EmitUsageFlushed(ctx context.Context, count int, elapsed time
 	}
 }
 
+// EmitUsageDropped emits a usage dropped event.
+func (r *Registry) EmitUsageDropped(ctx context.Context, dropped, deadLettered int, err error) {
+	r.mu.RLock()
+	plugins := r.onUsageDropped
+	r.mu.RUnlock()
+
+	for _, p := range plugins {
+		if hookErr := r.callWithTimeout(ctx, p.Name(), func() error {
+			return p.OnUsageDropped(ctx, dropped, deadLettered, err)
+		}); hookErr != nil {
+			r.logger.Warn("plugin OnUsageDropped failed",
+				log.String("plugin", p.Name()),
+				log.Error(hookErr),
+			)
+		}
+	}
+}
+
+// EmitUsageShardFlushed emits a meter shard flushed event.
+func (r *Registry) EmitUsageShardFlushed(ctx context.Context, shard, depth int, lag time.Duration) {
+	r.mu.RLock()
+	plugins := r.onUsageShardFlushed
+	r.mu.RUnlock()
+
+	for _, p := range plugins {
+		if err := r.callWithTimeout(ctx, p.Name(), func() error {
+			return p.OnUsageShardFlushed(ctx, shard, depth, lag)
+		}); err != nil {
+			r.logger.Warn("plugin OnUsageShardFlushed failed",
+				log.String("plugin", p.Name()),
+				log.Error(err),
+			)
+		}
+	}
+}
+
+// EmitUsageRejected emits a usage rejected event.
+func (r *Registry) EmitUsageRejected(ctx context.Context, event interface{}, err error) {
+	r.mu.RLock()
+	plugins := r.onUsageRejected
+	r.mu.RUnlock()
+
+	for _, p := range plugins {
+		if hookErr := r.callWithTimeout(ctx, p.Name(), func() error {
+			return p.OnUsageRejected(ctx, event, err)
+		}); hookErr != nil {
+			r.logger.Warn("plugin OnUsageRejected failed",
+				log.String("plugin", p.Name()),
+				log.Error(hookErr),
+			)
+		}
+	}
+}
+
+// EmitUsageVoided emits a usage voided event.
+func (r *Registry) EmitUsageVoided(ctx context.Context, event interface{}) {
+	r.mu.RLock()
+	plugins := r.onUsageVoided
+	r.mu.RUnlock()
+
+	for _, p := range plugins {
+		if err := r.callWithTimeout(ctx, p.Name(), func() error {
+			return p.OnUsageVoided(ctx, event)
+		}); err != nil {
+			r.logger.Warn("plugin OnUsageVoided failed",
+				log.String("plugin", p.Name()),
+				log.Error(err),
+			)
+		}
+	}
+}
+
+// EmitUsageCorrected emits a usage corrected event.
+func (r *Registry) EmitUsageCorrected(ctx context.Context, event interface{}) {
+	r.mu.RLock()
+	plugins := r.onUsageCorrected
+	r.mu.RUnlock()
+
+	for _, p := range plugins {
+		if err := r.callWithTimeout(ctx, p.Name(), func() error {
+			return p.OnUsageCorrected(ctx, event)
+		}); err != nil {
+			r.logger.Warn("plugin OnUsageCorrected failed",
+				log.String("plugin", p.Name()),
+				log.Error(err),
+			)
+		}
+	}
+}
+
+// EmitUsageArchived emits a usage archived event.
+func (r *Registry) EmitUsageArchived(ctx context.Context, appID, name string, events int) {
+	r.mu.RLock()
+	plugins := r.onUsageArchived
+	r.mu.RUnlock()
+
+	for _, p := range plugins {
+		if err := r.callWithTimeout(ctx, p.Name(), func() error {
+			return p.OnUsageArchived(ctx, appID, name, events)
+		}); err != nil {
+			r.logger.Warn("plugin OnUsageArchived failed",
+				log.String("plugin", p.Name()),
+				log.Error(err),
+			)
+		}
+	}
+}
+
+// EmitUsageRestored emits a usage restored event.
+func (r *Registry) EmitUsageRestored(ctx context.Context, name string, restored int) {
+	r.mu.RLock()
+	plugins := r.onUsageRestored
+	r.mu.RUnlock()
+
+	for _, p := range plugins {
+		if err := r.callWithTimeout(ctx, p.Name(), func() error {
+			return p.OnUsageRestored(ctx, name, restored)
+		}); err != nil {
+			r.logger.Warn("plugin OnUsageRestored failed",
+				log.String("plugin", p.Name()),
+				log.Error(err),
+			)
+		}
+	}
+}
+
 // GetPaymentProviders returns all registered payment provider plugins.
 func (r *Registry) GetPaymentProviders() []PaymentProviderPlugin {
 	r.mu.RLock()
@@ -496,6 +654,13 @@ func (r *Registry) GetPricingStrategy(name string) PricingStrategy {
 	return r.pricingStrategies[name]
 }
 
+// GetUsageAggregator returns a usage aggregator by name.
+func (r *Registry) GetUsageAggregator(name string) UsageAggregator {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.usageAggregators[name]
+}
+
 // GetTaxCalculators returns all registered tax calculators.
 func (r *Registry) GetTaxCalculators() []TaxCalculator {
 	r.mu.RLock()
@@ -616,6 +781,24 @@ func (r *Registry) EmitSoftLimitReached(ctx context.Context, tenantID, featureKe
 	}
 }
 
+// EmitUsageThreshold emits a usage threshold event.
+func (r *Registry) EmitUsageThreshold(ctx context.Context, tenantID, featureKey string, threshold int, used, limit int64) {
+	r.mu.RLock()
+	plugins := r.onUsageThreshold
+	r.mu.RUnlock()
+
+	for _, p := range plugins {
+		if err := r.callWithTimeout(ctx, p.Name(), func() error {
+			return p.OnUsageThreshold(ctx, tenantID, featureKey, threshold, used, limit)
+		}); err != nil {
+			r.logger.Warn("plugin OnUsageThreshold failed",
+				log.String("plugin", p.Name()),
+				log.Error(err),
+			)
+		}
+	}
+}
+
 // EmitUsageIngested emits a usage ingested event.
 func (r *Registry) EmitUsageIngested(ctx context.Context, events []interface{}) {
 	r.mu.RLock()
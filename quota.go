@@ -0,0 +1,387 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	log "github.com/xraph/go-utils/log"
+
+	"github.com/xraph/ledger/cachebus"
+	"github.com/xraph/ledger/entitlement"
+	"github.com/xraph/ledger/id"
+	"github.com/xraph/ledger/meter"
+	"github.com/xraph/ledger/plan"
+)
+
+const (
+	// defaultReservationTTL is how long Reserve holds quota when it is
+	// given no TTL.
+	defaultReservationTTL = time.Hour
+
+	// quotaGrace is how long a hard-limit counter outlives its usage
+	// window, so reservations committed late still find it.
+	quotaGrace = 24 * time.Hour
+)
+
+// quota is a hard-limited feature of the tenant and app in context.
+type quota struct {
+	key   entitlement.QuotaKey
+	feat  *plan.Feature
+	agg   meter.Aggregation
+	limit int64 // -1 when the counter is not capped
+	start time.Time
+	end   time.Time
+}
+
+// Consume atomically checks that the current tenant has quantity left of a
+// metered feature and records it as usage. Unlike Entitled followed by
+// Meter, concurrent callers cannot overshoot the limit: the check and the
+// increment are a single conditional update of a per-window counter in the
+// store. When the quota would be exceeded nothing is recorded, and the
+// result is returned together with an error wrapping ErrQuotaExceeded.
+// Usage the meter buffer cannot take, including usage dropped under
+// OverflowDrop, fails with ErrMeterBufferFull and is returned to the quota.
+//
+// The counter is seeded from the usage stored for the window the first
+// time the feature is consumed in it, and usage recorded with Meter is
+// added to it as it is flushed, so Consume and Entitled count the same
+// usage. Only usage still buffered when the counter is seeded is missed.
+// Features with rolling periods are limited by their rate counters instead
+// and cannot be consumed.
+func (l *Ledger) Consume(ctx context.Context, featureKey string, quantity int64) (*entitlement.Result, error) {
+	if quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+	q, err := l.resolveQuota(ctx, featureKey)
+	if err != nil {
+		return nil, err
+	}
+
+	used, ok, err := l.addQuota(ctx, q, quantity, q.limit)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		l.plugins.EmitQuotaExceeded(ctx, q.key.TenantID, featureKey, used+quantity, q.feat.Limit)
+		return q.result(used, false), fmt.Errorf("%w: %s", ErrQuotaExceeded, featureKey)
+	}
+
+	event := &meter.UsageEvent{
+		TenantID:   q.key.TenantID,
+		AppID:      q.key.AppID,
+		FeatureKey: featureKey,
+		Quantity:   quantity,
+		Quota:      true,
+	}
+	if err := l.meterEvent(ctx, event); err != nil {
+		l.releaseQuota(ctx, q.key, quantity)
+		return nil, err
+	}
+	l.invalidate(ctx, cachebus.Message{Kind: cachebus.KindUsage, TenantID: q.key.TenantID, AppID: q.key.AppID, Features: []string{featureKey}})
+	return q.result(used, true), nil
+}
+
+// Reserve holds quantity of a metered feature's quota for the current
+// tenant, for jobs that learn their actual usage only when they finish.
+// The quantity counts against the limit straight away but is recorded as
+// usage only by CommitReservation. Reservations neither committed nor
+// released within ttl (default: one hour) are released automatically.
+// Reserve returns an error wrapping ErrQuotaExceeded when the quota would
+// be exceeded; the same restrictions as for Consume apply.
+func (l *Ledger) Reserve(ctx context.Context, featureKey string, quantity int64, ttl time.Duration) (*entitlement.Reservation, error) {
+	if quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+	if ttl <= 0 {
+		ttl = defaultReservationTTL
+	}
+	q, err := l.resolveQuota(ctx, featureKey)
+	if err != nil {
+		return nil, err
+	}
+
+	used, ok, err := l.addQuota(ctx, q, quantity, q.limit)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		l.plugins.EmitQuotaExceeded(ctx, q.key.TenantID, featureKey, used+quantity, q.feat.Limit)
+		return nil, fmt.Errorf("%w: %s", ErrQuotaExceeded, featureKey)
+	}
+
+	now := time.Now().UTC()
+	r := &entitlement.Reservation{
+		ID:          id.NewReservationID(),
+		TenantID:    q.key.TenantID,
+		AppID:       q.key.AppID,
+		FeatureKey:  featureKey,
+		WindowStart: q.key.WindowStart,
+		Quantity:    quantity,
+		ExpiresAt:   now.Add(ttl),
+		CreatedAt:   now,
+	}
+	if err := l.store.CreateReservation(ctx, r); err != nil {
+		l.releaseQuota(ctx, q.key, quantity)
+		return nil, err
+	}
+	l.invalidate(ctx, cachebus.Message{Kind: cachebus.KindUsage, TenantID: r.TenantID, AppID: r.AppID, Features: []string{featureKey}})
+	return r, nil
+}
+
+// CommitReservation settles a reservation of the current tenant with the
+// usage actually incurred, which may be less than was reserved but not
+// more. The usage is recorded with the reservation ID as idempotency key,
+// and the unused rest of the reservation is returned to the quota.
+// Reservations of other tenants are reported as ErrReservationNotFound.
+func (l *Ledger) CommitReservation(ctx context.Context, reservationID id.ReservationID, quantity int64) error {
+	if quantity < 0 {
+		return ErrInvalidQuantity
+	}
+	tenantID := extractTenantID(ctx)
+	appID := extractAppID(ctx)
+	if tenantID == "" || appID == "" {
+		return ErrInvalidInput
+	}
+	r, err := l.store.TakeReservation(ctx, tenantID, appID, reservationID)
+	if err != nil {
+		return err
+	}
+	if quantity > r.Quantity {
+		if err := l.store.CreateReservation(ctx, r); err != nil {
+			l.logger.Error("failed to restore reservation",
+				log.String("reservation_id", r.ID.String()),
+				log.Error(err),
+			)
+		}
+		return fmt.Errorf("%w: %d exceeds the %d reserved", ErrInvalidQuantity, quantity, r.Quantity)
+	}
+
+	if quantity > 0 {
+		err := l.meterEvent(ctx, &meter.UsageEvent{
+			TenantID:       r.TenantID,
+			AppID:          r.AppID,
+			FeatureKey:     r.FeatureKey,
+			Quantity:       quantity,
+			IdempotencyKey: r.ID.String(),
+			Quota:          true,
+		})
+		if err != nil {
+			if err := l.store.CreateReservation(ctx, r); err != nil {
+				l.logger.Error("failed to restore reservation",
+					log.String("reservation_id", r.ID.String()),
+					log.Error(err),
+				)
+			}
+			return err
+		}
+	}
+	l.releaseQuota(ctx, r.Key(), r.Quantity-quantity)
+	l.invalidate(ctx, cachebus.Message{Kind: cachebus.KindUsage, TenantID: r.TenantID, AppID: r.AppID, Features: []string{r.FeatureKey}})
+	return nil
+}
+
+// ReleaseReservation returns the whole quantity of a reservation of the
+// current tenant to the quota without recording usage. Reservations of
+// other tenants are reported as ErrReservationNotFound.
+func (l *Ledger) ReleaseReservation(ctx context.Context, reservationID id.ReservationID) error {
+	tenantID := extractTenantID(ctx)
+	appID := extractAppID(ctx)
+	if tenantID == "" || appID == "" {
+		return ErrInvalidInput
+	}
+	return l.releaseReservation(ctx, tenantID, appID, reservationID)
+}
+
+// releaseReservation returns the quantity of a reservation of the tenant
+// to the quota.
+func (l *Ledger) releaseReservation(ctx context.Context, tenantID, appID string, reservationID id.ReservationID) error {
+	r, err := l.store.TakeReservation(ctx, tenantID, appID, reservationID)
+	if err != nil {
+		return err
+	}
+	l.releaseQuota(ctx, r.Key(), r.Quantity)
+	l.invalidate(ctx, cachebus.Message{Kind: cachebus.KindUsage, TenantID: r.TenantID, AppID: r.AppID, Features: []string{r.FeatureKey}})
+	return nil
+}
+
+// resolveQuota looks up the hard-limit counter of a metered feature for
+// the tenant and app in context.
+func (l *Ledger) resolveQuota(ctx context.Context, featureKey string) (*quota, error) {
+	tenantID := extractTenantID(ctx)
+	appID := extractAppID(ctx)
+	if tenantID == "" || appID == "" || featureKey == "" {
+		return nil, ErrInvalidInput
+	}
+
+	sub, err := l.store.GetActiveSubscription(ctx, tenantID, appID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNoActiveSubscription, err)
+	}
+	p, err := l.store.GetPlan(ctx, sub.PlanID)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := l.store.ListOverrides(ctx, tenantID, appID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	feat := l.planFeature(ctx, appID, p, featureKey, overrides, now)
+	if feat == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoEntitlement, featureKey)
+	}
+	if feat.Type != plan.FeatureMetered || feat.Period.Rolling() {
+		return nil, fmt.Errorf("%w: feature %q has no consumable quota", ErrInvalidInput, featureKey)
+	}
+	agg := l.aggregationFor(ctx, appID, feat)
+	if agg.Kind() != meter.AggregateSum {
+		return nil, fmt.Errorf("%w: %q cannot back a hard limit", ErrUnsupportedAggregation, agg.Kind())
+	}
+
+	start, end := usageWindow(sub, p, feat.Period, now)
+	q := &quota{
+		key: entitlement.QuotaKey{
+			TenantID:    tenantID,
+			AppID:       appID,
+			FeatureKey:  featureKey,
+			WindowStart: start.UTC(),
+		},
+		feat:  feat,
+		agg:   agg,
+		limit: feat.Limit,
+		start: start,
+		end:   end,
+	}
+	if feat.SoftLimit {
+		q.limit = -1
+	}
+	return q, nil
+}
+
+// addQuota adds qty to the counter of q within limit, seeding the counter
+// from the window's stored usage first if it does not exist yet.
+func (l *Ledger) addQuota(ctx context.Context, q *quota, qty, limit int64) (int64, bool, error) {
+	used, ok, err := l.store.AddQuota(ctx, q.key, qty, limit)
+	if !errors.Is(err, ErrQuotaCounterNotFound) {
+		return used, ok, err
+	}
+
+	seed, err := l.aggregateUsage(ctx, q.key.TenantID, q.key.AppID, q.feat, q.agg, q.start, q.end)
+	if err != nil {
+		return 0, false, err
+	}
+	var expires time.Time
+	if !q.end.IsZero() {
+		expires = q.end.Add(quotaGrace)
+	}
+	if err := l.store.SeedQuota(ctx, q.key, seed, expires); err != nil {
+		return 0, false, err
+	}
+	return l.store.AddQuota(ctx, q.key, qty, limit)
+}
+
+// countQuotas adds flushed usage recorded with Meter to the hard-limit
+// counters of its window. Counters not created yet are left to be seeded
+// from the stored usage; failures are logged.
+func (l *Ledger) countQuotas(ctx context.Context, inserted []*meter.UsageEvent) {
+	totals := make(map[entitlement.QuotaKey]int64)
+	for _, e := range inserted {
+		if e.Quota || !e.Counted() {
+			continue
+		}
+		w, ok := l.tenantRules(ctx, e.TenantID, e.AppID).quotas[e.FeatureKey]
+		if !ok || e.Timestamp.Before(w.start) || (!w.end.IsZero() && !e.Timestamp.Before(w.end)) {
+			continue
+		}
+		key := entitlement.QuotaKey{TenantID: e.TenantID, AppID: e.AppID, FeatureKey: e.FeatureKey, WindowStart: w.start.UTC()}
+		totals[key] += e.Quantity
+	}
+	for key, qty := range totals {
+		_, _, err := l.store.AddQuota(ctx, key, qty, -1)
+		if err != nil && !errors.Is(err, ErrQuotaCounterNotFound) {
+			l.logger.Error("failed to count usage against quota",
+				log.String("tenant_id", key.TenantID),
+				log.String("feature", key.FeatureKey),
+				log.Int64("quantity", qty),
+				log.Error(err),
+			)
+		}
+	}
+}
+
+// releaseQuota takes qty back off a counter. Counters already purged are
+// left alone; failures are logged.
+func (l *Ledger) releaseQuota(ctx context.Context, key entitlement.QuotaKey, qty int64) {
+	if qty == 0 {
+		return
+	}
+	_, _, err := l.store.AddQuota(ctx, key, -qty, -1)
+	if err != nil && !errors.Is(err, ErrQuotaCounterNotFound) {
+		l.logger.Error("failed to release quota",
+			log.String("tenant_id", key.TenantID),
+			log.String("feature", key.FeatureKey),
+			log.Int64("quantity", qty),
+			log.Error(err),
+		)
+	}
+}
+
+// result builds the entitlement result of q once used units are counted,
+// or of a denied request. It is neither cached nor reported to plugins.
+func (q *quota) result(used int64, allowed bool) *entitlement.Result {
+	result := &entitlement.Result{
+		Allowed:   allowed,
+		Feature:   q.feat.Key,
+		Used:      used,
+		Limit:     q.feat.Limit,
+		Remaining: max(0, q.feat.Limit-used),
+		SoftLimit: q.feat.SoftLimit,
+	}
+	switch {
+	case !allowed:
+		result.Reason = "quota exceeded"
+	case q.feat.Limit == -1:
+		result.Remaining = -1
+	case used > q.feat.Limit:
+		result.Reason = "over soft limit"
+	}
+	return result
+}
+
+// quotaWorker releases expired reservations and purges expired counters
+// every quota sweep interval.
+func (l *Ledger) quotaWorker(ctx context.Context) {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(l.quotaSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stopChan:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now().UTC()
+		expired, err := l.store.ExpiredReservations(ctx, now, 100)
+		if err != nil {
+			l.logger.Error("failed to list expired reservations", log.Error(err))
+		}
+		for _, r := range expired {
+			if err := l.releaseReservation(ctx, r.TenantID, r.AppID, r.ID); err != nil && !errors.Is(err, ErrReservationNotFound) {
+				l.logger.Error("failed to release expired reservation",
+					log.String("reservation_id", r.ID.String()),
+					log.Error(err),
+				)
+			}
+		}
+		if _, err := l.store.PurgeQuotas(ctx, now); err != nil {
+			l.logger.Error("failed to purge quota counters", log.Error(err))
+		}
+	}
+}
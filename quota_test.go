@@ -0,0 +1,151 @@
+package ledger_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xraph/ledger"
+	"github.com/xraph/ledger/plan"
+	"github.com/xraph/ledger/store/memory"
+)
+
+// renderMinutes is a hard-limited feature with 10 units a month.
+var renderMinutes = []plan.Feature{{
+	Key: "render", Name: "Render minutes", Type: plan.FeatureMetered, Limit: 10, Period: plan.PeriodMonthly,
+}}
+
+func TestReservationExpires(t *testing.T) {
+	l := startLedger(t, memory.New(), ledger.WithQuotaSweepInterval(5*time.Millisecond))
+	subscribe(t, l, "t1", renderMinutes)
+
+	tctx := tenantContext("t1", "app")
+	r, err := l.Reserve(tctx, "render", 8, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Consume(tctx, "render", 3); !errors.Is(err, ledger.ErrQuotaExceeded) {
+		t.Fatalf("Consume error = %v, want ErrQuotaExceeded while reserved", err)
+	}
+
+	// The sweep releases the reservation, returning its quota.
+	eventually(t, "the reservation to be released", func() bool {
+		_, err := l.Consume(tctx, "render", 3)
+		return err == nil
+	})
+	if err := l.CommitReservation(tctx, r.ID, 1); !errors.Is(err, ledger.ErrReservationNotFound) {
+		t.Errorf("CommitReservation error = %v, want ErrReservationNotFound", err)
+	}
+}
+
+func TestReservationCommittedOnce(t *testing.T) {
+	s := memory.New()
+	l := startLedger(t, s, ledger.WithMeterConfig(1, 5*time.Millisecond))
+	subscribe(t, l, "t1", renderMinutes)
+
+	tctx := tenantContext("t1", "app")
+	r, err := l.Reserve(tctx, "render", 5, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.CommitReservation(tctx, r.ID, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.CommitReservation(tctx, r.ID, 3); !errors.Is(err, ledger.ErrReservationNotFound) {
+		t.Fatalf("second CommitReservation error = %v, want ErrReservationNotFound", err)
+	}
+	eventually(t, "the committed usage to be flushed", func() bool { return usageCount(t, s, "t1") == 1 })
+
+	// Only the committed 3 count: 7 are left and the unused 2 are back.
+	res, err := l.Consume(tctx, "render", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Used != 10 || res.Remaining != 0 {
+		t.Errorf("Consume = %+v, want 10 used", res)
+	}
+	if _, err := l.Consume(tctx, "render", 1); !errors.Is(err, ledger.ErrQuotaExceeded) {
+		t.Errorf("Consume over the limit error = %v, want ErrQuotaExceeded", err)
+	}
+}
+
+func TestReservationOwnedByTenant(t *testing.T) {
+	l := startLedger(t, memory.New())
+	subscribe(t, l, "t1", renderMinutes)
+
+	r, err := l.Reserve(tenantContext("t1", "app"), "render", 5, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ctx := range []context.Context{tenantContext("t2", "app"), tenantContext("t1", "other")} {
+		if err := l.CommitReservation(ctx, r.ID, 1); !errors.Is(err, ledger.ErrReservationNotFound) {
+			t.Errorf("CommitReservation by another tenant error = %v, want ErrReservationNotFound", err)
+		}
+		if err := l.ReleaseReservation(ctx, r.ID); !errors.Is(err, ledger.ErrReservationNotFound) {
+			t.Errorf("ReleaseReservation by another tenant error = %v, want ErrReservationNotFound", err)
+		}
+	}
+	if err := l.ReleaseReservation(context.Background(), r.ID); !errors.Is(err, ledger.ErrInvalidInput) {
+		t.Errorf("ReleaseReservation without a tenant error = %v, want ErrInvalidInput", err)
+	}
+	if err := l.ReleaseReservation(tenantContext("t1", "app"), r.ID); err != nil {
+		t.Errorf("ReleaseReservation by its tenant: %v", err)
+	}
+}
+
+func TestConsumeCountsMeteredUsage(t *testing.T) {
+	s := memory.New()
+	l := startLedger(t, s, ledger.WithMeterConfig(1, 5*time.Millisecond))
+	subscribe(t, l, "t1", renderMinutes)
+
+	tctx := tenantContext("t1", "app")
+	if _, err := l.Consume(tctx, "render", 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Meter(tctx, "render", 5); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "the usage to be flushed", func() bool { return usageCount(t, s, "t1") == 2 })
+
+	res, err := l.Entitled(tctx, "render")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Used != 7 {
+		t.Errorf("Entitled used = %d, want 7", res.Used)
+	}
+	// The metered 5 count against the counter too, so only 3 are left.
+	if _, err := l.Consume(tctx, "render", 4); !errors.Is(err, ledger.ErrQuotaExceeded) {
+		t.Fatalf("Consume of 4 error = %v, want ErrQuotaExceeded", err)
+	}
+	if res, err = l.Consume(tctx, "render", 3); err != nil {
+		t.Fatal(err)
+	}
+	if res.Used != 10 {
+		t.Errorf("Consume used = %d, want 10", res.Used)
+	}
+}
+
+func TestConsumeReturnsDroppedUsage(t *testing.T) {
+	s := newGatedStore()
+	l := startGated(t, s, ledger.WithMeterOverflow(ledger.OverflowDrop))
+	subscribe(t, l, "t1", renderMinutes)
+
+	tctx := tenantContext("t1", "app")
+	fillBuffer(t, l, s)
+	if _, err := l.Consume(tctx, "render", 4); !errors.Is(err, ledger.ErrMeterBufferFull) {
+		t.Fatalf("Consume error = %v, want ErrMeterBufferFull for the dropped usage", err)
+	}
+
+	// The dropped 4 went back to the quota, so all 10 are left.
+	s.open()
+	eventually(t, "the buffered events to be flushed", func() bool { return usageCount(t, s.Store, "t1") == 2 })
+	res, err := l.Consume(tctx, "render", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Used != 10 {
+		t.Errorf("Consume used = %d, want 10", res.Used)
+	}
+}
@@ -0,0 +1,259 @@
+package ledger
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	log "github.com/xraph/go-utils/log"
+
+	"github.com/xraph/ledger/meter"
+	"github.com/xraph/ledger/meter/ratelimit"
+	"github.com/xraph/ledger/plan"
+)
+
+// Metered features with a rolling period (plan.Period.Rolling) are counted
+// in the rate counter as they are metered, before the buffer flushes, so
+// entitlement checks on them read the counter instead of scanning events.
+// Retries of an event already counted in the window are counted once, and a
+// counter that has not seen a feature, such as an in-process counter after a
+// restart, is first seeded with the usage stored for the window. Counting
+// covers sum and count aggregations; other aggregations over rolling periods
+// scan the events in the trailing window.
+
+// rateRule is how a rolling plan feature is counted.
+type rateRule struct {
+	period  plan.Period
+	limit   int64
+	limiter plan.Limiter
+	count   bool // count events rather than sum quantities
+}
+
+// rateEntry holds the rolling features of a tenant's plan and the current
+// usage windows of the features Consume can limit, both keyed by feature
+// key. A nil map records that there are none.
+type rateEntry struct {
+	rules   map[string]rateRule
+	quotas  map[string]quotaWindow
+	expires time.Time
+}
+
+// quotaWindow is the usage window [start, end) of a hard-limit counter.
+type quotaWindow struct {
+	start time.Time
+	end   time.Time
+}
+
+// rateKey is the rate counter key of a tenant's feature.
+func rateKey(tenantID, appID string, pf *plan.Feature) string {
+	return strings.Join([]string{"ledger", appID, tenantID, pf.Key, string(pf.Period)}, ":")
+}
+
+// rateRule returns how pf is counted, and false when it is not.
+func (l *Ledger) rateRule(ctx context.Context, appID string, pf *plan.Feature) (rateRule, bool) {
+	if pf.Type != plan.FeatureMetered || !pf.Period.Rolling() {
+		return rateRule{}, false
+	}
+	rule := rateRule{period: pf.Period, limit: pf.Limit, limiter: pf.Limiter}
+	if rule.limiter == "" {
+		rule.limiter = plan.LimiterSliding
+	}
+	if rule.limiter == plan.LimiterTokenBucket && rule.limit <= 0 {
+		return rateRule{}, false
+	}
+	switch l.aggregationFor(ctx, appID, pf).Kind() {
+	case meter.AggregateSum:
+	case meter.AggregateCount:
+		rule.count = true
+	default:
+		return rateRule{}, false
+	}
+	return rule, true
+}
+
+// rateRulesFor returns the counted rolling features of the tenant's active
+// plan, with its overrides applied.
+func (l *Ledger) rateRulesFor(ctx context.Context, tenantID, appID string) map[string]rateRule {
+	return l.tenantRules(ctx, tenantID, appID).rules
+}
+
+// tenantRules returns the rolling features and quota windows of the
+// tenant's active plan, with its overrides applied. Lookups, including
+// tenants without a subscription, are cached for the entitlement cache TTL
+// or until the first quota window ends.
+func (l *Ledger) tenantRules(ctx context.Context, tenantID, appID string) rateEntry {
+	cacheKey := appID + "/" + tenantID
+	now := time.Now()
+
+	l.rateMu.Lock()
+	entry, ok := l.rateRules[cacheKey]
+	l.rateMu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry
+	}
+
+	entry = rateEntry{expires: now.Add(l.entitlementCacheTTL)}
+	if sub, err := l.store.GetActiveSubscription(ctx, tenantID, appID); err == nil {
+		if p, err := l.store.GetPlan(ctx, sub.PlanID); err == nil {
+			overrides, _ := l.store.ListOverrides(ctx, tenantID, appID) //nolint:errcheck // plan limits apply without overrides
+			for _, key := range overrideKeys(p, overrides) {
+				pf := l.planFeature(ctx, appID, p, key, overrides, now)
+				if pf == nil {
+					continue
+				}
+				if rule, ok := l.rateRule(ctx, appID, pf); ok {
+					if entry.rules == nil {
+						entry.rules = make(map[string]rateRule)
+					}
+					entry.rules[pf.Key] = rule
+				}
+				if pf.Type == plan.FeatureMetered && !pf.Period.Rolling() &&
+					l.aggregationFor(ctx, appID, pf).Kind() == meter.AggregateSum {
+					start, end := usageWindow(sub, p, pf.Period, now)
+					if entry.quotas == nil {
+						entry.quotas = make(map[string]quotaWindow)
+					}
+					entry.quotas[pf.Key] = quotaWindow{start: start, end: end}
+					if !end.IsZero() && end.Before(entry.expires) {
+						entry.expires = end
+					}
+				}
+			}
+		}
+	}
+
+	l.rateMu.Lock()
+	l.rateRules[cacheKey] = entry
+	l.rateMu.Unlock()
+	return entry
+}
+
+// forgetRateRules drops the cached rolling features of a tenant after its
+// subscription changes.
+func (l *Ledger) forgetRateRules(tenantID, appID string) {
+	l.rateMu.Lock()
+	delete(l.rateRules, appID+"/"+tenantID)
+	l.rateMu.Unlock()
+}
+
+// countRate adds a metered event to the rate counter when its feature has a
+// counted rolling period. An event whose dedupe key, its idempotency key or
+// caller-assigned ID, was already counted in the window is skipped. Counter
+// failures are logged; the event itself is already buffered.
+func (l *Ledger) countRate(ctx context.Context, event *meter.UsageEvent, dedupe string) {
+	rule, ok := l.rateRulesFor(ctx, event.TenantID, event.AppID)[event.FeatureKey]
+	if !ok {
+		return
+	}
+	n := event.Quantity
+	if rule.count {
+		n = 1
+	}
+
+	pf := &plan.Feature{Key: event.FeatureKey, Period: rule.period}
+	key := rateKey(event.TenantID, event.AppID, pf)
+	now := time.Now()
+	counted, err := l.markRate(ctx, event.TenantID, event.AppID, pf.Key, key, rule, dedupe, now)
+	if err == nil && !counted {
+		if rule.limiter == plan.LimiterTokenBucket {
+			_, err = l.rateCounter.Take(ctx, key, rule.limit, rule.period.Length(), n, now)
+		} else {
+			err = ratelimit.SlidingAdd(ctx, l.rateCounter, key, rule.period.Length(), n, event.Timestamp)
+		}
+	}
+	if err != nil {
+		l.logger.Warn("failed to count usage for rate limit",
+			log.String("feature", event.FeatureKey),
+			log.Error(err),
+		)
+	}
+}
+
+// markRate seeds the counter key if it is cold, then records dedupe as
+// counted and reports whether it already was. An empty dedupe is never
+// counted before.
+func (l *Ledger) markRate(ctx context.Context, tenantID, appID, featureKey, key string, rule rateRule, dedupe string, now time.Time) (bool, error) {
+	l.rateCountMu.Lock()
+	defer l.rateCountMu.Unlock()
+
+	if err := l.warmRate(ctx, tenantID, appID, featureKey, key, rule, now); err != nil {
+		return false, err
+	}
+	if dedupe == "" {
+		return false, nil
+	}
+	marker := key + ":event:" + dedupe
+	values, err := l.rateCounter.Get(ctx, marker)
+	if err != nil || values[0] > 0 {
+		return err == nil, err
+	}
+	return false, l.rateCounter.Add(ctx, marker, 1, 2*rule.period.Length())
+}
+
+// warmRate seeds the counter key with the usage stored for its window the
+// first time the counter sees it, and keeps it marked as seeded for as long
+// as its buckets live. The caller holds rateCountMu.
+func (l *Ledger) warmRate(ctx context.Context, tenantID, appID, featureKey, key string, rule rateRule, now time.Time) error {
+	length := rule.period.Length()
+	marker := key + ":seeded"
+	values, err := l.rateCounter.Get(ctx, marker)
+	if err != nil {
+		return err
+	}
+	if values[0] == 0 {
+		agg := meter.Aggregation{Type: meter.AggregateSum}
+		if rule.count {
+			agg.Type = meter.AggregateCount
+		}
+		if rule.limiter == plan.LimiterTokenBucket {
+			used, err := l.store.Aggregate(ctx, tenantID, appID, featureKey, agg, now.Add(-length), time.Time{})
+			if err != nil {
+				return err
+			}
+			if _, err := l.rateCounter.Take(ctx, key, rule.limit, length, used, now); err != nil {
+				return err
+			}
+		} else {
+			current := ratelimit.SlidingStart(length, now)
+			for _, start := range []time.Time{current.Add(-length), current} {
+				used, err := l.store.Aggregate(ctx, tenantID, appID, featureKey, agg, start, start.Add(length))
+				if err != nil {
+					return err
+				}
+				if used == 0 {
+					continue
+				}
+				if err := ratelimit.SlidingAdd(ctx, l.rateCounter, key, length, used, start); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return l.rateCounter.Add(ctx, marker, 1, 2*length)
+}
+
+// rollingUsage returns the usage of a rolling feature from the rate
+// counter, and false when the feature is not counted there. Token bucket
+// features report the tokens spent, Limit minus the tokens left.
+func (l *Ledger) rollingUsage(ctx context.Context, tenantID, appID string, pf *plan.Feature, now time.Time) (int64, bool, error) {
+	rule, ok := l.rateRule(ctx, appID, pf)
+	if !ok {
+		return 0, false, nil
+	}
+	key := rateKey(tenantID, appID, pf)
+	if _, err := l.markRate(ctx, tenantID, appID, pf.Key, key, rule, "", now); err != nil {
+		return 0, false, err
+	}
+	if rule.limiter == plan.LimiterTokenBucket {
+		left, err := l.rateCounter.Take(ctx, key, rule.limit, rule.period.Length(), 0, now)
+		if err != nil {
+			return 0, false, err
+		}
+		return rule.limit - left, true, nil
+	}
+	used, err := ratelimit.SlidingUsage(ctx, l.rateCounter, key, rule.period.Length(), now)
+	if err != nil {
+		return 0, false, err
+	}
+	return used, true, nil
+}
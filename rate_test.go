@@ -0,0 +1,143 @@
+package ledger_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xraph/ledger"
+	"github.com/xraph/ledger/id"
+	"github.com/xraph/ledger/meter"
+	"github.com/xraph/ledger/meter/ratelimit"
+	"github.com/xraph/ledger/plan"
+	"github.com/xraph/ledger/store/memory"
+)
+
+func TestRollingPeriodEnforcedBeforeFlush(t *testing.T) {
+	tests := []struct {
+		name    string
+		limiter plan.Limiter
+	}{
+		{"Sliding window", plan.LimiterSliding},
+		{"Token bucket", plan.LimiterTokenBucket},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := memory.New()
+			// Nothing is flushed during the test, so the checks can only
+			// see the usage through the rate counter.
+			l := startLedger(t, s, ledger.WithMeterConfig(100, time.Hour))
+			subscribe(t, l, "t1", []plan.Feature{{
+				Key: "api_calls", Name: "API calls", Type: plan.FeatureMetered,
+				Limit: 3, Period: plan.PeriodMinute, Limiter: tt.limiter,
+			}})
+
+			tctx := tenantContext("t1", "app")
+			for i := range 3 {
+				res, err := l.Entitled(tctx, "api_calls")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !res.Allowed || res.Used != int64(i) {
+					t.Fatalf("check %d = %+v, want allowed with %d used", i, res, i)
+				}
+				if err := l.Meter(tctx, "api_calls", 1); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			res, err := l.Entitled(tctx, "api_calls")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Allowed || res.Used != 3 || res.Remaining != 0 {
+				t.Errorf("check after the limit = %+v, want denied with 3 used", res)
+			}
+			if n := usageCount(t, s, "t1"); n != 0 {
+				t.Errorf("%d events flushed, want none", n)
+			}
+		})
+	}
+}
+
+func TestRateCountsRetriesOnce(t *testing.T) {
+	s := memory.New()
+	l := startLedger(t, s, ledger.WithMeterConfig(100, time.Hour))
+	subscribe(t, l, "t1", []plan.Feature{{
+		Key: "api_calls", Name: "API calls", Type: plan.FeatureMetered, Limit: 10, Period: plan.PeriodMinute,
+	}})
+
+	tctx := tenantContext("t1", "app")
+	for range 2 {
+		if err := l.MeterWithOptions(tctx, "api_calls", 2, ledger.MeterOptions{IdempotencyKey: "req-1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A redrive resubmits the event with the ID it was first given.
+	eventID := id.NewUsageEventID()
+	for range 2 {
+		if err := l.MeterEvent(tctx, &meter.UsageEvent{ID: eventID, FeatureKey: "api_calls", Quantity: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := l.Entitled(tctx, "api_calls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Used != 3 {
+		t.Errorf("used = %d, want 3 with each retry counted once", res.Used)
+	}
+}
+
+func TestRateCounterSeededFromStore(t *testing.T) {
+	tests := []struct {
+		name    string
+		limiter plan.Limiter
+	}{
+		{"Sliding window", plan.LimiterSliding},
+		{"Token bucket", plan.LimiterTokenBucket},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := memory.New()
+			// Usage stored before the Ledger started, as after a restart
+			// that lost the in-process counter.
+			now := time.Now()
+			var events []*meter.UsageEvent
+			for _, at := range []time.Time{ratelimit.SlidingStart(time.Hour, now), now.Add(-3 * time.Hour)} {
+				events = append(events, &meter.UsageEvent{
+					ID: id.NewUsageEventID(), TenantID: "t1", AppID: "app", FeatureKey: "api_calls", Quantity: 4, Timestamp: at,
+				})
+			}
+			if _, err := s.IngestBatch(ctx, events); err != nil {
+				t.Fatal(err)
+			}
+
+			l := startLedger(t, s, ledger.WithMeterConfig(100, time.Hour))
+			subscribe(t, l, "t1", []plan.Feature{{
+				Key: "api_calls", Name: "API calls", Type: plan.FeatureMetered,
+				Limit: 10, Period: plan.PeriodHour, Limiter: tt.limiter,
+			}})
+
+			tctx := tenantContext("t1", "app")
+			res, err := l.Entitled(tctx, "api_calls")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Used != 4 {
+				t.Fatalf("used = %d, want the 4 stored in the last hour", res.Used)
+			}
+			if err := l.Meter(tctx, "api_calls", 1); err != nil {
+				t.Fatal(err)
+			}
+			if res, err = l.Entitled(tctx, "api_calls"); err != nil {
+				t.Fatal(err)
+			}
+			if res.Used != 5 {
+				t.Errorf("used = %d after metering, want 5", res.Used)
+			}
+		})
+	}
+}
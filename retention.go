@@ -0,0 +1,263 @@
+package ledger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"time"
+
+	log "github.com/xraph/go-utils/log"
+
+	"github.com/xraph/ledger/cachebus"
+	"github.com/xraph/ledger/id"
+	"github.com/xraph/ledger/meter"
+	"github.com/xraph/ledger/meter/archive"
+	"github.com/xraph/ledger/store"
+	"github.com/xraph/ledger/subscription"
+)
+
+// archiveBatchSize is the most events written to one archive file.
+const archiveBatchSize = 10000
+
+// RetentionPolicy keeps an app's raw usage events in the store for
+// Retention and moves older ones into archive files. It needs a store with
+// usage rollups (PostgreSQL and SQLite): the cutoff is rounded down to the
+// hour, so the hourly and daily rollups keep the archived usage and sum,
+// count and max aggregations over archived periods are unchanged. Events of
+// open billing periods are never archived, since invoices and the other
+// aggregations read them raw.
+type RetentionPolicy struct {
+	AppID     string
+	Retention time.Duration
+	// Format defaults to archive.FormatNDJSON.
+	Format archive.Format
+}
+
+func (p RetentionPolicy) validate() error {
+	if p.AppID == "" || p.Retention <= 0 {
+		return fmt.Errorf("%w: retention policy needs an app and a positive retention", ErrInvalidInput)
+	}
+	switch p.Format {
+	case "", archive.FormatNDJSON, archive.FormatParquet:
+		return nil
+	}
+	return fmt.Errorf("%w: unknown archive format %q", ErrInvalidInput, p.Format)
+}
+
+// ArchiveResult reports the events an archive run moved and the archive
+// files it wrote.
+type ArchiveResult struct {
+	Events int64
+	Files  []string
+}
+
+// ArchiveUsage moves the policy's app's usage events older than the
+// retention window into archive files, oldest first. Each file is written
+// before its events are deleted, so a failed run never loses events; a
+// file whose events could not be deleted restores as duplicates only.
+// Retention policies call it in the background.
+func (l *Ledger) ArchiveUsage(ctx context.Context, policy RetentionPolicy) (*ArchiveResult, error) {
+	if l.usageArchive == nil {
+		return nil, fmt.Errorf("%w: no usage archive configured", ErrInvalidInput)
+	}
+	if err := policy.validate(); err != nil {
+		return nil, err
+	}
+	if err := l.checkUsageRollups(); err != nil {
+		return nil, err
+	}
+	format := policy.Format
+	if format == "" {
+		format = archive.FormatNDJSON
+	}
+
+	cutoff, err := l.archiveCutoff(ctx, policy)
+	if err != nil {
+		return nil, err
+	}
+	result := &ArchiveResult{}
+	for ctx.Err() == nil {
+		events, err := l.store.ScanUsage(ctx, policy.AppID, cutoff, archiveBatchSize)
+		if err != nil {
+			return result, err
+		}
+		if len(events) == 0 {
+			return result, nil
+		}
+
+		name, err := l.writeArchive(ctx, policy.AppID, format, events)
+		if err != nil {
+			return result, err
+		}
+		ids := make([]id.UsageEventID, len(events))
+		for i, e := range events {
+			ids[i] = e.ID
+		}
+		deleted, err := l.store.DeleteUsage(ctx, ids)
+		if err != nil {
+			return result, err
+		}
+		result.Events += deleted
+		result.Files = append(result.Files, name)
+		l.plugins.EmitUsageArchived(ctx, policy.AppID, name, len(events))
+
+		if len(events) < archiveBatchSize {
+			return result, nil
+		}
+	}
+	return result, ctx.Err()
+}
+
+// checkUsageRollups returns an error unless the store keeps usage rollups,
+// without which archived usage would drop out of every aggregation.
+func (l *Ledger) checkUsageRollups() error {
+	if r, ok := l.store.(store.UsageRollups); ok && r.HasUsageRollups() {
+		return nil
+	}
+	return fmt.Errorf("%w: archiving usage needs a store with usage rollups", ErrInvalidInput)
+}
+
+// archiveCutoff returns the time before which policy archives events: the
+// end of the retention window, moved back to the start of the app's
+// earliest open billing period and rounded down to the hour. Invoices,
+// unique and last aggregations, usage aggregators, dimension groups and
+// corrections read the raw events of open periods.
+func (l *Ledger) archiveCutoff(ctx context.Context, policy RetentionPolicy) (time.Time, error) {
+	cutoff := time.Now().UTC().Add(-policy.Retention)
+	subs, err := l.store.ListSubscriptions(ctx, "", policy.AppID, subscription.ListOpts{})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("list subscriptions: %w", err)
+	}
+	for _, sub := range subs {
+		if sub.Status == subscription.StatusCanceled || sub.Status == subscription.StatusExpired {
+			continue
+		}
+		if !sub.CurrentPeriodStart.IsZero() && sub.CurrentPeriodStart.Before(cutoff) {
+			cutoff = sub.CurrentPeriodStart
+		}
+	}
+	return meter.BucketStart(meter.GranularityHour, cutoff), nil
+}
+
+// writeArchive writes events to a new archive file named after the app and
+// the first event, and returns the file name.
+func (l *Ledger) writeArchive(ctx context.Context, appID string, format archive.Format, events []*meter.UsageEvent) (string, error) {
+	var buf bytes.Buffer
+	if err := archive.Encode(&buf, format, events); err != nil {
+		return "", err
+	}
+
+	first := events[0]
+	name := path.Join(url.PathEscape(appID), fmt.Sprintf("usage-%s-%s%s",
+		first.Timestamp.UTC().Format("20060102T150405Z"), first.ID, format.Ext()))
+	w, err := l.usageArchive.Create(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		_ = w.Close() //nolint:errcheck // the write error is reported
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// RestoreUsage reads the archive file name back into the store. Events
+// still in the store are skipped. Restoring does not touch the rollups,
+// which still hold the archived usage.
+func (l *Ledger) RestoreUsage(ctx context.Context, name string) (*meter.IngestResult, error) {
+	if l.usageArchive == nil {
+		return nil, fmt.Errorf("%w: no usage archive configured", ErrInvalidInput)
+	}
+	format, err := archive.FormatOf(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	r, err := l.usageArchive.Open(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	events, err := archive.Decode(r, format)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &meter.IngestResult{}
+	for start := 0; start < len(events); start += archiveBatchSize {
+		end := min(start+archiveBatchSize, len(events))
+		res, err := l.store.RestoreUsage(ctx, events[start:end])
+		if err != nil {
+			return result, err
+		}
+		result.Inserted += res.Inserted
+		result.Duplicates += res.Duplicates
+		result.InsertedIDs = append(result.InsertedIDs, res.InsertedIDs...)
+	}
+
+	// Stores without rollups aggregate the restored events again.
+	seen := make(map[tenantApp]struct{})
+	for _, e := range events {
+		k := tenantApp{tenantID: e.TenantID, appID: e.AppID}
+		if _, ok := seen[k]; !ok {
+			seen[k] = struct{}{}
+			l.invalidate(ctx, cachebus.Message{Kind: cachebus.KindUsage, TenantID: e.TenantID, AppID: e.AppID})
+		}
+	}
+
+	l.plugins.EmitUsageRestored(ctx, name, result.Inserted)
+	return result, nil
+}
+
+// ListUsageArchives returns the names of the archive files of an app,
+// oldest first, or of every app when appID is empty.
+func (l *Ledger) ListUsageArchives(ctx context.Context, appID string) ([]string, error) {
+	if l.usageArchive == nil {
+		return nil, fmt.Errorf("%w: no usage archive configured", ErrInvalidInput)
+	}
+	prefix := ""
+	if appID != "" {
+		prefix = url.PathEscape(appID) + "/"
+	}
+	return l.usageArchive.List(ctx, prefix)
+}
+
+// retentionWorker applies the retention policies now and then every
+// retention interval.
+func (l *Ledger) retentionWorker(ctx context.Context) {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(l.retentionInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, policy := range l.retention {
+			result, err := l.ArchiveUsage(ctx, policy)
+			if err != nil {
+				l.logger.Error("failed to archive usage",
+					log.String("app_id", policy.AppID),
+					log.Error(err),
+				)
+			}
+			if result != nil && result.Events > 0 {
+				l.logger.Info("archived usage",
+					log.String("app_id", policy.AppID),
+					log.Int64("events", result.Events),
+					log.Int("files", len(result.Files)),
+				)
+			}
+		}
+
+		select {
+		case <-l.stopChan:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
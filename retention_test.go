@@ -0,0 +1,86 @@
+package ledger_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xraph/ledger"
+	"github.com/xraph/ledger/id"
+	"github.com/xraph/ledger/meter"
+	"github.com/xraph/ledger/meter/archive"
+	"github.com/xraph/ledger/plan"
+	"github.com/xraph/ledger/store/memory"
+	"github.com/xraph/ledger/types"
+)
+
+// rollupStore stands in for a store with usage rollups, so the memory
+// store accepts retention policies.
+type rollupStore struct{ *memory.Store }
+
+func (rollupStore) HasUsageRollups() bool { return true }
+
+func TestRetentionNeedsUsageRollups(t *testing.T) {
+	ctx := context.Background()
+	policy := ledger.RetentionPolicy{AppID: "app", Retention: time.Hour}
+
+	l := ledger.New(memory.New(), ledger.WithUsageArchive(archive.Dir(t.TempDir())), ledger.WithRetentionPolicy(policy))
+	if err := l.Start(ctx); !errors.Is(err, ledger.ErrInvalidInput) {
+		_ = l.Stop()
+		t.Fatalf("Start = %v, want ErrInvalidInput", err)
+	}
+
+	l = startLedger(t, memory.New(), ledger.WithUsageArchive(archive.Dir(t.TempDir())))
+	if _, err := l.ArchiveUsage(ctx, policy); !errors.Is(err, ledger.ErrInvalidInput) {
+		t.Fatalf("ArchiveUsage = %v, want ErrInvalidInput", err)
+	}
+}
+
+func TestRetentionKeepsOpenBillingPeriods(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	l := startLedger(t, rollupStore{s}, ledger.WithUsageArchive(archive.Dir(t.TempDir())))
+	_, sub := subscribe(t, l, "t1",
+		[]plan.Feature{{Key: "api_calls", Name: "API calls", Type: plan.FeatureMetered, Limit: -1, Period: plan.PeriodMonthly}},
+		plan.PriceTier{FeatureKey: "api_calls", Type: plan.TierGraduated, UpTo: -1, UnitAmount: types.USD(1)},
+	)
+	start := time.Now().UTC().AddDate(0, 0, -10).Truncate(time.Hour)
+	sub.CurrentPeriodStart = start
+	sub.CurrentPeriodEnd = start.AddDate(0, 1, 0)
+	if err := s.UpdateSubscription(ctx, sub); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every event is past the hour of retention, but only the one before
+	// the period is closed.
+	var events []*meter.UsageEvent
+	for _, at := range []time.Time{start.AddDate(0, 0, -2), start.Add(time.Hour), start.AddDate(0, 0, 2), time.Now().Add(-2 * time.Hour)} {
+		events = append(events, &meter.UsageEvent{
+			ID: id.NewUsageEventID(), TenantID: "t1", AppID: "app", FeatureKey: "api_calls", Quantity: 3, Timestamp: at,
+		})
+	}
+	if _, err := s.IngestBatch(ctx, events); err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := l.GenerateInvoice(ctx, sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := l.ArchiveUsage(ctx, ledger.RetentionPolicy{AppID: "app", Retention: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Events != 1 || len(result.Files) != 1 {
+		t.Fatalf("archived %d events in %d files, want the one before the period in one file", result.Events, len(result.Files))
+	}
+	after, err := l.GenerateInvoice(ctx, sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if before.Total.Amount != 9 || after.Total.Amount != before.Total.Amount {
+		t.Errorf("invoice totals before and after archiving = %v, %v, want 9 both times", before.Total, after.Total)
+	}
+}
@@ -0,0 +1,129 @@
+package ledger
+
+import (
+	"context"
+	"time"
+
+	"github.com/xraph/ledger/cachebus"
+	"github.com/xraph/ledger/invoice"
+	"github.com/xraph/ledger/meter"
+	"github.com/xraph/ledger/plan"
+	"github.com/xraph/ledger/subscription"
+)
+
+// SetSeats records that the current tenant holds seats seats of a seat
+// feature from now on. Seat features are gauges: entitlement checks read
+// the latest count and invoices bill the period's maximum or time-weighted
+// average (see plan.SeatBilling). Readings are written to the store
+// immediately, bypassing the meter buffer.
+func (l *Ledger) SetSeats(ctx context.Context, featureKey string, seats int64) error {
+	if seats < 0 {
+		return ErrInvalidQuantity
+	}
+	tenantID := extractTenantID(ctx)
+	appID := extractAppID(ctx)
+	if tenantID == "" || appID == "" || featureKey == "" {
+		return ErrInvalidInput
+	}
+	return l.recordSeats(ctx, tenantID, appID, featureKey, seats)
+}
+
+// AddSeat adds one seat to the current tenant's count and returns the new
+// count. It does not check the plan limit; call Entitled first.
+func (l *Ledger) AddSeat(ctx context.Context, featureKey string) (int64, error) {
+	return l.adjustSeats(ctx, featureKey, 1)
+}
+
+// RemoveSeat removes one seat from the current tenant's count and returns
+// the new count. Removing a seat when none are held fails with
+// ErrInvalidQuantity.
+func (l *Ledger) RemoveSeat(ctx context.Context, featureKey string) (int64, error) {
+	return l.adjustSeats(ctx, featureKey, -1)
+}
+
+// adjustSeats records the current seat count plus delta. Adjustments are
+// serialized within this Ledger; instances sharing a store should set
+// absolute counts with SetSeats instead.
+func (l *Ledger) adjustSeats(ctx context.Context, featureKey string, delta int64) (int64, error) {
+	tenantID := extractTenantID(ctx)
+	appID := extractAppID(ctx)
+	if tenantID == "" || appID == "" || featureKey == "" {
+		return 0, ErrInvalidInput
+	}
+
+	l.seatMu.Lock()
+	defer l.seatMu.Unlock()
+
+	seats, err := l.store.CurrentSeats(ctx, tenantID, appID, featureKey, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	seats += delta
+	if seats < 0 {
+		return 0, ErrInvalidQuantity
+	}
+	if err := l.recordSeats(ctx, tenantID, appID, featureKey, seats); err != nil {
+		return 0, err
+	}
+	return seats, nil
+}
+
+func (l *Ledger) recordSeats(ctx context.Context, tenantID, appID, featureKey string, seats int64) error {
+	err := l.store.RecordSeats(ctx, &meter.SeatReading{
+		TenantID:   tenantID,
+		AppID:      appID,
+		FeatureKey: featureKey,
+		Seats:      seats,
+		Timestamp:  time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	l.invalidate(ctx, cachebus.Message{Kind: cachebus.KindUsage, TenantID: tenantID, AppID: appID, Features: []string{featureKey}})
+	return nil
+}
+
+// seatLineItem bills a seat feature for the subscription's current period:
+// the maximum or time-weighted average seat count, per pf.SeatBilling,
+// priced with the feature's tiers. It returns nil when there is nothing to
+// bill.
+func (l *Ledger) seatLineItem(ctx context.Context, sub *subscription.Subscription, p *plan.Plan, pf *plan.Feature) (*invoice.LineItem, error) {
+	start, end := sub.CurrentPeriodStart, sub.CurrentPeriodEnd
+	initial, err := l.store.CurrentSeats(ctx, sub.TenantID, sub.AppID, pf.Key, start)
+	if err != nil {
+		return nil, err
+	}
+	readings, err := l.store.SeatReadings(ctx, sub.TenantID, sub.AppID, pf.Key, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	billing := pf.SeatBilling
+	if billing == "" {
+		billing = plan.SeatBillingMax
+	}
+	var seats int64
+	switch billing {
+	case plan.SeatBillingAverage:
+		seats = meter.AverageSeats(initial, readings, start, end)
+	default:
+		seats = meter.MaxSeats(initial, readings)
+	}
+	if seats <= 0 {
+		return nil, nil
+	}
+
+	amount := l.priceUsage(p, pf, p.Pricing.TiersFor(pf.Key), seats)
+	if !amount.IsPositive() {
+		return nil, nil
+	}
+	return &invoice.LineItem{
+		FeatureKey:  pf.Key,
+		Description: pf.Name + " (" + string(billing) + ")",
+		Quantity:    seats,
+		UnitAmount:  amount.Divide(seats),
+		Amount:      amount,
+		Type:        invoice.LineItemSeat,
+		Metadata:    map[string]string{"seat_billing": string(billing)},
+	}, nil
+}
@@ -0,0 +1,77 @@
+package ledger_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/xraph/ledger"
+	"github.com/xraph/ledger/invoice"
+	"github.com/xraph/ledger/plan"
+	"github.com/xraph/ledger/store/memory"
+	"github.com/xraph/ledger/types"
+)
+
+func TestSeats(t *testing.T) {
+	l := startLedger(t, memory.New())
+	_, sub := subscribe(t, l, "t1",
+		[]plan.Feature{{Key: "seats", Name: "Seats", Type: plan.FeatureSeat, Limit: 3}},
+		plan.PriceTier{FeatureKey: "seats", UpTo: -1, UnitAmount: types.USD(100)},
+	)
+	ctx := tenantContext("t1", "app")
+
+	for i, step := range []struct {
+		add  bool
+		want int64
+	}{{true, 1}, {true, 2}, {false, 1}, {false, 0}} {
+		adjust := l.RemoveSeat
+		if step.add {
+			adjust = l.AddSeat
+		}
+		got, err := adjust(ctx, "seats")
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if got != step.want {
+			t.Fatalf("step %d: seats = %d, want %d", i, got, step.want)
+		}
+	}
+	if _, err := l.RemoveSeat(ctx, "seats"); !errors.Is(err, ledger.ErrInvalidQuantity) {
+		t.Fatalf("RemoveSeat with no seats error = %v, want ErrInvalidQuantity", err)
+	}
+
+	// Entitlement checks read the latest count.
+	if err := l.SetSeats(ctx, "seats", 3); err != nil {
+		t.Fatal(err)
+	}
+	result, err := l.Entitled(ctx, "seats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Allowed || result.Used != 3 {
+		t.Errorf("Entitled = %+v, want 3 seats used and no room for another", result)
+	}
+	if err := l.SetSeats(ctx, "seats", 1); err != nil {
+		t.Fatal(err)
+	}
+	if result, err = l.Entitled(ctx, "seats"); err != nil {
+		t.Fatal(err)
+	}
+	if !result.Allowed || result.Used != 1 {
+		t.Errorf("Entitled after SetSeats(1) = %+v, want 1 seat used", result)
+	}
+
+	// The invoice bills the period's maximum.
+	inv, err := l.GenerateInvoice(tenantContext("t1", "app"), sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seats []invoice.LineItem
+	for _, li := range inv.LineItems {
+		if li.Type == invoice.LineItemSeat {
+			seats = append(seats, li)
+		}
+	}
+	if len(seats) != 1 || seats[0].Quantity != 3 || !seats[0].Amount.Equal(types.USD(300)) {
+		t.Errorf("seat lines = %+v, want 3 seats at 100", seats)
+	}
+}
@@ -3,6 +3,7 @@ package memory
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -29,10 +30,26 @@ type Store struct {
 	// Usage events storage
 	usageEvents []meter.UsageEvent
 
+	// Lifetime usage totals, keyed by tenant, app and feature
+	lifetime map[lifetimeKey]*meter.LifetimeTotal
+
+	// Seat gauge readings, in insertion order
+	seatReadings []meter.SeatReading
+
 	// Entitlement cache
 	entitlementCache map[string]*entitlement.Result
 	cacheExpiry      map[string]time.Time
 
+	// Hard-limit counters and reservations
+	quotas       map[string]*quotaCounter
+	reservations map[string]*entitlement.Reservation
+
+	// Per-tenant entitlement overrides
+	overrides map[string]*entitlement.Override
+
+	// Sent usage alerts and when their records expire
+	usageAlerts map[string]time.Time
+
 	// Invoice storage
 	invoices map[string]*invoice.Invoice
 
@@ -48,8 +65,13 @@ func New() *Store {
 		plans:            make(map[string]*plan.Plan),
 		subscriptions:    make(map[string]*subscription.Subscription),
 		usageEvents:      make([]meter.UsageEvent, 0),
+		lifetime:         make(map[lifetimeKey]*meter.LifetimeTotal),
 		entitlementCache: make(map[string]*entitlement.Result),
 		cacheExpiry:      make(map[string]time.Time),
+		quotas:           make(map[string]*quotaCounter),
+		reservations:     make(map[string]*entitlement.Reservation),
+		overrides:        make(map[string]*entitlement.Override),
+		usageAlerts:      make(map[string]time.Time),
 		invoices:         make(map[string]*invoice.Invoice),
 		coupons:          make(map[string]*coupon.Coupon),
 		features:         make(map[string]*feature.Feature),
@@ -188,7 +210,7 @@ func (s *Store) ListSubscriptions(_ context.Context, tenantID, appID string, opt
 	result := make([]*subscription.Subscription, 0)
 	for _, sub := range s.subscriptions {
 		if (tenantID == "" || sub.TenantID == tenantID) && (appID == "" || sub.AppID == appID) {
-			if opts.Status == "" || sub.Status == opts.Status {
+			if (opts.Status == "" || sub.Status == opts.Status) && (opts.PlanID.IsNil() || sub.PlanID == opts.PlanID) {
 				result = append(result, sub)
 			}
 		}
@@ -221,52 +243,208 @@ func (s *Store) CancelSubscription(_ context.Context, subID id.SubscriptionID, c
 }
 
 // Meter Store implementation
-func (s *Store) IngestBatch(_ context.Context, events []*meter.UsageEvent) error {
+func (s *Store) IngestBatch(_ context.Context, events []*meter.UsageEvent) (*meter.IngestResult, error) {
+	return s.insertUsage(events, true), nil
+}
+
+// RestoreUsage inserts archived events without adding them to the lifetime
+// totals again; the memory store keeps no rollups.
+func (s *Store) RestoreUsage(_ context.Context, events []*meter.UsageEvent) (*meter.IngestResult, error) {
+	return s.insertUsage(events, false), nil
+}
+
+type lifetimeKey struct{ tenantID, appID, featureKey string }
+
+func (s *Store) insertUsage(events []*meter.UsageEvent, count bool) *meter.IngestResult {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	result := &meter.IngestResult{}
+next:
 	for _, e := range events {
-		// Check for duplicate idempotency key
-		if e.IdempotencyKey != "" {
-			for _, existing := range s.usageEvents {
-				if existing.IdempotencyKey == e.IdempotencyKey {
-					continue // Skip duplicate
-				}
+		// Skip events already stored (e.g. replayed from the WAL) and
+		// idempotency keys already seen for the same app and tenant
+		for _, existing := range s.usageEvents {
+			if existing.ID == e.ID ||
+				(e.IdempotencyKey != "" &&
+					existing.IdempotencyKey == e.IdempotencyKey &&
+					existing.AppID == e.AppID &&
+					existing.TenantID == e.TenantID) {
+				result.Duplicates++
+				continue next
 			}
 		}
 		s.usageEvents = append(s.usageEvents, *e)
+		result.Inserted++
+		result.InsertedIDs = append(result.InsertedIDs, e.ID)
+		if count && e.Counted() {
+			s.addLifetime(e, 1)
+		}
 	}
-	return nil
+	return result
 }
 
-func (s *Store) Aggregate(_ context.Context, tenantID, appID, featureKey string, period plan.Period) (int64, error) {
+// addLifetime adds an event to, or with sign -1 removes it from, the
+// lifetime totals.
+func (s *Store) addLifetime(e *meter.UsageEvent, sign int64) {
+	k := lifetimeKey{tenantID: e.TenantID, appID: e.AppID, featureKey: e.FeatureKey}
+	t, ok := s.lifetime[k]
+	if !ok {
+		t = &meter.LifetimeTotal{}
+		s.lifetime[k] = t
+	}
+	t.Quantity += sign * e.Quantity
+	t.Events += sign
+}
+
+func (s *Store) LifetimeUsage(_ context.Context, tenantID, appID string, featureKeys []string) (map[string]meter.LifetimeTotal, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	var total int64
-	now := time.Now()
-	startOfPeriod := getStartOfPeriod(now, period)
+	result := make(map[string]meter.LifetimeTotal, len(featureKeys))
+	for _, key := range featureKeys {
+		if t, ok := s.lifetime[lifetimeKey{tenantID: tenantID, appID: appID, featureKey: key}]; ok {
+			result[key] = *t
+		}
+	}
+	return result, nil
+}
 
-	for _, event := range s.usageEvents {
-		if event.TenantID == tenantID &&
-			event.AppID == appID &&
-			event.FeatureKey == featureKey &&
-			event.Timestamp.After(startOfPeriod) {
-			total += event.Quantity
+func (s *Store) Aggregate(ctx context.Context, tenantID, appID, featureKey string, agg meter.Aggregation, start, end time.Time) (int64, error) {
+	totals, err := s.AggregateMulti(ctx, tenantID, appID, map[string]meter.Aggregation{featureKey: agg}, start, end)
+	if err != nil {
+		return 0, err
+	}
+	return totals[featureKey], nil
+}
+
+// usageTotal accumulates one feature's aggregation over a pass of events.
+type usageTotal struct {
+	agg    meter.Aggregation
+	value  int64
+	last   time.Time
+	seen   map[string]struct{}
+	hasAny bool
+}
+
+func (t *usageTotal) add(e *meter.UsageEvent) {
+	switch t.agg.Kind() {
+	case meter.AggregateCount:
+		t.value++
+	case meter.AggregateMax:
+		if !t.hasAny || e.Quantity > t.value {
+			t.value = e.Quantity
+		}
+	case meter.AggregateUnique:
+		if v, ok := e.Metadata[t.agg.Property]; ok {
+			t.seen[v] = struct{}{}
+		}
+	case meter.AggregateLast:
+		if !t.hasAny || !e.Timestamp.Before(t.last) {
+			t.value, t.last = e.Quantity, e.Timestamp
 		}
+	default: // meter.AggregateSum
+		t.value += e.Quantity
 	}
+	t.hasAny = true
+}
 
-	return total, nil
+func (t *usageTotal) result() int64 {
+	if t.agg.Kind() == meter.AggregateUnique {
+		return int64(len(t.seen))
+	}
+	return t.value
 }
 
-func (s *Store) AggregateMulti(ctx context.Context, tenantID, appID string, featureKeys []string, period plan.Period) (map[string]int64, error) {
-	result := make(map[string]int64)
-	for _, key := range featureKeys {
-		total, err := s.Aggregate(ctx, tenantID, appID, key, period)
-		if err != nil {
-			return nil, err
+// AggregateMulti computes every feature's aggregation in a single pass over
+// the stored events.
+func (s *Store) AggregateMulti(_ context.Context, tenantID, appID string, features map[string]meter.Aggregation, start, end time.Time) (map[string]int64, error) {
+	totals := make(map[string]*usageTotal, len(features))
+	for key, agg := range features {
+		kind := agg.Kind()
+		if !kind.IsBuiltin() {
+			return nil, fmt.Errorf("%w: %q", ledger.ErrUnsupportedAggregation, agg.Type)
+		}
+		if kind == meter.AggregateUnique && agg.Property == "" {
+			return nil, fmt.Errorf("%w: unique aggregation needs a property", ledger.ErrUnsupportedAggregation)
+		}
+		totals[key] = &usageTotal{agg: agg, seen: make(map[string]struct{})}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for i := range s.usageEvents {
+		event := &s.usageEvents[i]
+		if event.TenantID != tenantID ||
+			event.AppID != appID ||
+			!event.Counted() ||
+			!inWindow(event.Timestamp, start, end) {
+			continue
+		}
+		if t, ok := totals[event.FeatureKey]; ok {
+			t.add(event)
+		}
+	}
+
+	result := make(map[string]int64, len(totals))
+	for key, t := range totals {
+		result[key] = t.result()
+	}
+	return result, nil
+}
+
+// AggregateGroups computes the aggregation for every combination of the
+// grouped metadata values in a single pass over the stored events.
+func (s *Store) AggregateGroups(_ context.Context, tenantID, appID string, agg meter.Aggregation, opts meter.QueryOpts) ([]*meter.GroupTotal, error) {
+	kind := agg.Kind()
+	if !kind.IsBuiltin() {
+		return nil, fmt.Errorf("%w: %q", ledger.ErrUnsupportedAggregation, agg.Type)
+	}
+	if kind == meter.AggregateUnique && agg.Property == "" {
+		return nil, fmt.Errorf("%w: unique aggregation needs a property", ledger.ErrUnsupportedAggregation)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type group struct {
+		dims  map[string]string
+		total *usageTotal
+	}
+	var (
+		groups []*group
+		byKey  = make(map[string]*group)
+	)
+	for i := range s.usageEvents {
+		event := &s.usageEvents[i]
+		if event.TenantID != tenantID ||
+			event.AppID != appID ||
+			event.FeatureKey != opts.FeatureKey ||
+			!event.Counted() ||
+			!inWindow(event.Timestamp, opts.Start, opts.End) ||
+			!meter.MatchDimensions(event.Metadata, opts.Dimensions) {
+			continue
+		}
+
+		dims := make(map[string]string, len(opts.GroupBy))
+		key := ""
+		for _, k := range opts.GroupBy {
+			dims[k] = event.Metadata[k]
+			key += k + "\x00" + dims[k] + "\x00"
+		}
+		g, ok := byKey[key]
+		if !ok {
+			g = &group{dims: dims, total: &usageTotal{agg: agg, seen: make(map[string]struct{})}}
+			byKey[key] = g
+			groups = append(groups, g)
 		}
-		result[key] = total
+		g.total.add(event)
+	}
+
+	result := make([]*meter.GroupTotal, len(groups))
+	for i, g := range groups {
+		result[i] = &meter.GroupTotal{Dimensions: g.dims, Value: g.total.result()}
 	}
 	return result, nil
 }
@@ -279,9 +457,8 @@ func (s *Store) QueryUsage(_ context.Context, tenantID, appID string, opts meter
 	for i := range s.usageEvents {
 		e := &s.usageEvents[i]
 		if (tenantID == "" || e.TenantID == tenantID) && (appID == "" || e.AppID == appID) {
-			if opts.FeatureKey == "" || e.FeatureKey == opts.FeatureKey {
-				if (opts.Start.IsZero() || e.Timestamp.After(opts.Start)) &&
-					(opts.End.IsZero() || e.Timestamp.Before(opts.End)) {
+			if (opts.FeatureKey == "" || e.FeatureKey == opts.FeatureKey) && meter.MatchDimensions(e.Metadata, opts.Dimensions) {
+				if inWindow(e.Timestamp, opts.Start, opts.End) {
 					result = append(result, e)
 				}
 			}
@@ -307,6 +484,153 @@ func (s *Store) PurgeUsage(_ context.Context, before time.Time) (int64, error) {
 	return count, nil
 }
 
+// ScanUsage returns up to limit events of an app with timestamps before
+// before, oldest first.
+func (s *Store) ScanUsage(_ context.Context, appID string, before time.Time, limit int) ([]*meter.UsageEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*meter.UsageEvent, 0)
+	for i := range s.usageEvents {
+		e := s.usageEvents[i]
+		if e.AppID == appID && e.Timestamp.Before(before) {
+			result = append(result, &e)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].Timestamp.Equal(result[j].Timestamp) {
+			return result[i].Timestamp.Before(result[j].Timestamp)
+		}
+		return result[i].ID.String() < result[j].ID.String()
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// DeleteUsage removes events by ID.
+func (s *Store) DeleteUsage(_ context.Context, eventIDs []id.UsageEventID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	remove := make(map[id.UsageEventID]struct{}, len(eventIDs))
+	for _, evtID := range eventIDs {
+		remove[evtID] = struct{}{}
+	}
+	var count int64
+	newEvents := make([]meter.UsageEvent, 0, len(s.usageEvents))
+	for _, e := range s.usageEvents {
+		if _, ok := remove[e.ID]; ok {
+			count++
+		} else {
+			newEvents = append(newEvents, e)
+		}
+	}
+	s.usageEvents = newEvents
+	return count, nil
+}
+
+func (s *Store) VoidUsage(_ context.Context, tenantID, appID string, opts meter.VoidOpts) (*meter.UsageEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.usageEvents {
+		e := &s.usageEvents[i]
+		if e.TenantID != tenantID || e.AppID != appID {
+			continue
+		}
+		if !opts.EventID.IsNil() {
+			if e.ID != opts.EventID {
+				continue
+			}
+		} else if e.IdempotencyKey == "" || e.IdempotencyKey != opts.IdempotencyKey {
+			continue
+		}
+		if e.VoidedAt != nil {
+			return nil, ledger.ErrUsageEventVoided
+		}
+		voidedAt := opts.VoidedAt
+		e.VoidedAt = &voidedAt
+		e.VoidReason = opts.Reason
+		if !e.Correction {
+			s.addLifetime(e, -1)
+		}
+		evt := *e
+		return &evt, nil
+	}
+	return nil, ledger.ErrUsageEventNotFound
+}
+
+func (s *Store) SumCorrections(_ context.Context, tenantID, appID string, featureKeys []string, start, end time.Time) (map[string]int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make(map[string]int64, len(featureKeys))
+	wanted := make(map[string]struct{}, len(featureKeys))
+	for _, key := range featureKeys {
+		wanted[key] = struct{}{}
+	}
+	for i := range s.usageEvents {
+		e := &s.usageEvents[i]
+		if e.TenantID != tenantID || e.AppID != appID ||
+			!e.Correction || e.VoidedAt != nil ||
+			!inWindow(e.Timestamp, start, end) {
+			continue
+		}
+		if _, ok := wanted[e.FeatureKey]; ok {
+			result[e.FeatureKey] += e.Quantity
+		}
+	}
+	return result, nil
+}
+
+// Seat gauge implementation
+func (s *Store) RecordSeats(_ context.Context, r *meter.SeatReading) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seatReadings = append(s.seatReadings, *r)
+	return nil
+}
+
+func (s *Store) CurrentSeats(_ context.Context, tenantID, appID, featureKey string, at time.Time) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var (
+		seats  int64
+		latest time.Time
+		found  bool
+	)
+	for i := range s.seatReadings {
+		r := &s.seatReadings[i]
+		if r.TenantID != tenantID || r.AppID != appID || r.FeatureKey != featureKey || r.Timestamp.After(at) {
+			continue
+		}
+		// Later insertions win ties.
+		if !found || !r.Timestamp.Before(latest) {
+			seats, latest, found = r.Seats, r.Timestamp, true
+		}
+	}
+	return seats, nil
+}
+
+func (s *Store) SeatReadings(_ context.Context, tenantID, appID, featureKey string, start, end time.Time) ([]*meter.SeatReading, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []*meter.SeatReading
+	for i := range s.seatReadings {
+		r := s.seatReadings[i]
+		if r.TenantID == tenantID && r.AppID == appID && r.FeatureKey == featureKey && inWindow(r.Timestamp, start, end) {
+			result = append(result, &r)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Timestamp.Before(result[j].Timestamp) })
+	return result, nil
+}
+
 // Entitlement Store implementation
 func (s *Store) GetCached(_ context.Context, tenantID, appID, featureKey string) (*entitlement.Result, error) {
 	s.mu.RLock()
@@ -357,6 +681,179 @@ func (s *Store) InvalidateFeature(_ context.Context, tenantID, appID, featureKey
 	return nil
 }
 
+// Quota Store implementation
+
+type quotaCounter struct {
+	used      int64
+	expiresAt time.Time
+}
+
+func quotaKey(key entitlement.QuotaKey) string {
+	return fmt.Sprintf("%s:%s:%s:%s", key.TenantID, key.AppID, key.FeatureKey, key.WindowStart.UTC().Format(time.RFC3339Nano))
+}
+
+func (s *Store) SeedQuota(_ context.Context, key entitlement.QuotaKey, used int64, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := quotaKey(key)
+	if _, ok := s.quotas[k]; !ok {
+		s.quotas[k] = &quotaCounter{used: used, expiresAt: expiresAt}
+	}
+	return nil
+}
+
+func (s *Store) AddQuota(_ context.Context, key entitlement.QuotaKey, qty, limit int64) (int64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.quotas[quotaKey(key)]
+	if !ok {
+		return 0, false, ledger.ErrQuotaCounterNotFound
+	}
+	if limit >= 0 && c.used+qty > limit {
+		return c.used, false, nil
+	}
+	c.used += qty
+	return c.used, true, nil
+}
+
+func (s *Store) PurgeQuotas(_ context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for k, c := range s.quotas {
+		if !c.expiresAt.IsZero() && c.expiresAt.Before(before) {
+			delete(s.quotas, k)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (s *Store) CreateReservation(_ context.Context, r *entitlement.Reservation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rsv := *r
+	s.reservations[r.ID.String()] = &rsv
+	return nil
+}
+
+func (s *Store) TakeReservation(_ context.Context, tenantID, appID string, reservationID id.ReservationID) (*entitlement.Reservation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.reservations[reservationID.String()]
+	if !ok || r.TenantID != tenantID || r.AppID != appID {
+		return nil, ledger.ErrReservationNotFound
+	}
+	delete(s.reservations, reservationID.String())
+	return r, nil
+}
+
+func (s *Store) ExpiredReservations(_ context.Context, before time.Time, limit int) ([]*entitlement.Reservation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []*entitlement.Reservation
+	for _, r := range s.reservations {
+		if r.ExpiresAt.Before(before) {
+			rsv := *r
+			result = append(result, &rsv)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ExpiresAt.Before(result[j].ExpiresAt) })
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// Override Store implementation
+func (s *Store) CreateOverride(_ context.Context, o *entitlement.Override) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.overrides[o.ID.String()]; exists {
+		return ledger.ErrAlreadyExists
+	}
+	ovr := *o
+	s.overrides[o.ID.String()] = &ovr
+	return nil
+}
+
+func (s *Store) GetOverride(_ context.Context, overrideID id.OverrideID) (*entitlement.Override, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	o, ok := s.overrides[overrideID.String()]
+	if !ok {
+		return nil, ledger.ErrOverrideNotFound
+	}
+	ovr := *o
+	return &ovr, nil
+}
+
+func (s *Store) ListOverrides(_ context.Context, tenantID, appID string) ([]*entitlement.Override, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []*entitlement.Override
+	for _, o := range s.overrides {
+		if o.TenantID == tenantID && o.AppID == appID {
+			ovr := *o
+			result = append(result, &ovr)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].CreatedAt.After(result[j].CreatedAt)
+		}
+		return result[i].ID.String() > result[j].ID.String()
+	})
+	return result, nil
+}
+
+func (s *Store) DeleteOverride(_ context.Context, overrideID id.OverrideID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.overrides[overrideID.String()]; !ok {
+		return ledger.ErrOverrideNotFound
+	}
+	delete(s.overrides, overrideID.String())
+	return nil
+}
+
+// Usage alert Store implementation
+func (s *Store) RecordUsageAlert(_ context.Context, key entitlement.QuotaKey, threshold int, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := fmt.Sprintf("%s:%d", quotaKey(key), threshold)
+	if _, ok := s.usageAlerts[k]; ok {
+		return false, nil
+	}
+	s.usageAlerts[k] = expiresAt
+	return true, nil
+}
+
+func (s *Store) PurgeUsageAlerts(_ context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for k, expiresAt := range s.usageAlerts {
+		if !expiresAt.IsZero() && expiresAt.Before(before) {
+			delete(s.usageAlerts, k)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
 // Invoice Store implementation
 func (s *Store) CreateInvoice(_ context.Context, inv *invoice.Invoice) error {
 	s.mu.Lock()
@@ -657,13 +1154,9 @@ func (s *Store) Close() error {
 }
 
 // Helper functions
-func getStartOfPeriod(t time.Time, period plan.Period) time.Time {
-	switch period {
-	case plan.PeriodMonthly:
-		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
-	case plan.PeriodYearly:
-		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location())
-	default:
-		return time.Time{}
-	}
+
+// inWindow reports whether t falls in [start, end), treating zero bounds as
+// open.
+func inWindow(t, start, end time.Time) bool {
+	return (start.IsZero() || !t.Before(start)) && (end.IsZero() || t.Before(end))
 }
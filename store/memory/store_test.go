@@ -0,0 +1,15 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/xraph/ledger/store/storetest"
+)
+
+func TestQueryUsageWindow(t *testing.T) {
+	storetest.QueryUsageWindow(t, New())
+}
+
+func TestListSubscriptionsByPlan(t *testing.T) {
+	storetest.ListSubscriptionsByPlan(t, New())
+}
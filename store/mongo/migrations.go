@@ -217,5 +217,176 @@ func init() {
 				return mexec.DropCollection(ctx, (*featureCatalogModel)(nil))
 			},
 		},
+		&migrate.Migration{
+			Name:    "scope_usage_idempotency_keys",
+			Version: "20240101000008",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				mexec, ok := exec.(*mongomigrate.Executor)
+				if !ok {
+					return fmt.Errorf("expected mongomigrate executor, got %T", exec)
+				}
+
+				// The old global idempotency_key index is dropped by Store.Migrate.
+				return mexec.CreateIndexes(ctx, colUsageEvents, []mongo.IndexModel{
+					{
+						Keys: bson.D{{Key: "app_id", Value: 1}, {Key: "tenant_id", Value: 1}, {Key: "idempotency_key", Value: 1}},
+						Options: options.Index().SetUnique(true).
+							SetPartialFilterExpression(bson.M{"idempotency_key": bson.M{"$exists": true}}),
+					},
+				})
+			},
+			Down: func(_ context.Context, _ migrate.Executor) error {
+				// The scoped index is harmless if left in place.
+				return nil
+			},
+		},
+		&migrate.Migration{
+			Name:    "add_usage_corrections_index",
+			Version: "20240101000009",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				mexec, ok := exec.(*mongomigrate.Executor)
+				if !ok {
+					return fmt.Errorf("expected mongomigrate executor, got %T", exec)
+				}
+
+				return mexec.CreateIndexes(ctx, colUsageEvents, []mongo.IndexModel{
+					{
+						Keys:    bson.D{{Key: "tenant_id", Value: 1}, {Key: "app_id", Value: 1}, {Key: "feature_key", Value: 1}, {Key: "timestamp", Value: -1}},
+						Options: options.Index().SetPartialFilterExpression(bson.M{"correction": true}),
+					},
+				})
+			},
+			Down: func(_ context.Context, _ migrate.Executor) error {
+				// The partial index is harmless if left in place.
+				return nil
+			},
+		},
+		&migrate.Migration{
+			Name:    "create_ledger_seat_readings",
+			Version: "20240101000010",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				mexec, ok := exec.(*mongomigrate.Executor)
+				if !ok {
+					return fmt.Errorf("expected mongomigrate executor, got %T", exec)
+				}
+
+				if err := mexec.CreateCollection(ctx, (*seatReadingModel)(nil)); err != nil {
+					return err
+				}
+
+				return mexec.CreateIndexes(ctx, colSeatReadings, []mongo.IndexModel{
+					{Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "app_id", Value: 1}, {Key: "feature_key", Value: 1}, {Key: "timestamp", Value: -1}}},
+				})
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				mexec, ok := exec.(*mongomigrate.Executor)
+				if !ok {
+					return fmt.Errorf("expected mongomigrate executor, got %T", exec)
+				}
+				return mexec.DropCollection(ctx, (*seatReadingModel)(nil))
+			},
+		},
+		&migrate.Migration{
+			Name:    "create_ledger_usage_counters",
+			Version: "20240101000011",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				mexec, ok := exec.(*mongomigrate.Executor)
+				if !ok {
+					return fmt.Errorf("expected mongomigrate executor, got %T", exec)
+				}
+				// Counters are keyed by _id; Store.Migrate backfills them.
+				return mexec.CreateCollection(ctx, (*usageCounterModel)(nil))
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				mexec, ok := exec.(*mongomigrate.Executor)
+				if !ok {
+					return fmt.Errorf("expected mongomigrate executor, got %T", exec)
+				}
+				return mexec.DropCollection(ctx, (*usageCounterModel)(nil))
+			},
+		},
+		&migrate.Migration{
+			Name:    "create_ledger_quotas",
+			Version: "20240101000012",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				mexec, ok := exec.(*mongomigrate.Executor)
+				if !ok {
+					return fmt.Errorf("expected mongomigrate executor, got %T", exec)
+				}
+
+				if err := mexec.CreateCollection(ctx, (*quotaCounterModel)(nil)); err != nil {
+					return err
+				}
+				if err := mexec.CreateCollection(ctx, (*reservationModel)(nil)); err != nil {
+					return err
+				}
+				if err := mexec.CreateIndexes(ctx, colQuotaCounters, []mongo.IndexModel{
+					{Keys: bson.D{{Key: "expires_at", Value: 1}}},
+				}); err != nil {
+					return err
+				}
+				return mexec.CreateIndexes(ctx, colReservations, []mongo.IndexModel{
+					{Keys: bson.D{{Key: "expires_at", Value: 1}}},
+				})
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				mexec, ok := exec.(*mongomigrate.Executor)
+				if !ok {
+					return fmt.Errorf("expected mongomigrate executor, got %T", exec)
+				}
+				if err := mexec.DropCollection(ctx, (*reservationModel)(nil)); err != nil {
+					return err
+				}
+				return mexec.DropCollection(ctx, (*quotaCounterModel)(nil))
+			},
+		},
+		&migrate.Migration{
+			Name:    "create_ledger_entitlement_overrides",
+			Version: "20240101000013",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				mexec, ok := exec.(*mongomigrate.Executor)
+				if !ok {
+					return fmt.Errorf("expected mongomigrate executor, got %T", exec)
+				}
+
+				if err := mexec.CreateCollection(ctx, (*overrideModel)(nil)); err != nil {
+					return err
+				}
+				return mexec.CreateIndexes(ctx, colOverrides, []mongo.IndexModel{
+					{Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "app_id", Value: 1}, {Key: "created_at", Value: -1}}},
+				})
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				mexec, ok := exec.(*mongomigrate.Executor)
+				if !ok {
+					return fmt.Errorf("expected mongomigrate executor, got %T", exec)
+				}
+				return mexec.DropCollection(ctx, (*overrideModel)(nil))
+			},
+		},
+		&migrate.Migration{
+			Name:    "create_ledger_usage_alerts",
+			Version: "20240101000014",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				mexec, ok := exec.(*mongomigrate.Executor)
+				if !ok {
+					return fmt.Errorf("expected mongomigrate executor, got %T", exec)
+				}
+
+				if err := mexec.CreateCollection(ctx, (*usageAlertModel)(nil)); err != nil {
+					return err
+				}
+				return mexec.CreateIndexes(ctx, colUsageAlerts, []mongo.IndexModel{
+					{Keys: bson.D{{Key: "expires_at", Value: 1}}},
+				})
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				mexec, ok := exec.(*mongomigrate.Executor)
+				if !ok {
+					return fmt.Errorf("expected mongomigrate executor, got %T", exec)
+				}
+				return mexec.DropCollection(ctx, (*usageAlertModel)(nil))
+			},
+		},
 	)
 }
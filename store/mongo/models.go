@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"time"
 
+	"go.mongodb.org/mongo-driver/v2/bson"
+
 	"github.com/xraph/grove"
 
 	"github.com/xraph/ledger/coupon"
@@ -40,16 +42,18 @@ type planModel struct {
 }
 
 type featureModel struct {
-	ID        string            `bson:"id"`
-	Key       string            `bson:"key"`
-	Name      string            `bson:"name"`
-	Type      string            `bson:"type"`
-	Limit     int64             `bson:"limit"`
-	Period    string            `bson:"period"`
-	SoftLimit bool              `bson:"soft_limit"`
-	Metadata  map[string]string `bson:"metadata,omitempty"`
-	CreatedAt time.Time         `bson:"created_at"`
-	UpdatedAt time.Time         `bson:"updated_at"`
+	ID          string            `bson:"id"`
+	Key         string            `bson:"key"`
+	Name        string            `bson:"name"`
+	Type        string            `bson:"type"`
+	Limit       int64             `bson:"limit"`
+	Period      string            `bson:"period"`
+	SoftLimit   bool              `bson:"soft_limit"`
+	Metadata    map[string]string `bson:"metadata,omitempty"`
+	CreatedAt   time.Time         `bson:"created_at"`
+	UpdatedAt   time.Time         `bson:"updated_at"`
+	SeatBilling string            `bson:"seat_billing,omitempty"`
+	Limiter     string            `bson:"limiter,omitempty"`
 }
 
 type pricingModel struct {
@@ -64,30 +68,33 @@ type pricingModel struct {
 }
 
 type priceTierModel struct {
-	FeatureKey         string `bson:"feature_key"`
-	Type               string `bson:"type"`
-	UpTo               int64  `bson:"up_to"`
-	UnitAmountCents    int64  `bson:"unit_amount_cents"`
-	UnitAmountCurrency string `bson:"unit_amount_currency"`
-	FlatAmountCents    int64  `bson:"flat_amount_cents"`
-	FlatAmountCurrency string `bson:"flat_amount_currency"`
-	Priority           int    `bson:"priority"`
+	FeatureKey         string            `bson:"feature_key"`
+	Type               string            `bson:"type"`
+	UpTo               int64             `bson:"up_to"`
+	UnitAmountCents    int64             `bson:"unit_amount_cents"`
+	UnitAmountCurrency string            `bson:"unit_amount_currency"`
+	FlatAmountCents    int64             `bson:"flat_amount_cents"`
+	FlatAmountCurrency string            `bson:"flat_amount_currency"`
+	Priority           int               `bson:"priority"`
+	Dimensions         map[string]string `bson:"dimensions,omitempty"`
 }
 
 func toPlanModel(p *plan.Plan) *planModel {
 	features := make([]featureModel, len(p.Features))
 	for i, f := range p.Features {
 		features[i] = featureModel{
-			ID:        f.ID.String(),
-			Key:       f.Key,
-			Name:      f.Name,
-			Type:      string(f.Type),
-			Limit:     f.Limit,
-			Period:    string(f.Period),
-			SoftLimit: f.SoftLimit,
-			Metadata:  f.Metadata,
-			CreatedAt: f.CreatedAt,
-			UpdatedAt: f.UpdatedAt,
+			ID:          f.ID.String(),
+			Key:         f.Key,
+			Name:        f.Name,
+			Type:        string(f.Type),
+			Limit:       f.Limit,
+			Period:      string(f.Period),
+			SoftLimit:   f.SoftLimit,
+			Metadata:    f.Metadata,
+			CreatedAt:   f.CreatedAt,
+			UpdatedAt:   f.UpdatedAt,
+			SeatBilling: string(f.SeatBilling),
+			Limiter:     string(f.Limiter),
 		}
 	}
 
@@ -104,6 +111,7 @@ func toPlanModel(p *plan.Plan) *planModel {
 				FlatAmountCents:    t.FlatAmount.Amount,
 				FlatAmountCurrency: t.FlatAmount.Currency,
 				Priority:           t.Priority,
+				Dimensions:         t.Dimensions,
 			}
 		}
 		pricing = &pricingModel{
@@ -158,14 +166,16 @@ func fromPlanModel(m *planModel) (*plan.Plan, error) {
 				CreatedAt: f.CreatedAt,
 				UpdatedAt: f.UpdatedAt,
 			},
-			ID:        fID,
-			Key:       f.Key,
-			Name:      f.Name,
-			Type:      plan.FeatureType(f.Type),
-			Limit:     f.Limit,
-			Period:    plan.Period(f.Period),
-			SoftLimit: f.SoftLimit,
-			Metadata:  f.Metadata,
+			ID:          fID,
+			Key:         f.Key,
+			Name:        f.Name,
+			Type:        plan.FeatureType(f.Type),
+			Limit:       f.Limit,
+			Period:      plan.Period(f.Period),
+			SoftLimit:   f.SoftLimit,
+			Metadata:    f.Metadata,
+			SeatBilling: plan.SeatBilling(f.SeatBilling),
+			Limiter:     plan.Limiter(f.Limiter),
 		}
 	}
 
@@ -189,6 +199,7 @@ func fromPlanModel(m *planModel) (*plan.Plan, error) {
 				UnitAmount: types.Money{Amount: t.UnitAmountCents, Currency: t.UnitAmountCurrency},
 				FlatAmount: types.Money{Amount: t.FlatAmountCents, Currency: t.FlatAmountCurrency},
 				Priority:   t.Priority,
+				Dimensions: t.Dimensions,
 			}
 		}
 		pricing = &plan.Pricing{
@@ -317,6 +328,10 @@ type usageEventModel struct {
 	Timestamp      time.Time         `grove:"timestamp"       bson:"timestamp"`
 	IdempotencyKey string            `grove:"idempotency_key" bson:"idempotency_key,omitempty"`
 	Metadata       map[string]string `grove:"metadata"        bson:"metadata,omitempty"`
+	Correction     bool              `grove:"correction"      bson:"correction,omitempty"`
+	Reason         string            `grove:"reason"          bson:"reason,omitempty"`
+	VoidedAt       *time.Time        `grove:"voided_at"       bson:"voided_at,omitempty"`
+	VoidReason     string            `grove:"void_reason"     bson:"void_reason,omitempty"`
 	CreatedAt      time.Time         `grove:"created_at"      bson:"created_at"`
 }
 
@@ -330,6 +345,10 @@ func toUsageEventModel(e *meter.UsageEvent) *usageEventModel {
 		Timestamp:      e.Timestamp,
 		IdempotencyKey: e.IdempotencyKey,
 		Metadata:       e.Metadata,
+		Correction:     e.Correction,
+		Reason:         e.Reason,
+		VoidedAt:       e.VoidedAt,
+		VoidReason:     e.VoidReason,
 		CreatedAt:      time.Now().UTC(),
 	}
 }
@@ -349,6 +368,133 @@ func fromUsageEventModel(m *usageEventModel) (*meter.UsageEvent, error) {
 		Timestamp:      m.Timestamp,
 		IdempotencyKey: m.IdempotencyKey,
 		Metadata:       m.Metadata,
+		Correction:     m.Correction,
+		Reason:         m.Reason,
+		VoidedAt:       m.VoidedAt,
+		VoidReason:     m.VoidReason,
+	}, nil
+}
+
+// ==================== Seat Reading models ====================
+
+type seatReadingModel struct {
+	grove.BaseModel `grove:"table:ledger_seat_readings"`
+
+	ID         bson.ObjectID `grove:"id,pk"       bson:"_id,omitempty"`
+	TenantID   string        `grove:"tenant_id"   bson:"tenant_id"`
+	AppID      string        `grove:"app_id"      bson:"app_id"`
+	FeatureKey string        `grove:"feature_key" bson:"feature_key"`
+	Seats      int64         `grove:"seats"       bson:"seats"`
+	Timestamp  time.Time     `grove:"timestamp"   bson:"timestamp"`
+}
+
+func toSeatReadingModel(r *meter.SeatReading) *seatReadingModel {
+	return &seatReadingModel{
+		TenantID:   r.TenantID,
+		AppID:      r.AppID,
+		FeatureKey: r.FeatureKey,
+		Seats:      r.Seats,
+		Timestamp:  r.Timestamp,
+	}
+}
+
+func fromSeatReadingModel(m *seatReadingModel) *meter.SeatReading {
+	return &meter.SeatReading{
+		TenantID:   m.TenantID,
+		AppID:      m.AppID,
+		FeatureKey: m.FeatureKey,
+		Seats:      m.Seats,
+		Timestamp:  m.Timestamp,
+	}
+}
+
+// ==================== Usage Counter models ====================
+
+type usageCounterModel struct {
+	grove.BaseModel `grove:"table:ledger_usage_counters"`
+
+	CounterKey string `grove:"counter_key,pk" bson:"_id"`
+	TenantID   string `grove:"tenant_id"      bson:"tenant_id"`
+	AppID      string `grove:"app_id"         bson:"app_id"`
+	FeatureKey string `grove:"feature_key"    bson:"feature_key"`
+	Quantity   int64  `grove:"quantity"       bson:"quantity"`
+	Events     int64  `grove:"events"         bson:"events"`
+}
+
+func usageCounterKey(tenantID, appID, featureKey string) string {
+	return tenantID + ":" + appID + ":" + featureKey
+}
+
+// ==================== Quota models ====================
+
+type quotaCounterModel struct {
+	grove.BaseModel `grove:"table:ledger_quota_counters"`
+
+	CounterKey  string     `grove:"counter_key,pk" bson:"_id"`
+	TenantID    string     `grove:"tenant_id"      bson:"tenant_id"`
+	AppID       string     `grove:"app_id"         bson:"app_id"`
+	FeatureKey  string     `grove:"feature_key"    bson:"feature_key"`
+	WindowStart time.Time  `grove:"window_start"   bson:"window_start"`
+	Used        int64      `grove:"used"           bson:"used"`
+	ExpiresAt   *time.Time `grove:"expires_at"     bson:"expires_at,omitempty"`
+}
+
+func quotaCounterKey(key entitlement.QuotaKey) string {
+	return key.TenantID + ":" + key.AppID + ":" + key.FeatureKey + ":" + key.WindowStart.UTC().Format(time.RFC3339Nano)
+}
+
+type usageAlertModel struct {
+	grove.BaseModel `grove:"table:ledger_usage_alerts"`
+
+	AlertKey    string     `grove:"alert_key,pk" bson:"_id"`
+	TenantID    string     `grove:"tenant_id"    bson:"tenant_id"`
+	AppID       string     `grove:"app_id"       bson:"app_id"`
+	FeatureKey  string     `grove:"feature_key"  bson:"feature_key"`
+	WindowStart time.Time  `grove:"window_start" bson:"window_start"`
+	Threshold   int        `grove:"threshold"    bson:"threshold"`
+	ExpiresAt   *time.Time `grove:"expires_at"   bson:"expires_at,omitempty"`
+}
+
+type reservationModel struct {
+	grove.BaseModel `grove:"table:ledger_reservations"`
+
+	ID          string    `grove:"id,pk"        bson:"_id"`
+	TenantID    string    `grove:"tenant_id"    bson:"tenant_id"`
+	AppID       string    `grove:"app_id"       bson:"app_id"`
+	FeatureKey  string    `grove:"feature_key"  bson:"feature_key"`
+	WindowStart time.Time `grove:"window_start" bson:"window_start"`
+	Quantity    int64     `grove:"quantity"     bson:"quantity"`
+	ExpiresAt   time.Time `grove:"expires_at"   bson:"expires_at"`
+	CreatedAt   time.Time `grove:"created_at"   bson:"created_at"`
+}
+
+func toReservationModel(r *entitlement.Reservation) *reservationModel {
+	return &reservationModel{
+		ID:          r.ID.String(),
+		TenantID:    r.TenantID,
+		AppID:       r.AppID,
+		FeatureKey:  r.FeatureKey,
+		WindowStart: r.WindowStart,
+		Quantity:    r.Quantity,
+		ExpiresAt:   r.ExpiresAt,
+		CreatedAt:   r.CreatedAt,
+	}
+}
+
+func fromReservationModel(m *reservationModel) (*entitlement.Reservation, error) {
+	rsvID, err := id.ParseReservationID(m.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &entitlement.Reservation{
+		ID:          rsvID,
+		TenantID:    m.TenantID,
+		AppID:       m.AppID,
+		FeatureKey:  m.FeatureKey,
+		WindowStart: m.WindowStart,
+		Quantity:    m.Quantity,
+		ExpiresAt:   m.ExpiresAt,
+		CreatedAt:   m.CreatedAt,
 	}, nil
 }
 
@@ -402,6 +548,54 @@ func fromEntitlementCacheModel(m *entitlementCacheModel) *entitlement.Result {
 	}
 }
 
+// ==================== Override models ====================
+
+type overrideModel struct {
+	grove.BaseModel `grove:"table:ledger_entitlement_overrides"`
+
+	ID         string     `grove:"id,pk"       bson:"_id"`
+	TenantID   string     `grove:"tenant_id"   bson:"tenant_id"`
+	AppID      string     `grove:"app_id"      bson:"app_id"`
+	FeatureKey string     `grove:"feature_key" bson:"feature_key"`
+	Mode       string     `grove:"mode"        bson:"mode"`
+	Value      int64      `grove:"value"       bson:"value"`
+	ExpiresAt  *time.Time `grove:"expires_at"  bson:"expires_at,omitempty"`
+	Reason     string     `grove:"reason"      bson:"reason"`
+	CreatedAt  time.Time  `grove:"created_at"  bson:"created_at"`
+}
+
+func toOverrideModel(o *entitlement.Override) *overrideModel {
+	return &overrideModel{
+		ID:         o.ID.String(),
+		TenantID:   o.TenantID,
+		AppID:      o.AppID,
+		FeatureKey: o.FeatureKey,
+		Mode:       string(o.Mode),
+		Value:      o.Value,
+		ExpiresAt:  o.ExpiresAt,
+		Reason:     o.Reason,
+		CreatedAt:  o.CreatedAt,
+	}
+}
+
+func fromOverrideModel(m *overrideModel) (*entitlement.Override, error) {
+	ovrID, err := id.ParseOverrideID(m.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &entitlement.Override{
+		ID:         ovrID,
+		TenantID:   m.TenantID,
+		AppID:      m.AppID,
+		FeatureKey: m.FeatureKey,
+		Mode:       entitlement.OverrideMode(m.Mode),
+		Value:      m.Value,
+		ExpiresAt:  m.ExpiresAt,
+		Reason:     m.Reason,
+		CreatedAt:  m.CreatedAt,
+	}, nil
+}
+
 // ==================== Invoice models ====================
 
 type invoiceModel struct {
@@ -637,40 +831,46 @@ func fromCouponModel(m *couponModel) (*coupon.Coupon, error) {
 type featureCatalogModel struct {
 	grove.BaseModel `grove:"table:ledger_features"`
 
-	ID           string            `grove:"id,pk"         bson:"_id"`
-	Key          string            `grove:"key"           bson:"key"`
-	Name         string            `grove:"name"          bson:"name"`
-	Description  string            `grove:"description"   bson:"description"`
-	Type         string            `grove:"type"          bson:"type"`
-	DefaultLimit int64             `grove:"default_limit" bson:"default_limit"`
-	Period       string            `grove:"period"        bson:"period"`
-	SoftLimit    bool              `grove:"soft_limit"    bson:"soft_limit"`
-	Status       string            `grove:"status"        bson:"status"`
-	AppID        string            `grove:"app_id"        bson:"app_id"`
-	ProviderID   string            `grove:"provider_id"   bson:"provider_id"`
-	ProviderName string            `grove:"provider_name" bson:"provider_name"`
-	Metadata     map[string]string `grove:"metadata"      bson:"metadata,omitempty"`
-	CreatedAt    time.Time         `grove:"created_at"    bson:"created_at"`
-	UpdatedAt    time.Time         `grove:"updated_at"    bson:"updated_at"`
+	ID                  string            `grove:"id,pk"                bson:"_id"`
+	Key                 string            `grove:"key"                  bson:"key"`
+	Name                string            `grove:"name"                 bson:"name"`
+	Description         string            `grove:"description"          bson:"description"`
+	Type                string            `grove:"type"                 bson:"type"`
+	DefaultLimit        int64             `grove:"default_limit"        bson:"default_limit"`
+	Period              string            `grove:"period"               bson:"period"`
+	SoftLimit           bool              `grove:"soft_limit"           bson:"soft_limit"`
+	Status              string            `grove:"status"               bson:"status"`
+	AppID               string            `grove:"app_id"               bson:"app_id"`
+	ProviderID          string            `grove:"provider_id"          bson:"provider_id"`
+	ProviderName        string            `grove:"provider_name"        bson:"provider_name"`
+	Aggregation         string            `grove:"aggregation"          bson:"aggregation,omitempty"`
+	AggregationProperty string            `grove:"aggregation_property" bson:"aggregation_property,omitempty"`
+	Dimensions          []string          `grove:"dimensions"           bson:"dimensions,omitempty"`
+	Metadata            map[string]string `grove:"metadata"             bson:"metadata,omitempty"`
+	CreatedAt           time.Time         `grove:"created_at"           bson:"created_at"`
+	UpdatedAt           time.Time         `grove:"updated_at"           bson:"updated_at"`
 }
 
 func toFeatureCatalogModel(f *feature.Feature) *featureCatalogModel {
 	return &featureCatalogModel{
-		ID:           f.ID.String(),
-		Key:          f.Key,
-		Name:         f.Name,
-		Description:  f.Description,
-		Type:         string(f.Type),
-		DefaultLimit: f.DefaultLimit,
-		Period:       string(f.Period),
-		SoftLimit:    f.SoftLimit,
-		Status:       string(f.Status),
-		AppID:        f.AppID,
-		ProviderID:   f.ProviderID,
-		ProviderName: f.ProviderName,
-		Metadata:     f.Metadata,
-		CreatedAt:    f.CreatedAt,
-		UpdatedAt:    f.UpdatedAt,
+		ID:                  f.ID.String(),
+		Key:                 f.Key,
+		Name:                f.Name,
+		Description:         f.Description,
+		Type:                string(f.Type),
+		DefaultLimit:        f.DefaultLimit,
+		Period:              string(f.Period),
+		SoftLimit:           f.SoftLimit,
+		Status:              string(f.Status),
+		AppID:               f.AppID,
+		ProviderID:          f.ProviderID,
+		ProviderName:        f.ProviderName,
+		Aggregation:         string(f.Aggregation),
+		AggregationProperty: f.AggregationProperty,
+		Dimensions:          f.Dimensions,
+		Metadata:            f.Metadata,
+		CreatedAt:           f.CreatedAt,
+		UpdatedAt:           f.UpdatedAt,
 	}
 }
 
@@ -685,18 +885,21 @@ func fromFeatureCatalogModel(m *featureCatalogModel) (*feature.Feature, error) {
 			CreatedAt: m.CreatedAt,
 			UpdatedAt: m.UpdatedAt,
 		},
-		ID:           featID,
-		Key:          m.Key,
-		Name:         m.Name,
-		Description:  m.Description,
-		Type:         feature.FeatureType(m.Type),
-		DefaultLimit: m.DefaultLimit,
-		Period:       feature.Period(m.Period),
-		SoftLimit:    m.SoftLimit,
-		Status:       feature.Status(m.Status),
-		AppID:        m.AppID,
-		ProviderID:   m.ProviderID,
-		ProviderName: m.ProviderName,
-		Metadata:     m.Metadata,
+		ID:                  featID,
+		Key:                 m.Key,
+		Name:                m.Name,
+		Description:         m.Description,
+		Type:                feature.FeatureType(m.Type),
+		DefaultLimit:        m.DefaultLimit,
+		Period:              feature.Period(m.Period),
+		SoftLimit:           m.SoftLimit,
+		Status:              feature.Status(m.Status),
+		AppID:               m.AppID,
+		ProviderID:          m.ProviderID,
+		ProviderName:        m.ProviderName,
+		Aggregation:         meter.AggregationType(m.Aggregation),
+		AggregationProperty: m.AggregationProperty,
+		Dimensions:          m.Dimensions,
+		Metadata:            m.Metadata,
 	}, nil
 }
@@ -34,6 +34,12 @@ const (
 	colInvoices      = "ledger_invoices"
 	colCoupons       = "ledger_coupons"
 	colFeatures      = "ledger_features"
+	colSeatReadings  = "ledger_seat_readings"
+	colUsageCounters = "ledger_usage_counters"
+	colQuotaCounters = "ledger_quota_counters"
+	colReservations  = "ledger_reservations"
+	colOverrides     = "ledger_entitlement_overrides"
+	colUsageAlerts   = "ledger_usage_alerts"
 )
 
 // compile-time interface check
@@ -58,6 +64,10 @@ func (s *Store) DB() *grove.DB { return s.db }
 
 // Migrate creates indexes for all ledger collections.
 func (s *Store) Migrate(ctx context.Context) error {
+	// Idempotency keys used to be unique across all tenants; they are now
+	// scoped to (app_id, tenant_id).
+	_ = s.mdb.Collection(colUsageEvents).Indexes().DropOne(ctx, "idempotency_key_1") //nolint:errcheck // best-effort: only present on older databases
+
 	indexes := migrationIndexes()
 
 	for col, models := range indexes {
@@ -69,7 +79,37 @@ func (s *Store) Migrate(ctx context.Context) error {
 			return fmt.Errorf("ledger/mongo: migrate %s indexes: %w", col, err)
 		}
 	}
-	return nil
+	return s.backfillUsageCounters(ctx)
+}
+
+// backfillUsageCounters seeds the lifetime counters from the stored usage
+// events the first time the counters collection is used.
+func (s *Store) backfillUsageCounters(ctx context.Context) error {
+	n, err := s.mdb.Collection(colUsageCounters).EstimatedDocumentCount(ctx)
+	if err != nil {
+		return fmt.Errorf("ledger/mongo: count usage counters: %w", err)
+	}
+	if n > 0 {
+		return nil
+	}
+
+	pipeline := bson.A{
+		bson.M{"$match": countedUsage(bson.M{})},
+		bson.M{"$group": bson.M{
+			"_id":         bson.M{"$concat": bson.A{"$tenant_id", ":", "$app_id", ":", "$feature_key"}},
+			"tenant_id":   bson.M{"$first": "$tenant_id"},
+			"app_id":      bson.M{"$first": "$app_id"},
+			"feature_key": bson.M{"$first": "$feature_key"},
+			"quantity":    bson.M{"$sum": "$quantity"},
+			"events":      bson.M{"$sum": 1},
+		}},
+		bson.M{"$merge": bson.M{"into": colUsageCounters, "whenMatched": "keepExisting", "whenNotMatched": "insert"}},
+	}
+	cursor, err := s.mdb.Collection(colUsageEvents).Aggregate(ctx, pipeline)
+	if err != nil {
+		return fmt.Errorf("ledger/mongo: backfill usage counters: %w", err)
+	}
+	return cursor.Close(ctx)
 }
 
 // Ping checks database connectivity.
@@ -131,6 +171,9 @@ func (s *Store) ListPlans(ctx context.Context, appID string, opts plan.ListOpts)
 	if opts.Status != "" {
 		filter["status"] = string(opts.Status)
 	}
+	if !opts.PlanID.IsNil() {
+		filter["plan_id"] = opts.PlanID.String()
+	}
 
 	q := s.mdb.NewFind(&models).
 		Filter(filter).
@@ -326,43 +369,131 @@ func (s *Store) CancelSubscription(ctx context.Context, subID id.SubscriptionID,
 
 // ==================== Meter Store ====================
 
-func (s *Store) IngestBatch(ctx context.Context, events []*meter.UsageEvent) error {
-	if len(events) == 0 {
-		return nil
-	}
+func (s *Store) IngestBatch(ctx context.Context, events []*meter.UsageEvent) (*meter.IngestResult, error) {
+	return s.insertUsage(ctx, events, true)
+}
+
+// insertUsage inserts usage events one at a time, adding each counted
+// event to the lifetime counters when count is set.
+func (s *Store) insertUsage(ctx context.Context, events []*meter.UsageEvent, count bool) (*meter.IngestResult, error) {
+	result := &meter.IngestResult{}
 	for _, e := range events {
 		m := toUsageEventModel(e)
 		_, err := s.mdb.NewInsert(m).Exec(ctx)
 		if err != nil {
-			// Skip duplicates for idempotency
+			// Duplicate _id (WAL replay) or idempotency key for the
+			// same app and tenant
 			if mongo.IsDuplicateKeyError(err) {
+				result.Duplicates++
 				continue
 			}
-			return fmt.Errorf("ledger/mongo: ingest event: %w", err)
+			return nil, fmt.Errorf("ledger/mongo: ingest event: %w", err)
+		}
+		result.Inserted++
+		result.InsertedIDs = append(result.InsertedIDs, e.ID)
+		if count && e.Counted() {
+			if err := s.addLifetime(ctx, e.TenantID, e.AppID, e.FeatureKey, e.Quantity, 1); err != nil {
+				return nil, err
+			}
 		}
 	}
-	return nil
+	return result, nil
 }
 
-func (s *Store) Aggregate(ctx context.Context, tenantID, appID, featureKey string, period plan.Period) (int64, error) {
-	startOfPeriod := getStartOfPeriod(time.Now(), period)
-
-	pipeline := bson.A{
-		bson.M{
-			"$match": bson.M{
+// addLifetime adds quantity and events to a lifetime counter, creating it
+// on first use.
+func (s *Store) addLifetime(ctx context.Context, tenantID, appID, featureKey string, quantity, events int64) error {
+	key := usageCounterKey(tenantID, appID, featureKey)
+	_, err := s.mdb.NewUpdate((*usageCounterModel)(nil)).
+		Filter(bson.M{"_id": key}).
+		SetUpdate(bson.M{
+			"$inc": bson.M{"quantity": quantity, "events": events},
+			"$setOnInsert": bson.M{
 				"tenant_id":   tenantID,
 				"app_id":      appID,
 				"feature_key": featureKey,
-				"timestamp":   bson.M{"$gt": startOfPeriod},
 			},
-		},
-		bson.M{
-			"$group": bson.M{
-				"_id":   nil,
-				"total": bson.M{"$sum": "$quantity"},
-			},
-		},
+		}).
+		Upsert().
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("ledger/mongo: update usage counter: %w", err)
 	}
+	return nil
+}
+
+// LifetimeUsage reads the lifetime counters kept by IngestBatch and
+// VoidUsage.
+func (s *Store) LifetimeUsage(ctx context.Context, tenantID, appID string, featureKeys []string) (map[string]meter.LifetimeTotal, error) {
+	result := make(map[string]meter.LifetimeTotal, len(featureKeys))
+	if len(featureKeys) == 0 {
+		return result, nil
+	}
+
+	keys := make([]string, len(featureKeys))
+	for i, key := range featureKeys {
+		keys[i] = usageCounterKey(tenantID, appID, key)
+	}
+	var models []usageCounterModel
+	err := s.mdb.NewFind(&models).
+		Filter(bson.M{"_id": bson.M{"$in": keys}}).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ledger/mongo: lifetime usage: %w", err)
+	}
+	for _, m := range models {
+		result[m.FeatureKey] = meter.LifetimeTotal{Quantity: m.Quantity, Events: m.Events}
+	}
+	return result, nil
+}
+
+// countedUsage restricts a usage event filter to the events that count
+// towards aggregations: neither voided nor corrections.
+func countedUsage(filter bson.M) bson.M {
+	filter["voided_at"] = nil
+	filter["correction"] = bson.M{"$ne": true}
+	return filter
+}
+
+func (s *Store) Aggregate(ctx context.Context, tenantID, appID, featureKey string, agg meter.Aggregation, start, end time.Time) (int64, error) {
+	match := countedUsage(bson.M{
+		"tenant_id":   tenantID,
+		"app_id":      appID,
+		"feature_key": featureKey,
+	})
+	if ts := timeRange(start, end); len(ts) > 0 {
+		match["timestamp"] = ts
+	}
+
+	var stages bson.A
+	switch agg.Kind() {
+	case meter.AggregateSum:
+		stages = bson.A{bson.M{"$group": bson.M{"_id": nil, "total": bson.M{"$sum": "$quantity"}}}}
+	case meter.AggregateCount:
+		stages = bson.A{bson.M{"$group": bson.M{"_id": nil, "total": bson.M{"$sum": 1}}}}
+	case meter.AggregateMax:
+		stages = bson.A{bson.M{"$group": bson.M{"_id": nil, "total": bson.M{"$max": "$quantity"}}}}
+	case meter.AggregateUnique:
+		if agg.Property == "" {
+			return 0, fmt.Errorf("%w: unique aggregation needs a property", ledger.ErrUnsupportedAggregation)
+		}
+		field := "metadata." + agg.Property
+		match[field] = bson.M{"$exists": true}
+		stages = bson.A{
+			bson.M{"$group": bson.M{"_id": "$" + field}},
+			bson.M{"$count": "total"},
+		}
+	case meter.AggregateLast:
+		stages = bson.A{
+			bson.M{"$sort": bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}}},
+			bson.M{"$limit": 1},
+			bson.M{"$project": bson.M{"total": "$quantity"}},
+		}
+	default:
+		return 0, fmt.Errorf("%w: %q", ledger.ErrUnsupportedAggregation, agg.Type)
+	}
+
+	pipeline := append(bson.A{bson.M{"$match": match}}, stages...)
 
 	cursor, err := s.mdb.Collection(colUsageEvents).Aggregate(ctx, pipeline)
 	if err != nil {
@@ -383,15 +514,156 @@ func (s *Store) Aggregate(ctx context.Context, tenantID, appID, featureKey strin
 	return results[0].Total, nil
 }
 
-func (s *Store) AggregateMulti(ctx context.Context, tenantID, appID string, featureKeys []string, period plan.Period) (map[string]int64, error) {
-	result := make(map[string]int64)
-	for _, key := range featureKeys {
-		total, err := s.Aggregate(ctx, tenantID, appID, key, period)
+// AggregateMulti answers every sum, count, max and last aggregation with a
+// single $group pipeline keyed by feature; unique aggregations are queried
+// per feature.
+func (s *Store) AggregateMulti(ctx context.Context, tenantID, appID string, features map[string]meter.Aggregation, start, end time.Time) (map[string]int64, error) {
+	result := make(map[string]int64, len(features))
+	var grouped []string
+	for key, agg := range features {
+		if agg.Kind().IsBuiltin() && agg.Kind() != meter.AggregateUnique {
+			grouped = append(grouped, key)
+			continue
+		}
+		total, err := s.Aggregate(ctx, tenantID, appID, key, agg, start, end)
 		if err != nil {
 			return nil, err
 		}
 		result[key] = total
 	}
+	if len(grouped) == 0 {
+		return result, nil
+	}
+
+	match := countedUsage(bson.M{
+		"tenant_id":   tenantID,
+		"app_id":      appID,
+		"feature_key": bson.M{"$in": grouped},
+	})
+	if ts := timeRange(start, end); len(ts) > 0 {
+		match["timestamp"] = ts
+	}
+
+	pipeline := bson.A{
+		bson.M{"$match": match},
+		bson.M{"$sort": bson.D{{Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}}},
+		bson.M{"$group": bson.M{
+			"_id":   "$feature_key",
+			"sum":   bson.M{"$sum": "$quantity"},
+			"count": bson.M{"$sum": 1},
+			"max":   bson.M{"$max": "$quantity"},
+			"last":  bson.M{"$last": "$quantity"},
+		}},
+	}
+
+	cursor, err := s.mdb.Collection(colUsageEvents).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("ledger/mongo: aggregate multi: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		FeatureKey string `bson:"_id"`
+		Sum        int64  `bson:"sum"`
+		Count      int64  `bson:"count"`
+		Max        int64  `bson:"max"`
+		Last       int64  `bson:"last"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("ledger/mongo: aggregate multi decode: %w", err)
+	}
+
+	for _, key := range grouped {
+		result[key] = 0
+	}
+	for _, row := range rows {
+		switch features[row.FeatureKey].Kind() {
+		case meter.AggregateCount:
+			result[row.FeatureKey] = row.Count
+		case meter.AggregateMax:
+			result[row.FeatureKey] = row.Max
+		case meter.AggregateLast:
+			result[row.FeatureKey] = row.Last
+		default:
+			result[row.FeatureKey] = row.Sum
+		}
+	}
+	return result, nil
+}
+
+// AggregateGroups groups the usage events by the requested metadata values
+// with a single $group pipeline keyed by the array of values.
+func (s *Store) AggregateGroups(ctx context.Context, tenantID, appID string, agg meter.Aggregation, opts meter.QueryOpts) ([]*meter.GroupTotal, error) {
+	match := countedUsage(bson.M{
+		"tenant_id":   tenantID,
+		"app_id":      appID,
+		"feature_key": opts.FeatureKey,
+	})
+	if ts := timeRange(opts.Start, opts.End); len(ts) > 0 {
+		match["timestamp"] = ts
+	}
+	for k, v := range opts.Dimensions {
+		match["metadata."+k] = v
+	}
+
+	var total bson.M
+	switch agg.Kind() {
+	case meter.AggregateSum:
+		total = bson.M{"$sum": "$quantity"}
+	case meter.AggregateCount:
+		total = bson.M{"$sum": 1}
+	case meter.AggregateMax:
+		total = bson.M{"$max": "$quantity"}
+	case meter.AggregateUnique:
+		if agg.Property == "" {
+			return nil, fmt.Errorf("%w: unique aggregation needs a property", ledger.ErrUnsupportedAggregation)
+		}
+		total = bson.M{"$addToSet": "$metadata." + agg.Property}
+	case meter.AggregateLast:
+		total = bson.M{"$last": "$quantity"}
+	default:
+		return nil, fmt.Errorf("%w: %q", ledger.ErrUnsupportedAggregation, agg.Type)
+	}
+
+	group := make(bson.A, len(opts.GroupBy))
+	for i, key := range opts.GroupBy {
+		group[i] = bson.M{"$ifNull": bson.A{"$metadata." + key, ""}}
+	}
+
+	pipeline := bson.A{
+		bson.M{"$match": match},
+		bson.M{"$sort": bson.D{{Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}}},
+		bson.M{"$group": bson.M{"_id": group, "total": total}},
+	}
+	if agg.Kind() == meter.AggregateUnique {
+		pipeline = append(pipeline, bson.M{"$set": bson.M{"total": bson.M{"$size": "$total"}}})
+	}
+	pipeline = append(pipeline, bson.M{"$sort": bson.M{"_id": 1}})
+
+	cursor, err := s.mdb.Collection(colUsageEvents).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("ledger/mongo: aggregate groups: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Values []string `bson:"_id"`
+		Total  int64    `bson:"total"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("ledger/mongo: aggregate groups decode: %w", err)
+	}
+
+	result := make([]*meter.GroupTotal, len(rows))
+	for i, row := range rows {
+		dims := make(map[string]string, len(opts.GroupBy))
+		for j, key := range opts.GroupBy {
+			if j < len(row.Values) {
+				dims[key] = row.Values[j]
+			}
+		}
+		result[i] = &meter.GroupTotal{Dimensions: dims, Value: row.Total}
+	}
 	return result, nil
 }
 
@@ -421,9 +693,12 @@ func (s *Store) QueryUsage(ctx context.Context, tenantID, appID string, opts met
 			filter["timestamp"] = bson.M{}
 		}
 		if ts, ok := filter["timestamp"].(bson.M); ok {
-			ts["$lte"] = opts.End
+			ts["$lt"] = opts.End
 		}
 	}
+	for k, v := range opts.Dimensions {
+		filter["metadata."+k] = v
+	}
 
 	q := s.mdb.NewFind(&models).
 		Filter(filter).
@@ -461,6 +736,201 @@ func (s *Store) PurgeUsage(ctx context.Context, before time.Time) (int64, error)
 	return res.DeletedCount(), nil
 }
 
+// ScanUsage returns up to limit events of an app with timestamps before
+// before, oldest first.
+func (s *Store) ScanUsage(ctx context.Context, appID string, before time.Time, limit int) ([]*meter.UsageEvent, error) {
+	var models []usageEventModel
+	q := s.mdb.NewFind(&models).
+		Filter(bson.M{"app_id": appID, "timestamp": bson.M{"$lt": before}}).
+		Sort(bson.D{{Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}})
+	if limit > 0 {
+		q = q.Limit(int64(limit))
+	}
+	if err := q.Scan(ctx); err != nil {
+		return nil, fmt.Errorf("ledger/mongo: scan usage: %w", err)
+	}
+
+	result := make([]*meter.UsageEvent, len(models))
+	for i := range models {
+		evt, err := fromUsageEventModel(&models[i])
+		if err != nil {
+			return nil, err
+		}
+		result[i] = evt
+	}
+	return result, nil
+}
+
+// DeleteUsage removes events by ID.
+func (s *Store) DeleteUsage(ctx context.Context, eventIDs []id.UsageEventID) (int64, error) {
+	if len(eventIDs) == 0 {
+		return 0, nil
+	}
+	ids := make([]string, len(eventIDs))
+	for i, evtID := range eventIDs {
+		ids[i] = evtID.String()
+	}
+	res, err := s.mdb.NewDelete((*usageEventModel)(nil)).
+		Filter(bson.M{"_id": bson.M{"$in": ids}}).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("ledger/mongo: delete usage: %w", err)
+	}
+	return res.DeletedCount(), nil
+}
+
+// RestoreUsage inserts archived events without adding them to the lifetime
+// counters again; the MongoDB store keeps no rollups.
+func (s *Store) RestoreUsage(ctx context.Context, events []*meter.UsageEvent) (*meter.IngestResult, error) {
+	return s.insertUsage(ctx, events, false)
+}
+
+func (s *Store) VoidUsage(ctx context.Context, tenantID, appID string, opts meter.VoidOpts) (*meter.UsageEvent, error) {
+	filter := bson.M{"tenant_id": tenantID, "app_id": appID}
+	if !opts.EventID.IsNil() {
+		filter["_id"] = opts.EventID.String()
+	} else {
+		filter["idempotency_key"] = opts.IdempotencyKey
+	}
+
+	var m usageEventModel
+	if err := s.mdb.NewFind(&m).Filter(filter).Scan(ctx); err != nil {
+		if isNoDocuments(err) {
+			return nil, ledger.ErrUsageEventNotFound
+		}
+		return nil, fmt.Errorf("ledger/mongo: get usage event: %w", err)
+	}
+	if m.VoidedAt != nil {
+		return nil, ledger.ErrUsageEventVoided
+	}
+
+	res, err := s.mdb.NewUpdate((*usageEventModel)(nil)).
+		Filter(bson.M{"_id": m.ID, "voided_at": nil}).
+		Set("voided_at", opts.VoidedAt).
+		Set("void_reason", opts.Reason).
+		Exec(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ledger/mongo: void usage event: %w", err)
+	}
+	if res.MatchedCount() == 0 {
+		return nil, ledger.ErrUsageEventVoided
+	}
+
+	if !m.Correction {
+		if err := s.addLifetime(ctx, m.TenantID, m.AppID, m.FeatureKey, -m.Quantity, -1); err != nil {
+			return nil, err
+		}
+	}
+
+	evt, err := fromUsageEventModel(&m)
+	if err != nil {
+		return nil, err
+	}
+	evt.VoidedAt = &opts.VoidedAt
+	evt.VoidReason = opts.Reason
+	return evt, nil
+}
+
+func (s *Store) SumCorrections(ctx context.Context, tenantID, appID string, featureKeys []string, start, end time.Time) (map[string]int64, error) {
+	result := make(map[string]int64, len(featureKeys))
+	if len(featureKeys) == 0 {
+		return result, nil
+	}
+
+	match := bson.M{
+		"tenant_id":   tenantID,
+		"app_id":      appID,
+		"feature_key": bson.M{"$in": featureKeys},
+		"correction":  true,
+		"voided_at":   nil,
+	}
+	if ts := timeRange(start, end); len(ts) > 0 {
+		match["timestamp"] = ts
+	}
+
+	pipeline := bson.A{
+		bson.M{"$match": match},
+		bson.M{"$group": bson.M{"_id": "$feature_key", "total": bson.M{"$sum": "$quantity"}}},
+	}
+	cursor, err := s.mdb.Collection(colUsageEvents).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("ledger/mongo: sum corrections: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		FeatureKey string `bson:"_id"`
+		Total      int64  `bson:"total"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("ledger/mongo: sum corrections decode: %w", err)
+	}
+	for _, row := range rows {
+		result[row.FeatureKey] = row.Total
+	}
+	return result, nil
+}
+
+// ==================== Seat Gauge Store ====================
+
+// Seat readings are kept apart from usage events, so purging usage never
+// loses a tenant's current seat count. The generated _id orders readings
+// that share a timestamp.
+
+func (s *Store) RecordSeats(ctx context.Context, r *meter.SeatReading) error {
+	if _, err := s.mdb.NewInsert(toSeatReadingModel(r)).Exec(ctx); err != nil {
+		return fmt.Errorf("ledger/mongo: record seats: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) CurrentSeats(ctx context.Context, tenantID, appID, featureKey string, at time.Time) (int64, error) {
+	var models []seatReadingModel
+	err := s.mdb.NewFind(&models).
+		Filter(bson.M{
+			"tenant_id":   tenantID,
+			"app_id":      appID,
+			"feature_key": featureKey,
+			"timestamp":   bson.M{"$lte": at},
+		}).
+		Sort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}}).
+		Limit(1).
+		Scan(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("ledger/mongo: current seats: %w", err)
+	}
+	if len(models) == 0 {
+		return 0, nil
+	}
+	return models[0].Seats, nil
+}
+
+func (s *Store) SeatReadings(ctx context.Context, tenantID, appID, featureKey string, start, end time.Time) ([]*meter.SeatReading, error) {
+	filter := bson.M{
+		"tenant_id":   tenantID,
+		"app_id":      appID,
+		"feature_key": featureKey,
+	}
+	if ts := timeRange(start, end); len(ts) > 0 {
+		filter["timestamp"] = ts
+	}
+
+	var models []seatReadingModel
+	err := s.mdb.NewFind(&models).
+		Filter(filter).
+		Sort(bson.D{{Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}}).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ledger/mongo: seat readings: %w", err)
+	}
+
+	result := make([]*meter.SeatReading, len(models))
+	for i := range models {
+		result[i] = fromSeatReadingModel(&models[i])
+	}
+	return result, nil
+}
+
 // ==================== Entitlement Cache Store ====================
 
 func (s *Store) GetCached(ctx context.Context, tenantID, appID, featureKey string) (*entitlement.Result, error) {
@@ -531,6 +1001,204 @@ func (s *Store) InvalidateFeature(ctx context.Context, tenantID, appID, featureK
 	return nil
 }
 
+// ==================== Quota Store ====================
+
+// Hard-limit counters are checked and incremented by a single conditional
+// findAndModify, so concurrent consumers cannot overshoot the limit.
+
+func (s *Store) SeedQuota(ctx context.Context, key entitlement.QuotaKey, used int64, expiresAt time.Time) error {
+	set := bson.M{
+		"tenant_id":    key.TenantID,
+		"app_id":       key.AppID,
+		"feature_key":  key.FeatureKey,
+		"window_start": key.WindowStart,
+		"used":         used,
+	}
+	if !expiresAt.IsZero() {
+		set["expires_at"] = expiresAt
+	}
+	_, err := s.mdb.NewUpdate((*quotaCounterModel)(nil)).
+		Filter(bson.M{"_id": quotaCounterKey(key)}).
+		SetUpdate(bson.M{"$setOnInsert": set}).
+		Upsert().
+		Exec(ctx)
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("ledger/mongo: seed quota: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) AddQuota(ctx context.Context, key entitlement.QuotaKey, qty, limit int64) (int64, bool, error) {
+	filter := bson.M{"_id": quotaCounterKey(key)}
+	if limit >= 0 {
+		filter["used"] = bson.M{"$lte": limit - qty}
+	}
+
+	var m quotaCounterModel
+	err := s.mdb.Collection(colQuotaCounters).FindOneAndUpdate(ctx, filter,
+		bson.M{"$inc": bson.M{"used": qty}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&m)
+	if err == nil {
+		return m.Used, true, nil
+	}
+	if !isNoDocuments(err) {
+		return 0, false, fmt.Errorf("ledger/mongo: add quota: %w", err)
+	}
+
+	err = s.mdb.Collection(colQuotaCounters).FindOne(ctx, bson.M{"_id": quotaCounterKey(key)}).Decode(&m)
+	if err != nil {
+		if isNoDocuments(err) {
+			return 0, false, ledger.ErrQuotaCounterNotFound
+		}
+		return 0, false, fmt.Errorf("ledger/mongo: get quota: %w", err)
+	}
+	return m.Used, false, nil
+}
+
+func (s *Store) PurgeQuotas(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.mdb.NewDelete((*quotaCounterModel)(nil)).
+		Filter(bson.M{"expires_at": bson.M{"$lt": before}}).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("ledger/mongo: purge quotas: %w", err)
+	}
+	return res.DeletedCount(), nil
+}
+
+func (s *Store) CreateReservation(ctx context.Context, r *entitlement.Reservation) error {
+	if _, err := s.mdb.NewInsert(toReservationModel(r)).Exec(ctx); err != nil {
+		return fmt.Errorf("ledger/mongo: create reservation: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) TakeReservation(ctx context.Context, tenantID, appID string, reservationID id.ReservationID) (*entitlement.Reservation, error) {
+	var m reservationModel
+	filter := bson.M{"_id": reservationID.String(), "tenant_id": tenantID, "app_id": appID}
+	err := s.mdb.Collection(colReservations).FindOneAndDelete(ctx, filter).Decode(&m)
+	if err != nil {
+		if isNoDocuments(err) {
+			return nil, ledger.ErrReservationNotFound
+		}
+		return nil, fmt.Errorf("ledger/mongo: take reservation: %w", err)
+	}
+	return fromReservationModel(&m)
+}
+
+func (s *Store) ExpiredReservations(ctx context.Context, before time.Time, limit int) ([]*entitlement.Reservation, error) {
+	var models []reservationModel
+	q := s.mdb.NewFind(&models).
+		Filter(bson.M{"expires_at": bson.M{"$lt": before}}).
+		Sort(bson.D{{Key: "expires_at", Value: 1}})
+	if limit > 0 {
+		q = q.Limit(int64(limit))
+	}
+	if err := q.Scan(ctx); err != nil {
+		return nil, fmt.Errorf("ledger/mongo: expired reservations: %w", err)
+	}
+
+	result := make([]*entitlement.Reservation, len(models))
+	for i := range models {
+		r, err := fromReservationModel(&models[i])
+		if err != nil {
+			return nil, err
+		}
+		result[i] = r
+	}
+	return result, nil
+}
+
+// ==================== Override Store ====================
+
+func (s *Store) CreateOverride(ctx context.Context, o *entitlement.Override) error {
+	if _, err := s.mdb.NewInsert(toOverrideModel(o)).Exec(ctx); err != nil {
+		return fmt.Errorf("ledger/mongo: create override: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) GetOverride(ctx context.Context, overrideID id.OverrideID) (*entitlement.Override, error) {
+	var m overrideModel
+	err := s.mdb.NewFind(&m).
+		Filter(bson.M{"_id": overrideID.String()}).
+		Scan(ctx)
+	if err != nil {
+		if isNoDocuments(err) {
+			return nil, ledger.ErrOverrideNotFound
+		}
+		return nil, fmt.Errorf("ledger/mongo: get override: %w", err)
+	}
+	return fromOverrideModel(&m)
+}
+
+func (s *Store) ListOverrides(ctx context.Context, tenantID, appID string) ([]*entitlement.Override, error) {
+	var models []overrideModel
+	err := s.mdb.NewFind(&models).
+		Filter(bson.M{"tenant_id": tenantID, "app_id": appID}).
+		Sort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ledger/mongo: list overrides: %w", err)
+	}
+
+	result := make([]*entitlement.Override, len(models))
+	for i := range models {
+		o, err := fromOverrideModel(&models[i])
+		if err != nil {
+			return nil, err
+		}
+		result[i] = o
+	}
+	return result, nil
+}
+
+func (s *Store) DeleteOverride(ctx context.Context, overrideID id.OverrideID) error {
+	res, err := s.mdb.NewDelete((*overrideModel)(nil)).
+		Filter(bson.M{"_id": overrideID.String()}).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("ledger/mongo: delete override: %w", err)
+	}
+	if res.DeletedCount() == 0 {
+		return ledger.ErrOverrideNotFound
+	}
+	return nil
+}
+
+// ==================== Usage Alert Store ====================
+
+func (s *Store) RecordUsageAlert(ctx context.Context, key entitlement.QuotaKey, threshold int, expiresAt time.Time) (bool, error) {
+	m := &usageAlertModel{
+		AlertKey:    fmt.Sprintf("%s:%d", quotaCounterKey(key), threshold),
+		TenantID:    key.TenantID,
+		AppID:       key.AppID,
+		FeatureKey:  key.FeatureKey,
+		WindowStart: key.WindowStart,
+		Threshold:   threshold,
+	}
+	if !expiresAt.IsZero() {
+		m.ExpiresAt = &expiresAt
+	}
+	if _, err := s.mdb.NewInsert(m).Exec(ctx); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("ledger/mongo: record usage alert: %w", err)
+	}
+	return true, nil
+}
+
+func (s *Store) PurgeUsageAlerts(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.mdb.NewDelete((*usageAlertModel)(nil)).
+		Filter(bson.M{"expires_at": bson.M{"$lt": before}}).
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("ledger/mongo: purge usage alerts: %w", err)
+	}
+	return res.DeletedCount(), nil
+}
+
 // ==================== Invoice Store ====================
 
 func (s *Store) CreateInvoice(ctx context.Context, inv *invoice.Invoice) error {
@@ -976,16 +1644,16 @@ func now() time.Time {
 	return time.Now().UTC()
 }
 
-// getStartOfPeriod returns the start of the given period.
-func getStartOfPeriod(t time.Time, period plan.Period) time.Time {
-	switch period {
-	case plan.PeriodMonthly:
-		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
-	case plan.PeriodYearly:
-		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location())
-	default:
-		return time.Time{}
+// timeRange builds a [start, end) timestamp filter, omitting zero bounds.
+func timeRange(start, end time.Time) bson.M {
+	ts := bson.M{}
+	if !start.IsZero() {
+		ts["$gte"] = start
+	}
+	if !end.IsZero() {
+		ts["$lt"] = end
 	}
+	return ts
 }
 
 // isNoDocuments checks if an error wraps mongo.ErrNoDocuments.
@@ -1013,8 +1681,13 @@ func migrationIndexes() map[string][]mongo.IndexModel {
 			{Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "app_id", Value: 1}, {Key: "feature_key", Value: 1}, {Key: "timestamp", Value: -1}}},
 			{Keys: bson.D{{Key: "timestamp", Value: -1}}},
 			{
-				Keys:    bson.D{{Key: "idempotency_key", Value: 1}},
-				Options: options.Index().SetUnique(true).SetSparse(true),
+				Keys: bson.D{{Key: "app_id", Value: 1}, {Key: "tenant_id", Value: 1}, {Key: "idempotency_key", Value: 1}},
+				Options: options.Index().SetUnique(true).
+					SetPartialFilterExpression(bson.M{"idempotency_key": bson.M{"$exists": true}}),
+			},
+			{
+				Keys:    bson.D{{Key: "tenant_id", Value: 1}, {Key: "app_id", Value: 1}, {Key: "feature_key", Value: 1}, {Key: "timestamp", Value: -1}},
+				Options: options.Index().SetPartialFilterExpression(bson.M{"correction": true}),
 			},
 		},
 		colEntitlements: {
@@ -1042,5 +1715,20 @@ func migrationIndexes() map[string][]mongo.IndexModel {
 			{Keys: bson.D{{Key: "app_id", Value: 1}, {Key: "status", Value: 1}}},
 			{Keys: bson.D{{Key: "app_id", Value: 1}, {Key: "created_at", Value: 1}}},
 		},
+		colSeatReadings: {
+			{Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "app_id", Value: 1}, {Key: "feature_key", Value: 1}, {Key: "timestamp", Value: -1}}},
+		},
+		colQuotaCounters: {
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}},
+		},
+		colReservations: {
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}},
+		},
+		colOverrides: {
+			{Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "app_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		},
+		colUsageAlerts: {
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}},
+		},
 	}
 }
@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/xraph/ledger/cachebus"
+)
+
+// DefaultBusChannel is the notification channel used by NewBus when none
+// is given.
+const DefaultBusChannel = "ledger_cache"
+
+// maxPayload is the largest notification payload PostgreSQL accepts.
+const maxPayload = 7999
+
+// compile-time interface check
+var _ cachebus.Bus = (*Bus)(nil)
+
+// Bus implements cachebus.Bus on PostgreSQL LISTEN/NOTIFY, so every Ledger
+// instance connected to the same database drops stale entitlements.
+// Messages are sent as JSON notification payloads, which PostgreSQL limits
+// to under 8000 bytes; larger messages are split by their features.
+type Bus struct {
+	pool    *pgxpool.Pool
+	channel string
+}
+
+// NewBus creates a Bus that notifies on channel through pool. Each
+// subscription holds one connection of the pool for as long as it runs.
+func NewBus(pool *pgxpool.Pool, channel string) *Bus {
+	if channel == "" {
+		channel = DefaultBusChannel
+	}
+	return &Bus{pool: pool, channel: channel}
+}
+
+// Publish implements cachebus.Bus. A message too large for one
+// notification is sent as several, in a single statement.
+func (b *Bus) Publish(ctx context.Context, msg cachebus.Message) error {
+	payloads, err := encodePayloads(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := b.pool.Exec(ctx, "SELECT pg_notify($1, p) FROM unnest($2::text[]) AS p", b.channel, payloads); err != nil {
+		return fmt.Errorf("ledger/postgres: notify cache message: %w", err)
+	}
+	return nil
+}
+
+// encodePayloads encodes msg as notification payloads of at most
+// maxPayload bytes. A message that does not fit is split in two by its
+// features, recursively; a single feature that still does not fit is
+// widened to all of the tenant's features, which no features means.
+func encodePayloads(msg cachebus.Message) ([]string, error) {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("ledger/postgres: marshal cache message: %w", err)
+	}
+	if len(payload) <= maxPayload {
+		return []string{string(payload)}, nil
+	}
+
+	switch len(msg.Features) {
+	case 0:
+		return nil, fmt.Errorf("ledger/postgres: cache message of %d bytes exceeds the notification limit", len(payload))
+	case 1:
+		msg.Features = nil
+		return encodePayloads(msg)
+	}
+	half := len(msg.Features) / 2
+	first, second := msg, msg
+	first.Features, second.Features = msg.Features[:half], msg.Features[half:]
+	payloads, err := encodePayloads(first)
+	if err != nil {
+		return nil, err
+	}
+	rest, err := encodePayloads(second)
+	if err != nil {
+		return nil, err
+	}
+	return append(payloads, rest...), nil
+}
+
+// Subscribe implements cachebus.Bus. The listening connection is taken out
+// of the pool and closed when the subscription ends, so it never returns
+// to the pool in the LISTEN state.
+func (b *Bus) Subscribe(ctx context.Context, handle func(cachebus.Message)) error {
+	pc, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("ledger/postgres: acquire listen connection: %w", err)
+	}
+	conn := pc.Hijack()
+	defer conn.Close(context.Background()) //nolint:errcheck // connection is discarded
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{b.channel}.Sanitize()); err != nil {
+		return fmt.Errorf("ledger/postgres: listen %s: %w", b.channel, err)
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("ledger/postgres: wait for cache message: %w", err)
+		}
+
+		var msg cachebus.Message
+		if err := json.Unmarshal([]byte(n.Payload), &msg); err != nil {
+			continue // not one of ours
+		}
+		handle(msg)
+	}
+}
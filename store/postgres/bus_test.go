@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/xraph/ledger/cachebus"
+)
+
+// manyFeatures returns n feature keys, far more than fit in one
+// notification for large n.
+func manyFeatures(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("feature_%04d", i)
+	}
+	return keys
+}
+
+func TestEncodePayloadsSplitsFeatures(t *testing.T) {
+	msg := cachebus.Message{Kind: cachebus.KindUsage, TenantID: "t1", AppID: "app", Features: manyFeatures(2000)}
+	payloads, err := encodePayloads(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payloads) < 2 {
+		t.Fatalf("got %d payloads, want the message split", len(payloads))
+	}
+
+	var features []string
+	for _, p := range payloads {
+		if len(p) > maxPayload {
+			t.Errorf("payload of %d bytes exceeds %d", len(p), maxPayload)
+		}
+		var got cachebus.Message
+		if err := json.Unmarshal([]byte(p), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Kind != msg.Kind || got.TenantID != msg.TenantID || got.AppID != msg.AppID || len(got.Features) == 0 {
+			t.Errorf("payload message = %+v, want a usage message of t1 with features", got)
+		}
+		features = append(features, got.Features...)
+	}
+	if !slices.Equal(features, msg.Features) {
+		t.Errorf("payloads carry %d features, want all %d in order", len(features), len(msg.Features))
+	}
+}
+
+func TestEncodePayloadsWidensOversizedFeature(t *testing.T) {
+	msg := cachebus.Message{Kind: cachebus.KindUsage, TenantID: "t1", AppID: "app", Features: []string{strings.Repeat("x", maxPayload)}}
+	payloads, err := encodePayloads(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got cachebus.Message
+	if len(payloads) != 1 || json.Unmarshal([]byte(payloads[0]), &got) != nil || len(got.Features) != 0 {
+		t.Fatalf("payloads = %q, want one message for all features", payloads)
+	}
+
+	msg = cachebus.Message{Kind: cachebus.KindSubscription, TenantID: strings.Repeat("t", maxPayload)}
+	if _, err := encodePayloads(msg); err == nil {
+		t.Fatal("encoded a message that cannot fit, want an error")
+	}
+}
+
+func TestBusDeliversSplitMessages(t *testing.T) {
+	dsn := os.Getenv(dsnEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", dsnEnv)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	// A channel of its own keeps concurrent runs apart.
+	bus := NewBus(pool, fmt.Sprintf("ledger_cache_test_%d", time.Now().UnixNano()))
+	var (
+		mu       sync.Mutex
+		received []cachebus.Message
+	)
+	done := make(chan error, 1)
+	go func() {
+		done <- bus.Subscribe(ctx, func(msg cachebus.Message) {
+			mu.Lock()
+			defer mu.Unlock()
+			received = append(received, msg)
+		})
+	}()
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received)
+	}
+	wait := func(what string, cond func() bool) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); !cond(); {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	// LISTEN runs in the background, so publish until it hears something.
+	ready := cachebus.Message{Kind: cachebus.KindCatalog}
+	wait("the subscription to start", func() bool {
+		if err := bus.Publish(ctx, ready); err != nil {
+			t.Fatal(err)
+		}
+		return count() > 0
+	})
+
+	mu.Lock()
+	received = nil
+	mu.Unlock()
+	msg := cachebus.Message{Kind: cachebus.KindUsage, TenantID: "t1", AppID: "app", Features: manyFeatures(2000)}
+	if err := bus.Publish(ctx, msg); err != nil {
+		t.Fatal(err)
+	}
+	wait("every feature to arrive", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		var features []string
+		for _, m := range received {
+			if m.Kind == cachebus.KindUsage {
+				features = append(features, m.Features...)
+			}
+		}
+		return slices.Equal(features, msg.Features)
+	})
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Subscribe returned %v after cancel, want context.Canceled", err)
+	}
+}
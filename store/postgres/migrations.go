@@ -270,6 +270,398 @@ ALTER TABLE ledger_plans DROP COLUMN IF EXISTS provider_name;
 ALTER TABLE ledger_features DROP COLUMN IF EXISTS provider_id;
 ALTER TABLE ledger_features DROP COLUMN IF EXISTS provider_name;
 ALTER TABLE ledger_invoices DROP COLUMN IF EXISTS provider_name;
+`)
+				return err
+			},
+		},
+		&migrate.Migration{
+			Name:    "scope_usage_idempotency_keys",
+			Version: "20240101000009",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+DROP INDEX IF EXISTS idx_ledger_usage_idempotency;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_usage_idempotency ON ledger_usage_events (app_id, tenant_id, idempotency_key) WHERE idempotency_key != '';
+`)
+				return err
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+DROP INDEX IF EXISTS idx_ledger_usage_idempotency;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_usage_idempotency ON ledger_usage_events (idempotency_key) WHERE idempotency_key != '';
+`)
+				return err
+			},
+		},
+		&migrate.Migration{
+			Name:    "add_feature_aggregation",
+			Version: "20240101000010",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+ALTER TABLE ledger_features ADD COLUMN IF NOT EXISTS aggregation TEXT NOT NULL DEFAULT '';
+ALTER TABLE ledger_features ADD COLUMN IF NOT EXISTS aggregation_property TEXT NOT NULL DEFAULT '';
+`)
+				return err
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+ALTER TABLE ledger_features DROP COLUMN IF EXISTS aggregation;
+ALTER TABLE ledger_features DROP COLUMN IF EXISTS aggregation_property;
+`)
+				return err
+			},
+		},
+		&migrate.Migration{
+			Name:    "create_ledger_usage_rollups",
+			Version: "20240101000011",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS ledger_usage_rollups_hourly (
+    tenant_id    TEXT NOT NULL,
+    app_id       TEXT NOT NULL,
+    feature_key  TEXT NOT NULL,
+    bucket_start TIMESTAMPTZ NOT NULL,
+    quantity_sum BIGINT NOT NULL DEFAULT 0,
+    event_count  BIGINT NOT NULL DEFAULT 0,
+    quantity_max BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (tenant_id, app_id, feature_key, bucket_start)
+);
+
+CREATE TABLE IF NOT EXISTS ledger_usage_rollups_daily (
+    tenant_id    TEXT NOT NULL,
+    app_id       TEXT NOT NULL,
+    feature_key  TEXT NOT NULL,
+    bucket_start TIMESTAMPTZ NOT NULL,
+    quantity_sum BIGINT NOT NULL DEFAULT 0,
+    event_count  BIGINT NOT NULL DEFAULT 0,
+    quantity_max BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (tenant_id, app_id, feature_key, bucket_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_usage_rollups_hourly_bucket ON ledger_usage_rollups_hourly (bucket_start);
+CREATE INDEX IF NOT EXISTS idx_ledger_usage_rollups_daily_bucket ON ledger_usage_rollups_daily (bucket_start);
+
+INSERT INTO ledger_usage_rollups_hourly (tenant_id, app_id, feature_key, bucket_start, quantity_sum, event_count, quantity_max)
+SELECT tenant_id, app_id, feature_key, date_trunc('hour', timestamp AT TIME ZONE 'UTC') AT TIME ZONE 'UTC',
+       SUM(quantity), COUNT(*), MAX(quantity)
+FROM ledger_usage_events
+GROUP BY 1, 2, 3, 4
+ON CONFLICT DO NOTHING;
+
+INSERT INTO ledger_usage_rollups_daily (tenant_id, app_id, feature_key, bucket_start, quantity_sum, event_count, quantity_max)
+SELECT tenant_id, app_id, feature_key, date_trunc('day', bucket_start AT TIME ZONE 'UTC') AT TIME ZONE 'UTC',
+       SUM(quantity_sum), SUM(event_count), MAX(quantity_max)
+FROM ledger_usage_rollups_hourly
+GROUP BY 1, 2, 3, 4
+ON CONFLICT DO NOTHING;
+`)
+				return err
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+DROP TABLE IF EXISTS ledger_usage_rollups_daily;
+DROP TABLE IF EXISTS ledger_usage_rollups_hourly;
+`)
+				return err
+			},
+		},
+		&migrate.Migration{
+			Name:    "add_usage_corrections_and_voids",
+			Version: "20240101000012",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+ALTER TABLE ledger_usage_events ADD COLUMN IF NOT EXISTS correction BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE ledger_usage_events ADD COLUMN IF NOT EXISTS reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE ledger_usage_events ADD COLUMN IF NOT EXISTS voided_at TIMESTAMPTZ;
+ALTER TABLE ledger_usage_events ADD COLUMN IF NOT EXISTS void_reason TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_ledger_usage_corrections ON ledger_usage_events (tenant_id, app_id, feature_key, timestamp) WHERE correction;
+`)
+				return err
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+DROP INDEX IF EXISTS idx_ledger_usage_corrections;
+ALTER TABLE ledger_usage_events DROP COLUMN IF EXISTS correction;
+ALTER TABLE ledger_usage_events DROP COLUMN IF EXISTS reason;
+ALTER TABLE ledger_usage_events DROP COLUMN IF EXISTS voided_at;
+ALTER TABLE ledger_usage_events DROP COLUMN IF EXISTS void_reason;
+`)
+				return err
+			},
+		},
+		&migrate.Migration{
+			Name:    "add_feature_dimensions",
+			Version: "20240101000013",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+ALTER TABLE ledger_features ADD COLUMN IF NOT EXISTS dimensions JSONB NOT NULL DEFAULT '[]';
+`)
+				return err
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+ALTER TABLE ledger_features DROP COLUMN IF EXISTS dimensions;
+`)
+				return err
+			},
+		},
+		&migrate.Migration{
+			Name:    "create_ledger_seat_readings",
+			Version: "20240101000014",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS ledger_seat_readings (
+    seq         BIGSERIAL PRIMARY KEY,
+    tenant_id   TEXT NOT NULL,
+    app_id      TEXT NOT NULL,
+    feature_key TEXT NOT NULL,
+    seats       BIGINT NOT NULL,
+    timestamp   TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_seat_readings_feature ON ledger_seat_readings (tenant_id, app_id, feature_key, timestamp);
+`)
+				return err
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `DROP TABLE IF EXISTS ledger_seat_readings`)
+				return err
+			},
+		},
+		&migrate.Migration{
+			Name:    "create_ledger_usage_counters",
+			Version: "20240101000015",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS ledger_usage_counters (
+    tenant_id   TEXT NOT NULL,
+    app_id      TEXT NOT NULL,
+    feature_key TEXT NOT NULL,
+    quantity    BIGINT NOT NULL DEFAULT 0,
+    events      BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (tenant_id, app_id, feature_key)
+);
+
+ALTER TABLE ledger_usage_events ADD COLUMN IF NOT EXISTS restored BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE OR REPLACE FUNCTION ledger_count_usage() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        IF NEW.restored OR NEW.correction OR NEW.voided_at IS NOT NULL THEN
+            RETURN NULL;
+        END IF;
+        INSERT INTO ledger_usage_counters (tenant_id, app_id, feature_key, quantity, events)
+        VALUES (NEW.tenant_id, NEW.app_id, NEW.feature_key, NEW.quantity, 1)
+        ON CONFLICT (tenant_id, app_id, feature_key) DO UPDATE
+        SET quantity = ledger_usage_counters.quantity + EXCLUDED.quantity,
+            events = ledger_usage_counters.events + 1;
+    ELSIF OLD.voided_at IS NULL AND NEW.voided_at IS NOT NULL AND NOT NEW.correction THEN
+        UPDATE ledger_usage_counters
+        SET quantity = quantity - NEW.quantity, events = events - 1
+        WHERE tenant_id = NEW.tenant_id AND app_id = NEW.app_id AND feature_key = NEW.feature_key;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS ledger_usage_counters_insert ON ledger_usage_events;
+CREATE TRIGGER ledger_usage_counters_insert AFTER INSERT ON ledger_usage_events
+    FOR EACH ROW EXECUTE FUNCTION ledger_count_usage();
+
+DROP TRIGGER IF EXISTS ledger_usage_counters_void ON ledger_usage_events;
+CREATE TRIGGER ledger_usage_counters_void AFTER UPDATE OF voided_at ON ledger_usage_events
+    FOR EACH ROW EXECUTE FUNCTION ledger_count_usage();
+
+INSERT INTO ledger_usage_counters (tenant_id, app_id, feature_key, quantity, events)
+SELECT tenant_id, app_id, feature_key, SUM(quantity), COUNT(*)
+FROM ledger_usage_events
+WHERE voided_at IS NULL AND NOT correction
+GROUP BY 1, 2, 3
+ON CONFLICT DO NOTHING;
+`)
+				return err
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+DROP TRIGGER IF EXISTS ledger_usage_counters_void ON ledger_usage_events;
+DROP TRIGGER IF EXISTS ledger_usage_counters_insert ON ledger_usage_events;
+DROP FUNCTION IF EXISTS ledger_count_usage();
+DROP TABLE IF EXISTS ledger_usage_counters;
+ALTER TABLE ledger_usage_events DROP COLUMN IF EXISTS restored;
+`)
+				return err
+			},
+		},
+		&migrate.Migration{
+			Name:    "create_ledger_quotas",
+			Version: "20240101000016",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS ledger_quota_counters (
+    tenant_id    TEXT NOT NULL,
+    app_id       TEXT NOT NULL,
+    feature_key  TEXT NOT NULL,
+    window_start TIMESTAMPTZ NOT NULL,
+    used         BIGINT NOT NULL DEFAULT 0,
+    expires_at   TIMESTAMPTZ,
+    PRIMARY KEY (tenant_id, app_id, feature_key, window_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_quota_counters_expires ON ledger_quota_counters (expires_at) WHERE expires_at IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS ledger_reservations (
+    id           TEXT PRIMARY KEY,
+    tenant_id    TEXT NOT NULL,
+    app_id       TEXT NOT NULL,
+    feature_key  TEXT NOT NULL,
+    window_start TIMESTAMPTZ NOT NULL,
+    quantity     BIGINT NOT NULL,
+    expires_at   TIMESTAMPTZ NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_reservations_expires ON ledger_reservations (expires_at);
+`)
+				return err
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+DROP TABLE IF EXISTS ledger_reservations;
+DROP TABLE IF EXISTS ledger_quota_counters;
+`)
+				return err
+			},
+		},
+		&migrate.Migration{
+			Name:    "create_ledger_entitlement_overrides",
+			Version: "20240101000017",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS ledger_entitlement_overrides (
+    id          TEXT PRIMARY KEY,
+    tenant_id   TEXT NOT NULL,
+    app_id      TEXT NOT NULL,
+    feature_key TEXT NOT NULL,
+    mode        TEXT NOT NULL,
+    value       BIGINT NOT NULL,
+    expires_at  TIMESTAMPTZ,
+    reason      TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_entitlement_overrides_tenant ON ledger_entitlement_overrides (tenant_id, app_id, created_at DESC);
+`)
+				return err
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `DROP TABLE IF EXISTS ledger_entitlement_overrides`)
+				return err
+			},
+		},
+		&migrate.Migration{
+			Name:    "create_ledger_usage_alerts",
+			Version: "20240101000018",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS ledger_usage_alerts (
+    tenant_id    TEXT NOT NULL,
+    app_id       TEXT NOT NULL,
+    feature_key  TEXT NOT NULL,
+    window_start TIMESTAMPTZ NOT NULL,
+    threshold    INTEGER NOT NULL,
+    expires_at   TIMESTAMPTZ,
+    PRIMARY KEY (tenant_id, app_id, feature_key, window_start, threshold)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_usage_alerts_expires ON ledger_usage_alerts (expires_at) WHERE expires_at IS NOT NULL;
+`)
+				return err
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `DROP TABLE IF EXISTS ledger_usage_alerts`)
+				return err
+			},
+		},
+		&migrate.Migration{
+			Name:    "maintain_usage_rollups_in_triggers",
+			Version: "20240101000019",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				// Inserts add their own quantity to the rollups and voids
+				// subtract it, so buckets are never rebuilt from raw events,
+				// which archival and purges remove. A voided bucket max is
+				// only recomputed when every event it covers is still raw.
+				_, err := exec.Exec(ctx, `
+CREATE OR REPLACE FUNCTION ledger_rollup_usage() RETURNS trigger AS $$
+DECLARE
+    hour_start TIMESTAMPTZ := date_trunc('hour', NEW.timestamp AT TIME ZONE 'UTC') AT TIME ZONE 'UTC';
+    day_start  TIMESTAMPTZ := date_trunc('day', NEW.timestamp AT TIME ZONE 'UTC') AT TIME ZONE 'UTC';
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        IF NEW.restored OR NEW.correction OR NEW.voided_at IS NOT NULL THEN
+            RETURN NULL;
+        END IF;
+        INSERT INTO ledger_usage_rollups_hourly AS r (tenant_id, app_id, feature_key, bucket_start, quantity_sum, event_count, quantity_max)
+        VALUES (NEW.tenant_id, NEW.app_id, NEW.feature_key, hour_start, NEW.quantity, 1, NEW.quantity)
+        ON CONFLICT (tenant_id, app_id, feature_key, bucket_start) DO UPDATE
+        SET quantity_sum = r.quantity_sum + EXCLUDED.quantity_sum,
+            event_count = r.event_count + EXCLUDED.event_count,
+            quantity_max = GREATEST(r.quantity_max, EXCLUDED.quantity_max);
+        INSERT INTO ledger_usage_rollups_daily AS r (tenant_id, app_id, feature_key, bucket_start, quantity_sum, event_count, quantity_max)
+        VALUES (NEW.tenant_id, NEW.app_id, NEW.feature_key, day_start, NEW.quantity, 1, NEW.quantity)
+        ON CONFLICT (tenant_id, app_id, feature_key, bucket_start) DO UPDATE
+        SET quantity_sum = r.quantity_sum + EXCLUDED.quantity_sum,
+            event_count = r.event_count + EXCLUDED.event_count,
+            quantity_max = GREATEST(r.quantity_max, EXCLUDED.quantity_max);
+    ELSIF OLD.voided_at IS NULL AND NEW.voided_at IS NOT NULL AND NOT NEW.correction THEN
+        UPDATE ledger_usage_rollups_hourly AS r
+        SET quantity_sum = r.quantity_sum - NEW.quantity,
+            event_count = r.event_count - 1,
+            quantity_max = CASE
+                WHEN NEW.quantity < r.quantity_max OR raw.events <> r.event_count - 1 THEN r.quantity_max
+                ELSE raw.quantity_max
+            END
+        FROM (
+            SELECT COUNT(*) AS events, COALESCE(MAX(quantity), 0) AS quantity_max
+            FROM ledger_usage_events
+            WHERE tenant_id = NEW.tenant_id AND app_id = NEW.app_id AND feature_key = NEW.feature_key
+                AND timestamp >= hour_start AND timestamp < hour_start + INTERVAL '1 hour'
+                AND voided_at IS NULL AND NOT correction
+        ) AS raw
+        WHERE r.tenant_id = NEW.tenant_id AND r.app_id = NEW.app_id AND r.feature_key = NEW.feature_key
+            AND r.bucket_start = hour_start;
+        UPDATE ledger_usage_rollups_daily AS r
+        SET quantity_sum = r.quantity_sum - NEW.quantity,
+            event_count = r.event_count - 1,
+            quantity_max = CASE
+                WHEN NEW.quantity < r.quantity_max THEN r.quantity_max
+                ELSE (SELECT COALESCE(MAX(h.quantity_max), 0)
+                    FROM ledger_usage_rollups_hourly AS h
+                    WHERE h.tenant_id = NEW.tenant_id AND h.app_id = NEW.app_id AND h.feature_key = NEW.feature_key
+                        AND h.bucket_start >= day_start AND h.bucket_start < day_start + INTERVAL '1 day'
+                        AND h.event_count > 0)
+            END
+        WHERE r.tenant_id = NEW.tenant_id AND r.app_id = NEW.app_id AND r.feature_key = NEW.feature_key
+            AND r.bucket_start = day_start;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS ledger_usage_rollups_insert ON ledger_usage_events;
+CREATE TRIGGER ledger_usage_rollups_insert AFTER INSERT ON ledger_usage_events
+    FOR EACH ROW EXECUTE FUNCTION ledger_rollup_usage();
+
+DROP TRIGGER IF EXISTS ledger_usage_rollups_void ON ledger_usage_events;
+CREATE TRIGGER ledger_usage_rollups_void AFTER UPDATE OF voided_at ON ledger_usage_events
+    FOR EACH ROW EXECUTE FUNCTION ledger_rollup_usage();
+`)
+				return err
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+DROP TRIGGER IF EXISTS ledger_usage_rollups_void ON ledger_usage_events;
+DROP TRIGGER IF EXISTS ledger_usage_rollups_insert ON ledger_usage_events;
+DROP FUNCTION IF EXISTS ledger_rollup_usage();
 `)
 				return err
 			},
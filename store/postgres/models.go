@@ -200,6 +200,11 @@ type usageEventModel struct {
 	Timestamp      time.Time         `grove:"timestamp"`
 	IdempotencyKey string            `grove:"idempotency_key"`
 	Metadata       map[string]string `grove:"metadata,type:jsonb"`
+	Correction     bool              `grove:"correction"`
+	Reason         string            `grove:"reason"`
+	VoidedAt       *time.Time        `grove:"voided_at"`
+	VoidReason     string            `grove:"void_reason"`
+	Restored       bool              `grove:"restored"`
 	CreatedAt      time.Time         `grove:"created_at"`
 }
 
@@ -217,6 +222,10 @@ func toUsageEventModel(e *meter.UsageEvent) *usageEventModel {
 		Timestamp:      e.Timestamp,
 		IdempotencyKey: e.IdempotencyKey,
 		Metadata:       metadata,
+		Correction:     e.Correction,
+		Reason:         e.Reason,
+		VoidedAt:       e.VoidedAt,
+		VoidReason:     e.VoidReason,
 		CreatedAt:      time.Now().UTC(),
 	}
 }
@@ -236,6 +245,10 @@ func fromUsageEventModel(m *usageEventModel) (*meter.UsageEvent, error) {
 		Timestamp:      m.Timestamp,
 		IdempotencyKey: m.IdempotencyKey,
 		Metadata:       m.Metadata,
+		Correction:     m.Correction,
+		Reason:         m.Reason,
+		VoidedAt:       m.VoidedAt,
+		VoidReason:     m.VoidReason,
 	}, nil
 }
 
@@ -289,6 +302,99 @@ func fromEntitlementCacheModel(m *entitlementCacheModel) *entitlement.Result {
 	}
 }
 
+// ==================== Reservation models ====================
+
+type reservationModel struct {
+	grove.BaseModel `grove:"table:ledger_reservations"`
+
+	ID          string    `grove:"id,pk"`
+	TenantID    string    `grove:"tenant_id"`
+	AppID       string    `grove:"app_id"`
+	FeatureKey  string    `grove:"feature_key"`
+	WindowStart time.Time `grove:"window_start"`
+	Quantity    int64     `grove:"quantity"`
+	ExpiresAt   time.Time `grove:"expires_at"`
+	CreatedAt   time.Time `grove:"created_at"`
+}
+
+func toReservationModel(r *entitlement.Reservation) *reservationModel {
+	return &reservationModel{
+		ID:          r.ID.String(),
+		TenantID:    r.TenantID,
+		AppID:       r.AppID,
+		FeatureKey:  r.FeatureKey,
+		WindowStart: r.WindowStart,
+		Quantity:    r.Quantity,
+		ExpiresAt:   r.ExpiresAt,
+		CreatedAt:   r.CreatedAt,
+	}
+}
+
+func fromReservationModel(m *reservationModel) (*entitlement.Reservation, error) {
+	rsvID, err := id.ParseReservationID(m.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &entitlement.Reservation{
+		ID:          rsvID,
+		TenantID:    m.TenantID,
+		AppID:       m.AppID,
+		FeatureKey:  m.FeatureKey,
+		WindowStart: m.WindowStart,
+		Quantity:    m.Quantity,
+		ExpiresAt:   m.ExpiresAt,
+		CreatedAt:   m.CreatedAt,
+	}, nil
+}
+
+// ==================== Override models ====================
+
+type overrideModel struct {
+	grove.BaseModel `grove:"table:ledger_entitlement_overrides"`
+
+	ID         string     `grove:"id,pk"`
+	TenantID   string     `grove:"tenant_id"`
+	AppID      string     `grove:"app_id"`
+	FeatureKey string     `grove:"feature_key"`
+	Mode       string     `grove:"mode"`
+	Value      int64      `grove:"value"`
+	ExpiresAt  *time.Time `grove:"expires_at"`
+	Reason     string     `grove:"reason"`
+	CreatedAt  time.Time  `grove:"created_at"`
+}
+
+func toOverrideModel(o *entitlement.Override) *overrideModel {
+	return &overrideModel{
+		ID:         o.ID.String(),
+		TenantID:   o.TenantID,
+		AppID:      o.AppID,
+		FeatureKey: o.FeatureKey,
+		Mode:       string(o.Mode),
+		Value:      o.Value,
+		ExpiresAt:  o.ExpiresAt,
+		Reason:     o.Reason,
+		CreatedAt:  o.CreatedAt,
+	}
+}
+
+func fromOverrideModel(m *overrideModel) (*entitlement.Override, error) {
+	ovrID, err := id.ParseOverrideID(m.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &entitlement.Override{
+		ID:         ovrID,
+		TenantID:   m.TenantID,
+		AppID:      m.AppID,
+		FeatureKey: m.FeatureKey,
+		Mode:       entitlement.OverrideMode(m.Mode),
+		Value:      m.Value,
+		ExpiresAt:  m.ExpiresAt,
+		Reason:     m.Reason,
+		CreatedAt:  m.CreatedAt,
+	}, nil
+}
+
 // ==================== Invoice models ====================
 
 type invoiceModel struct {
@@ -485,21 +591,24 @@ func fromCouponModel(m *couponModel) (*coupon.Coupon, error) {
 type featureModel struct {
 	grove.BaseModel `grove:"table:ledger_features"`
 
-	ID           string            `grove:"id,pk"`
-	Key          string            `grove:"key"`
-	Name         string            `grove:"name"`
-	Description  string            `grove:"description"`
-	Type         string            `grove:"type"`
-	DefaultLimit int64             `grove:"default_limit"`
-	Period       string            `grove:"period"`
-	SoftLimit    bool              `grove:"soft_limit"`
-	Status       string            `grove:"status"`
-	AppID        string            `grove:"app_id"`
-	ProviderID   string            `grove:"provider_id"`
-	ProviderName string            `grove:"provider_name"`
-	Metadata     map[string]string `grove:"metadata,type:jsonb"`
-	CreatedAt    time.Time         `grove:"created_at"`
-	UpdatedAt    time.Time         `grove:"updated_at"`
+	ID                  string            `grove:"id,pk"`
+	Key                 string            `grove:"key"`
+	Name                string            `grove:"name"`
+	Description         string            `grove:"description"`
+	Type                string            `grove:"type"`
+	DefaultLimit        int64             `grove:"default_limit"`
+	Period              string            `grove:"period"`
+	SoftLimit           bool              `grove:"soft_limit"`
+	Status              string            `grove:"status"`
+	AppID               string            `grove:"app_id"`
+	ProviderID          string            `grove:"provider_id"`
+	ProviderName        string            `grove:"provider_name"`
+	Aggregation         string            `grove:"aggregation"`
+	AggregationProperty string            `grove:"aggregation_property"`
+	Dimensions          []string          `grove:"dimensions,type:jsonb"`
+	Metadata            map[string]string `grove:"metadata,type:jsonb"`
+	CreatedAt           time.Time         `grove:"created_at"`
+	UpdatedAt           time.Time         `grove:"updated_at"`
 }
 
 func toFeatureModel(f *feature.Feature) *featureModel {
@@ -507,22 +616,29 @@ func toFeatureModel(f *feature.Feature) *featureModel {
 	if metadata == nil {
 		metadata = make(map[string]string)
 	}
+	dimensions := f.Dimensions
+	if dimensions == nil {
+		dimensions = []string{}
+	}
 	return &featureModel{
-		ID:           f.ID.String(),
-		Key:          f.Key,
-		Name:         f.Name,
-		Description:  f.Description,
-		Type:         string(f.Type),
-		DefaultLimit: f.DefaultLimit,
-		Period:       string(f.Period),
-		SoftLimit:    f.SoftLimit,
-		Status:       string(f.Status),
-		AppID:        f.AppID,
-		ProviderID:   f.ProviderID,
-		ProviderName: f.ProviderName,
-		Metadata:     metadata,
-		CreatedAt:    f.CreatedAt,
-		UpdatedAt:    f.UpdatedAt,
+		ID:                  f.ID.String(),
+		Key:                 f.Key,
+		Name:                f.Name,
+		Description:         f.Description,
+		Type:                string(f.Type),
+		DefaultLimit:        f.DefaultLimit,
+		Period:              string(f.Period),
+		SoftLimit:           f.SoftLimit,
+		Status:              string(f.Status),
+		AppID:               f.AppID,
+		ProviderID:          f.ProviderID,
+		ProviderName:        f.ProviderName,
+		Aggregation:         string(f.Aggregation),
+		AggregationProperty: f.AggregationProperty,
+		Dimensions:          dimensions,
+		Metadata:            metadata,
+		CreatedAt:           f.CreatedAt,
+		UpdatedAt:           f.UpdatedAt,
 	}
 }
 
@@ -537,18 +653,21 @@ func fromFeatureModel(m *featureModel) (*feature.Feature, error) {
 			CreatedAt: m.CreatedAt,
 			UpdatedAt: m.UpdatedAt,
 		},
-		ID:           featID,
-		Key:          m.Key,
-		Name:         m.Name,
-		Description:  m.Description,
-		Type:         feature.FeatureType(m.Type),
-		DefaultLimit: m.DefaultLimit,
-		Period:       feature.Period(m.Period),
-		SoftLimit:    m.SoftLimit,
-		Status:       feature.Status(m.Status),
-		AppID:        m.AppID,
-		ProviderID:   m.ProviderID,
-		ProviderName: m.ProviderName,
-		Metadata:     m.Metadata,
+		ID:                  featID,
+		Key:                 m.Key,
+		Name:                m.Name,
+		Description:         m.Description,
+		Type:                feature.FeatureType(m.Type),
+		DefaultLimit:        m.DefaultLimit,
+		Period:              feature.Period(m.Period),
+		SoftLimit:           m.SoftLimit,
+		Status:              feature.Status(m.Status),
+		AppID:               m.AppID,
+		ProviderID:          m.ProviderID,
+		ProviderName:        m.ProviderName,
+		Aggregation:         meter.AggregationType(m.Aggregation),
+		AggregationProperty: m.AggregationProperty,
+		Dimensions:          m.Dimensions,
+		Metadata:            m.Metadata,
 	}, nil
 }
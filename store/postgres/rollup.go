@@ -0,0 +1,149 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xraph/ledger/meter"
+)
+
+// Usage rollups
+//
+// ledger_usage_rollups_hourly and ledger_usage_rollups_daily hold the sum,
+// count and max of counted usage (no voided events or corrections) per
+// tenant, app, feature and UTC bucket. The ledger_usage_rollups triggers
+// apply each inserted or voided event as a delta in the statement that
+// writes it, so duplicates rejected by the insert never reach the rollups
+// and concurrent flushes add up instead of overwriting each other. Buckets
+// are never rebuilt from raw events: archival deletes them and the rollups
+// keep the archived usage. Sum, count and max aggregations read whole days
+// and hours from the rollups and only scan raw events for the sub-hour
+// edges of the window, for any number of features in one query.
+
+const (
+	rollupHourlyTable = "ledger_usage_rollups_hourly"
+	rollupDailyTable  = "ledger_usage_rollups_daily"
+)
+
+// HasUsageRollups reports that the store keeps usage rollups, so archived
+// usage still counts towards sum, count and max aggregations.
+func (s *Store) HasUsageRollups() bool { return true }
+
+// rollupTotals is the sum, count and max of one feature's usage.
+type rollupTotals struct {
+	FeatureKey string `grove:"feature_key"`
+	Sum        int64  `grove:"total_sum"`
+	Count      int64  `grove:"total_count"`
+	Max        int64  `grove:"total_max"`
+}
+
+// pick returns the total matching a rollable aggregation type.
+func (t rollupTotals) pick(kind meter.AggregationType) int64 {
+	switch kind {
+	case meter.AggregateCount:
+		return t.Count
+	case meter.AggregateMax:
+		return t.Max
+	}
+	return t.Sum
+}
+
+// aggregateRollups returns the sum, count and max of each feature's usage in
+// [start, end) with a single query that reads the rollup tables plus the raw
+// events at the window edges, grouped by feature. Features without usage
+// are absent from the result.
+func (s *Store) aggregateRollups(ctx context.Context, tenantID, appID string, featureKeys []string, start, end time.Time) (map[string]rollupTotals, error) {
+	args := []any{tenantID, appID}
+	keys := make([]string, len(featureKeys))
+	for i, key := range featureKeys {
+		args = append(args, key)
+		keys[i] = fmt.Sprintf("$%d", len(args))
+	}
+	keyCond := "tenant_id = $1 AND app_id = $2 AND feature_key IN (" + strings.Join(keys, ", ") + ")"
+
+	bound := func(col string, sp meter.Span) string {
+		var cond string
+		if !sp.Start.IsZero() {
+			args = append(args, sp.Start)
+			cond += fmt.Sprintf(" AND %s >= $%d", col, len(args))
+		}
+		if !sp.End.IsZero() {
+			args = append(args, sp.End)
+			cond += fmt.Sprintf(" AND %s < $%d", col, len(args))
+		}
+		return cond
+	}
+
+	var parts []string
+	for _, sp := range meter.SplitWindow(start, end) {
+		switch sp.Granularity {
+		case meter.GranularityRaw:
+			parts = append(parts, "SELECT feature_key, SUM(quantity) AS s, COUNT(*) AS c, MAX(quantity) AS m"+
+				" FROM ledger_usage_events WHERE "+keyCond+" AND "+countedUsage+bound("timestamp", sp)+" GROUP BY feature_key")
+		case meter.GranularityHour, meter.GranularityDay:
+			table := rollupHourlyTable
+			if sp.Granularity == meter.GranularityDay {
+				table = rollupDailyTable
+			}
+			parts = append(parts, "SELECT feature_key, SUM(quantity_sum) AS s, SUM(event_count) AS c, MAX(quantity_max) AS m"+
+				" FROM "+table+" WHERE "+keyCond+" AND event_count > 0"+bound("bucket_start", sp)+" GROUP BY feature_key")
+		}
+	}
+
+	query := `SELECT feature_key,
+			COALESCE(SUM(s), 0)::bigint AS total_sum,
+			COALESCE(SUM(c), 0)::bigint AS total_count,
+			COALESCE(MAX(m), 0)::bigint AS total_max
+		FROM (` + strings.Join(parts, " UNION ALL ") + `) AS spans
+		GROUP BY feature_key`
+
+	var rows []rollupTotals
+	if err := s.pg.NewRaw(query, args...).Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+	totals := make(map[string]rollupTotals, len(rows))
+	for _, row := range rows {
+		totals[row.FeatureKey] = row
+	}
+	return totals, nil
+}
+
+// purgeUsage deletes the events before the cutoff, subtracts the ones in
+// the hour and day the cutoff falls inside from those buckets and drops
+// the buckets that lie entirely before it, in one statement. The maxima of
+// the partial buckets are left as they were.
+func (s *Store) purgeUsage(ctx context.Context, before time.Time) (int64, error) {
+	hour := meter.BucketStart(meter.GranularityHour, before)
+	day := meter.BucketStart(meter.GranularityDay, before)
+
+	edge := func(table, bucket string) string {
+		return `UPDATE ` + table + ` AS r
+			SET quantity_sum = r.quantity_sum - p.quantity_sum, event_count = r.event_count - p.event_count
+			FROM (
+				SELECT tenant_id, app_id, feature_key, SUM(quantity) AS quantity_sum, COUNT(*) AS event_count
+				FROM purged WHERE timestamp >= ` + bucket + ` AND ` + countedUsage + `
+				GROUP BY tenant_id, app_id, feature_key
+			) AS p
+			WHERE r.tenant_id = p.tenant_id AND r.app_id = p.app_id AND r.feature_key = p.feature_key
+				AND r.bucket_start = ` + bucket
+	}
+	query := `WITH purged AS (
+			DELETE FROM ledger_usage_events WHERE timestamp < $1
+			RETURNING tenant_id, app_id, feature_key, timestamp, quantity, voided_at, correction
+		), hourly_edge AS (` + edge(rollupHourlyTable, "$2") + `
+		), daily_edge AS (` + edge(rollupDailyTable, "$3") + `
+		), hourly_old AS (
+			DELETE FROM ` + rollupHourlyTable + ` WHERE bucket_start < $2
+		), daily_old AS (
+			DELETE FROM ` + rollupDailyTable + ` WHERE bucket_start < $3
+		)
+		SELECT COUNT(*) FROM purged`
+
+	var purged int64
+	if err := s.pg.NewRaw(query, before, hour, day).Scan(ctx, &purged); err != nil {
+		return 0, fmt.Errorf("ledger/postgres: purge usage: %w", err)
+	}
+	return purged, nil
+}
@@ -3,8 +3,10 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/xraph/grove"
@@ -119,6 +121,10 @@ func (s *Store) ListPlans(ctx context.Context, appID string, opts plan.ListOpts)
 		argIdx++
 		q = q.Where(fmt.Sprintf("status = $%d", argIdx), string(opts.Status))
 	}
+	if !opts.PlanID.IsNil() {
+		argIdx++
+		q = q.Where(fmt.Sprintf("plan_id = $%d", argIdx), opts.PlanID.String())
+	}
 	if opts.Limit > 0 {
 		q = q.Limit(opts.Limit)
 	}
@@ -474,46 +480,226 @@ func (s *Store) CancelSubscription(ctx context.Context, subID id.SubscriptionID,
 
 // ==================== Meter Store ====================
 
-func (s *Store) IngestBatch(ctx context.Context, events []*meter.UsageEvent) error {
-	if len(events) == 0 {
-		return nil
-	}
-	models := make([]usageEventModel, len(events))
-	for i, e := range events {
-		models[i] = *toUsageEventModel(e)
+func (s *Store) IngestBatch(ctx context.Context, events []*meter.UsageEvent) (*meter.IngestResult, error) {
+	return s.insertUsage(ctx, events, false)
+}
+
+// RestoreUsage inserts archived events without adding them to the rollups
+// or the lifetime counters, which still hold the archived usage.
+func (s *Store) RestoreUsage(ctx context.Context, events []*meter.UsageEvent) (*meter.IngestResult, error) {
+	return s.insertUsage(ctx, events, true)
+}
+
+// usageInsertChunk bounds the rows of one usage insert, keeping its bind
+// parameters well under the PostgreSQL limit.
+const usageInsertChunk = 1000
+
+// insertUsage inserts usage events and reports the IDs of the new ones.
+// Conflicts on the primary key (WAL replay) or on the
+// (app_id, tenant_id, idempotency_key) index are skipped. The
+// ledger_usage_counters and ledger_usage_rollups triggers add the inserted
+// events to the lifetime counters and the rollups unless they are marked
+// restored.
+func (s *Store) insertUsage(ctx context.Context, events []*meter.UsageEvent, restored bool) (*meter.IngestResult, error) {
+	result := &meter.IngestResult{}
+	for start := 0; start < len(events); start += usageInsertChunk {
+		chunk := events[start:min(start+usageInsertChunk, len(events))]
+		byID := make(map[string]*meter.UsageEvent, len(chunk))
+		values := make([]string, len(chunk))
+		args := make([]any, 0, len(chunk)*14)
+		for i, e := range chunk {
+			m := toUsageEventModel(e)
+			metadata, err := json.Marshal(m.Metadata)
+			if err != nil {
+				return nil, fmt.Errorf("ledger/postgres: encode usage metadata: %w", err)
+			}
+			byID[m.ID] = e
+			n := len(args)
+			values[i] = fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d::jsonb, $%d, $%d, $%d, $%d, $%d, $%d)",
+				n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9, n+10, n+11, n+12, n+13, n+14)
+			args = append(args, m.ID, m.TenantID, m.AppID, m.FeatureKey, m.Quantity, m.Timestamp,
+				m.IdempotencyKey, string(metadata), m.Correction, m.Reason, m.VoidedAt, m.VoidReason,
+				restored, m.CreatedAt)
+		}
+
+		query := `INSERT INTO ledger_usage_events (id, tenant_id, app_id, feature_key, quantity, timestamp,
+				idempotency_key, metadata, correction, reason, voided_at, void_reason, restored, created_at)
+			VALUES ` + strings.Join(values, ", ") + `
+			ON CONFLICT DO NOTHING
+			RETURNING id`
+		var rows []struct {
+			ID string `grove:"id"`
+		}
+		if err := s.pg.NewRaw(query, args...).Scan(ctx, &rows); err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			if e, ok := byID[row.ID]; ok {
+				result.InsertedIDs = append(result.InsertedIDs, e.ID)
+			}
+		}
 	}
-	_, err := s.pg.NewInsert(&models).
-		OnConflict("(idempotency_key) WHERE idempotency_key != '' DO NOTHING").
-		Exec(ctx)
-	return err
+	result.Inserted = len(result.InsertedIDs)
+	result.Duplicates = len(events) - result.Inserted
+	return result, nil
 }
 
-func (s *Store) Aggregate(ctx context.Context, tenantID, appID, featureKey string, period plan.Period) (int64, error) {
-	startOfPeriod := getStartOfPeriod(time.Now(), period)
+// countedUsage restricts a usage event query to the events that count
+// towards aggregations: neither voided nor corrections.
+const countedUsage = "voided_at IS NULL AND NOT correction"
+
+func (s *Store) Aggregate(ctx context.Context, tenantID, appID, featureKey string, agg meter.Aggregation, start, end time.Time) (int64, error) {
+	if agg.Rollable() {
+		totals, err := s.aggregateRollups(ctx, tenantID, appID, []string{featureKey}, start, end)
+		if err != nil {
+			return 0, err
+		}
+		return totals[featureKey].pick(agg.Kind()), nil
+	}
+
+	where := "tenant_id = $1 AND app_id = $2 AND feature_key = $3 AND " + countedUsage
+	args := []any{tenantID, appID, featureKey}
+	if !start.IsZero() {
+		args = append(args, start)
+		where += fmt.Sprintf(" AND timestamp >= $%d", len(args))
+	}
+	if !end.IsZero() {
+		args = append(args, end)
+		where += fmt.Sprintf(" AND timestamp < $%d", len(args))
+	}
+
+	var query string
+	switch agg.Kind() {
+	case meter.AggregateUnique:
+		if agg.Property == "" {
+			return 0, fmt.Errorf("%w: unique aggregation needs a property", ledger.ErrUnsupportedAggregation)
+		}
+		args = append(args, agg.Property)
+		query = fmt.Sprintf("SELECT COUNT(DISTINCT metadata->>$%d) FROM ledger_usage_events WHERE ", len(args)) + where
+	case meter.AggregateLast:
+		query = "SELECT COALESCE((SELECT quantity FROM ledger_usage_events WHERE " + where +
+			" ORDER BY timestamp DESC, id DESC LIMIT 1), 0)"
+	default:
+		return 0, fmt.Errorf("%w: %q", ledger.ErrUnsupportedAggregation, agg.Type)
+	}
 
 	var total int64
-	err := s.pg.NewRaw(`
-		SELECT COALESCE(SUM(quantity), 0) FROM ledger_usage_events
-		WHERE tenant_id = $1 AND app_id = $2 AND feature_key = $3 AND timestamp > $4
-	`, tenantID, appID, featureKey, startOfPeriod).Scan(ctx, &total)
-	if err != nil {
+	if err := s.pg.NewRaw(query, args...).Scan(ctx, &total); err != nil {
 		return 0, err
 	}
 	return total, nil
 }
 
-func (s *Store) AggregateMulti(ctx context.Context, tenantID, appID string, featureKeys []string, period plan.Period) (map[string]int64, error) {
-	result := make(map[string]int64)
-	for _, key := range featureKeys {
-		total, err := s.Aggregate(ctx, tenantID, appID, key, period)
+// AggregateMulti answers every sum, count and max aggregation with a single
+// query grouped by feature; unique and last aggregations are queried per
+// feature.
+func (s *Store) AggregateMulti(ctx context.Context, tenantID, appID string, features map[string]meter.Aggregation, start, end time.Time) (map[string]int64, error) {
+	result := make(map[string]int64, len(features))
+	var rollable []string
+	for key, agg := range features {
+		if agg.Rollable() {
+			rollable = append(rollable, key)
+			continue
+		}
+		total, err := s.Aggregate(ctx, tenantID, appID, key, agg, start, end)
 		if err != nil {
 			return nil, err
 		}
 		result[key] = total
 	}
+	if len(rollable) == 0 {
+		return result, nil
+	}
+
+	totals, err := s.aggregateRollups(ctx, tenantID, appID, rollable, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range rollable {
+		result[key] = totals[key].pick(features[key].Kind())
+	}
 	return result, nil
 }
 
+// AggregateGroups groups the raw usage events by the requested metadata
+// values; the rollups carry no dimensions.
+func (s *Store) AggregateGroups(ctx context.Context, tenantID, appID string, agg meter.Aggregation, opts meter.QueryOpts) ([]*meter.GroupTotal, error) {
+	where := "tenant_id = $1 AND app_id = $2 AND feature_key = $3 AND " + countedUsage
+	args := []any{tenantID, appID, opts.FeatureKey}
+	if !opts.Start.IsZero() {
+		args = append(args, opts.Start)
+		where += fmt.Sprintf(" AND timestamp >= $%d", len(args))
+	}
+	if !opts.End.IsZero() {
+		args = append(args, opts.End)
+		where += fmt.Sprintf(" AND timestamp < $%d", len(args))
+	}
+	if len(opts.Dimensions) > 0 {
+		dims, err := json.Marshal(opts.Dimensions)
+		if err != nil {
+			return nil, fmt.Errorf("ledger/postgres: encode dimensions: %w", err)
+		}
+		args = append(args, string(dims))
+		where += fmt.Sprintf(" AND metadata @> $%d::jsonb", len(args))
+	}
+
+	var value string
+	switch agg.Kind() {
+	case meter.AggregateSum:
+		value = "COALESCE(SUM(quantity), 0)"
+	case meter.AggregateCount:
+		value = "COUNT(*)"
+	case meter.AggregateMax:
+		value = "COALESCE(MAX(quantity), 0)"
+	case meter.AggregateUnique:
+		if agg.Property == "" {
+			return nil, fmt.Errorf("%w: unique aggregation needs a property", ledger.ErrUnsupportedAggregation)
+		}
+		args = append(args, agg.Property)
+		value = fmt.Sprintf("COUNT(DISTINCT metadata->>$%d)", len(args))
+	case meter.AggregateLast:
+		value = "(array_agg(quantity ORDER BY timestamp DESC, id DESC))[1]"
+	default:
+		return nil, fmt.Errorf("%w: %q", ledger.ErrUnsupportedAggregation, agg.Type)
+	}
+
+	groups := make([]string, len(opts.GroupBy))
+	for i, key := range opts.GroupBy {
+		args = append(args, key)
+		groups[i] = fmt.Sprintf("COALESCE(metadata->>$%d, '')", len(args))
+	}
+
+	query := "SELECT json_build_array(" + strings.Join(groups, ", ") + ")::text AS dims, " + value + "::bigint AS value" +
+		" FROM ledger_usage_events WHERE " + where + " GROUP BY 1 ORDER BY 1"
+
+	var rows []usageGroup
+	if err := s.pg.NewRaw(query, args...).Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+	result := make([]*meter.GroupTotal, len(rows))
+	for i, row := range rows {
+		var values []string
+		if err := json.Unmarshal([]byte(row.Dims), &values); err != nil {
+			return nil, fmt.Errorf("ledger/postgres: decode usage group: %w", err)
+		}
+		dims := make(map[string]string, len(opts.GroupBy))
+		for j, key := range opts.GroupBy {
+			if j < len(values) {
+				dims[key] = values[j]
+			}
+		}
+		result[i] = &meter.GroupTotal{Dimensions: dims, Value: row.Value}
+	}
+	return result, nil
+}
+
+// usageGroup is one row of AggregateGroups: the grouped metadata values as a
+// JSON array and the aggregated value.
+type usageGroup struct {
+	Dims  string `grove:"dims"`
+	Value int64  `grove:"value"`
+}
+
 func (s *Store) QueryUsage(ctx context.Context, tenantID, appID string, opts meter.QueryOpts) ([]*meter.UsageEvent, error) {
 	var models []usageEventModel
 	q := s.pg.NewSelect(&models)
@@ -537,7 +723,15 @@ func (s *Store) QueryUsage(ctx context.Context, tenantID, appID string, opts met
 	}
 	if !opts.End.IsZero() {
 		argIdx++
-		q = q.Where(fmt.Sprintf("timestamp <= $%d", argIdx), opts.End)
+		q = q.Where(fmt.Sprintf("timestamp < $%d", argIdx), opts.End)
+	}
+	if len(opts.Dimensions) > 0 {
+		dims, err := json.Marshal(opts.Dimensions)
+		if err != nil {
+			return nil, fmt.Errorf("ledger/postgres: encode dimensions: %w", err)
+		}
+		argIdx++
+		q = q.Where(fmt.Sprintf("metadata @> $%d::jsonb", argIdx), string(dims))
 	}
 	if opts.Limit > 0 {
 		q = q.Limit(opts.Limit)
@@ -563,17 +757,221 @@ func (s *Store) QueryUsage(ctx context.Context, tenantID, appID string, opts met
 }
 
 func (s *Store) PurgeUsage(ctx context.Context, before time.Time) (int64, error) {
+	return s.purgeUsage(ctx, before)
+}
+
+// ScanUsage returns up to limit events of an app with timestamps before
+// before, oldest first.
+func (s *Store) ScanUsage(ctx context.Context, appID string, before time.Time, limit int) ([]*meter.UsageEvent, error) {
+	var models []usageEventModel
+	q := s.pg.NewSelect(&models).
+		Where("app_id = $1", appID).
+		Where("timestamp < $2", before).
+		OrderExpr("timestamp ASC, id ASC")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	if err := q.Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	result := make([]*meter.UsageEvent, len(models))
+	for i := range models {
+		evt, err := fromUsageEventModel(&models[i])
+		if err != nil {
+			return nil, err
+		}
+		result[i] = evt
+	}
+	return result, nil
+}
+
+// DeleteUsage removes events by ID and leaves the rollups untouched.
+func (s *Store) DeleteUsage(ctx context.Context, eventIDs []id.UsageEventID) (int64, error) {
+	if len(eventIDs) == 0 {
+		return 0, nil
+	}
+	args := make([]any, len(eventIDs))
+	ids := make([]string, len(eventIDs))
+	for i, evtID := range eventIDs {
+		args[i] = evtID.String()
+		ids[i] = fmt.Sprintf("$%d", i+1)
+	}
 	res, err := s.pg.NewDelete((*usageEventModel)(nil)).
-		Where("timestamp < $1", before).
+		Where("id IN ("+strings.Join(ids, ", ")+")", args...).
 		Exec(ctx)
 	if err != nil {
 		return 0, err
 	}
+	return res.RowsAffected()
+}
+
+func (s *Store) VoidUsage(ctx context.Context, tenantID, appID string, opts meter.VoidOpts) (*meter.UsageEvent, error) {
+	m := new(usageEventModel)
+	q := s.pg.NewSelect(m).
+		Where("tenant_id = $1", tenantID).
+		Where("app_id = $2", appID)
+	if !opts.EventID.IsNil() {
+		q = q.Where("id = $3", opts.EventID.String())
+	} else {
+		q = q.Where("idempotency_key = $3", opts.IdempotencyKey)
+	}
+	if err := q.Scan(ctx); err != nil {
+		if isNoRows(err) {
+			return nil, ledger.ErrUsageEventNotFound
+		}
+		return nil, err
+	}
+	if m.VoidedAt != nil {
+		return nil, ledger.ErrUsageEventVoided
+	}
+
+	res, err := s.pg.NewUpdate((*usageEventModel)(nil)).
+		Set("voided_at = $1", opts.VoidedAt).
+		Set("void_reason = $2", opts.Reason).
+		Where("id = $3", m.ID).
+		Where("voided_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
 	rows, err := res.RowsAffected()
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, ledger.ErrUsageEventVoided
+	}
+
+	evt, err := fromUsageEventModel(m)
+	if err != nil {
+		return nil, err
+	}
+	evt.VoidedAt = &opts.VoidedAt
+	evt.VoidReason = opts.Reason
+	return evt, nil
+}
+
+func (s *Store) SumCorrections(ctx context.Context, tenantID, appID string, featureKeys []string, start, end time.Time) (map[string]int64, error) {
+	result := make(map[string]int64, len(featureKeys))
+	if len(featureKeys) == 0 {
+		return result, nil
+	}
+
+	args := []any{tenantID, appID}
+	keys := make([]string, len(featureKeys))
+	for i, key := range featureKeys {
+		args = append(args, key)
+		keys[i] = fmt.Sprintf("$%d", len(args))
+	}
+	where := "tenant_id = $1 AND app_id = $2 AND feature_key IN (" + strings.Join(keys, ", ") + ")" +
+		" AND correction AND voided_at IS NULL"
+	if !start.IsZero() {
+		args = append(args, start)
+		where += fmt.Sprintf(" AND timestamp >= $%d", len(args))
+	}
+	if !end.IsZero() {
+		args = append(args, end)
+		where += fmt.Sprintf(" AND timestamp < $%d", len(args))
+	}
+
+	var rows []struct {
+		FeatureKey string `grove:"feature_key"`
+		Total      int64  `grove:"total"`
+	}
+	query := "SELECT feature_key, COALESCE(SUM(quantity), 0)::bigint AS total FROM ledger_usage_events WHERE " +
+		where + " GROUP BY feature_key"
+	if err := s.pg.NewRaw(query, args...).Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[row.FeatureKey] = row.Total
+	}
+	return result, nil
+}
+
+// LifetimeUsage reads the lifetime counters, which the
+// ledger_usage_counters triggers keep in step with inserts and voids.
+func (s *Store) LifetimeUsage(ctx context.Context, tenantID, appID string, featureKeys []string) (map[string]meter.LifetimeTotal, error) {
+	result := make(map[string]meter.LifetimeTotal, len(featureKeys))
+	if len(featureKeys) == 0 {
+		return result, nil
+	}
+
+	args := []any{tenantID, appID}
+	keys := make([]string, len(featureKeys))
+	for i, key := range featureKeys {
+		args = append(args, key)
+		keys[i] = fmt.Sprintf("$%d", len(args))
+	}
+
+	var rows []struct {
+		FeatureKey string `grove:"feature_key"`
+		Quantity   int64  `grove:"quantity"`
+		Events     int64  `grove:"events"`
+	}
+	query := "SELECT feature_key, quantity, events FROM ledger_usage_counters" +
+		" WHERE tenant_id = $1 AND app_id = $2 AND feature_key IN (" + strings.Join(keys, ", ") + ")"
+	if err := s.pg.NewRaw(query, args...).Scan(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("ledger/postgres: lifetime usage: %w", err)
 	}
-	return rows, nil
+	for _, row := range rows {
+		result[row.FeatureKey] = meter.LifetimeTotal{Quantity: row.Quantity, Events: row.Events}
+	}
+	return result, nil
+}
+
+// ==================== Seat Gauge Store ====================
+
+// Seat readings are kept apart from usage events, so purging usage never
+// loses a tenant's current seat count. seq orders readings that share a
+// timestamp.
+
+func (s *Store) RecordSeats(ctx context.Context, r *meter.SeatReading) error {
+	_, err := s.pg.NewRaw(`INSERT INTO ledger_seat_readings (tenant_id, app_id, feature_key, seats, timestamp)
+		VALUES ($1, $2, $3, $4, $5)`, r.TenantID, r.AppID, r.FeatureKey, r.Seats, r.Timestamp).Exec(ctx)
+	return err
+}
+
+func (s *Store) CurrentSeats(ctx context.Context, tenantID, appID, featureKey string, at time.Time) (int64, error) {
+	var seats int64
+	err := s.pg.NewRaw(`SELECT COALESCE((SELECT seats FROM ledger_seat_readings
+		WHERE tenant_id = $1 AND app_id = $2 AND feature_key = $3 AND timestamp <= $4
+		ORDER BY timestamp DESC, seq DESC LIMIT 1), 0)`, tenantID, appID, featureKey, at).Scan(ctx, &seats)
+	return seats, err
+}
+
+func (s *Store) SeatReadings(ctx context.Context, tenantID, appID, featureKey string, start, end time.Time) ([]*meter.SeatReading, error) {
+	where := "tenant_id = $1 AND app_id = $2 AND feature_key = $3"
+	args := []any{tenantID, appID, featureKey}
+	if !start.IsZero() {
+		args = append(args, start)
+		where += fmt.Sprintf(" AND timestamp >= $%d", len(args))
+	}
+	if !end.IsZero() {
+		args = append(args, end)
+		where += fmt.Sprintf(" AND timestamp < $%d", len(args))
+	}
+
+	var rows []struct {
+		Seats     int64     `grove:"seats"`
+		Timestamp time.Time `grove:"timestamp"`
+	}
+	query := "SELECT seats, timestamp FROM ledger_seat_readings WHERE " + where + " ORDER BY timestamp, seq"
+	if err := s.pg.NewRaw(query, args...).Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+	result := make([]*meter.SeatReading, len(rows))
+	for i, row := range rows {
+		result[i] = &meter.SeatReading{
+			TenantID:   tenantID,
+			AppID:      appID,
+			FeatureKey: featureKey,
+			Seats:      row.Seats,
+			Timestamp:  row.Timestamp,
+		}
+	}
+	return result, nil
 }
 
 // ==================== Entitlement Cache Store ====================
@@ -628,6 +1026,195 @@ func (s *Store) InvalidateFeature(ctx context.Context, tenantID, appID, featureK
 	return err
 }
 
+// ==================== Quota Store ====================
+
+// Hard-limit counters are checked and incremented by a single conditional
+// UPDATE, so concurrent consumers cannot overshoot the limit.
+
+func (s *Store) SeedQuota(ctx context.Context, key entitlement.QuotaKey, used int64, expiresAt time.Time) error {
+	var expires any
+	if !expiresAt.IsZero() {
+		expires = expiresAt
+	}
+	_, err := s.pg.NewRaw(`INSERT INTO ledger_quota_counters (tenant_id, app_id, feature_key, window_start, used, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT DO NOTHING`,
+		key.TenantID, key.AppID, key.FeatureKey, key.WindowStart, used, expires).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("ledger/postgres: seed quota: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) AddQuota(ctx context.Context, key entitlement.QuotaKey, qty, limit int64) (int64, bool, error) {
+	var rows []struct {
+		Used int64 `grove:"used"`
+	}
+	err := s.pg.NewRaw(`UPDATE ledger_quota_counters SET used = used + $5
+		WHERE tenant_id = $1 AND app_id = $2 AND feature_key = $3 AND window_start = $4
+		AND ($6::bigint < 0 OR used + $5 <= $6::bigint)
+		RETURNING used`, key.TenantID, key.AppID, key.FeatureKey, key.WindowStart, qty, limit).Scan(ctx, &rows)
+	if err != nil {
+		return 0, false, fmt.Errorf("ledger/postgres: add quota: %w", err)
+	}
+	if len(rows) > 0 {
+		return rows[0].Used, true, nil
+	}
+
+	err = s.pg.NewRaw(`SELECT used FROM ledger_quota_counters
+		WHERE tenant_id = $1 AND app_id = $2 AND feature_key = $3 AND window_start = $4`,
+		key.TenantID, key.AppID, key.FeatureKey, key.WindowStart).Scan(ctx, &rows)
+	if err != nil {
+		return 0, false, fmt.Errorf("ledger/postgres: get quota: %w", err)
+	}
+	if len(rows) == 0 {
+		return 0, false, ledger.ErrQuotaCounterNotFound
+	}
+	return rows[0].Used, false, nil
+}
+
+func (s *Store) PurgeQuotas(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.pg.NewRaw(`DELETE FROM ledger_quota_counters WHERE expires_at < $1`, before).Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("ledger/postgres: purge quotas: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+func (s *Store) CreateReservation(ctx context.Context, r *entitlement.Reservation) error {
+	_, err := s.pg.NewInsert(toReservationModel(r)).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("ledger/postgres: create reservation: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) TakeReservation(ctx context.Context, tenantID, appID string, reservationID id.ReservationID) (*entitlement.Reservation, error) {
+	var models []reservationModel
+	err := s.pg.NewRaw(`DELETE FROM ledger_reservations WHERE id = $1 AND tenant_id = $2 AND app_id = $3
+		RETURNING id, tenant_id, app_id, feature_key, window_start, quantity, expires_at, created_at`,
+		reservationID.String(), tenantID, appID).Scan(ctx, &models)
+	if err != nil {
+		return nil, fmt.Errorf("ledger/postgres: take reservation: %w", err)
+	}
+	if len(models) == 0 {
+		return nil, ledger.ErrReservationNotFound
+	}
+	return fromReservationModel(&models[0])
+}
+
+func (s *Store) ExpiredReservations(ctx context.Context, before time.Time, limit int) ([]*entitlement.Reservation, error) {
+	var models []reservationModel
+	q := s.pg.NewSelect(&models).
+		Where("expires_at < $1", before).
+		OrderExpr("expires_at ASC")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	if err := q.Scan(ctx); err != nil {
+		return nil, fmt.Errorf("ledger/postgres: expired reservations: %w", err)
+	}
+
+	result := make([]*entitlement.Reservation, len(models))
+	for i := range models {
+		r, err := fromReservationModel(&models[i])
+		if err != nil {
+			return nil, err
+		}
+		result[i] = r
+	}
+	return result, nil
+}
+
+// ==================== Override Store ====================
+
+func (s *Store) CreateOverride(ctx context.Context, o *entitlement.Override) error {
+	if _, err := s.pg.NewInsert(toOverrideModel(o)).Exec(ctx); err != nil {
+		return fmt.Errorf("ledger/postgres: create override: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) GetOverride(ctx context.Context, overrideID id.OverrideID) (*entitlement.Override, error) {
+	m := new(overrideModel)
+	err := s.pg.NewSelect(m).
+		Where("id = $1", overrideID.String()).
+		Scan(ctx)
+	if err != nil {
+		if isNoRows(err) {
+			return nil, ledger.ErrOverrideNotFound
+		}
+		return nil, fmt.Errorf("ledger/postgres: get override: %w", err)
+	}
+	return fromOverrideModel(m)
+}
+
+func (s *Store) ListOverrides(ctx context.Context, tenantID, appID string) ([]*entitlement.Override, error) {
+	var models []overrideModel
+	err := s.pg.NewSelect(&models).
+		Where("tenant_id = $1", tenantID).
+		Where("app_id = $2", appID).
+		OrderExpr("created_at DESC, id DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ledger/postgres: list overrides: %w", err)
+	}
+
+	result := make([]*entitlement.Override, len(models))
+	for i := range models {
+		o, err := fromOverrideModel(&models[i])
+		if err != nil {
+			return nil, err
+		}
+		result[i] = o
+	}
+	return result, nil
+}
+
+func (s *Store) DeleteOverride(ctx context.Context, overrideID id.OverrideID) error {
+	res, err := s.pg.NewDelete((*overrideModel)(nil)).
+		Where("id = $1", overrideID.String()).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("ledger/postgres: delete override: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ledger.ErrOverrideNotFound
+	}
+	return nil
+}
+
+// ==================== Usage Alert Store ====================
+
+func (s *Store) RecordUsageAlert(ctx context.Context, key entitlement.QuotaKey, threshold int, expiresAt time.Time) (bool, error) {
+	var expires any
+	if !expiresAt.IsZero() {
+		expires = expiresAt
+	}
+	res, err := s.pg.NewRaw(`INSERT INTO ledger_usage_alerts (tenant_id, app_id, feature_key, window_start, threshold, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT DO NOTHING`,
+		key.TenantID, key.AppID, key.FeatureKey, key.WindowStart, threshold, expires).Exec(ctx)
+	if err != nil {
+		return false, fmt.Errorf("ledger/postgres: record usage alert: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("ledger/postgres: record usage alert: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (s *Store) PurgeUsageAlerts(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.pg.NewRaw(`DELETE FROM ledger_usage_alerts WHERE expires_at < $1`, before).Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("ledger/postgres: purge usage alerts: %w", err)
+	}
+	return res.RowsAffected()
+}
+
 // ==================== Invoice Store ====================
 
 func (s *Store) CreateInvoice(ctx context.Context, inv *invoice.Invoice) error {
@@ -902,18 +1489,6 @@ func now() time.Time {
 	return time.Now().UTC()
 }
 
-// getStartOfPeriod returns the start of the given period.
-func getStartOfPeriod(t time.Time, period plan.Period) time.Time {
-	switch period {
-	case plan.PeriodMonthly:
-		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
-	case plan.PeriodYearly:
-		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location())
-	default:
-		return time.Time{}
-	}
-}
-
 // isNoRows checks for the standard sql.ErrNoRows sentinel.
 func isNoRows(err error) bool {
 	return errors.Is(err, sql.ErrNoRows)
@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/xraph/grove"
+	"github.com/xraph/grove/drivers/pgdriver"
+
+	"github.com/xraph/ledger/id"
+	"github.com/xraph/ledger/meter"
+	"github.com/xraph/ledger/store/storetest"
+)
+
+// dsnEnv names the environment variable holding the DSN of a scratch
+// PostgreSQL database. Tests that need one are skipped when it is unset.
+const dsnEnv = "LEDGER_POSTGRES_DSN"
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	dsn := os.Getenv(dsnEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", dsnEnv)
+	}
+	db, err := grove.Open(pgdriver.Open(dsn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(db)
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// testTenant returns a tenant ID no earlier run used, so tests can share
+// a database.
+func testTenant() string {
+	return "t-" + id.NewUsageEventID().String()
+}
+
+func TestQueryUsageWindow(t *testing.T) {
+	storetest.QueryUsageWindow(t, newTestStore(t))
+}
+
+func TestListSubscriptionsByPlan(t *testing.T) {
+	storetest.ListSubscriptionsByPlan(t, newTestStore(t))
+}
+
+func TestBackdatedUsageIntoArchivedHour(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+	tenantID := testTenant()
+	hour := meter.BucketStart(meter.GranularityHour, time.Now().UTC().Add(-48*time.Hour))
+	start, end := hour, hour.Add(2*time.Hour)
+
+	usageEvent := func(qty int64, ts time.Time) *meter.UsageEvent {
+		return &meter.UsageEvent{
+			ID: id.NewUsageEventID(), TenantID: tenantID, AppID: tenantID, FeatureKey: "api", Quantity: qty, Timestamp: ts,
+		}
+	}
+	events := []*meter.UsageEvent{
+		usageEvent(1, hour.Add(time.Minute)),
+		usageEvent(2, hour.Add(20*time.Minute)),
+		usageEvent(3, hour.Add(40*time.Minute)),
+		usageEvent(4, hour.Add(90*time.Minute)),
+	}
+	if _, err := s.IngestBatch(ctx, events); err != nil {
+		t.Fatal(err)
+	}
+
+	aggregate := func() (sum, count, peak int64) {
+		t.Helper()
+		totals := make([]int64, 3)
+		for i, kind := range []meter.AggregationType{meter.AggregateSum, meter.AggregateCount, meter.AggregateMax} {
+			total, err := s.Aggregate(ctx, tenantID, tenantID, "api", meter.Aggregation{Type: kind}, start, end)
+			if err != nil {
+				t.Fatal(err)
+			}
+			totals[i] = total
+		}
+		return totals[0], totals[1], totals[2]
+	}
+	sum, count, peak := aggregate()
+	if sum != 10 || count != 4 || peak != 4 {
+		t.Fatalf("before archiving: sum %d, count %d, max %d, want 10, 4, 4", sum, count, peak)
+	}
+
+	// Archive the first hour: its raw events go, its rollups stay.
+	archived, err := s.ScanUsage(ctx, tenantID, hour.Add(time.Hour), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]id.UsageEventID, len(archived))
+	for i, e := range archived {
+		ids[i] = e.ID
+	}
+	if deleted, err := s.DeleteUsage(ctx, ids); err != nil || deleted != 3 {
+		t.Fatalf("DeleteUsage = %d, %v, want the 3 events of the hour", deleted, err)
+	}
+	if sum, count, peak = aggregate(); sum != 10 || count != 4 || peak != 4 {
+		t.Fatalf("after archiving: sum %d, count %d, max %d, want 10, 4, 4", sum, count, peak)
+	}
+
+	// A late event for the archived hour adds to its rollups only itself.
+	if _, err := s.IngestBatch(ctx, []*meter.UsageEvent{usageEvent(5, hour.Add(30*time.Minute))}); err != nil {
+		t.Fatal(err)
+	}
+	if sum, count, peak = aggregate(); sum != 15 || count != 5 || peak != 5 {
+		t.Fatalf("after the backdated event: sum %d, count %d, max %d, want 15, 5, 5", sum, count, peak)
+	}
+}
@@ -269,5 +269,373 @@ ALTER TABLE ledger_invoices ADD COLUMN provider_name TEXT NOT NULL DEFAULT '';
 				return nil
 			},
 		},
+		&migrate.Migration{
+			Name:    "scope_usage_idempotency_keys",
+			Version: "20240101000009",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+DROP INDEX IF EXISTS idx_ledger_usage_idempotency;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_usage_idempotency ON ledger_usage_events (app_id, tenant_id, idempotency_key) WHERE idempotency_key != '';
+`)
+				return err
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+DROP INDEX IF EXISTS idx_ledger_usage_idempotency;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_usage_idempotency ON ledger_usage_events (idempotency_key) WHERE idempotency_key != '';
+`)
+				return err
+			},
+		},
+		&migrate.Migration{
+			Name:    "add_feature_aggregation",
+			Version: "20240101000010",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+ALTER TABLE ledger_features ADD COLUMN aggregation TEXT NOT NULL DEFAULT '';
+ALTER TABLE ledger_features ADD COLUMN aggregation_property TEXT NOT NULL DEFAULT '';
+`)
+				return err
+			},
+			Down: func(_ context.Context, _ migrate.Executor) error {
+				// SQLite does not support DROP COLUMN in older versions;
+				// these columns are harmless if left in place.
+				return nil
+			},
+		},
+		&migrate.Migration{
+			Name:    "create_ledger_usage_rollups",
+			Version: "20240101000011",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				// bucket_start holds Unix seconds of the UTC bucket start.
+				_, err := exec.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS ledger_usage_rollups_hourly (
+    tenant_id    TEXT NOT NULL,
+    app_id       TEXT NOT NULL,
+    feature_key  TEXT NOT NULL,
+    bucket_start INTEGER NOT NULL,
+    quantity_sum INTEGER NOT NULL DEFAULT 0,
+    event_count  INTEGER NOT NULL DEFAULT 0,
+    quantity_max INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (tenant_id, app_id, feature_key, bucket_start)
+);
+
+CREATE TABLE IF NOT EXISTS ledger_usage_rollups_daily (
+    tenant_id    TEXT NOT NULL,
+    app_id       TEXT NOT NULL,
+    feature_key  TEXT NOT NULL,
+    bucket_start INTEGER NOT NULL,
+    quantity_sum INTEGER NOT NULL DEFAULT 0,
+    event_count  INTEGER NOT NULL DEFAULT 0,
+    quantity_max INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (tenant_id, app_id, feature_key, bucket_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_usage_rollups_hourly_bucket ON ledger_usage_rollups_hourly (bucket_start);
+CREATE INDEX IF NOT EXISTS idx_ledger_usage_rollups_daily_bucket ON ledger_usage_rollups_daily (bucket_start);
+
+INSERT OR IGNORE INTO ledger_usage_rollups_hourly (tenant_id, app_id, feature_key, bucket_start, quantity_sum, event_count, quantity_max)
+SELECT tenant_id, app_id, feature_key, CAST(strftime('%s', timestamp) AS INTEGER) / 3600 * 3600,
+       SUM(quantity), COUNT(*), MAX(quantity)
+FROM ledger_usage_events
+WHERE strftime('%s', timestamp) IS NOT NULL
+GROUP BY 1, 2, 3, 4;
+
+INSERT OR IGNORE INTO ledger_usage_rollups_daily (tenant_id, app_id, feature_key, bucket_start, quantity_sum, event_count, quantity_max)
+SELECT tenant_id, app_id, feature_key, bucket_start / 86400 * 86400,
+       SUM(quantity_sum), SUM(event_count), MAX(quantity_max)
+FROM ledger_usage_rollups_hourly
+GROUP BY 1, 2, 3, 4;
+`)
+				return err
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+DROP TABLE IF EXISTS ledger_usage_rollups_daily;
+DROP TABLE IF EXISTS ledger_usage_rollups_hourly;
+`)
+				return err
+			},
+		},
+		&migrate.Migration{
+			Name:    "add_usage_corrections_and_voids",
+			Version: "20240101000012",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+ALTER TABLE ledger_usage_events ADD COLUMN correction INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE ledger_usage_events ADD COLUMN reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE ledger_usage_events ADD COLUMN voided_at TEXT;
+ALTER TABLE ledger_usage_events ADD COLUMN void_reason TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_ledger_usage_corrections ON ledger_usage_events (tenant_id, app_id, feature_key, timestamp) WHERE correction = 1;
+`)
+				return err
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				// SQLite does not support DROP COLUMN in older versions;
+				// the columns are harmless if left in place.
+				_, err := exec.Exec(ctx, `DROP INDEX IF EXISTS idx_ledger_usage_corrections`)
+				return err
+			},
+		},
+		&migrate.Migration{
+			Name:    "add_feature_dimensions",
+			Version: "20240101000013",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+ALTER TABLE ledger_features ADD COLUMN dimensions TEXT NOT NULL DEFAULT '[]';
+`)
+				return err
+			},
+			Down: func(_ context.Context, _ migrate.Executor) error {
+				// SQLite does not support DROP COLUMN in older versions;
+				// the column is harmless if left in place.
+				return nil
+			},
+		},
+		&migrate.Migration{
+			Name:    "create_ledger_seat_readings",
+			Version: "20240101000014",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS ledger_seat_readings (
+    seq         INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id   TEXT NOT NULL,
+    app_id      TEXT NOT NULL,
+    feature_key TEXT NOT NULL,
+    seats       INTEGER NOT NULL,
+    timestamp   TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_seat_readings_feature ON ledger_seat_readings (tenant_id, app_id, feature_key, timestamp);
+`)
+				return err
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `DROP TABLE IF EXISTS ledger_seat_readings`)
+				return err
+			},
+		},
+		&migrate.Migration{
+			Name:    "create_ledger_usage_counters",
+			Version: "20240101000015",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS ledger_usage_counters (
+    tenant_id   TEXT NOT NULL,
+    app_id      TEXT NOT NULL,
+    feature_key TEXT NOT NULL,
+    quantity    INTEGER NOT NULL DEFAULT 0,
+    events      INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (tenant_id, app_id, feature_key)
+);
+
+ALTER TABLE ledger_usage_events ADD COLUMN restored INTEGER NOT NULL DEFAULT 0;
+
+CREATE TRIGGER IF NOT EXISTS ledger_usage_counters_insert AFTER INSERT ON ledger_usage_events
+WHEN NEW.restored = 0 AND NEW.correction = 0 AND NEW.voided_at IS NULL
+BEGIN
+    INSERT INTO ledger_usage_counters (tenant_id, app_id, feature_key, quantity, events)
+    VALUES (NEW.tenant_id, NEW.app_id, NEW.feature_key, NEW.quantity, 1)
+    ON CONFLICT (tenant_id, app_id, feature_key) DO UPDATE
+    SET quantity = quantity + excluded.quantity, events = events + 1;
+END;
+
+CREATE TRIGGER IF NOT EXISTS ledger_usage_counters_void AFTER UPDATE OF voided_at ON ledger_usage_events
+WHEN OLD.voided_at IS NULL AND NEW.voided_at IS NOT NULL AND NEW.correction = 0
+BEGIN
+    UPDATE ledger_usage_counters
+    SET quantity = quantity - NEW.quantity, events = events - 1
+    WHERE tenant_id = NEW.tenant_id AND app_id = NEW.app_id AND feature_key = NEW.feature_key;
+END;
+
+INSERT OR IGNORE INTO ledger_usage_counters (tenant_id, app_id, feature_key, quantity, events)
+SELECT tenant_id, app_id, feature_key, SUM(quantity), COUNT(*)
+FROM ledger_usage_events
+WHERE voided_at IS NULL AND correction = 0
+GROUP BY 1, 2, 3;
+`)
+				return err
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				// The restored column is harmless if left in place.
+				_, err := exec.Exec(ctx, `
+DROP TRIGGER IF EXISTS ledger_usage_counters_void;
+DROP TRIGGER IF EXISTS ledger_usage_counters_insert;
+DROP TABLE IF EXISTS ledger_usage_counters;
+`)
+				return err
+			},
+		},
+		&migrate.Migration{
+			Name:    "create_ledger_quotas",
+			Version: "20240101000016",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS ledger_quota_counters (
+    tenant_id    TEXT NOT NULL,
+    app_id       TEXT NOT NULL,
+    feature_key  TEXT NOT NULL,
+    window_start TEXT NOT NULL,
+    used         INTEGER NOT NULL DEFAULT 0,
+    expires_at   TEXT,
+    PRIMARY KEY (tenant_id, app_id, feature_key, window_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_quota_counters_expires ON ledger_quota_counters (expires_at);
+
+CREATE TABLE IF NOT EXISTS ledger_reservations (
+    id           TEXT PRIMARY KEY,
+    tenant_id    TEXT NOT NULL,
+    app_id       TEXT NOT NULL,
+    feature_key  TEXT NOT NULL,
+    window_start TEXT NOT NULL,
+    quantity     INTEGER NOT NULL,
+    expires_at   TEXT NOT NULL,
+    created_at   TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_reservations_expires ON ledger_reservations (expires_at);
+`)
+				return err
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+DROP TABLE IF EXISTS ledger_reservations;
+DROP TABLE IF EXISTS ledger_quota_counters;
+`)
+				return err
+			},
+		},
+		&migrate.Migration{
+			Name:    "create_ledger_entitlement_overrides",
+			Version: "20240101000017",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS ledger_entitlement_overrides (
+    id          TEXT PRIMARY KEY,
+    tenant_id   TEXT NOT NULL,
+    app_id      TEXT NOT NULL,
+    feature_key TEXT NOT NULL,
+    mode        TEXT NOT NULL,
+    value       INTEGER NOT NULL,
+    expires_at  TEXT,
+    reason      TEXT NOT NULL DEFAULT '',
+    created_at  TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_entitlement_overrides_tenant ON ledger_entitlement_overrides (tenant_id, app_id, created_at DESC);
+`)
+				return err
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `DROP TABLE IF EXISTS ledger_entitlement_overrides`)
+				return err
+			},
+		},
+		&migrate.Migration{
+			Name:    "create_ledger_usage_alerts",
+			Version: "20240101000018",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS ledger_usage_alerts (
+    tenant_id    TEXT NOT NULL,
+    app_id       TEXT NOT NULL,
+    feature_key  TEXT NOT NULL,
+    window_start TEXT NOT NULL,
+    threshold    INTEGER NOT NULL,
+    expires_at   TEXT,
+    PRIMARY KEY (tenant_id, app_id, feature_key, window_start, threshold)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_usage_alerts_expires ON ledger_usage_alerts (expires_at);
+`)
+				return err
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				_, err := exec.Exec(ctx, `DROP TABLE IF EXISTS ledger_usage_alerts`)
+				return err
+			},
+		},
+		&migrate.Migration{
+			Name:    "maintain_usage_rollups_in_triggers",
+			Version: "20240101000019",
+			Up: func(ctx context.Context, exec migrate.Executor) error {
+				// bucket_hour holds Unix seconds of the UTC hour an event
+				// falls in. Inserts add their own quantity to the rollups
+				// and voids subtract it, so buckets are never rebuilt from
+				// raw events, which archival and purges remove. A voided
+				// bucket max is only recomputed when every event it covers
+				// is still raw.
+				_, err := exec.Exec(ctx, `
+ALTER TABLE ledger_usage_events ADD COLUMN bucket_hour INTEGER NOT NULL DEFAULT 0;
+
+UPDATE ledger_usage_events SET bucket_hour = CAST(strftime('%s', timestamp) AS INTEGER) / 3600 * 3600
+WHERE strftime('%s', timestamp) IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_ledger_usage_bucket_hour ON ledger_usage_events (tenant_id, app_id, feature_key, bucket_hour);
+
+CREATE TRIGGER IF NOT EXISTS ledger_usage_rollups_insert AFTER INSERT ON ledger_usage_events
+WHEN NEW.restored = 0 AND NEW.correction = 0 AND NEW.voided_at IS NULL
+BEGIN
+    INSERT INTO ledger_usage_rollups_hourly (tenant_id, app_id, feature_key, bucket_start, quantity_sum, event_count, quantity_max)
+    VALUES (NEW.tenant_id, NEW.app_id, NEW.feature_key, NEW.bucket_hour, NEW.quantity, 1, NEW.quantity)
+    ON CONFLICT (tenant_id, app_id, feature_key, bucket_start) DO UPDATE
+    SET quantity_sum = quantity_sum + excluded.quantity_sum,
+        event_count = event_count + excluded.event_count,
+        quantity_max = MAX(quantity_max, excluded.quantity_max);
+    INSERT INTO ledger_usage_rollups_daily (tenant_id, app_id, feature_key, bucket_start, quantity_sum, event_count, quantity_max)
+    VALUES (NEW.tenant_id, NEW.app_id, NEW.feature_key, NEW.bucket_hour / 86400 * 86400, NEW.quantity, 1, NEW.quantity)
+    ON CONFLICT (tenant_id, app_id, feature_key, bucket_start) DO UPDATE
+    SET quantity_sum = quantity_sum + excluded.quantity_sum,
+        event_count = event_count + excluded.event_count,
+        quantity_max = MAX(quantity_max, excluded.quantity_max);
+END;
+
+CREATE TRIGGER IF NOT EXISTS ledger_usage_rollups_void AFTER UPDATE OF voided_at ON ledger_usage_events
+WHEN OLD.voided_at IS NULL AND NEW.voided_at IS NOT NULL AND NEW.correction = 0
+BEGIN
+    UPDATE ledger_usage_rollups_hourly
+    SET quantity_sum = quantity_sum - NEW.quantity,
+        event_count = event_count - 1,
+        quantity_max = CASE
+            WHEN NEW.quantity < quantity_max THEN quantity_max
+            WHEN (SELECT COUNT(*) FROM ledger_usage_events
+                WHERE tenant_id = NEW.tenant_id AND app_id = NEW.app_id AND feature_key = NEW.feature_key
+                    AND bucket_hour = NEW.bucket_hour AND voided_at IS NULL AND correction = 0) <> event_count - 1
+                THEN quantity_max
+            ELSE (SELECT COALESCE(MAX(quantity), 0) FROM ledger_usage_events
+                WHERE tenant_id = NEW.tenant_id AND app_id = NEW.app_id AND feature_key = NEW.feature_key
+                    AND bucket_hour = NEW.bucket_hour AND voided_at IS NULL AND correction = 0)
+        END
+    WHERE tenant_id = NEW.tenant_id AND app_id = NEW.app_id AND feature_key = NEW.feature_key
+        AND bucket_start = NEW.bucket_hour;
+    UPDATE ledger_usage_rollups_daily
+    SET quantity_sum = quantity_sum - NEW.quantity,
+        event_count = event_count - 1,
+        quantity_max = CASE
+            WHEN NEW.quantity < quantity_max THEN quantity_max
+            ELSE (SELECT COALESCE(MAX(h.quantity_max), 0) FROM ledger_usage_rollups_hourly AS h
+                WHERE h.tenant_id = NEW.tenant_id AND h.app_id = NEW.app_id AND h.feature_key = NEW.feature_key
+                    AND h.bucket_start >= NEW.bucket_hour / 86400 * 86400
+                    AND h.bucket_start < NEW.bucket_hour / 86400 * 86400 + 86400
+                    AND h.event_count > 0)
+        END
+    WHERE tenant_id = NEW.tenant_id AND app_id = NEW.app_id AND feature_key = NEW.feature_key
+        AND bucket_start = NEW.bucket_hour / 86400 * 86400;
+END;
+`)
+				return err
+			},
+			Down: func(ctx context.Context, exec migrate.Executor) error {
+				// The bucket_hour column is harmless if left in place.
+				_, err := exec.Exec(ctx, `
+DROP TRIGGER IF EXISTS ledger_usage_rollups_void;
+DROP TRIGGER IF EXISTS ledger_usage_rollups_insert;
+DROP INDEX IF EXISTS idx_ledger_usage_bucket_hour;
+`)
+				return err
+			},
+		},
 	)
 }
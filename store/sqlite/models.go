@@ -197,15 +197,21 @@ func fromSubscriptionModel(m *subscriptionModel) (*subscription.Subscription, er
 type usageEventModel struct {
 	grove.BaseModel `grove:"table:ledger_usage_events"`
 
-	ID             string    `grove:"id,pk"`
-	TenantID       string    `grove:"tenant_id"`
-	AppID          string    `grove:"app_id"`
-	FeatureKey     string    `grove:"feature_key"`
-	Quantity       int64     `grove:"quantity"`
-	Timestamp      time.Time `grove:"timestamp"`
-	IdempotencyKey string    `grove:"idempotency_key"`
-	Metadata       string    `grove:"metadata"` // JSON text
-	CreatedAt      time.Time `grove:"created_at"`
+	ID             string     `grove:"id,pk"`
+	TenantID       string     `grove:"tenant_id"`
+	AppID          string     `grove:"app_id"`
+	FeatureKey     string     `grove:"feature_key"`
+	Quantity       int64      `grove:"quantity"`
+	Timestamp      time.Time  `grove:"timestamp"`
+	IdempotencyKey string     `grove:"idempotency_key"`
+	Metadata       string     `grove:"metadata"` // JSON text
+	Correction     bool       `grove:"correction"`
+	Reason         string     `grove:"reason"`
+	VoidedAt       *time.Time `grove:"voided_at"`
+	VoidReason     string     `grove:"void_reason"`
+	Restored       bool       `grove:"restored"`
+	BucketHour     int64      `grove:"bucket_hour"` // Unix seconds of the UTC hour
+	CreatedAt      time.Time  `grove:"created_at"`
 }
 
 func toUsageEventModel(e *meter.UsageEvent) *usageEventModel {
@@ -220,6 +226,11 @@ func toUsageEventModel(e *meter.UsageEvent) *usageEventModel {
 		Timestamp:      e.Timestamp,
 		IdempotencyKey: e.IdempotencyKey,
 		Metadata:       string(metadata),
+		Correction:     e.Correction,
+		Reason:         e.Reason,
+		VoidedAt:       e.VoidedAt,
+		VoidReason:     e.VoidReason,
+		BucketHour:     meter.BucketStart(meter.GranularityHour, e.Timestamp).Unix(),
 		CreatedAt:      time.Now().UTC(),
 	}
 }
@@ -244,6 +255,10 @@ func fromUsageEventModel(m *usageEventModel) (*meter.UsageEvent, error) {
 		Timestamp:      m.Timestamp,
 		IdempotencyKey: m.IdempotencyKey,
 		Metadata:       metadata,
+		Correction:     m.Correction,
+		Reason:         m.Reason,
+		VoidedAt:       m.VoidedAt,
+		VoidReason:     m.VoidReason,
 	}, nil
 }
 
@@ -297,6 +312,99 @@ func fromEntitlementCacheModel(m *entitlementCacheModel) *entitlement.Result {
 	}
 }
 
+// ==================== Reservation models ====================
+
+type reservationModel struct {
+	grove.BaseModel `grove:"table:ledger_reservations"`
+
+	ID          string    `grove:"id,pk"`
+	TenantID    string    `grove:"tenant_id"`
+	AppID       string    `grove:"app_id"`
+	FeatureKey  string    `grove:"feature_key"`
+	WindowStart time.Time `grove:"window_start"`
+	Quantity    int64     `grove:"quantity"`
+	ExpiresAt   time.Time `grove:"expires_at"`
+	CreatedAt   time.Time `grove:"created_at"`
+}
+
+func toReservationModel(r *entitlement.Reservation) *reservationModel {
+	return &reservationModel{
+		ID:          r.ID.String(),
+		TenantID:    r.TenantID,
+		AppID:       r.AppID,
+		FeatureKey:  r.FeatureKey,
+		WindowStart: r.WindowStart,
+		Quantity:    r.Quantity,
+		ExpiresAt:   r.ExpiresAt,
+		CreatedAt:   r.CreatedAt,
+	}
+}
+
+func fromReservationModel(m *reservationModel) (*entitlement.Reservation, error) {
+	rsvID, err := id.ParseReservationID(m.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &entitlement.Reservation{
+		ID:          rsvID,
+		TenantID:    m.TenantID,
+		AppID:       m.AppID,
+		FeatureKey:  m.FeatureKey,
+		WindowStart: m.WindowStart,
+		Quantity:    m.Quantity,
+		ExpiresAt:   m.ExpiresAt,
+		CreatedAt:   m.CreatedAt,
+	}, nil
+}
+
+// ==================== Override models ====================
+
+type overrideModel struct {
+	grove.BaseModel `grove:"table:ledger_entitlement_overrides"`
+
+	ID         string     `grove:"id,pk"`
+	TenantID   string     `grove:"tenant_id"`
+	AppID      string     `grove:"app_id"`
+	FeatureKey string     `grove:"feature_key"`
+	Mode       string     `grove:"mode"`
+	Value      int64      `grove:"value"`
+	ExpiresAt  *time.Time `grove:"expires_at"`
+	Reason     string     `grove:"reason"`
+	CreatedAt  time.Time  `grove:"created_at"`
+}
+
+func toOverrideModel(o *entitlement.Override) *overrideModel {
+	return &overrideModel{
+		ID:         o.ID.String(),
+		TenantID:   o.TenantID,
+		AppID:      o.AppID,
+		FeatureKey: o.FeatureKey,
+		Mode:       string(o.Mode),
+		Value:      o.Value,
+		ExpiresAt:  o.ExpiresAt,
+		Reason:     o.Reason,
+		CreatedAt:  o.CreatedAt,
+	}
+}
+
+func fromOverrideModel(m *overrideModel) (*entitlement.Override, error) {
+	ovrID, err := id.ParseOverrideID(m.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &entitlement.Override{
+		ID:         ovrID,
+		TenantID:   m.TenantID,
+		AppID:      m.AppID,
+		FeatureKey: m.FeatureKey,
+		Mode:       entitlement.OverrideMode(m.Mode),
+		Value:      m.Value,
+		ExpiresAt:  m.ExpiresAt,
+		Reason:     m.Reason,
+		CreatedAt:  m.CreatedAt,
+	}, nil
+}
+
 // ==================== Invoice models ====================
 
 type invoiceModel struct {
@@ -498,25 +606,32 @@ func fromCouponModel(m *couponModel) (*coupon.Coupon, error) {
 type featureModel struct {
 	grove.BaseModel `grove:"table:ledger_features"`
 
-	ID           string    `grove:"id,pk"`
-	Key          string    `grove:"key"`
-	Name         string    `grove:"name"`
-	Description  string    `grove:"description"`
-	Type         string    `grove:"type"`
-	DefaultLimit int64     `grove:"default_limit"`
-	Period       string    `grove:"period"`
-	SoftLimit    int       `grove:"soft_limit"`
-	Status       string    `grove:"status"`
-	AppID        string    `grove:"app_id"`
-	ProviderID   string    `grove:"provider_id"`
-	ProviderName string    `grove:"provider_name"`
-	Metadata     string    `grove:"metadata"` // JSON text
-	CreatedAt    time.Time `grove:"created_at"`
-	UpdatedAt    time.Time `grove:"updated_at"`
+	ID                  string    `grove:"id,pk"`
+	Key                 string    `grove:"key"`
+	Name                string    `grove:"name"`
+	Description         string    `grove:"description"`
+	Type                string    `grove:"type"`
+	DefaultLimit        int64     `grove:"default_limit"`
+	Period              string    `grove:"period"`
+	SoftLimit           int       `grove:"soft_limit"`
+	Status              string    `grove:"status"`
+	AppID               string    `grove:"app_id"`
+	ProviderID          string    `grove:"provider_id"`
+	ProviderName        string    `grove:"provider_name"`
+	Aggregation         string    `grove:"aggregation"`
+	AggregationProperty string    `grove:"aggregation_property"`
+	Dimensions          string    `grove:"dimensions"` // JSON text
+	Metadata            string    `grove:"metadata"`   // JSON text
+	CreatedAt           time.Time `grove:"created_at"`
+	UpdatedAt           time.Time `grove:"updated_at"`
 }
 
 func toFeatureModel(f *feature.Feature) *featureModel {
 	metadata, _ := json.Marshal(f.Metadata) //nolint:errcheck // best-effort
+	dimensions := []byte("[]")
+	if len(f.Dimensions) > 0 {
+		dimensions, _ = json.Marshal(f.Dimensions) //nolint:errcheck // best-effort
+	}
 
 	softLimit := 0
 	if f.SoftLimit {
@@ -524,21 +639,24 @@ func toFeatureModel(f *feature.Feature) *featureModel {
 	}
 
 	return &featureModel{
-		ID:           f.ID.String(),
-		Key:          f.Key,
-		Name:         f.Name,
-		Description:  f.Description,
-		Type:         string(f.Type),
-		DefaultLimit: f.DefaultLimit,
-		Period:       string(f.Period),
-		SoftLimit:    softLimit,
-		Status:       string(f.Status),
-		AppID:        f.AppID,
-		ProviderID:   f.ProviderID,
-		ProviderName: f.ProviderName,
-		Metadata:     string(metadata),
-		CreatedAt:    f.CreatedAt,
-		UpdatedAt:    f.UpdatedAt,
+		ID:                  f.ID.String(),
+		Key:                 f.Key,
+		Name:                f.Name,
+		Description:         f.Description,
+		Type:                string(f.Type),
+		DefaultLimit:        f.DefaultLimit,
+		Period:              string(f.Period),
+		SoftLimit:           softLimit,
+		Status:              string(f.Status),
+		AppID:               f.AppID,
+		ProviderID:          f.ProviderID,
+		ProviderName:        f.ProviderName,
+		Aggregation:         string(f.Aggregation),
+		AggregationProperty: f.AggregationProperty,
+		Dimensions:          string(dimensions),
+		Metadata:            string(metadata),
+		CreatedAt:           f.CreatedAt,
+		UpdatedAt:           f.UpdatedAt,
 	}
 }
 
@@ -552,24 +670,31 @@ func fromFeatureModel(m *featureModel) (*feature.Feature, error) {
 	if m.Metadata != "" {
 		_ = json.Unmarshal([]byte(m.Metadata), &metadata) //nolint:errcheck // best-effort
 	}
+	var dimensions []string
+	if m.Dimensions != "" {
+		_ = json.Unmarshal([]byte(m.Dimensions), &dimensions) //nolint:errcheck // best-effort
+	}
 
 	return &feature.Feature{
 		Entity: types.Entity{
 			CreatedAt: m.CreatedAt,
 			UpdatedAt: m.UpdatedAt,
 		},
-		ID:           featureID,
-		Key:          m.Key,
-		Name:         m.Name,
-		Description:  m.Description,
-		Type:         feature.FeatureType(m.Type),
-		DefaultLimit: m.DefaultLimit,
-		Period:       feature.Period(m.Period),
-		SoftLimit:    m.SoftLimit != 0,
-		Status:       feature.Status(m.Status),
-		AppID:        m.AppID,
-		ProviderID:   m.ProviderID,
-		ProviderName: m.ProviderName,
-		Metadata:     metadata,
+		ID:                  featureID,
+		Key:                 m.Key,
+		Name:                m.Name,
+		Description:         m.Description,
+		Type:                feature.FeatureType(m.Type),
+		DefaultLimit:        m.DefaultLimit,
+		Period:              feature.Period(m.Period),
+		SoftLimit:           m.SoftLimit != 0,
+		Status:              feature.Status(m.Status),
+		AppID:               m.AppID,
+		ProviderID:          m.ProviderID,
+		ProviderName:        m.ProviderName,
+		Aggregation:         meter.AggregationType(m.Aggregation),
+		AggregationProperty: m.AggregationProperty,
+		Dimensions:          dimensions,
+		Metadata:            metadata,
 	}, nil
 }
@@ -0,0 +1,167 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xraph/ledger/meter"
+)
+
+// Usage rollups
+//
+// ledger_usage_rollups_hourly and ledger_usage_rollups_daily hold the sum,
+// count and max of counted usage (no voided events or corrections) per
+// tenant, app, feature and UTC bucket, keyed by the bucket start in Unix
+// seconds. The ledger_usage_rollups triggers apply each inserted or voided
+// event as a delta in the statement that writes it, so duplicates rejected
+// by the insert never reach the rollups. Buckets are never rebuilt from raw
+// events: archival deletes them and the rollups keep the archived usage.
+// Sum, count and max aggregations read whole days and hours from the
+// rollups and only scan raw events for the sub-hour edges of the window,
+// for any number of features in one query.
+
+const (
+	rollupHourlyTable = "ledger_usage_rollups_hourly"
+	rollupDailyTable  = "ledger_usage_rollups_daily"
+)
+
+// HasUsageRollups reports that the store keeps usage rollups, so archived
+// usage still counts towards sum, count and max aggregations.
+func (s *Store) HasUsageRollups() bool { return true }
+
+// rollupTotals is the sum, count and max of one feature's usage.
+type rollupTotals struct {
+	FeatureKey string `grove:"feature_key"`
+	Sum        int64  `grove:"total_sum"`
+	Count      int64  `grove:"total_count"`
+	Max        int64  `grove:"total_max"`
+}
+
+// pick returns the total matching a rollable aggregation type.
+func (t rollupTotals) pick(kind meter.AggregationType) int64 {
+	switch kind {
+	case meter.AggregateCount:
+		return t.Count
+	case meter.AggregateMax:
+		return t.Max
+	}
+	return t.Sum
+}
+
+// aggregateRollups returns the sum, count and max of each feature's usage in
+// [start, end) with a single query that reads the rollup tables plus the raw
+// events at the window edges, grouped by feature. Features without usage
+// are absent from the result.
+func (s *Store) aggregateRollups(ctx context.Context, tenantID, appID string, featureKeys []string, start, end time.Time) (map[string]rollupTotals, error) {
+	keyCond := "tenant_id = ? AND app_id = ? AND feature_key IN (?" + strings.Repeat(", ?", len(featureKeys)-1) + ")"
+	keyArgs := make([]any, 0, len(featureKeys)+2)
+	keyArgs = append(keyArgs, tenantID, appID)
+	for _, key := range featureKeys {
+		keyArgs = append(keyArgs, key)
+	}
+
+	var (
+		parts []string
+		args  []any
+	)
+	for _, sp := range meter.SplitWindow(start, end) {
+		args = append(args, keyArgs...)
+		var part string
+		switch sp.Granularity {
+		case meter.GranularityRaw:
+			part = "SELECT feature_key, SUM(quantity) AS s, COUNT(*) AS c, MAX(quantity) AS m FROM ledger_usage_events WHERE " +
+				keyCond + " AND " + countedUsage
+			if !sp.Start.IsZero() {
+				part += " AND timestamp >= ?"
+				args = append(args, sp.Start)
+			}
+			if !sp.End.IsZero() {
+				part += " AND timestamp < ?"
+				args = append(args, sp.End)
+			}
+		default:
+			table := rollupHourlyTable
+			if sp.Granularity == meter.GranularityDay {
+				table = rollupDailyTable
+			}
+			part = "SELECT feature_key, SUM(quantity_sum) AS s, SUM(event_count) AS c, MAX(quantity_max) AS m FROM " + table +
+				" WHERE " + keyCond + " AND event_count > 0"
+			if !sp.Start.IsZero() {
+				part += " AND bucket_start >= ?"
+				args = append(args, sp.Start.Unix())
+			}
+			if !sp.End.IsZero() {
+				part += " AND bucket_start < ?"
+				args = append(args, sp.End.Unix())
+			}
+		}
+		parts = append(parts, part+" GROUP BY feature_key")
+	}
+
+	query := `SELECT feature_key,
+			COALESCE(SUM(s), 0) AS total_sum,
+			COALESCE(SUM(c), 0) AS total_count,
+			COALESCE(MAX(m), 0) AS total_max
+		FROM (` + strings.Join(parts, " UNION ALL ") + `)
+		GROUP BY feature_key`
+
+	var rows []rollupTotals
+	if err := s.sdb.NewRaw(query, args...).Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+	totals := make(map[string]rollupTotals, len(rows))
+	for _, row := range rows {
+		totals[row.FeatureKey] = row
+	}
+	return totals, nil
+}
+
+// purgeUsage subtracts the events before the cutoff from the hour and day
+// the cutoff falls inside, deletes them and drops the buckets that lie
+// entirely before the cutoff. The maxima of the partial buckets are left as
+// they were.
+func (s *Store) purgeUsage(ctx context.Context, before time.Time) (int64, error) {
+	hour := meter.BucketStart(meter.GranularityHour, before)
+	day := meter.BucketStart(meter.GranularityDay, before)
+
+	edge := func(table string, bucket time.Time) error {
+		query := `UPDATE ` + table + ` AS r
+			SET quantity_sum = r.quantity_sum - p.quantity_sum, event_count = r.event_count - p.event_count
+			FROM (
+				SELECT tenant_id, app_id, feature_key, SUM(quantity) AS quantity_sum, COUNT(*) AS event_count
+				FROM ledger_usage_events
+				WHERE timestamp >= ? AND timestamp < ? AND ` + countedUsage + `
+				GROUP BY tenant_id, app_id, feature_key
+			) AS p
+			WHERE r.tenant_id = p.tenant_id AND r.app_id = p.app_id AND r.feature_key = p.feature_key
+				AND r.bucket_start = ?`
+		_, err := s.sdb.NewRaw(query, bucket, before, bucket.Unix()).Exec(ctx)
+		return err
+	}
+	if err := edge(rollupHourlyTable, hour); err != nil {
+		return 0, fmt.Errorf("ledger/sqlite: purge rollups: %w", err)
+	}
+	if err := edge(rollupDailyTable, day); err != nil {
+		return 0, fmt.Errorf("ledger/sqlite: purge rollups: %w", err)
+	}
+
+	res, err := s.sdb.NewDelete((*usageEventModel)(nil)).
+		Where("timestamp < ?", before).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	for table, bucket := range map[string]time.Time{rollupHourlyTable: hour, rollupDailyTable: day} {
+		if _, err := s.sdb.NewRaw(`DELETE FROM `+table+` WHERE bucket_start < ?`, bucket.Unix()).Exec(ctx); err != nil {
+			return rows, fmt.Errorf("ledger/sqlite: purge rollups: %w", err)
+		}
+	}
+	return rows, nil
+}
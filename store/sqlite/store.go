@@ -3,8 +3,11 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/xraph/grove"
@@ -397,6 +400,9 @@ func (s *Store) ListSubscriptions(ctx context.Context, tenantID, appID string, o
 	if opts.Status != "" {
 		q = q.Where("status = ?", string(opts.Status))
 	}
+	if !opts.PlanID.IsNil() {
+		q = q.Where("plan_id = ?", opts.PlanID.String())
+	}
 	if opts.Limit > 0 {
 		q = q.Limit(opts.Limit)
 	}
@@ -456,46 +462,250 @@ func (s *Store) CancelSubscription(ctx context.Context, subID id.SubscriptionID,
 
 // ==================== Meter Store ====================
 
-func (s *Store) IngestBatch(ctx context.Context, events []*meter.UsageEvent) error {
-	if len(events) == 0 {
-		return nil
-	}
-	models := make([]usageEventModel, len(events))
-	for i, e := range events {
-		models[i] = *toUsageEventModel(e)
+func (s *Store) IngestBatch(ctx context.Context, events []*meter.UsageEvent) (*meter.IngestResult, error) {
+	return s.insertUsage(ctx, events, false)
+}
+
+// RestoreUsage inserts archived events without adding them to the rollups
+// or the lifetime counters, which still hold the archived usage.
+func (s *Store) RestoreUsage(ctx context.Context, events []*meter.UsageEvent) (*meter.IngestResult, error) {
+	return s.insertUsage(ctx, events, true)
+}
+
+// usageInsertChunk bounds the rows of one usage insert, keeping its bind
+// parameters under the SQLite limit.
+const usageInsertChunk = 1000
+
+// insertUsage inserts usage events and reports the IDs of the new ones.
+// Conflicts on the primary key (WAL replay) or on the
+// (app_id, tenant_id, idempotency_key) index are skipped. The
+// ledger_usage_counters and ledger_usage_rollups triggers add the inserted
+// events to the lifetime counters and the rollups unless they are marked
+// restored.
+func (s *Store) insertUsage(ctx context.Context, events []*meter.UsageEvent, restored bool) (*meter.IngestResult, error) {
+	const row = "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+
+	result := &meter.IngestResult{}
+	for start := 0; start < len(events); start += usageInsertChunk {
+		chunk := events[start:min(start+usageInsertChunk, len(events))]
+		byID := make(map[string]*meter.UsageEvent, len(chunk))
+		args := make([]any, 0, len(chunk)*15)
+		for _, e := range chunk {
+			m := toUsageEventModel(e)
+			byID[m.ID] = e
+			args = append(args, m.ID, m.TenantID, m.AppID, m.FeatureKey, m.Quantity, m.Timestamp,
+				m.IdempotencyKey, m.Metadata, m.Correction, m.Reason, m.VoidedAt, m.VoidReason,
+				restored, m.BucketHour, m.CreatedAt)
+		}
+
+		query := `INSERT INTO ledger_usage_events (id, tenant_id, app_id, feature_key, quantity, timestamp,
+				idempotency_key, metadata, correction, reason, voided_at, void_reason, restored, bucket_hour, created_at)
+			VALUES ` + row + strings.Repeat(", "+row, len(chunk)-1) + `
+			ON CONFLICT DO NOTHING
+			RETURNING id`
+		var rows []struct {
+			ID string `grove:"id"`
+		}
+		if err := s.sdb.NewRaw(query, args...).Scan(ctx, &rows); err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			if e, ok := byID[r.ID]; ok {
+				result.InsertedIDs = append(result.InsertedIDs, e.ID)
+			}
+		}
 	}
-	_, err := s.sdb.NewInsert(&models).
-		OnConflict("(idempotency_key) WHERE idempotency_key != '' DO NOTHING").
-		Exec(ctx)
-	return err
+	result.Inserted = len(result.InsertedIDs)
+	result.Duplicates = len(events) - result.Inserted
+	return result, nil
 }
 
-func (s *Store) Aggregate(ctx context.Context, tenantID, appID, featureKey string, period plan.Period) (int64, error) {
-	startOfPeriod := getStartOfPeriod(time.Now(), period)
+// countedUsage restricts a usage event query to the events that count
+// towards aggregations: neither voided nor corrections.
+const countedUsage = "voided_at IS NULL AND correction = 0"
+
+func (s *Store) Aggregate(ctx context.Context, tenantID, appID, featureKey string, agg meter.Aggregation, start, end time.Time) (int64, error) {
+	if agg.Rollable() {
+		totals, err := s.aggregateRollups(ctx, tenantID, appID, []string{featureKey}, start, end)
+		if err != nil {
+			return 0, err
+		}
+		return totals[featureKey].pick(agg.Kind()), nil
+	}
+
+	where := "tenant_id = ? AND app_id = ? AND feature_key = ? AND " + countedUsage
+	args := []any{tenantID, appID, featureKey}
+	if !start.IsZero() {
+		args = append(args, start)
+		where += " AND timestamp >= ?"
+	}
+	if !end.IsZero() {
+		args = append(args, end)
+		where += " AND timestamp < ?"
+	}
+
+	var query string
+	switch agg.Kind() {
+	case meter.AggregateUnique:
+		if agg.Property == "" {
+			return 0, fmt.Errorf("%w: unique aggregation needs a property", ledger.ErrUnsupportedAggregation)
+		}
+		// The JSON path placeholder precedes the WHERE placeholders.
+		args = append([]any{metadataPath(agg.Property)}, args...)
+		query = "SELECT COUNT(DISTINCT json_extract(metadata, ?)) FROM ledger_usage_events WHERE " + where
+	case meter.AggregateLast:
+		query = "SELECT COALESCE((SELECT quantity FROM ledger_usage_events WHERE " + where +
+			" ORDER BY timestamp DESC, id DESC LIMIT 1), 0)"
+	default:
+		return 0, fmt.Errorf("%w: %q", ledger.ErrUnsupportedAggregation, agg.Type)
+	}
 
 	var total int64
-	err := s.sdb.NewRaw(`
-		SELECT COALESCE(SUM(quantity), 0) FROM ledger_usage_events
-		WHERE tenant_id = ? AND app_id = ? AND feature_key = ? AND timestamp > ?
-	`, tenantID, appID, featureKey, startOfPeriod).Scan(ctx, &total)
-	if err != nil {
+	if err := s.sdb.NewRaw(query, args...).Scan(ctx, &total); err != nil {
 		return 0, err
 	}
 	return total, nil
 }
 
-func (s *Store) AggregateMulti(ctx context.Context, tenantID, appID string, featureKeys []string, period plan.Period) (map[string]int64, error) {
-	result := make(map[string]int64)
-	for _, key := range featureKeys {
-		total, err := s.Aggregate(ctx, tenantID, appID, key, period)
+// AggregateMulti answers every sum, count and max aggregation with a single
+// query grouped by feature; unique and last aggregations are queried per
+// feature.
+func (s *Store) AggregateMulti(ctx context.Context, tenantID, appID string, features map[string]meter.Aggregation, start, end time.Time) (map[string]int64, error) {
+	result := make(map[string]int64, len(features))
+	var rollable []string
+	for key, agg := range features {
+		if agg.Rollable() {
+			rollable = append(rollable, key)
+			continue
+		}
+		total, err := s.Aggregate(ctx, tenantID, appID, key, agg, start, end)
 		if err != nil {
 			return nil, err
 		}
 		result[key] = total
 	}
+	if len(rollable) == 0 {
+		return result, nil
+	}
+
+	totals, err := s.aggregateRollups(ctx, tenantID, appID, rollable, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range rollable {
+		result[key] = totals[key].pick(features[key].Kind())
+	}
 	return result, nil
 }
 
+// AggregateGroups groups the raw usage events by the requested metadata
+// values; the rollups carry no dimensions.
+func (s *Store) AggregateGroups(ctx context.Context, tenantID, appID string, agg meter.Aggregation, opts meter.QueryOpts) ([]*meter.GroupTotal, error) {
+	// The select placeholders precede the WHERE placeholders.
+	var args []any
+	groups := make([]string, len(opts.GroupBy))
+	for i, key := range opts.GroupBy {
+		args = append(args, metadataPath(key))
+		groups[i] = "COALESCE(json_extract(metadata, ?), '')"
+	}
+	prop := "NULL"
+	if agg.Kind() == meter.AggregateUnique {
+		if agg.Property == "" {
+			return nil, fmt.Errorf("%w: unique aggregation needs a property", ledger.ErrUnsupportedAggregation)
+		}
+		args = append(args, metadataPath(agg.Property))
+		prop = "json_extract(metadata, ?)"
+	}
+
+	where := "tenant_id = ? AND app_id = ? AND feature_key = ? AND " + countedUsage
+	args = append(args, tenantID, appID, opts.FeatureKey)
+	if !opts.Start.IsZero() {
+		args = append(args, opts.Start)
+		where += " AND timestamp >= ?"
+	}
+	if !opts.End.IsZero() {
+		args = append(args, opts.End)
+		where += " AND timestamp < ?"
+	}
+	cond, condArgs := dimensionsCond(opts.Dimensions)
+	where += cond
+	args = append(args, condArgs...)
+
+	events := "SELECT json_array(" + strings.Join(groups, ", ") + ") AS dims, quantity, timestamp, id, " + prop + " AS prop" +
+		" FROM ledger_usage_events WHERE " + where
+
+	var value string
+	switch agg.Kind() {
+	case meter.AggregateSum:
+		value = "COALESCE(SUM(quantity), 0)"
+	case meter.AggregateCount:
+		value = "COUNT(*)"
+	case meter.AggregateMax:
+		value = "COALESCE(MAX(quantity), 0)"
+	case meter.AggregateUnique:
+		value = "COUNT(DISTINCT prop)"
+	case meter.AggregateLast:
+		events = "SELECT dims, quantity, ROW_NUMBER() OVER (PARTITION BY dims ORDER BY timestamp DESC, id DESC) AS rn FROM (" + events + ")"
+		value = "COALESCE(SUM(CASE WHEN rn = 1 THEN quantity END), 0)"
+	default:
+		return nil, fmt.Errorf("%w: %q", ledger.ErrUnsupportedAggregation, agg.Type)
+	}
+
+	query := "SELECT dims, " + value + " AS value FROM (" + events + ") GROUP BY dims ORDER BY dims"
+
+	var rows []usageGroup
+	if err := s.sdb.NewRaw(query, args...).Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+	result := make([]*meter.GroupTotal, len(rows))
+	for i, row := range rows {
+		var values []string
+		if err := json.Unmarshal([]byte(row.Dims), &values); err != nil {
+			return nil, fmt.Errorf("ledger/sqlite: decode usage group: %w", err)
+		}
+		dims := make(map[string]string, len(opts.GroupBy))
+		for j, key := range opts.GroupBy {
+			if j < len(values) {
+				dims[key] = values[j]
+			}
+		}
+		result[i] = &meter.GroupTotal{Dimensions: dims, Value: row.Value}
+	}
+	return result, nil
+}
+
+// usageGroup is one row of AggregateGroups: the grouped metadata values as a
+// JSON array and the aggregated value.
+type usageGroup struct {
+	Dims  string `grove:"dims"`
+	Value int64  `grove:"value"`
+}
+
+// metadataPath returns the JSON path of a metadata key for json_extract.
+func metadataPath(key string) string {
+	return fmt.Sprintf(`$."%s"`, key)
+}
+
+// dimensionsCond returns the condition restricting usage events to the given
+// metadata values, in key order, and its arguments.
+func dimensionsCond(dims map[string]string) (string, []any) {
+	keys := make([]string, 0, len(dims))
+	for k := range dims {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var (
+		cond string
+		args []any
+	)
+	for _, k := range keys {
+		cond += " AND json_extract(metadata, ?) = ?"
+		args = append(args, metadataPath(k), dims[k])
+	}
+	return cond, args
+}
+
 func (s *Store) QueryUsage(ctx context.Context, tenantID, appID string, opts meter.QueryOpts) ([]*meter.UsageEvent, error) {
 	var models []usageEventModel
 	q := s.sdb.NewSelect(&models)
@@ -514,7 +724,10 @@ func (s *Store) QueryUsage(ctx context.Context, tenantID, appID string, opts met
 		q = q.Where("timestamp >= ?", opts.Start)
 	}
 	if !opts.End.IsZero() {
-		q = q.Where("timestamp <= ?", opts.End)
+		q = q.Where("timestamp < ?", opts.End)
+	}
+	if cond, args := dimensionsCond(opts.Dimensions); cond != "" {
+		q = q.Where(strings.TrimPrefix(cond, " AND "), args...)
 	}
 	if opts.Limit > 0 {
 		q = q.Limit(opts.Limit)
@@ -540,17 +753,217 @@ func (s *Store) QueryUsage(ctx context.Context, tenantID, appID string, opts met
 }
 
 func (s *Store) PurgeUsage(ctx context.Context, before time.Time) (int64, error) {
-	res, err := s.sdb.NewDelete((*usageEventModel)(nil)).
+	return s.purgeUsage(ctx, before)
+}
+
+// ScanUsage returns up to limit events of an app with timestamps before
+// before, oldest first.
+func (s *Store) ScanUsage(ctx context.Context, appID string, before time.Time, limit int) ([]*meter.UsageEvent, error) {
+	var models []usageEventModel
+	q := s.sdb.NewSelect(&models).
+		Where("app_id = ?", appID).
 		Where("timestamp < ?", before).
+		OrderExpr("timestamp ASC, id ASC")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	if err := q.Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	result := make([]*meter.UsageEvent, len(models))
+	for i := range models {
+		evt, err := fromUsageEventModel(&models[i])
+		if err != nil {
+			return nil, err
+		}
+		result[i] = evt
+	}
+	return result, nil
+}
+
+// DeleteUsage removes events by ID and leaves the rollups untouched.
+func (s *Store) DeleteUsage(ctx context.Context, eventIDs []id.UsageEventID) (int64, error) {
+	if len(eventIDs) == 0 {
+		return 0, nil
+	}
+	args := make([]any, len(eventIDs))
+	for i, evtID := range eventIDs {
+		args[i] = evtID.String()
+	}
+	res, err := s.sdb.NewDelete((*usageEventModel)(nil)).
+		Where("id IN (?"+strings.Repeat(", ?", len(args)-1)+")", args...).
 		Exec(ctx)
 	if err != nil {
 		return 0, err
 	}
+	return res.RowsAffected()
+}
+
+func (s *Store) VoidUsage(ctx context.Context, tenantID, appID string, opts meter.VoidOpts) (*meter.UsageEvent, error) {
+	m := new(usageEventModel)
+	q := s.sdb.NewSelect(m).
+		Where("tenant_id = ?", tenantID).
+		Where("app_id = ?", appID)
+	if !opts.EventID.IsNil() {
+		q = q.Where("id = ?", opts.EventID.String())
+	} else {
+		q = q.Where("idempotency_key = ?", opts.IdempotencyKey)
+	}
+	if err := q.Scan(ctx); err != nil {
+		if isNoRows(err) {
+			return nil, ledger.ErrUsageEventNotFound
+		}
+		return nil, err
+	}
+	if m.VoidedAt != nil {
+		return nil, ledger.ErrUsageEventVoided
+	}
+
+	res, err := s.sdb.NewUpdate((*usageEventModel)(nil)).
+		Set("voided_at = ?", opts.VoidedAt).
+		Set("void_reason = ?", opts.Reason).
+		Where("id = ?", m.ID).
+		Where("voided_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
 	rows, err := res.RowsAffected()
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, ledger.ErrUsageEventVoided
+	}
+
+	evt, err := fromUsageEventModel(m)
+	if err != nil {
+		return nil, err
+	}
+	evt.VoidedAt = &opts.VoidedAt
+	evt.VoidReason = opts.Reason
+	return evt, nil
+}
+
+func (s *Store) SumCorrections(ctx context.Context, tenantID, appID string, featureKeys []string, start, end time.Time) (map[string]int64, error) {
+	result := make(map[string]int64, len(featureKeys))
+	if len(featureKeys) == 0 {
+		return result, nil
+	}
+
+	where := "tenant_id = ? AND app_id = ? AND feature_key IN (?" + strings.Repeat(", ?", len(featureKeys)-1) + ")" +
+		" AND correction = 1 AND voided_at IS NULL"
+	args := make([]any, 0, len(featureKeys)+4)
+	args = append(args, tenantID, appID)
+	for _, key := range featureKeys {
+		args = append(args, key)
+	}
+	if !start.IsZero() {
+		args = append(args, start)
+		where += " AND timestamp >= ?"
+	}
+	if !end.IsZero() {
+		args = append(args, end)
+		where += " AND timestamp < ?"
+	}
+
+	var rows []struct {
+		FeatureKey string `grove:"feature_key"`
+		Total      int64  `grove:"total"`
+	}
+	query := "SELECT feature_key, COALESCE(SUM(quantity), 0) AS total FROM ledger_usage_events WHERE " +
+		where + " GROUP BY feature_key"
+	if err := s.sdb.NewRaw(query, args...).Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[row.FeatureKey] = row.Total
+	}
+	return result, nil
+}
+
+// LifetimeUsage reads the lifetime counters, which the
+// ledger_usage_counters triggers keep in step with inserts and voids.
+func (s *Store) LifetimeUsage(ctx context.Context, tenantID, appID string, featureKeys []string) (map[string]meter.LifetimeTotal, error) {
+	result := make(map[string]meter.LifetimeTotal, len(featureKeys))
+	if len(featureKeys) == 0 {
+		return result, nil
+	}
+
+	args := make([]any, 0, len(featureKeys)+2)
+	args = append(args, tenantID, appID)
+	for _, key := range featureKeys {
+		args = append(args, key)
+	}
+
+	var rows []struct {
+		FeatureKey string `grove:"feature_key"`
+		Quantity   int64  `grove:"quantity"`
+		Events     int64  `grove:"events"`
+	}
+	query := "SELECT feature_key, quantity, events FROM ledger_usage_counters" +
+		" WHERE tenant_id = ? AND app_id = ? AND feature_key IN (?" + strings.Repeat(", ?", len(featureKeys)-1) + ")"
+	if err := s.sdb.NewRaw(query, args...).Scan(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("ledger/sqlite: lifetime usage: %w", err)
+	}
+	for _, row := range rows {
+		result[row.FeatureKey] = meter.LifetimeTotal{Quantity: row.Quantity, Events: row.Events}
+	}
+	return result, nil
+}
+
+// ==================== Seat Gauge Store ====================
+
+// Seat readings are kept apart from usage events, so purging usage never
+// loses a tenant's current seat count. seq orders readings that share a
+// timestamp.
+
+func (s *Store) RecordSeats(ctx context.Context, r *meter.SeatReading) error {
+	_, err := s.sdb.NewRaw(`INSERT INTO ledger_seat_readings (tenant_id, app_id, feature_key, seats, timestamp)
+		VALUES (?, ?, ?, ?, ?)`, r.TenantID, r.AppID, r.FeatureKey, r.Seats, r.Timestamp).Exec(ctx)
+	return err
+}
+
+func (s *Store) CurrentSeats(ctx context.Context, tenantID, appID, featureKey string, at time.Time) (int64, error) {
+	var seats int64
+	err := s.sdb.NewRaw(`SELECT COALESCE((SELECT seats FROM ledger_seat_readings
+		WHERE tenant_id = ? AND app_id = ? AND feature_key = ? AND timestamp <= ?
+		ORDER BY timestamp DESC, seq DESC LIMIT 1), 0)`, tenantID, appID, featureKey, at).Scan(ctx, &seats)
+	return seats, err
+}
+
+func (s *Store) SeatReadings(ctx context.Context, tenantID, appID, featureKey string, start, end time.Time) ([]*meter.SeatReading, error) {
+	where := "tenant_id = ? AND app_id = ? AND feature_key = ?"
+	args := []any{tenantID, appID, featureKey}
+	if !start.IsZero() {
+		args = append(args, start)
+		where += " AND timestamp >= ?"
+	}
+	if !end.IsZero() {
+		args = append(args, end)
+		where += " AND timestamp < ?"
+	}
+
+	var rows []struct {
+		Seats     int64     `grove:"seats"`
+		Timestamp time.Time `grove:"timestamp"`
+	}
+	query := "SELECT seats, timestamp FROM ledger_seat_readings WHERE " + where + " ORDER BY timestamp, seq"
+	if err := s.sdb.NewRaw(query, args...).Scan(ctx, &rows); err != nil {
+		return nil, err
 	}
-	return rows, nil
+	result := make([]*meter.SeatReading, len(rows))
+	for i, row := range rows {
+		result[i] = &meter.SeatReading{
+			TenantID:   tenantID,
+			AppID:      appID,
+			FeatureKey: featureKey,
+			Seats:      row.Seats,
+			Timestamp:  row.Timestamp,
+		}
+	}
+	return result, nil
 }
 
 // ==================== Entitlement Cache Store ====================
@@ -605,6 +1018,195 @@ func (s *Store) InvalidateFeature(ctx context.Context, tenantID, appID, featureK
 	return err
 }
 
+// ==================== Quota Store ====================
+
+// Hard-limit counters are checked and incremented by a single conditional
+// UPDATE, so concurrent consumers cannot overshoot the limit.
+
+func (s *Store) SeedQuota(ctx context.Context, key entitlement.QuotaKey, used int64, expiresAt time.Time) error {
+	var expires any
+	if !expiresAt.IsZero() {
+		expires = expiresAt
+	}
+	_, err := s.sdb.NewRaw(`INSERT OR IGNORE INTO ledger_quota_counters (tenant_id, app_id, feature_key, window_start, used, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		key.TenantID, key.AppID, key.FeatureKey, key.WindowStart, used, expires).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("ledger/sqlite: seed quota: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) AddQuota(ctx context.Context, key entitlement.QuotaKey, qty, limit int64) (int64, bool, error) {
+	var rows []struct {
+		Used int64 `grove:"used"`
+	}
+	err := s.sdb.NewRaw(`UPDATE ledger_quota_counters SET used = used + ?
+		WHERE tenant_id = ? AND app_id = ? AND feature_key = ? AND window_start = ?
+		AND (? < 0 OR used + ? <= ?)
+		RETURNING used`, qty, key.TenantID, key.AppID, key.FeatureKey, key.WindowStart, limit, qty, limit).Scan(ctx, &rows)
+	if err != nil {
+		return 0, false, fmt.Errorf("ledger/sqlite: add quota: %w", err)
+	}
+	if len(rows) > 0 {
+		return rows[0].Used, true, nil
+	}
+
+	err = s.sdb.NewRaw(`SELECT used FROM ledger_quota_counters
+		WHERE tenant_id = ? AND app_id = ? AND feature_key = ? AND window_start = ?`,
+		key.TenantID, key.AppID, key.FeatureKey, key.WindowStart).Scan(ctx, &rows)
+	if err != nil {
+		return 0, false, fmt.Errorf("ledger/sqlite: get quota: %w", err)
+	}
+	if len(rows) == 0 {
+		return 0, false, ledger.ErrQuotaCounterNotFound
+	}
+	return rows[0].Used, false, nil
+}
+
+func (s *Store) PurgeQuotas(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.sdb.NewRaw(`DELETE FROM ledger_quota_counters WHERE expires_at < ?`, before).Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("ledger/sqlite: purge quotas: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+func (s *Store) CreateReservation(ctx context.Context, r *entitlement.Reservation) error {
+	_, err := s.sdb.NewInsert(toReservationModel(r)).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("ledger/sqlite: create reservation: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) TakeReservation(ctx context.Context, tenantID, appID string, reservationID id.ReservationID) (*entitlement.Reservation, error) {
+	var models []reservationModel
+	err := s.sdb.NewRaw(`DELETE FROM ledger_reservations WHERE id = ? AND tenant_id = ? AND app_id = ?
+		RETURNING id, tenant_id, app_id, feature_key, window_start, quantity, expires_at, created_at`,
+		reservationID.String(), tenantID, appID).Scan(ctx, &models)
+	if err != nil {
+		return nil, fmt.Errorf("ledger/sqlite: take reservation: %w", err)
+	}
+	if len(models) == 0 {
+		return nil, ledger.ErrReservationNotFound
+	}
+	return fromReservationModel(&models[0])
+}
+
+func (s *Store) ExpiredReservations(ctx context.Context, before time.Time, limit int) ([]*entitlement.Reservation, error) {
+	var models []reservationModel
+	q := s.sdb.NewSelect(&models).
+		Where("expires_at < ?", before).
+		OrderExpr("expires_at ASC")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	if err := q.Scan(ctx); err != nil {
+		return nil, fmt.Errorf("ledger/sqlite: expired reservations: %w", err)
+	}
+
+	result := make([]*entitlement.Reservation, len(models))
+	for i := range models {
+		r, err := fromReservationModel(&models[i])
+		if err != nil {
+			return nil, err
+		}
+		result[i] = r
+	}
+	return result, nil
+}
+
+// ==================== Override Store ====================
+
+func (s *Store) CreateOverride(ctx context.Context, o *entitlement.Override) error {
+	if _, err := s.sdb.NewInsert(toOverrideModel(o)).Exec(ctx); err != nil {
+		return fmt.Errorf("ledger/sqlite: create override: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) GetOverride(ctx context.Context, overrideID id.OverrideID) (*entitlement.Override, error) {
+	m := new(overrideModel)
+	err := s.sdb.NewSelect(m).
+		Where("id = ?", overrideID.String()).
+		Scan(ctx)
+	if err != nil {
+		if isNoRows(err) {
+			return nil, ledger.ErrOverrideNotFound
+		}
+		return nil, fmt.Errorf("ledger/sqlite: get override: %w", err)
+	}
+	return fromOverrideModel(m)
+}
+
+func (s *Store) ListOverrides(ctx context.Context, tenantID, appID string) ([]*entitlement.Override, error) {
+	var models []overrideModel
+	err := s.sdb.NewSelect(&models).
+		Where("tenant_id = ?", tenantID).
+		Where("app_id = ?", appID).
+		OrderExpr("created_at DESC, id DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ledger/sqlite: list overrides: %w", err)
+	}
+
+	result := make([]*entitlement.Override, len(models))
+	for i := range models {
+		o, err := fromOverrideModel(&models[i])
+		if err != nil {
+			return nil, err
+		}
+		result[i] = o
+	}
+	return result, nil
+}
+
+func (s *Store) DeleteOverride(ctx context.Context, overrideID id.OverrideID) error {
+	res, err := s.sdb.NewDelete((*overrideModel)(nil)).
+		Where("id = ?", overrideID.String()).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("ledger/sqlite: delete override: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ledger.ErrOverrideNotFound
+	}
+	return nil
+}
+
+// ==================== Usage Alert Store ====================
+
+func (s *Store) RecordUsageAlert(ctx context.Context, key entitlement.QuotaKey, threshold int, expiresAt time.Time) (bool, error) {
+	var expires any
+	if !expiresAt.IsZero() {
+		expires = expiresAt
+	}
+	res, err := s.sdb.NewRaw(`INSERT OR IGNORE INTO ledger_usage_alerts (tenant_id, app_id, feature_key, window_start, threshold, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		key.TenantID, key.AppID, key.FeatureKey, key.WindowStart, threshold, expires).Exec(ctx)
+	if err != nil {
+		return false, fmt.Errorf("ledger/sqlite: record usage alert: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("ledger/sqlite: record usage alert: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (s *Store) PurgeUsageAlerts(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.sdb.NewRaw(`DELETE FROM ledger_usage_alerts WHERE expires_at < ?`, before).Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("ledger/sqlite: purge usage alerts: %w", err)
+	}
+	return res.RowsAffected()
+}
+
 // ==================== Invoice Store ====================
 
 func (s *Store) CreateInvoice(ctx context.Context, inv *invoice.Invoice) error {
@@ -867,18 +1469,6 @@ func now() time.Time {
 	return time.Now().UTC()
 }
 
-// getStartOfPeriod returns the start of the given period.
-func getStartOfPeriod(t time.Time, period plan.Period) time.Time {
-	switch period {
-	case plan.PeriodMonthly:
-		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
-	case plan.PeriodYearly:
-		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location())
-	default:
-		return time.Time{}
-	}
-}
-
 // isNoRows checks for the standard sql.ErrNoRows sentinel.
 func isNoRows(err error) bool {
 	return errors.Is(err, sql.ErrNoRows)
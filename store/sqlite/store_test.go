@@ -0,0 +1,160 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xraph/grove"
+	"github.com/xraph/grove/drivers/sqlitedriver"
+
+	"github.com/xraph/ledger/id"
+	"github.com/xraph/ledger/meter"
+	"github.com/xraph/ledger/store/storetest"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	db, err := grove.Open(sqlitedriver.Open(filepath.Join(t.TempDir(), "ledger.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(db)
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func usageEvent(qty int64, ts time.Time) *meter.UsageEvent {
+	return &meter.UsageEvent{
+		ID: id.NewUsageEventID(), TenantID: "t", AppID: "a", FeatureKey: "api", Quantity: qty, Timestamp: ts,
+	}
+}
+
+func TestQueryUsageWindow(t *testing.T) {
+	storetest.QueryUsageWindow(t, newTestStore(t))
+}
+
+func TestListSubscriptionsByPlan(t *testing.T) {
+	storetest.ListSubscriptionsByPlan(t, newTestStore(t))
+}
+
+func TestBackdatedUsageIntoArchivedHour(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+	hour := meter.BucketStart(meter.GranularityHour, time.Now().UTC().Add(-48*time.Hour))
+	start, end := hour, hour.Add(2*time.Hour)
+
+	events := []*meter.UsageEvent{
+		usageEvent(1, hour.Add(time.Minute)),
+		usageEvent(2, hour.Add(20*time.Minute)),
+		usageEvent(3, hour.Add(40*time.Minute)),
+		usageEvent(4, hour.Add(90*time.Minute)),
+	}
+	if _, err := s.IngestBatch(ctx, events); err != nil {
+		t.Fatal(err)
+	}
+
+	aggregate := func() (sum, count, peak int64) {
+		t.Helper()
+		totals := make([]int64, 3)
+		for i, kind := range []meter.AggregationType{meter.AggregateSum, meter.AggregateCount, meter.AggregateMax} {
+			total, err := s.Aggregate(ctx, "t", "a", "api", meter.Aggregation{Type: kind}, start, end)
+			if err != nil {
+				t.Fatal(err)
+			}
+			totals[i] = total
+		}
+		return totals[0], totals[1], totals[2]
+	}
+	sum, count, peak := aggregate()
+	if sum != 10 || count != 4 || peak != 4 {
+		t.Fatalf("before archiving: sum %d, count %d, max %d, want 10, 4, 4", sum, count, peak)
+	}
+
+	// Archive the first hour: its raw events go, its rollups stay.
+	archived, err := s.ScanUsage(ctx, "a", hour.Add(time.Hour), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]id.UsageEventID, len(archived))
+	for i, e := range archived {
+		ids[i] = e.ID
+	}
+	if deleted, err := s.DeleteUsage(ctx, ids); err != nil || deleted != 3 {
+		t.Fatalf("DeleteUsage = %d, %v, want the 3 events of the hour", deleted, err)
+	}
+	if sum, count, peak = aggregate(); sum != 10 || count != 4 || peak != 4 {
+		t.Fatalf("after archiving: sum %d, count %d, max %d, want 10, 4, 4", sum, count, peak)
+	}
+
+	// A late event for the archived hour adds to its rollups only itself.
+	if _, err := s.IngestBatch(ctx, []*meter.UsageEvent{usageEvent(5, hour.Add(30*time.Minute))}); err != nil {
+		t.Fatal(err)
+	}
+	if sum, count, peak = aggregate(); sum != 15 || count != 5 || peak != 5 {
+		t.Fatalf("after the backdated event: sum %d, count %d, max %d, want 15, 5, 5", sum, count, peak)
+	}
+}
+
+func TestIngestBatchSkipsDuplicates(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+	now := time.Now().UTC()
+	keyed := func(tenantID, appID, key string) *meter.UsageEvent {
+		e := usageEvent(1, now)
+		e.TenantID, e.AppID, e.IdempotencyKey = tenantID, appID, key
+		return e
+	}
+
+	first := keyed("t", "a", "k1")
+	res, err := s.IngestBatch(ctx, []*meter.UsageEvent{first})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Inserted != 1 || res.Duplicates != 0 {
+		t.Fatalf("first batch = %+v, want one insert", res)
+	}
+
+	// Idempotency keys are unique per app and tenant only; events without
+	// a key are never deduplicated.
+	retried := keyed("t", "a", "k1")
+	otherTenant := keyed("t2", "a", "k1")
+	otherApp := keyed("t", "a2", "k1")
+	inBatch := keyed("t", "a", "k2")
+	unkeyed := usageEvent(1, now)
+	batch := []*meter.UsageEvent{
+		retried, otherTenant, otherApp, inBatch, keyed("t", "a", "k2"),
+		unkeyed, usageEvent(1, now), first,
+	}
+	res, err = s.IngestBatch(ctx, batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Inserted != 5 || res.Duplicates != 3 {
+		t.Fatalf("second batch = %+v, want 5 inserted and 3 duplicates", res)
+	}
+	inserted := make(map[id.UsageEventID]bool, len(res.InsertedIDs))
+	for _, eid := range res.InsertedIDs {
+		inserted[eid] = true
+	}
+	for _, e := range []*meter.UsageEvent{otherTenant, otherApp, inBatch, unkeyed} {
+		if !inserted[e.ID] {
+			t.Errorf("event of %s/%s keyed %q was not reported inserted", e.AppID, e.TenantID, e.IdempotencyKey)
+		}
+	}
+	if inserted[retried.ID] || inserted[first.ID] {
+		t.Error("a duplicate was reported inserted")
+	}
+
+	total, err := s.Aggregate(ctx, "t", "a", "api", meter.Aggregation{}, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 4 {
+		t.Errorf("usage of t in a = %d, want 4 counted once each", total)
+	}
+}
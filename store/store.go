@@ -46,11 +46,36 @@ type Store interface {
 	CancelSubscription(ctx context.Context, subID id.SubscriptionID, cancelAt time.Time) error
 
 	// Meter methods
-	IngestBatch(ctx context.Context, events []*meter.UsageEvent) error
-	Aggregate(ctx context.Context, tenantID, appID, featureKey string, period plan.Period) (int64, error)
-	AggregateMulti(ctx context.Context, tenantID, appID string, featureKeys []string, period plan.Period) (map[string]int64, error)
+	IngestBatch(ctx context.Context, events []*meter.UsageEvent) (*meter.IngestResult, error)
+	// Aggregate reduces usage in [start, end) with a built-in aggregation;
+	// zero times leave the range open.
+	Aggregate(ctx context.Context, tenantID, appID, featureKey string, agg meter.Aggregation, start, end time.Time) (int64, error)
+	AggregateMulti(ctx context.Context, tenantID, appID string, features map[string]meter.Aggregation, start, end time.Time) (map[string]int64, error)
+	// AggregateGroups reduces one feature's usage per combination of the
+	// opts.GroupBy metadata values.
+	AggregateGroups(ctx context.Context, tenantID, appID string, agg meter.Aggregation, opts meter.QueryOpts) ([]*meter.GroupTotal, error)
 	QueryUsage(ctx context.Context, tenantID, appID string, opts meter.QueryOpts) ([]*meter.UsageEvent, error)
 	PurgeUsage(ctx context.Context, before time.Time) (int64, error)
+	// VoidUsage excludes one event from every aggregation; voided events and
+	// corrections are still returned by QueryUsage.
+	VoidUsage(ctx context.Context, tenantID, appID string, opts meter.VoidOpts) (*meter.UsageEvent, error)
+	SumCorrections(ctx context.Context, tenantID, appID string, featureKeys []string, start, end time.Time) (map[string]int64, error)
+	// LifetimeUsage returns running usage totals kept at ingestion, which
+	// purges and archiving leave untouched.
+	LifetimeUsage(ctx context.Context, tenantID, appID string, featureKeys []string) (map[string]meter.LifetimeTotal, error)
+	// ScanUsage returns up to limit events of an app older than before,
+	// oldest first, for archiving. DeleteUsage removes archived events and
+	// RestoreUsage inserts them back; neither touches the rollups or the
+	// lifetime totals.
+	ScanUsage(ctx context.Context, appID string, before time.Time, limit int) ([]*meter.UsageEvent, error)
+	DeleteUsage(ctx context.Context, eventIDs []id.UsageEventID) (int64, error)
+	RestoreUsage(ctx context.Context, events []*meter.UsageEvent) (*meter.IngestResult, error)
+
+	// Seat gauge methods
+	RecordSeats(ctx context.Context, r *meter.SeatReading) error
+	// CurrentSeats returns the latest seat reading at or before at, or 0.
+	CurrentSeats(ctx context.Context, tenantID, appID, featureKey string, at time.Time) (int64, error)
+	SeatReadings(ctx context.Context, tenantID, appID, featureKey string, start, end time.Time) ([]*meter.SeatReading, error)
 
 	// Entitlement methods
 	GetCached(ctx context.Context, tenantID, appID, featureKey string) (*entitlement.Result, error)
@@ -58,6 +83,27 @@ type Store interface {
 	Invalidate(ctx context.Context, tenantID, appID string) error
 	InvalidateFeature(ctx context.Context, tenantID, appID, featureKey string) error
 
+	// Quota methods back hard limits: counters per usage window that are
+	// checked and incremented in one atomic step, and the reservations
+	// holding quota in them.
+	SeedQuota(ctx context.Context, key entitlement.QuotaKey, used int64, expiresAt time.Time) error
+	AddQuota(ctx context.Context, key entitlement.QuotaKey, qty, limit int64) (int64, bool, error)
+	PurgeQuotas(ctx context.Context, before time.Time) (int64, error)
+	CreateReservation(ctx context.Context, r *entitlement.Reservation) error
+	TakeReservation(ctx context.Context, tenantID, appID string, reservationID id.ReservationID) (*entitlement.Reservation, error)
+	ExpiredReservations(ctx context.Context, before time.Time, limit int) ([]*entitlement.Reservation, error)
+
+	// Override methods hold per-tenant entitlement overrides.
+	CreateOverride(ctx context.Context, o *entitlement.Override) error
+	GetOverride(ctx context.Context, overrideID id.OverrideID) (*entitlement.Override, error)
+	ListOverrides(ctx context.Context, tenantID, appID string) ([]*entitlement.Override, error)
+	DeleteOverride(ctx context.Context, overrideID id.OverrideID) error
+
+	// Usage alert methods remember the threshold alerts sent per usage
+	// window, so each is sent once.
+	RecordUsageAlert(ctx context.Context, key entitlement.QuotaKey, threshold int, expiresAt time.Time) (bool, error)
+	PurgeUsageAlerts(ctx context.Context, before time.Time) (int64, error)
+
 	// Invoice methods
 	CreateInvoice(ctx context.Context, inv *invoice.Invoice) error
 	GetInvoice(ctx context.Context, invID id.InvoiceID) (*invoice.Invoice, error)
@@ -81,3 +127,10 @@ type Store interface {
 	Ping(ctx context.Context) error
 	Close() error
 }
+
+// UsageRollups is implemented by stores that keep hourly and daily usage
+// rollups. The rollups still hold the usage of events removed by
+// DeleteUsage, so only these stores accept retention policies.
+type UsageRollups interface {
+	HasUsageRollups() bool
+}
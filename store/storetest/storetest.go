@@ -0,0 +1,99 @@
+// Package storetest holds conformance checks that every store.Store
+// implementation must pass. Each store's tests call them with a fresh
+// store; the checks use a tenant of their own so stores can be shared.
+package storetest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/xraph/ledger/id"
+	"github.com/xraph/ledger/meter"
+	"github.com/xraph/ledger/store"
+	"github.com/xraph/ledger/subscription"
+)
+
+// QueryUsageWindow checks that QueryUsage returns the events with
+// timestamps in [Start, End), the same window Aggregate reduces, so an
+// event exactly at End belongs to the next period only.
+func QueryUsageWindow(t *testing.T, s store.Store) {
+	t.Helper()
+	ctx := context.Background()
+	tenantID := "t-" + id.NewUsageEventID().String()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+
+	var events []*meter.UsageEvent
+	for _, ts := range []time.Time{start.Add(-time.Second), start, end.Add(-time.Second), end} {
+		events = append(events, &meter.UsageEvent{
+			ID: id.NewUsageEventID(), TenantID: tenantID, AppID: "a", FeatureKey: "api", Quantity: 1, Timestamp: ts,
+		})
+	}
+	if _, err := s.IngestBatch(ctx, events); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.QueryUsage(ctx, tenantID, "a", meter.QueryOpts{FeatureKey: "api", Start: start, End: end})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d events, want the two in [start, end)", len(got))
+	}
+	for _, e := range got {
+		if e.Timestamp.Before(start) || !e.Timestamp.Before(end) {
+			t.Errorf("event at %v is outside [%v, %v)", e.Timestamp, start, end)
+		}
+	}
+
+	total, err := s.Aggregate(ctx, tenantID, "a", "api", meter.Aggregation{}, start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != int64(len(got)) {
+		t.Errorf("Aggregate = %d, QueryUsage returned %d events", total, len(got))
+	}
+}
+
+// ListSubscriptionsByPlan checks that ListSubscriptions returns only the
+// subscriptions of ListOpts.PlanID when it is set.
+func ListSubscriptionsByPlan(t *testing.T, s store.Store) {
+	t.Helper()
+	ctx := context.Background()
+	appID := "a-" + id.NewPlanID().String()
+	planA, planB := id.NewPlanID(), id.NewPlanID()
+
+	now := time.Now().UTC()
+	for i, planID := range []id.PlanID{planA, planB, planA} {
+		sub := &subscription.Subscription{
+			ID: id.NewSubscriptionID(), TenantID: fmt.Sprintf("t%d", i+1), AppID: appID, PlanID: planID,
+			Status: subscription.StatusActive, CurrentPeriodStart: now, CurrentPeriodEnd: now.AddDate(0, 1, 0),
+		}
+		if err := s.CreateSubscription(ctx, sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	subs, err := s.ListSubscriptions(ctx, "", appID, subscription.ListOpts{PlanID: planA})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != 2 {
+		t.Fatalf("got %d subscriptions, want the two on the plan", len(subs))
+	}
+	for _, sub := range subs {
+		if sub.PlanID != planA {
+			t.Errorf("subscription of %s is on plan %s, want %s", sub.TenantID, sub.PlanID, planA)
+		}
+	}
+
+	all, err := s.ListSubscriptions(ctx, "", appID, subscription.ListOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Errorf("got %d subscriptions without a plan filter, want 3", len(all))
+	}
+}
@@ -0,0 +1,142 @@
+package ledger
+
+import (
+	"context"
+	"slices"
+
+	log "github.com/xraph/go-utils/log"
+
+	"github.com/xraph/ledger/meter"
+)
+
+// usageStreamBuffer is the number of updates buffered per subscription
+// before further updates are dropped for it.
+const usageStreamBuffer = 64
+
+// UsageFilter selects the usage delivered to a SubscribeUsage subscriber.
+// Empty fields match everything.
+type UsageFilter struct {
+	TenantID    string
+	AppID       string
+	FeatureKeys []string
+}
+
+func (f UsageFilter) match(e *meter.UsageEvent) bool {
+	if (f.TenantID != "" && e.TenantID != f.TenantID) || (f.AppID != "" && e.AppID != f.AppID) {
+		return false
+	}
+	return len(f.FeatureKeys) == 0 || slices.Contains(f.FeatureKeys, e.FeatureKey)
+}
+
+// UsageUpdate is the part of one flushed batch that matches a subscription.
+type UsageUpdate struct {
+	// Events are the flushed usage events, including corrections. They are
+	// shared between subscribers and must not be modified.
+	Events []*meter.UsageEvent `json:"events"`
+	// Counters holds the running totals of every tenant and feature in
+	// Events.
+	Counters []UsageCounter `json:"counters"`
+}
+
+// UsageCounter is the counted usage (no corrections) of one tenant's
+// feature delivered to a subscription since it was created.
+type UsageCounter struct {
+	TenantID   string `json:"tenant_id"`
+	AppID      string `json:"app_id"`
+	FeatureKey string `json:"feature_key"`
+	Quantity   int64  `json:"quantity"`
+	Events     int64  `json:"events"`
+}
+
+type usageCounterKey struct {
+	tenantID, appID, featureKey string
+}
+
+type usageSubscription struct {
+	filter   UsageFilter
+	ch       chan UsageUpdate
+	counters map[usageCounterKey]*UsageCounter
+}
+
+// SubscribeUsage returns a channel that receives the usage matching filter
+// as it is flushed to the store, along with running per-tenant, per-feature
+// counters. The channel is closed when ctx is done or the Ledger stops.
+//
+// Delivery is best-effort: a subscriber that falls more than a few dozen
+// updates behind misses updates, but the counters in the next update it
+// receives are still complete. Only events newly written to the store are
+// delivered, so duplicates, such as WAL replays of events that were already
+// stored, are not delivered again.
+func (l *Ledger) SubscribeUsage(ctx context.Context, filter UsageFilter) (<-chan UsageUpdate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	sub := &usageSubscription{
+		filter:   filter,
+		ch:       make(chan UsageUpdate, usageStreamBuffer),
+		counters: make(map[usageCounterKey]*UsageCounter),
+	}
+	l.usageMu.Lock()
+	l.usageSubs[sub] = struct{}{}
+	l.usageMu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-l.stopChan:
+		}
+		l.usageMu.Lock()
+		delete(l.usageSubs, sub)
+		close(sub.ch)
+		l.usageMu.Unlock()
+	}()
+
+	return sub.ch, nil
+}
+
+// publishUsage hands newly stored events to the matching subscriptions
+// without blocking the flush.
+func (l *Ledger) publishUsage(events []*meter.UsageEvent) {
+	l.usageMu.Lock()
+	defer l.usageMu.Unlock()
+
+	for sub := range l.usageSubs {
+		var update UsageUpdate
+		var touched []usageCounterKey
+		for _, e := range events {
+			if !sub.filter.match(e) {
+				continue
+			}
+			update.Events = append(update.Events, e)
+
+			k := usageCounterKey{tenantID: e.TenantID, appID: e.AppID, featureKey: e.FeatureKey}
+			c, ok := sub.counters[k]
+			if !ok {
+				c = &UsageCounter{TenantID: e.TenantID, AppID: e.AppID, FeatureKey: e.FeatureKey}
+				sub.counters[k] = c
+			}
+			if !slices.Contains(touched, k) {
+				touched = append(touched, k)
+			}
+			if e.Counted() {
+				c.Quantity += e.Quantity
+				c.Events++
+			}
+		}
+		if len(update.Events) == 0 {
+			continue
+		}
+		for _, k := range touched {
+			update.Counters = append(update.Counters, *sub.counters[k])
+		}
+
+		select {
+		case sub.ch <- update:
+		default:
+			l.logger.Warn("usage subscriber is falling behind, dropping update",
+				log.Int("events", len(update.Events)),
+			)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package ledger_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xraph/ledger"
+	"github.com/xraph/ledger/store/memory"
+)
+
+func TestSubscribeUsageSkipsDuplicates(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := memory.New()
+	l := startLedger(t, s, ledger.WithMeterConfig(1, 5*time.Millisecond))
+
+	updates, err := l.SubscribeUsage(ctx, ledger.UsageFilter{TenantID: "t1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second event repeats the idempotency key of the first, so the
+	// store skips it and it must not reach the subscriber.
+	tctx := tenantContext("t1", "app")
+	for _, qty := range []int64{2, 5} {
+		if err := l.MeterWithOptions(tctx, "api_calls", qty, ledger.MeterOptions{IdempotencyKey: "req-1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	eventually(t, "the duplicate to reach the store", func() bool {
+		return l.MeterStats().Flushed == 2
+	})
+	if err := l.Meter(tctx, "api_calls", 3); err != nil {
+		t.Fatal(err)
+	}
+
+	var quantities []int64
+	var counted int64
+	for len(quantities) < 2 {
+		select {
+		case u := <-updates:
+			for _, e := range u.Events {
+				quantities = append(quantities, e.Quantity)
+			}
+			counted = u.Counters[0].Quantity
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for updates, got quantities %v", quantities)
+		}
+	}
+	if len(quantities) != 2 || quantities[0] != 2 || quantities[1] != 3 {
+		t.Errorf("delivered quantities %v, want [2 3]", quantities)
+	}
+	if counted != 5 {
+		t.Errorf("counter = %d, want 5", counted)
+	}
+}
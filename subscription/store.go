@@ -16,8 +16,11 @@ type Store interface {
 	Cancel(ctx context.Context, subID id.SubscriptionID, cancelAt time.Time) error
 }
 
+// ListOpts filters and pages subscriptions. A zero Status or PlanID
+// matches every subscription.
 type ListOpts struct {
 	Status Status
+	PlanID id.PlanID
 	Limit  int
 	Offset int
 }